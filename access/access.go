@@ -0,0 +1,686 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package access finds the databases and the objects a statement accesses, and how
+// it accesses them, from the statement alone.
+//
+// Without a schema, a name in a query can be of a table or a view, and whether a
+// table is a temporary one is only known from CREATE and DROP TEMPORARY TABLE. The
+// tables a procedure accesses aren't known.
+package access
+
+import (
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+)
+
+// Mode is how an object is accessed.
+type Mode int
+
+// The access modes.
+const (
+	ModeRead Mode = iota
+	ModeInsert
+	ModeUpdate
+	ModeDelete
+	ModeCreate
+	ModeAlter
+	ModeDrop
+	// ModeExecute is of the procedures called.
+	ModeExecute
+)
+
+var modeNames = [...]string{
+	ModeRead:    "read",
+	ModeInsert:  "insert",
+	ModeUpdate:  "update",
+	ModeDelete:  "delete",
+	ModeCreate:  "create",
+	ModeAlter:   "alter",
+	ModeDrop:    "drop",
+	ModeExecute: "execute",
+}
+
+// String implements fmt.Stringer interface.
+func (m Mode) String() string {
+	if m < 0 || int(m) >= len(modeNames) {
+		return "unknown"
+	}
+	return modeNames[m]
+}
+
+// Class is the coarse class of a statement.
+type Class int
+
+// The statement classes.
+const (
+	// ClassUtility is of the statements which are in none of the other classes,
+	// like SET, SHOW, USE and EXPLAIN.
+	ClassUtility Class = iota
+	// ClassDML is of the statements querying and modifying the data, and CALL.
+	ClassDML
+	// ClassDDL is of the statements defining the objects.
+	ClassDDL
+	// ClassDCL is of the statements managing the users, the roles and the privileges.
+	ClassDCL
+	// ClassTCL is of the transaction and the locking statements.
+	ClassTCL
+)
+
+var classNames = [...]string{
+	ClassUtility: "Utility",
+	ClassDML:     "DML",
+	ClassDDL:     "DDL",
+	ClassDCL:     "DCL",
+	ClassTCL:     "TCL",
+}
+
+// String implements fmt.Stringer interface.
+func (c Class) String() string {
+	if c < 0 || int(c) >= len(classNames) {
+		return "Unknown"
+	}
+	return classNames[c]
+}
+
+// ObjectKind is the kind of an object in a database.
+type ObjectKind int
+
+// The object kinds.
+const (
+	// KindTable is of a table, or a view as a query doesn't tell them apart.
+	KindTable ObjectKind = iota
+	KindView
+	KindSequence
+	KindProcedure
+	KindFunction
+	KindTrigger
+	KindEvent
+)
+
+var kindNames = [...]string{
+	KindTable:     "table",
+	KindView:      "view",
+	KindSequence:  "sequence",
+	KindProcedure: "procedure",
+	KindFunction:  "function",
+	KindTrigger:   "trigger",
+	KindEvent:     "event",
+}
+
+// String implements fmt.Stringer interface.
+func (k ObjectKind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "unknown"
+	}
+	return kindNames[k]
+}
+
+// Object is an object in a database.
+type Object struct {
+	Kind   ObjectKind
+	Schema model.CIStr
+	Name   model.CIStr
+	// Temporary is true for the temporary tables of CREATE and DROP TEMPORARY TABLE.
+	Temporary bool
+}
+
+// Info is what a statement accesses.
+type Info struct {
+	Class Class
+	// Databases are the databases accessed by each mode, including the ones of the objects.
+	Databases map[Mode][]model.CIStr
+	// Objects are the objects accessed by each mode.
+	Objects map[Mode][]Object
+}
+
+// Tables returns the tables, the views and the sequences accessed by a mode.
+func (info *Info) Tables(mode Mode) []Object {
+	var tables []Object
+	for _, obj := range info.Objects[mode] {
+		switch obj.Kind {
+		case KindTable, KindView, KindSequence:
+			tables = append(tables, obj)
+		}
+	}
+	return tables
+}
+
+// Analyze returns what a statement accesses. The unqualified names are of the
+// database currentDB, they're left unqualified if it's empty.
+//
+// The tables of a query are read, the modified ones are written by the mode of the
+// statement, and the ones defined are created, altered or dropped. A single table
+// UPDATE or DELETE only reads the table if it refers to its columns. REPLACE deletes
+// the rows it replaces, ON DUPLICATE KEY UPDATE updates them, and a WRITE lock reads
+// and updates the table. RENAME TABLE drops the old name and creates the new one,
+// and TRUNCATE TABLE drops the table like MySQL requires the DROP privilege for it.
+// The objects of an EXPLAIN are read, unless it's EXPLAIN ANALYZE, which runs the
+// statement.
+func Analyze(stmt ast.StmtNode, currentDB string) *Info {
+	a := &analyzer{
+		db:    model.NewCIStr(currentDB),
+		info:  &Info{Databases: make(map[Mode][]model.CIStr), Objects: make(map[Mode][]Object)},
+		class: ClassUtility,
+	}
+	a.stmt(stmt)
+	a.info.Class = a.class
+	return a.info
+}
+
+type analyzer struct {
+	db    model.CIStr
+	info  *Info
+	class Class
+}
+
+func (a *analyzer) schema(schema model.CIStr) model.CIStr {
+	if schema.L == "" {
+		return a.db
+	}
+	return schema
+}
+
+func (a *analyzer) object(kind ObjectKind, tn *ast.TableName) Object {
+	return Object{Kind: kind, Schema: a.schema(tn.Schema), Name: tn.Name}
+}
+
+func (a *analyzer) add(mode Mode, obj Object) {
+	for _, o := range a.info.Objects[mode] {
+		if o.Kind == obj.Kind && o.Schema.L == obj.Schema.L && o.Name.L == obj.Name.L {
+			return
+		}
+	}
+	a.info.Objects[mode] = append(a.info.Objects[mode], obj)
+	a.addDatabase(mode, obj.Schema)
+}
+
+func (a *analyzer) addTable(mode Mode, tn *ast.TableName) {
+	a.add(mode, a.object(KindTable, tn))
+}
+
+func (a *analyzer) addDatabase(mode Mode, name model.CIStr) {
+	if name = a.schema(name); name.L == "" {
+		return
+	}
+	for _, db := range a.info.Databases[mode] {
+		if db.L == name.L {
+			return
+		}
+	}
+	a.info.Databases[mode] = append(a.info.Databases[mode], name)
+}
+
+// reads adds the tables in a node as read, except the ones in the skipped nodes.
+func (a *analyzer) reads(n ast.Node, skipped ...ast.Node) {
+	n.Accept(&tableCollector{a: a, skipped: skipped})
+}
+
+type tableCollector struct {
+	a       *analyzer
+	skipped []ast.Node
+	// scopes are the WITH clauses enclosing the current node, innermost last.
+	scopes []*cteScope
+}
+
+// cteScope is the common table expressions of a query block, which hide the tables
+// of the same names in it.
+type cteScope struct {
+	owner ast.Node
+	with  *ast.WithClause
+	names map[string]bool
+}
+
+// withClause returns the WITH clause of a statement, or nil if it has none.
+func withClause(n ast.Node) *ast.WithClause {
+	switch x := n.(type) {
+	case *ast.SelectStmt:
+		return x.With
+	case *ast.SetOprStmt:
+		return x.With
+	case *ast.SetOprSelectList:
+		return x.With
+	case *ast.UpdateStmt:
+		return x.With
+	case *ast.DeleteStmt:
+		return x.With
+	}
+	return nil
+}
+
+// isCTE tells whether an unqualified name is of a common table expression in scope.
+func (v *tableCollector) isCTE(tn *ast.TableName) bool {
+	if tn.Schema.L != "" {
+		return false
+	}
+	for _, sc := range v.scopes {
+		if sc.names[tn.Name.L] {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *tableCollector) Enter(n ast.Node) (ast.Node, bool) {
+	for _, skipped := range v.skipped {
+		if n == skipped {
+			return n, true
+		}
+	}
+	if with := withClause(n); with != nil {
+		sc := &cteScope{owner: n, with: with, names: make(map[string]bool)}
+		// a recursive CTE can refer to itself, the other ones only to the ones before.
+		if with.IsRecursive {
+			for _, cte := range with.CTEs {
+				sc.names[cte.Name.L] = true
+			}
+		}
+		v.scopes = append(v.scopes, sc)
+	}
+	if tn, ok := n.(*ast.TableName); ok && !v.isCTE(tn) {
+		v.a.addTable(ModeRead, tn)
+	}
+	return n, false
+}
+
+func (v *tableCollector) Leave(n ast.Node) (ast.Node, bool) {
+	if len(v.scopes) == 0 {
+		return n, true
+	}
+	sc := v.scopes[len(v.scopes)-1]
+	if n == sc.owner {
+		v.scopes = v.scopes[:len(v.scopes)-1]
+		return n, true
+	}
+	for _, cte := range sc.with.CTEs {
+		if n == cte.Query {
+			sc.names[cte.Name.L] = true
+		}
+	}
+	return n, true
+}
+
+func (a *analyzer) stmt(stmt ast.StmtNode) {
+	switch x := stmt.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt, *ast.DoStmt:
+		a.class = ClassDML
+		a.reads(x)
+	case *ast.InsertStmt:
+		a.insert(x)
+	case *ast.UpdateStmt:
+		a.update(x)
+	case *ast.DeleteStmt:
+		a.delete(x)
+	case *ast.LoadDataStmt:
+		a.class = ClassDML
+		a.addTable(ModeInsert, x.Table)
+		if x.OnDuplicate == ast.OnDuplicateKeyHandlingReplace {
+			a.addTable(ModeDelete, x.Table)
+		}
+	case *ast.CallStmt:
+		a.class = ClassDML
+		a.add(ModeExecute, Object{Kind: KindProcedure, Schema: a.schema(x.Procedure.Schema), Name: x.Procedure.FnName})
+		for _, arg := range x.Procedure.Args {
+			a.reads(arg)
+		}
+
+	case *ast.CreateDatabaseStmt:
+		a.class = ClassDDL
+		a.addDatabase(ModeCreate, model.NewCIStr(x.Name))
+	case *ast.AlterDatabaseStmt:
+		a.class = ClassDDL
+		a.addDatabase(ModeAlter, model.NewCIStr(x.Name))
+	case *ast.DropDatabaseStmt:
+		a.class = ClassDDL
+		a.addDatabase(ModeDrop, model.NewCIStr(x.Name))
+	case *ast.CreateTableStmt:
+		a.class = ClassDDL
+		obj := a.object(KindTable, x.Table)
+		obj.Temporary = x.TemporaryKeyword != ast.TemporaryNone
+		a.add(ModeCreate, obj)
+		a.reads(x, x.Table)
+	case *ast.CreateViewStmt:
+		a.class = ClassDDL
+		a.add(ModeCreate, a.object(KindView, x.ViewName))
+		a.reads(x.Select)
+	case *ast.DropTableStmt:
+		a.class = ClassDDL
+		for _, tn := range x.Tables {
+			obj := a.object(KindTable, tn)
+			if x.IsView {
+				obj.Kind = KindView
+			}
+			obj.Temporary = x.TemporaryKeyword != ast.TemporaryNone
+			a.add(ModeDrop, obj)
+		}
+	case *ast.AlterTableStmt:
+		a.alterTable(x)
+	case *ast.RenameTableStmt:
+		a.class = ClassDDL
+		for _, t2t := range x.TableToTables {
+			a.addTable(ModeDrop, t2t.OldTable)
+			a.addTable(ModeCreate, t2t.NewTable)
+		}
+	case *ast.TruncateTableStmt:
+		a.class = ClassDDL
+		a.addTable(ModeDrop, x.Table)
+	case *ast.CreateIndexStmt:
+		a.class = ClassDDL
+		a.addTable(ModeAlter, x.Table)
+	case *ast.DropIndexStmt:
+		a.class = ClassDDL
+		a.addTable(ModeAlter, x.Table)
+	case *ast.RepairTableStmt:
+		a.class = ClassDDL
+		a.addTable(ModeAlter, x.Table)
+	case *ast.RecoverTableStmt:
+		a.class = ClassDDL
+		if x.Table != nil {
+			a.addTable(ModeCreate, x.Table)
+		}
+	case *ast.FlashBackTableStmt:
+		a.class = ClassDDL
+		obj := a.object(KindTable, x.Table)
+		if x.NewName != "" {
+			obj.Name = model.NewCIStr(x.NewName)
+		}
+		a.add(ModeCreate, obj)
+	case *ast.CreateSequenceStmt:
+		a.class = ClassDDL
+		a.add(ModeCreate, a.object(KindSequence, x.Name))
+	case *ast.AlterSequenceStmt:
+		a.class = ClassDDL
+		a.add(ModeAlter, a.object(KindSequence, x.Name))
+	case *ast.DropSequenceStmt:
+		a.class = ClassDDL
+		for _, tn := range x.Sequences {
+			a.add(ModeDrop, a.object(KindSequence, tn))
+		}
+	case *ast.CreateStatisticsStmt:
+		a.class = ClassDDL
+		a.addTable(ModeAlter, x.Table)
+	// the bodies of the stored programs aren't run when they're defined.
+	case *ast.CreateProcedureStmt:
+		a.class = ClassDDL
+		a.add(ModeCreate, a.object(KindProcedure, x.Name))
+	case *ast.CreateFunctionStmt:
+		a.class = ClassDDL
+		a.add(ModeCreate, a.object(KindFunction, x.Name))
+	case *ast.AlterProcedureStmt:
+		a.class = ClassDDL
+		a.add(ModeAlter, a.object(routineKind(x.IsFunction), x.Name))
+	case *ast.DropProcedureStmt:
+		a.class = ClassDDL
+		a.add(ModeDrop, a.object(routineKind(x.IsFunction), x.Name))
+	case *ast.CreateTriggerStmt:
+		a.class = ClassDDL
+		a.add(ModeCreate, a.object(KindTrigger, x.Name))
+		a.addTable(ModeAlter, x.Table)
+	case *ast.DropTriggerStmt:
+		a.class = ClassDDL
+		a.add(ModeDrop, a.object(KindTrigger, x.Name))
+	case *ast.CreateEventStmt:
+		a.class = ClassDDL
+		a.add(ModeCreate, a.object(KindEvent, x.Name))
+	case *ast.AlterEventStmt:
+		a.class = ClassDDL
+		a.add(ModeAlter, a.object(KindEvent, x.Name))
+		if x.NewName != nil {
+			a.add(ModeDrop, a.object(KindEvent, x.Name))
+			a.add(ModeCreate, a.object(KindEvent, x.NewName))
+		}
+	case *ast.DropEventStmt:
+		a.class = ClassDDL
+		a.add(ModeDrop, a.object(KindEvent, x.Name))
+
+	case *ast.GrantStmt, *ast.RevokeStmt, *ast.GrantRoleStmt, *ast.RevokeRoleStmt, *ast.GrantProxyStmt,
+		*ast.CreateUserStmt, *ast.AlterUserStmt, *ast.DropUserStmt, *ast.RenameUserStmt,
+		*ast.SetPwdStmt, *ast.SetRoleStmt, *ast.SetDefaultRoleStmt:
+		a.class = ClassDCL
+
+	case *ast.BeginStmt, *ast.CommitStmt, *ast.RollbackStmt, *ast.UnlockTablesStmt:
+		a.class = ClassTCL
+	case *ast.LockTablesStmt:
+		a.class = ClassTCL
+		for _, lock := range x.TableLocks {
+			a.addTable(ModeRead, lock.Table)
+			if lock.Type == model.TableLockWrite || lock.Type == model.TableLockWriteLocal {
+				a.addTable(ModeUpdate, lock.Table)
+			}
+		}
+
+	case *ast.ExplainStmt:
+		a.explain(x)
+	case *ast.TraceStmt:
+		a.stmt(x.Stmt)
+		a.class = ClassUtility
+	case *ast.UseStmt:
+		a.addDatabase(ModeRead, model.NewCIStr(x.DBName))
+	case *ast.ShowStmt:
+		if x.DBName != "" {
+			a.addDatabase(ModeRead, model.NewCIStr(x.DBName))
+		}
+		if x.Table != nil {
+			a.addTable(ModeRead, x.Table)
+		}
+	case *ast.SplitRegionStmt:
+		// splitting the regions takes the INSERT privilege in TiDB.
+		a.addTable(ModeInsert, x.Table)
+	case *ast.DropStatsStmt:
+		a.addTable(ModeAlter, x.Table)
+	case *ast.BRIEStmt:
+		mode := ModeRead
+		if x.Kind == ast.BRIEKindRestore {
+			mode = ModeCreate
+		}
+		for _, db := range x.Schemas {
+			a.addDatabase(mode, model.NewCIStr(db))
+		}
+		for _, tn := range x.Tables {
+			a.addTable(mode, tn)
+		}
+	case ast.DDLNode:
+		a.class = ClassDDL
+	default:
+		a.reads(x)
+	}
+}
+
+func routineKind(isFunction bool) ObjectKind {
+	if isFunction {
+		return KindFunction
+	}
+	return KindProcedure
+}
+
+func (a *analyzer) insert(stmt *ast.InsertStmt) {
+	a.class = ClassDML
+	tn := stmt.Table.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+	a.addTable(ModeInsert, tn)
+	if stmt.IsReplace {
+		a.addTable(ModeDelete, tn)
+	}
+	if len(stmt.OnDuplicate) > 0 {
+		a.addTable(ModeUpdate, tn)
+	}
+	a.reads(stmt, stmt.Table)
+}
+
+// tableSource is a table in a FROM clause, whose name is its alias if it has one.
+type tableSource struct {
+	name  model.CIStr
+	table *ast.TableName
+}
+
+// tableSources returns the tables in a FROM clause.
+func tableSources(n ast.ResultSetNode, sources []tableSource) []tableSource {
+	switch x := n.(type) {
+	case *ast.Join:
+		sources = tableSources(x.Left, sources)
+		if x.Right != nil {
+			sources = tableSources(x.Right, sources)
+		}
+	case *ast.TableSource:
+		switch src := x.Source.(type) {
+		case *ast.Join:
+			return tableSources(src, sources)
+		case *ast.TableName:
+			name := x.AsName
+			if name.L == "" {
+				name = src.Name
+			}
+			sources = append(sources, tableSource{name: name, table: src})
+		}
+	}
+	return sources
+}
+
+func findSource(sources []tableSource, name model.CIStr) *ast.TableName {
+	for _, src := range sources {
+		if src.name.L == name.L {
+			return src.table
+		}
+	}
+	return nil
+}
+
+// singleTable returns the table of a single table UPDATE or DELETE.
+func singleTable(refs *ast.TableRefsClause) *ast.TableName {
+	if refs.TableRefs.Right != nil {
+		return nil
+	}
+	ts, ok := refs.TableRefs.Left.(*ast.TableSource)
+	if !ok {
+		return nil
+	}
+	tn, _ := ts.Source.(*ast.TableName)
+	return tn
+}
+
+// hasColumns tells whether WHERE, ORDER BY or any of the expressions refers to a column.
+func hasColumns(where ast.ExprNode, order *ast.OrderByClause, exprs ...ast.ExprNode) bool {
+	v := &columnChecker{}
+	if where != nil {
+		where.Accept(v)
+	}
+	if order != nil {
+		order.Accept(v)
+	}
+	for _, expr := range exprs {
+		expr.Accept(v)
+	}
+	return v.found
+}
+
+type columnChecker struct {
+	found bool
+}
+
+func (v *columnChecker) Enter(n ast.Node) (ast.Node, bool) {
+	if _, ok := n.(*ast.ColumnNameExpr); ok {
+		v.found = true
+	}
+	return n, v.found
+}
+
+func (v *columnChecker) Leave(n ast.Node) (ast.Node, bool) {
+	return n, !v.found
+}
+
+func (a *analyzer) update(stmt *ast.UpdateStmt) {
+	a.class = ClassDML
+	if tn := singleTable(stmt.TableRefs); tn != nil {
+		a.addTable(ModeUpdate, tn)
+		exprs := make([]ast.ExprNode, 0, len(stmt.List))
+		for _, as := range stmt.List {
+			exprs = append(exprs, as.Expr)
+		}
+		if hasColumns(stmt.Where, stmt.Order, exprs...) {
+			a.addTable(ModeRead, tn)
+		}
+		a.reads(stmt, stmt.TableRefs)
+		return
+	}
+
+	sources := tableSources(stmt.TableRefs.TableRefs, nil)
+	for _, as := range stmt.List {
+		if tn := findSource(sources, as.Column.Table); tn != nil && as.Column.Table.L != "" {
+			a.addTable(ModeUpdate, tn)
+			continue
+		}
+		// the table of an unqualified column isn't known, it can be any of them.
+		for _, src := range sources {
+			a.addTable(ModeUpdate, src.table)
+		}
+	}
+	a.reads(stmt)
+}
+
+func (a *analyzer) delete(stmt *ast.DeleteStmt) {
+	a.class = ClassDML
+	if !stmt.IsMultiTable {
+		tn := singleTable(stmt.TableRefs)
+		a.addTable(ModeDelete, tn)
+		if hasColumns(stmt.Where, stmt.Order) {
+			a.addTable(ModeRead, tn)
+		}
+		a.reads(stmt, stmt.TableRefs)
+		return
+	}
+
+	sources := tableSources(stmt.TableRefs.TableRefs, nil)
+	for _, tn := range stmt.Tables.Tables {
+		if src := findSource(sources, tn.Name); src != nil {
+			a.addTable(ModeDelete, src)
+		} else {
+			a.addTable(ModeDelete, tn)
+		}
+	}
+	a.reads(stmt, stmt.Tables)
+}
+
+func (a *analyzer) alterTable(stmt *ast.AlterTableStmt) {
+	a.class = ClassDDL
+	a.addTable(ModeAlter, stmt.Table)
+	skipped := []ast.Node{stmt.Table}
+	for _, spec := range stmt.Specs {
+		switch spec.Tp {
+		case ast.AlterTableRenameTable:
+			a.addTable(ModeDrop, stmt.Table)
+			a.addTable(ModeCreate, spec.NewTable)
+			skipped = append(skipped, spec.NewTable)
+		case ast.AlterTableExchangePartition:
+			a.addTable(ModeAlter, spec.NewTable)
+			skipped = append(skipped, spec.NewTable)
+		}
+	}
+	// the tables referred to by the foreign keys are read.
+	a.reads(stmt, skipped...)
+}
+
+func (a *analyzer) explain(stmt *ast.ExplainStmt) {
+	if stmt.Analyze {
+		a.stmt(stmt.Stmt)
+		a.class = ClassUtility
+		return
+	}
+	inner := Analyze(stmt.Stmt, a.db.O)
+	for mode := ModeRead; mode <= ModeExecute; mode++ {
+		for _, obj := range inner.Objects[mode] {
+			a.add(ModeRead, obj)
+		}
+		for _, db := range inner.Databases[mode] {
+			a.addDatabase(ModeRead, db)
+		}
+	}
+}
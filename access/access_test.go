@@ -0,0 +1,171 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package access_test
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/access"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testAccessSuite{})
+
+type testAccessSuite struct{}
+
+// objects formats the objects like "read test.t", with the kind if it isn't a table.
+func objects(info *Info) []string {
+	list := []string{}
+	for mode := ModeRead; mode <= ModeExecute; mode++ {
+		for _, obj := range info.Objects[mode] {
+			s := mode.String() + " "
+			if obj.Temporary {
+				s += "temporary "
+			}
+			if obj.Kind != KindTable {
+				s += obj.Kind.String() + " "
+			}
+			if obj.Schema.O != "" {
+				s += obj.Schema.O + "."
+			}
+			list = append(list, s+obj.Name.O)
+		}
+	}
+	return list
+}
+
+func databases(info *Info) []string {
+	list := []string{}
+	for mode := ModeRead; mode <= ModeExecute; mode++ {
+		for _, db := range info.Databases[mode] {
+			list = append(list, mode.String()+" "+db.O)
+		}
+	}
+	return list
+}
+
+func (s *testAccessSuite) TestAnalyze(c *C) {
+	table := []struct {
+		sql     string
+		class   Class
+		objects []string
+	}{
+		{"select * from t, other.s where a in (select a from u)", ClassDML, []string{"read test.t", "read other.s", "read test.u"}},
+		{"with c as (select * from t) select * from c join other.c", ClassDML, []string{"read test.t", "read other.c"}},
+		// a CTE only hides the tables of its name in its query block.
+		{"select * from secret where a in (with secret as (select 1) select * from secret)", ClassDML, []string{"read test.secret"}},
+		{"select * from c where a in (with c as (select 1) select * from c) and b in (select b from c)", ClassDML, []string{"read test.c"}},
+		{"with c as (select * from c) select * from c", ClassDML, []string{"read test.c"}},
+		{"with recursive c (n) as (select 1 union all select n + 1 from c where n < 3) select * from c", ClassDML, []string{}},
+		{"with c as (select 1), d as (select * from c) select * from d", ClassDML, []string{}},
+		{"select a from t union select b from s", ClassDML, []string{"read test.t", "read test.s"}},
+		{"insert into t select * from s", ClassDML, []string{"read test.s", "insert test.t"}},
+		{"insert into t values (1) on duplicate key update a = a + 1", ClassDML, []string{"insert test.t", "update test.t"}},
+		{"replace into t set a = (select max(a) from t)", ClassDML, []string{"read test.t", "insert test.t", "delete test.t"}},
+		{"update t set a = 1", ClassDML, []string{"update test.t"}},
+		{"update t set a = a + 1", ClassDML, []string{"read test.t", "update test.t"}},
+		{"update t set a = 1 where b in (select b from s)", ClassDML, []string{"read test.t", "read test.s", "update test.t"}},
+		{"update t as x join s on x.a = s.a set x.b = s.b", ClassDML, []string{"read test.t", "read test.s", "update test.t"}},
+		{"update t join s using (a) set b = 1", ClassDML, []string{"read test.t", "read test.s", "update test.t", "update test.s"}},
+		{"delete from t", ClassDML, []string{"delete test.t"}},
+		{"delete from t where a > 0", ClassDML, []string{"read test.t", "delete test.t"}},
+		{"delete x from t as x join other.s using (a)", ClassDML, []string{"read test.t", "read other.s", "delete test.t"}},
+		{"load data infile '/tmp/t.csv' replace into table t", ClassDML, []string{"insert test.t", "delete test.t"}},
+		{"call other.p((select max(a) from t))", ClassDML, []string{"read test.t", "execute procedure other.p"}},
+		{"do (select 1 from t)", ClassDML, []string{"read test.t"}},
+
+		{"create database d", ClassDDL, nil},
+		{"create table t (a int, foreign key (a) references s (a))", ClassDDL, []string{"read test.s", "create test.t"}},
+		{"create temporary table t like s", ClassDDL, []string{"read test.s", "create temporary test.t"}},
+		{"create table t select * from s", ClassDDL, []string{"read test.s", "create test.t"}},
+		{"create view v as select * from t", ClassDDL, []string{"read test.t", "create view test.v"}},
+		{"drop temporary table t, s", ClassDDL, []string{"drop temporary test.t", "drop temporary test.s"}},
+		{"drop view other.v", ClassDDL, []string{"drop view other.v"}},
+		{"alter table t add column b int", ClassDDL, []string{"alter test.t"}},
+		{"alter table t rename to other.t", ClassDDL, []string{"create other.t", "alter test.t", "drop test.t"}},
+		{"rename table t to s, u to v", ClassDDL, []string{"create test.s", "create test.v", "drop test.t", "drop test.u"}},
+		{"truncate table t", ClassDDL, []string{"drop test.t"}},
+		{"create index i on t (a)", ClassDDL, []string{"alter test.t"}},
+		{"create sequence seq", ClassDDL, []string{"create sequence test.seq"}},
+		{"create trigger tr before insert on t for each row set @x = 1", ClassDDL, []string{"create trigger test.tr", "alter test.t"}},
+		{"create procedure p() select * from t", ClassDDL, []string{"create procedure test.p"}},
+		{"drop function other.f", ClassDDL, []string{"drop function other.f"}},
+
+		{"grant select on test.* to 'u'@'%'", ClassDCL, []string{}},
+		{"create user 'u'@'%'", ClassDCL, []string{}},
+		{"set password for 'u'@'%' = 'p'", ClassDCL, []string{}},
+
+		{"begin", ClassTCL, []string{}},
+		{"commit", ClassTCL, []string{}},
+		{"lock tables t read, other.s write", ClassTCL, []string{"read test.t", "read other.s", "update other.s"}},
+		{"unlock tables", ClassTCL, []string{}},
+
+		{"explain delete from t where a > 0", ClassUtility, []string{"read test.t"}},
+		{"explain analyze insert into t select * from s", ClassUtility, []string{"read test.s", "insert test.t"}},
+		{"show columns from other.t", ClassUtility, []string{"read other.t"}},
+		{"set @a = (select max(a) from t)", ClassUtility, []string{"read test.t"}},
+		{"analyze table t", ClassUtility, []string{"read test.t"}},
+		{"use other", ClassUtility, []string{}},
+	}
+	for _, tt := range table {
+		comment := Commentf("sql: %s", tt.sql)
+		stmt, err := parser.New().ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		info := Analyze(stmt, "test")
+		c.Assert(info.Class, Equals, tt.class, comment)
+		if tt.objects != nil {
+			c.Assert(objects(info), DeepEquals, tt.objects, comment)
+		}
+	}
+}
+
+func (s *testAccessSuite) TestDatabases(c *C) {
+	table := []struct {
+		sql       string
+		databases []string
+	}{
+		{"select * from t join other.s", []string{"read test", "read other"}},
+		{"insert into other.t select * from t", []string{"read test", "insert other"}},
+		{"create database d", []string{"create d"}},
+		{"alter database character set = 'utf8mb4'", []string{"alter test"}},
+		{"drop database if exists d", []string{"drop d"}},
+		{"use other", []string{"read other"}},
+		{"show tables in other", []string{"read other"}},
+	}
+	for _, tt := range table {
+		stmt, err := parser.New().ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, Commentf("sql: %s", tt.sql))
+		c.Assert(databases(Analyze(stmt, "test")), DeepEquals, tt.databases, Commentf("sql: %s", tt.sql))
+	}
+
+	stmt, err := parser.New().ParseOneStmt("select * from t join other.s", "", "")
+	c.Assert(err, IsNil)
+	info := Analyze(stmt, "")
+	c.Assert(databases(info), DeepEquals, []string{"read other"})
+	c.Assert(info.Tables(ModeRead), HasLen, 2)
+	c.Assert(info.Tables(ModeRead)[0].Schema.O, Equals, "")
+}
+
+func (s *testAccessSuite) TestString(c *C) {
+	c.Assert(ModeDelete.String(), Equals, "delete")
+	c.Assert(ClassTCL.String(), Equals, "TCL")
+	c.Assert(Class(100).String(), Equals, "Unknown")
+	c.Assert(KindSequence.String(), Equals, "sequence")
+}
@@ -32,6 +32,12 @@ type IndexAdviseStmt struct {
 
 // Restore implements Node Accept interface.
 func (n *IndexAdviseStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "INDEX ADVISE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("INDEX ADVISE ")
 	if n.IsLocal {
 		ctx.WriteKeyWord("LOCAL ")
@@ -23,6 +23,23 @@ import (
 	"github.com/pingcap/parser/types"
 )
 
+// Pos is a position in the SQL text. Line and Col start from 1, Col counts characters
+// rather than bytes. Offset is the byte offset from the beginning of the SQL text.
+type Pos struct {
+	Line   int
+	Col    int
+	Offset int
+}
+
+// Comment is a comment in the SQL text, such as `-- text`, `# text` or `/* text */`.
+// Optimizer hints and `/*!` sections aren't comments.
+type Comment struct {
+	// Text is the whole comment, including its `--`, `#` or `/*` and `*/` marks.
+	Text  string
+	Start Pos
+	End   Pos
+}
+
 // Node is the basic element of the AST.
 // Interfaces embed Node should have 'Node' name suffix.
 type Node interface {
@@ -45,6 +62,19 @@ type Node interface {
 	SetOriginTextPosition(offset int)
 	// OriginTextPosition get the start offset of this node in the origin text.
 	OriginTextPosition() int
+	// SetPos sets the start and end positions of this node in the origin text.
+	SetPos(start, end Pos)
+	// StartPos returns the position of the first character of this node in the origin text.
+	// It's the zero Pos if this node isn't built from the origin text.
+	StartPos() Pos
+	// EndPos returns the position right after the last character of this node in the origin text.
+	EndPos() Pos
+	// AttachComment attaches a comment before this node, or after it if trailing is true.
+	AttachComment(c *Comment, trailing bool)
+	// LeadingComments returns the comments attached before this node.
+	LeadingComments() []*Comment
+	// TrailingComments returns the comments attached after this node.
+	TrailingComments() []*Comment
 }
 
 // Flags indicates whether an expression contains certain types of expression.
@@ -137,7 +167,7 @@ type ResultField struct {
 }
 
 // ResultSetNode interface has a ResultFields property, represents a Node that returns result set.
-// Implementations include SelectStmt, SubqueryExpr, TableSource, TableName, Join and SetOprStmt.
+// Implementations include SelectStmt, SubqueryExpr, TableSource, TableName, Join, SetOprStmt and JSONTable.
 type ResultSetNode interface {
 	Node
 
@@ -0,0 +1,398 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package astutil provides the helpers to traverse and rewrite the AST.
+//
+// Unlike ast.Visitor, the traversal doesn't depend on the Accept methods, the
+// children of a node are found from its fields by reflection, so that every node
+// can be reached, replaced or deleted.
+package astutil
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/pingcap/parser/ast"
+)
+
+// ApplyFunc is called by Apply for each node, see Apply.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses root recursively and returns the possibly replaced root.
+//
+// For each node, pre is called before its children are traversed, and post is
+// called after. If pre returns false, the children and post are skipped. If post
+// returns false, the traversal stops. pre and post may be nil.
+//
+// The children are the nodes held by the exported fields of a node, in the field
+// order, including those of the non-node structs of package ast, such as
+// ast.TableOption. The ResultFields referred by the resolved columns aren't
+// children. A node is traversed only once even if it's held by several fields.
+//
+// So Apply reaches the nodes which the Accept methods skip. Notably, the names of
+// the stored routines, the triggers and the events are held as *ast.TableName, such
+// as ast.CreateProcedureStmt.Name, and they are traversed by Apply but not
+// by Accept. The callers looking for tables check Cursor.Name or the parent.
+//
+// The nodes may be changed by the Cursor methods, the nodes added by Replace are
+// traversed, but those inserted by InsertBefore and InsertAfter are not. The
+// children and post are skipped if pre deletes the node.
+func Apply(root ast.Node, pre, post ApplyFunc) (result ast.Node) {
+	holder := &struct{ Node ast.Node }{root}
+	a := &applier{
+		pre:     pre,
+		post:    post,
+		visited: make(map[ast.Node]struct{}),
+	}
+	defer func() {
+		if r := recover(); r != nil && r != abort {
+			panic(r)
+		}
+		result = holder.Node
+	}()
+	if root != nil {
+		a.apply("", nil, reflect.ValueOf(holder).Elem().Field(0), root)
+	}
+	return holder.Node
+}
+
+var abort = new(int)
+
+// Cursor describes the node being traversed by Apply.
+type Cursor struct {
+	parents []ast.Node
+	name    string
+	iter    *iterator
+	// loc is the location of the node, it's the slice if the node is in a slice.
+	loc  reflect.Value
+	node ast.Node
+}
+
+// Node returns the current node, it's nil after the node is deleted.
+func (c *Cursor) Node() ast.Node {
+	return c.node
+}
+
+// Parent returns the parent of the current node, it's nil for the root.
+func (c *Cursor) Parent() ast.Node {
+	if len(c.parents) == 0 {
+		return nil
+	}
+	return c.parents[len(c.parents)-1]
+}
+
+// Parents returns the ancestors of the current node, from the parent to the root.
+func (c *Cursor) Parents() []ast.Node {
+	parents := make([]ast.Node, 0, len(c.parents))
+	for i := len(c.parents) - 1; i >= 0; i-- {
+		parents = append(parents, c.parents[i])
+	}
+	return parents
+}
+
+// Name returns the name of the field of the parent which holds the current node.
+// The field of a non-node struct is named after its path, such as "Options.TableNames".
+// It's "" for the root.
+func (c *Cursor) Name() string {
+	return c.name
+}
+
+// Index returns the index of the current node in the slice which holds it, it's
+// -1 if the node isn't in a slice.
+func (c *Cursor) Index() int {
+	if c.iter == nil {
+		return -1
+	}
+	return c.iter.index
+}
+
+// Replace replaces the current node with n, n must be assignable to the field.
+func (c *Cursor) Replace(n ast.Node) {
+	loc := c.loc
+	if c.iter != nil {
+		loc = loc.Index(c.iter.index)
+	}
+	loc.Set(nodeValue(n, loc.Type(), c.name))
+	c.node = n
+}
+
+// Delete deletes the current node. A node in a slice is removed from the slice,
+// otherwise the field is set to nil, it panics if the field isn't a pointer or
+// an interface.
+func (c *Cursor) Delete() {
+	if c.iter == nil {
+		switch c.loc.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			c.loc.Set(reflect.Zero(c.loc.Type()))
+		default:
+			panic(fmt.Sprintf("astutil: field %s of type %s can't be deleted", c.name, c.loc.Type()))
+		}
+		c.node = nil
+		return
+	}
+	s, i := c.loc, c.iter.index
+	reflect.Copy(s.Slice(i, s.Len()), s.Slice(i+1, s.Len()))
+	s.Index(s.Len() - 1).Set(reflect.Zero(s.Type().Elem()))
+	s.SetLen(s.Len() - 1)
+	c.iter.step--
+	c.node = nil
+}
+
+// InsertBefore inserts n before the current node in the slice which holds it,
+// it panics if the node isn't in a slice.
+func (c *Cursor) InsertBefore(n ast.Node) {
+	s, i := c.sliceForInsert(), c.iter.index
+	v := nodeValue(n, s.Type().Elem(), c.name)
+	s.Set(reflect.Append(s, reflect.Zero(s.Type().Elem())))
+	reflect.Copy(s.Slice(i+1, s.Len()), s.Slice(i, s.Len()))
+	s.Index(i).Set(v)
+	c.iter.index++
+}
+
+// InsertAfter inserts n after the current node in the slice which holds it,
+// it panics if the node isn't in a slice.
+func (c *Cursor) InsertAfter(n ast.Node) {
+	s, i := c.sliceForInsert(), c.iter.index
+	v := nodeValue(n, s.Type().Elem(), c.name)
+	s.Set(reflect.Append(s, reflect.Zero(s.Type().Elem())))
+	reflect.Copy(s.Slice(i+2, s.Len()), s.Slice(i+1, s.Len()))
+	s.Index(i + 1).Set(v)
+	c.iter.step++
+}
+
+func (c *Cursor) sliceForInsert() reflect.Value {
+	if c.iter == nil {
+		panic(fmt.Sprintf("astutil: field %s isn't a slice", c.name))
+	}
+	if c.node == nil {
+		panic("astutil: insert next to a deleted node")
+	}
+	return c.loc
+}
+
+// nodeValue returns the value of n to be set to a location of type typ.
+func nodeValue(n ast.Node, typ reflect.Type, name string) reflect.Value {
+	v := reflect.ValueOf(n)
+	switch {
+	case n == nil:
+	case typ.Kind() == reflect.Struct && v.Type() == reflect.PtrTo(typ):
+		// the node is held by value.
+		return v.Elem()
+	case v.Type().AssignableTo(typ):
+		return v
+	}
+	panic(fmt.Sprintf("astutil: %T can't be assigned to field %s of type %s", n, name, typ))
+}
+
+type iterator struct {
+	index, step int
+}
+
+type applier struct {
+	pre, post ApplyFunc
+	cursor    Cursor
+	// parents is the stack of the nodes being traversed.
+	parents []ast.Node
+	visited map[ast.Node]struct{}
+}
+
+func (a *applier) apply(name string, iter *iterator, loc reflect.Value, n ast.Node) {
+	saved := a.cursor
+	a.cursor = Cursor{
+		parents: a.parents,
+		name:    name,
+		iter:    iter,
+		loc:     loc,
+		node:    n,
+	}
+	defer func() {
+		a.cursor = saved
+	}()
+	if a.pre != nil && !a.pre(&a.cursor) {
+		return
+	}
+	n = a.cursor.node
+	if n == nil {
+		return
+	}
+	if v := reflect.ValueOf(n); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		if _, ok := a.visited[n]; !ok {
+			a.visited[n] = struct{}{}
+			a.parents = append(a.parents, n)
+			a.applyStruct("", v.Elem())
+			a.parents = a.parents[:len(a.parents)-1]
+		}
+	}
+	if a.post != nil && !a.post(&a.cursor) {
+		panic(abort)
+	}
+}
+
+// applyStruct traverses the nodes held by the struct v, prefix is prepended to the field names.
+func (a *applier) applyStruct(prefix string, v reflect.Value) {
+	for _, f := range fieldsOf(v.Type()) {
+		fv := v.Field(f.index)
+		name := prefix + f.name
+		switch f.kind {
+		case fieldNode:
+			if n := nodeOf(fv); n != nil {
+				a.apply(name, nil, fv, n)
+			}
+		case fieldNodeList:
+			iter := &iterator{}
+			for iter.index = 0; iter.index < fv.Len(); iter.index += iter.step {
+				iter.step = 1
+				if n := nodeOf(fv.Index(iter.index)); n != nil {
+					a.apply(name, iter, fv, n)
+				}
+			}
+		case fieldStruct:
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			a.applyStruct(name+".", fv)
+		case fieldStructList:
+			for i := 0; i < fv.Len(); i++ {
+				elem := fv.Index(i)
+				if elem.Kind() == reflect.Ptr {
+					if elem.IsNil() {
+						continue
+					}
+					elem = elem.Elem()
+				}
+				a.applyStruct(name+".", elem)
+			}
+		case fieldInterface:
+			if fv.IsNil() {
+				continue
+			}
+			if elem := fv.Elem(); elem.Kind() == reflect.Ptr && !elem.IsNil() && isASTStruct(elem.Type()) {
+				a.applyStruct(name+".", elem.Elem())
+			}
+		}
+	}
+}
+
+// nodeOf returns the node held by v, or nil if there is none.
+func nodeOf(v reflect.Value) ast.Node {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Interface().(ast.Node)
+	case reflect.Struct:
+		return v.Addr().Interface().(ast.Node)
+	}
+	return nil
+}
+
+type fieldKind int
+
+const (
+	// fieldNode holds a node, by an interface, a pointer or a value.
+	fieldNode fieldKind = iota
+	// fieldNodeList holds a slice of nodes.
+	fieldNodeList
+	// fieldStruct holds a non-node struct of package ast, by a pointer or a value.
+	fieldStruct
+	// fieldStructList holds a slice of non-node structs of package ast.
+	fieldStructList
+	// fieldInterface holds a non-node interface, such as ast.PartitionDefinitionClause,
+	// whose dynamic value may be a non-node struct of package ast.
+	fieldInterface
+)
+
+type field struct {
+	index int
+	name  string
+	kind  fieldKind
+}
+
+var (
+	nodeType        = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	resultFieldType = reflect.TypeOf(ast.ResultField{})
+	astPkgPath      = resultFieldType.PkgPath()
+
+	fieldsMu    sync.RWMutex
+	fieldsCache = make(map[reflect.Type][]field)
+)
+
+// fieldsOf returns the fields of the struct type t which may hold nodes.
+func fieldsOf(t reflect.Type) []field {
+	fieldsMu.RLock()
+	cached, ok := fieldsCache[t]
+	fieldsMu.RUnlock()
+	if ok {
+		return cached
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			// unexported.
+			continue
+		}
+		ft := f.Type
+		kind := fieldNode
+		if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+			if ft.Kind() == reflect.Array {
+				// the elements of an array can't be deleted or inserted.
+				continue
+			}
+			ft = ft.Elem()
+			kind = fieldNodeList
+		}
+		switch {
+		case isNodeType(ft):
+		case isASTStruct(ft):
+			if kind == fieldNodeList {
+				kind = fieldStructList
+			} else {
+				kind = fieldStruct
+			}
+		case ft.Kind() == reflect.Interface && ft.PkgPath() == astPkgPath && kind == fieldNode:
+			kind = fieldInterface
+		default:
+			continue
+		}
+		fields = append(fields, field{index: i, name: f.Name, kind: kind})
+	}
+	fieldsMu.Lock()
+	fieldsCache[t] = fields
+	fieldsMu.Unlock()
+	return fields
+}
+
+func isNodeType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return t.Implements(nodeType)
+	case reflect.Struct:
+		return reflect.PtrTo(t).Implements(nodeType)
+	}
+	return false
+}
+
+func isASTStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// the ResultFields are bound to the columns by the resolving process.
+	return t.Kind() == reflect.Struct && t.PkgPath() == astPkgPath && t != resultFieldType
+}
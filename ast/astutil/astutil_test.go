@@ -0,0 +1,282 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astutil_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	. "github.com/pingcap/parser/ast/astutil"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/model"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testAstUtilSuite{})
+
+type testAstUtilSuite struct {
+}
+
+func parse(c *C, sql string) ast.StmtNode {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	return stmt
+}
+
+func restore(c *C, n ast.Node) string {
+	var sb strings.Builder
+	c.Assert(n.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)), IsNil)
+	return sb.String()
+}
+
+func (s *testAstUtilSuite) TestApplyRewrite(c *C) {
+	// qualify every unqualified column and replace table t1 with t2.
+	stmt := parse(c, "SELECT a, t1.b FROM t1 WHERE c IN (SELECT d FROM t3) ORDER BY a")
+	Apply(stmt, func(cur *Cursor) bool {
+		switch n := cur.Node().(type) {
+		case *ast.ColumnName:
+			if n.Table.L == "" {
+				n.Table = model.NewCIStr("t1")
+			}
+		case *ast.TableName:
+			if n.Name.L == "t1" {
+				cur.Replace(&ast.TableName{Name: model.NewCIStr("t2")})
+			}
+		}
+		return true
+	}, nil)
+	c.Assert(restore(c, stmt), Equals, "SELECT `t1`.`a`,`t1`.`b` FROM `t2` WHERE `t1`.`c` IN (SELECT `t1`.`d` FROM `t3`) ORDER BY `t1`.`a`")
+
+	// the replaced nodes are traversed.
+	var names []string
+	Inspect(stmt, func(n ast.Node) bool {
+		if tn, ok := n.(*ast.TableName); ok {
+			names = append(names, tn.Name.O)
+		}
+		return true
+	})
+	c.Assert(names, DeepEquals, []string{"t2", "t3"})
+
+	// the root can be replaced too.
+	root := Apply(stmt, func(cur *Cursor) bool {
+		if cur.Parent() == nil {
+			cur.Replace(parse(c, "SELECT 1"))
+		}
+		return true
+	}, nil)
+	c.Assert(restore(c, root), Equals, "SELECT 1")
+}
+
+func (s *testAstUtilSuite) TestCursorList(c *C) {
+	stmt := parse(c, "SELECT a, b, c FROM t WHERE a > 1")
+	Apply(stmt, nil, func(cur *Cursor) bool {
+		field, ok := cur.Node().(*ast.SelectField)
+		if !ok {
+			return true
+		}
+		c.Assert(cur.Name(), Equals, "Fields")
+		switch field.Expr.(*ast.ColumnNameExpr).Name.Name.L {
+		case "a":
+			c.Assert(cur.Index(), Equals, 0)
+			cur.InsertBefore(&ast.SelectField{WildCard: &ast.WildCardField{}})
+		case "b":
+			c.Assert(cur.Index(), Equals, 2)
+			cur.Delete()
+		case "c":
+			c.Assert(cur.Index(), Equals, 2)
+			cur.InsertAfter(&ast.SelectField{Expr: ast.NewValueExpr(1, "", "")})
+		}
+		return true
+	})
+	c.Assert(restore(c, stmt), Equals, "SELECT *,`a`,`c`,1 FROM `t` WHERE `a`>1")
+
+	// a field which isn't a slice is set to nil by Delete.
+	Apply(stmt, func(cur *Cursor) bool {
+		if cur.Name() == "Where" {
+			c.Assert(cur.Index(), Equals, -1)
+			cur.Delete()
+		}
+		return true
+	}, nil)
+	c.Assert(restore(c, stmt), Equals, "SELECT *,`a`,`c`,1 FROM `t`")
+
+	Apply(stmt, func(cur *Cursor) bool {
+		if cur.Name() == "From" {
+			c.Assert(func() { cur.InsertAfter(&ast.TableRefsClause{}) }, PanicMatches, "astutil: field From isn't a slice")
+			c.Assert(func() { cur.Replace(&ast.TableName{}) }, PanicMatches, "astutil: \\*ast.TableName can't be assigned to field From of type \\*ast.TableRefsClause")
+		}
+		return true
+	}, nil)
+}
+
+func (s *testAstUtilSuite) TestCursorParents(c *C) {
+	stmt := parse(c, "CREATE TABLE t (a int DEFAULT 1, b int) UNION = (t1, t2) PARTITION BY RANGE (a) (PARTITION p0 VALUES LESS THAN (10))")
+	var (
+		parents []string
+		names   []string
+	)
+	Apply(stmt, func(cur *Cursor) bool {
+		switch n := cur.Node().(type) {
+		case ast.ValueExpr:
+			if n.GetValue() == int64(1) {
+				for _, p := range cur.Parents() {
+					parents = append(parents, reflectName(p))
+				}
+			}
+		case *ast.TableName:
+			names = append(names, cur.Name())
+		}
+		return true
+	}, nil)
+	c.Assert(parents, DeepEquals, []string{"ColumnOption", "ColumnDef", "CreateTableStmt"})
+	c.Assert(names, DeepEquals, []string{"Table", "Options.TableNames", "Options.TableNames"})
+
+	// the nodes in the non-node structs are traversed.
+	var exprs int
+	InspectType(stmt, func(ast.ExprNode) bool {
+		exprs++
+		return true
+	})
+	// 1, a and 10.
+	c.Assert(exprs, Equals, 3)
+}
+
+func (s *testAstUtilSuite) TestInspectType(c *C) {
+	stmt := parse(c, "SELECT a FROM t1 JOIN (SELECT b FROM t2) AS s ON t1.id = s.id WHERE c = (SELECT MAX(d) FROM t3)")
+	var tables []string
+	for _, tn := range TableNames(stmt) {
+		tables = append(tables, tn.Name.O)
+	}
+	c.Assert(tables, DeepEquals, []string{"t1", "t2", "t3"})
+	var columns []string
+	for _, cn := range ColumnNames(stmt) {
+		columns = append(columns, cn.String())
+	}
+	// the FROM clause comes before the fields in ast.SelectStmt.
+	c.Assert(columns, DeepEquals, []string{"b", "t1.id", "s.id", "c", "d", "a"})
+
+	// the children are skipped if f returns false.
+	var selects int
+	InspectType(stmt, func(*ast.SelectStmt) bool {
+		selects++
+		return false
+	})
+	c.Assert(selects, Equals, 1)
+
+	c.Assert(func() { InspectType(stmt, func(int) bool { return true }) }, PanicMatches, "astutil: InspectType expects .*")
+
+	// the traversal stops if post returns false.
+	var visited int
+	Apply(stmt, nil, func(cur *Cursor) bool {
+		visited++
+		tn, ok := cur.Node().(*ast.TableName)
+		return !ok || tn.Name.L != "t2"
+	})
+	// t1, its TableSource and t2.
+	c.Assert(visited, Equals, 3)
+}
+
+// tableNameCollector collects the table names visited by Accept.
+type tableNameCollector struct {
+	names []string
+}
+
+func (v *tableNameCollector) Enter(n ast.Node) (ast.Node, bool) {
+	if tn, ok := n.(*ast.TableName); ok {
+		v.names = append(v.names, tn.Name.O)
+	}
+	return n, false
+}
+
+func (v *tableNameCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+func (s *testAstUtilSuite) TestAcceptDivergence(c *C) {
+	cases := []struct {
+		sql    string
+		apply  []string
+		accept []string
+	}{
+		{"CREATE VIEW v AS SELECT * FROM t", []string{"v", "t"}, []string{"v", "t"}},
+		// the names of the routines, the triggers and the events are only reached by Apply.
+		{"CREATE PROCEDURE p() SELECT * FROM t", []string{"p", "t"}, []string{"t"}},
+		{"CREATE FUNCTION f() RETURNS INT RETURN 1", []string{"f"}, nil},
+		{"DROP PROCEDURE p", []string{"p"}, nil},
+		{"CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW SET NEW.a = 1", []string{"tr", "t"}, []string{"t"}},
+		{"DROP EVENT e", []string{"e"}, nil},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt := parse(c, ca.sql)
+		var names []string
+		Apply(stmt, func(cur *Cursor) bool {
+			if tn, ok := cur.Node().(*ast.TableName); ok {
+				names = append(names, tn.Name.O)
+			}
+			return true
+		}, nil)
+		c.Assert(names, DeepEquals, ca.apply, comment)
+		v := &tableNameCollector{}
+		stmt.Accept(v)
+		c.Assert(v.names, DeepEquals, ca.accept, comment)
+	}
+
+	// the routine name can be told apart by the field holding it.
+	var fields []string
+	Apply(parse(c, "CREATE PROCEDURE p() SELECT * FROM t"), func(cur *Cursor) bool {
+		if _, ok := cur.Node().(*ast.TableName); ok {
+			fields = append(fields, reflectName(cur.Parent())+"."+cur.Name())
+		}
+		return true
+	}, nil)
+	c.Assert(fields, DeepEquals, []string{"CreateProcedureStmt.Name", "TableSource.Source"})
+}
+
+func (s *testAstUtilSuite) TestTableNamesOfRoutines(c *C) {
+	cases := []struct {
+		sql    string
+		tables []string
+	}{
+		{"CREATE PROCEDURE p() SELECT * FROM t", []string{"t"}},
+		{"CREATE FUNCTION f() RETURNS INT RETURN (SELECT MAX(a) FROM t)", []string{"t"}},
+		{"DROP PROCEDURE p", nil},
+		{"CALL p(1)", nil},
+		{"CALL db.p((SELECT a FROM t))", []string{"t"}},
+		{"CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW INSERT INTO log VALUES (NEW.a)", []string{"t", "log"}},
+		{"DROP TRIGGER tr", nil},
+		{"CREATE EVENT e ON SCHEDULE EVERY 1 DAY DO DELETE FROM t", []string{"t"}},
+		{"ALTER EVENT e RENAME TO e2", nil},
+		{"DROP EVENT e", nil},
+	}
+	for _, ca := range cases {
+		var tables []string
+		for _, tn := range TableNames(parse(c, ca.sql)) {
+			tables = append(tables, tn.Name.O)
+		}
+		c.Assert(tables, DeepEquals, ca.tables, Commentf("for %s", ca.sql))
+	}
+}
+
+func reflectName(n ast.Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astutil
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pingcap/parser/ast"
+)
+
+// Inspect traverses root in pre-order like Apply, f is called for each node and
+// the children of the node are skipped if f returns false.
+func Inspect(root ast.Node, f func(ast.Node) bool) {
+	Apply(root, func(c *Cursor) bool {
+		return f(c.Node())
+	}, nil)
+}
+
+// InspectType is like Inspect, but f is only called for the nodes of its argument
+// type, which is a node type such as *ast.TableName or an interface such as
+// ast.ExprNode. f must be a function like `func(*ast.TableName) bool`, or it panics.
+func InspectType(root ast.Node, f interface{}) {
+	fv := reflect.ValueOf(f)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.NumOut() != 1 || ft.Out(0).Kind() != reflect.Bool ||
+		!ft.In(0).Implements(nodeType) {
+		panic(fmt.Sprintf("astutil: InspectType expects a func(<node type>) bool, but got %s", ft))
+	}
+	typ := ft.In(0)
+	in := make([]reflect.Value, 1)
+	Inspect(root, func(n ast.Node) bool {
+		v := reflect.ValueOf(n)
+		if !v.Type().AssignableTo(typ) {
+			return true
+		}
+		in[0] = v
+		return fv.Call(in)[0].Bool()
+	})
+}
+
+// TableNames returns the table names in root, in the order of traversal. Unlike Apply,
+// they don't include the names of the routines, the triggers and the events, which are
+// held by ast.TableName too.
+func TableNames(root ast.Node) []*ast.TableName {
+	var names []*ast.TableName
+	Apply(root, func(c *Cursor) bool {
+		if n, ok := c.Node().(*ast.TableName); ok && !isRoutineName(c) {
+			names = append(names, n)
+		}
+		return true
+	}, nil)
+	return names
+}
+
+// isRoutineName tells whether the table name at c names a routine, a trigger or an event.
+func isRoutineName(c *Cursor) bool {
+	switch c.Parent().(type) {
+	case *ast.CreateProcedureStmt, *ast.CreateFunctionStmt, *ast.AlterProcedureStmt, *ast.DropProcedureStmt,
+		*ast.CreateTriggerStmt, *ast.DropTriggerStmt,
+		*ast.CreateEventStmt, *ast.AlterEventStmt, *ast.DropEventStmt:
+		return c.Name() == "Name" || c.Name() == "NewName"
+	}
+	return false
+}
+
+// ColumnNames returns the column names in root, in the order of traversal.
+func ColumnNames(root ast.Node) []*ast.ColumnName {
+	var names []*ast.ColumnName
+	InspectType(root, func(n *ast.ColumnName) bool {
+		names = append(names, n)
+		return true
+	})
+	return names
+}
@@ -22,6 +22,28 @@ import (
 type node struct {
 	text   string
 	offset int
+	start  pos
+	end    pos
+	// comments is nil unless some comments are attached.
+	comments *nodeComments
+}
+
+// pos is Pos kept in less memory, since every node has two of them.
+type pos struct {
+	line, col, offset int32
+}
+
+func packPos(p Pos) pos {
+	return pos{int32(p.Line), int32(p.Col), int32(p.Offset)}
+}
+
+func (p pos) unpack() Pos {
+	return Pos{Line: int(p.line), Col: int(p.col), Offset: int(p.offset)}
+}
+
+type nodeComments struct {
+	leading  []*Comment
+	trailing []*Comment
 }
 
 // SetOriginTextPosition implements Node interface.
@@ -34,6 +56,49 @@ func (n *node) OriginTextPosition() int {
 	return n.offset
 }
 
+// SetPos implements Node interface.
+func (n *node) SetPos(start, end Pos) {
+	n.start, n.end = packPos(start), packPos(end)
+}
+
+// StartPos implements Node interface.
+func (n *node) StartPos() Pos {
+	return n.start.unpack()
+}
+
+// EndPos implements Node interface.
+func (n *node) EndPos() Pos {
+	return n.end.unpack()
+}
+
+// AttachComment implements Node interface.
+func (n *node) AttachComment(c *Comment, trailing bool) {
+	if n.comments == nil {
+		n.comments = &nodeComments{}
+	}
+	if trailing {
+		n.comments.trailing = append(n.comments.trailing, c)
+	} else {
+		n.comments.leading = append(n.comments.leading, c)
+	}
+}
+
+// LeadingComments implements Node interface.
+func (n *node) LeadingComments() []*Comment {
+	if n.comments == nil {
+		return nil
+	}
+	return n.comments.leading
+}
+
+// TrailingComments implements Node interface.
+func (n *node) TrailingComments() []*Comment {
+	if n.comments == nil {
+		return nil
+	}
+	return n.comments.trailing
+}
+
 // SetText implements Node interface.
 func (n *node) SetText(text string) {
 	n.text = text
@@ -0,0 +1,206 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pingcap/parser/format"
+)
+
+// commentAnchor is a node which comments can be attached to.
+type commentAnchor struct {
+	node       Node
+	depth      int
+	start, end int
+}
+
+func newCommentAnchor(n Node, depth int) *commentAnchor {
+	return &commentAnchor{node: n, depth: depth, start: n.StartPos().Offset, end: n.EndPos().Offset}
+}
+
+// contains returns true if a is inside the anchor, which is nil for the whole text.
+func (enclosing *commentAnchor) contains(a *commentAnchor) bool {
+	return enclosing == nil || (a != enclosing && enclosing.start <= a.start && a.end <= enclosing.end)
+}
+
+// anchorCollector collects the nodes which comments can be attached to.
+type anchorCollector struct {
+	anchors []*commentAnchor
+	depth   int
+}
+
+// Enter implements Visitor interface.
+func (c *anchorCollector) Enter(n Node) (Node, bool) {
+	c.depth++
+	if canHoldComments(n) && n.EndPos() != (Pos{}) {
+		c.anchors = append(c.anchors, newCommentAnchor(n, c.depth))
+	}
+	return n, false
+}
+
+// Leave implements Visitor interface.
+func (c *anchorCollector) Leave(n Node) (Node, bool) {
+	c.depth--
+	return n, true
+}
+
+// canHoldComments returns true if the Restore method of n emits the comments attached
+// to it. They are the statements and the nodes which are usually written on their own.
+func canHoldComments(n Node) bool {
+	switch n.(type) {
+	case *UnparsedStmt:
+		// the text of an UnparsedStmt includes its comments.
+		return false
+	case StmtNode, *ColumnDef, *Constraint, *AlterTableSpec, *SelectField, *TableSource, *ByItem, *Assignment:
+		return true
+	}
+	return false
+}
+
+// AttachComments attaches the comments, which are sorted by their positions, to the
+// nodes of stmts. Comments are attached to the statements, column definitions,
+// constraints, ALTER TABLE specifications, select fields, table sources, ORDER BY
+// and GROUP BY items and assignments, whose Restore emits them again if the
+// format.RestoreComments flag is set. Among the nodes inside the innermost one
+// enclosing a comment:
+//   - a comment following a node on the same line is attached after it,
+//   - otherwise it's attached before the next node,
+//   - otherwise it's attached after the previous node.
+//
+// If there are no such nodes, the comment is attached after the enclosing one.
+// The comments inside an UnparsedStmt are dropped, since its text includes them.
+func AttachComments(stmts []StmtNode, comments []*Comment) {
+	collector := &anchorCollector{}
+	for _, stmt := range stmts {
+		if un, ok := stmt.(*UnparsedStmt); ok {
+			collector.anchors = append(collector.anchors, newCommentAnchor(un, 1))
+			continue
+		}
+		stmt.Accept(collector)
+	}
+	attachComments(comments, collector.anchors)
+}
+
+// attachComments attaches the comments in a single pass over them and the anchors,
+// which are walked in the order of their positions. The anchors are nested like the
+// nodes, so the ones enclosing the current comment are kept in a stack.
+func attachComments(comments []*Comment, anchors []*commentAnchor) {
+	// byStart is sorted by the start, the outer one first if several start at the
+	// same position, and byEnd is sorted by the end, the outer one last.
+	byStart := append([]*commentAnchor(nil), anchors...)
+	sort.SliceStable(byStart, func(i, j int) bool {
+		a, b := byStart[i], byStart[j]
+		return a.start < b.start || (a.start == b.start && a.depth < b.depth)
+	})
+	byEnd := append([]*commentAnchor(nil), anchors...)
+	sort.SliceStable(byEnd, func(i, j int) bool {
+		a, b := byEnd[i], byEnd[j]
+		return a.end < b.end || (a.end == b.end && a.depth > b.depth)
+	})
+
+	var (
+		// open holds the anchors enclosing the current position, the innermost last.
+		open []*commentAnchor
+		// opened is the number of the anchors in byStart starting before the comment,
+		// ended is the number of those in byEnd ending before it.
+		opened, ended int
+	)
+	for _, c := range comments {
+		for ; opened < len(byStart) && byStart[opened].start <= c.Start.Offset; opened++ {
+			a := byStart[opened]
+			for len(open) > 0 && open[len(open)-1].end <= a.start {
+				open = open[:len(open)-1]
+			}
+			open = append(open, a)
+		}
+		for len(open) > 0 && open[len(open)-1].end < c.End.Offset {
+			open = open[:len(open)-1]
+		}
+		var enclosing *commentAnchor
+		if len(open) > 0 {
+			enclosing = open[len(open)-1]
+		}
+		if enclosing != nil && !canHoldComments(enclosing.node) {
+			continue
+		}
+
+		for ended < len(byEnd) && byEnd[ended].end <= c.Start.Offset {
+			ended++
+		}
+		var prev, next *commentAnchor
+		for i := ended - 1; i >= 0 && (enclosing == nil || byEnd[i].end >= enclosing.start); i-- {
+			if enclosing.contains(byEnd[i]) {
+				prev = byEnd[i]
+				break
+			}
+		}
+		i := opened
+		for i < len(byStart) && byStart[i].start < c.End.Offset {
+			i++
+		}
+		for ; i < len(byStart) && (enclosing == nil || byStart[i].start <= enclosing.end); i++ {
+			if enclosing.contains(byStart[i]) {
+				next = byStart[i]
+				break
+			}
+		}
+
+		switch {
+		case prev != nil && prev.node.EndPos().Line == c.Start.Line:
+			prev.node.AttachComment(c, true)
+		case next != nil:
+			next.node.AttachComment(c, false)
+		case prev != nil:
+			prev.node.AttachComment(c, true)
+		case enclosing != nil:
+			enclosing.node.AttachComment(c, true)
+		}
+	}
+}
+
+// isLineComment returns true if the comment is terminated by the line end.
+func isLineComment(text string) bool {
+	return strings.HasPrefix(text, "--") || strings.HasPrefix(text, "#")
+}
+
+// restoreLeadingComments writes the comments attached before n.
+func restoreLeadingComments(ctx *format.RestoreCtx, n Node) {
+	if !ctx.Flags.HasCommentsFlag() {
+		return
+	}
+	for _, c := range n.LeadingComments() {
+		ctx.WritePlain(c.Text)
+		if isLineComment(c.Text) {
+			ctx.WriteLineBreak("\n")
+		} else {
+			ctx.WritePlain(" ")
+		}
+	}
+}
+
+// restoreTrailingComments writes the comments attached after n.
+func restoreTrailingComments(ctx *format.RestoreCtx, n Node) {
+	if !ctx.Flags.HasCommentsFlag() {
+		return
+	}
+	for _, c := range n.TrailingComments() {
+		ctx.WritePlain(" ")
+		ctx.WritePlain(c.Text)
+		if isLineComment(c.Text) {
+			ctx.WriteLineBreak("\n")
+		}
+	}
+}
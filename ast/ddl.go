@@ -14,6 +14,9 @@
 package ast
 
 import (
+	"strings"
+	"unicode/utf8"
+
 	"github.com/pingcap/errors"
 	"github.com/pingcap/parser/auth"
 	"github.com/pingcap/parser/format"
@@ -38,6 +41,17 @@ var (
 	_ DDLNode = &RenameTableStmt{}
 	_ DDLNode = &TruncateTableStmt{}
 	_ DDLNode = &RepairTableStmt{}
+	_ DDLNode = &CreateProcedureStmt{}
+	_ DDLNode = &CreateFunctionStmt{}
+	_ DDLNode = &AlterProcedureStmt{}
+	_ DDLNode = &DropProcedureStmt{}
+	_ DDLNode = &CreateTriggerStmt{}
+	_ DDLNode = &DropTriggerStmt{}
+	_ DDLNode = &CreateEventStmt{}
+	_ DDLNode = &AlterEventStmt{}
+	_ DDLNode = &DropEventStmt{}
+
+	_ StmtNode = &ReturnStmt{}
 
 	_ Node = &AlterTableSpec{}
 	_ Node = &ColumnDef{}
@@ -46,6 +60,8 @@ var (
 	_ Node = &Constraint{}
 	_ Node = &IndexPartSpecification{}
 	_ Node = &ReferenceDef{}
+	_ Node = &StoredParameter{}
+	_ Node = &EventSchedule{}
 )
 
 // CharsetOpt is used for parsing charset option from SQL.
@@ -110,6 +126,9 @@ type CreateDatabaseStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateDatabaseStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("CREATE DATABASE ")
 	if n.IfNotExists {
 		ctx.WriteKeyWord("IF NOT EXISTS ")
@@ -147,6 +166,9 @@ type AlterDatabaseStmt struct {
 
 // Restore implements Node interface.
 func (n *AlterDatabaseStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("ALTER DATABASE")
 	if !n.AlterDefaultDatabase {
 		ctx.WritePlain(" ")
@@ -183,6 +205,9 @@ type DropDatabaseStmt struct {
 
 // Restore implements Node interface.
 func (n *DropDatabaseStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("DROP DATABASE ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -493,6 +518,19 @@ type ColumnOption struct {
 
 // Restore implements Node interface.
 func (n *ColumnOption) Restore(ctx *format.RestoreCtx) error {
+	if ctx.Dialect == format.DialectANSI {
+		switch n.Tp {
+		case ColumnOptionAutoIncrement:
+			ctx.WriteKeyWord("GENERATED BY DEFAULT AS IDENTITY")
+			return nil
+		case ColumnOptionOnUpdate:
+			return ctx.Unsupported("ON UPDATE", format.DialectANSI)
+		case ColumnOptionComment:
+			return ctx.Unsupported("COMMENT", format.DialectANSI)
+		case ColumnOptionColumnFormat, ColumnOptionStorage, ColumnOptionAutoRandom:
+			return ctx.Unsupported("column storage options", format.DialectANSI)
+		}
+	}
 	switch n.Tp {
 	case ColumnOptionNoOption:
 		return nil
@@ -741,6 +779,9 @@ type Constraint struct {
 
 // Restore implements Node interface.
 func (n *Constraint) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	switch n.Tp {
 	case ConstraintNoConstraint:
 		return nil
@@ -876,11 +917,21 @@ type ColumnDef struct {
 
 // Restore implements Node interface.
 func (n *ColumnDef) Restore(ctx *format.RestoreCtx) error {
+	return n.restore(ctx, 0)
+}
+
+// restore restores the column definition, padding is the number of spaces added
+// after the column name to align the column types.
+func (n *ColumnDef) restore(ctx *format.RestoreCtx, padding int) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if err := n.Name.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while splicing ColumnDef Name")
 	}
 	if n.Tp != nil {
 		ctx.WritePlain(" ")
+		ctx.WritePlain(strings.Repeat(" ", padding))
 		if err := n.Tp.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while splicing ColumnDef Type")
 		}
@@ -964,6 +1015,9 @@ type CreateTableStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateTableStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	switch n.TemporaryKeyword {
 	case TemporaryNone:
 		ctx.WriteKeyWord("CREATE TABLE ")
@@ -989,12 +1043,35 @@ func (n *CreateTableStmt) Restore(ctx *format.RestoreCtx) error {
 	lenCols := len(n.Cols)
 	lenConstraints := len(n.Constraints)
 	if lenCols+lenConstraints > 0 {
+		// in the pretty-printing mode, the columns and constraints are on their own
+		// lines, and the column types are aligned.
+		var nameWidths []int
+		maxNameWidth := 0
+		if ctx.IsPretty() {
+			nameWidths = make([]int, lenCols)
+			for i, col := range n.Cols {
+				var sb strings.Builder
+				if err := col.Name.Restore(format.NewRestoreCtx(ctx.Flags, &sb)); err != nil {
+					return errors.Annotatef(err, "An error occurred while splicing CreateTableStmt ColumnDef: [%v]", i)
+				}
+				nameWidths[i] = utf8.RuneCountInString(sb.String())
+				if nameWidths[i] > maxNameWidth {
+					maxNameWidth = nameWidths[i]
+				}
+			}
+		}
 		ctx.WritePlain(" (")
+		ctx.Indent()
 		for i, col := range n.Cols {
 			if i > 0 {
 				ctx.WritePlain(",")
 			}
-			if err := col.Restore(ctx); err != nil {
+			ctx.WriteLineBreak("")
+			padding := 0
+			if nameWidths != nil {
+				padding = maxNameWidth - nameWidths[i]
+			}
+			if err := col.restore(ctx, padding); err != nil {
 				return errors.Annotatef(err, "An error occurred while splicing CreateTableStmt ColumnDef: [%v]", i)
 			}
 		}
@@ -1002,13 +1079,21 @@ func (n *CreateTableStmt) Restore(ctx *format.RestoreCtx) error {
 			if i > 0 || lenCols >= 1 {
 				ctx.WritePlain(",")
 			}
+			ctx.WriteLineBreak("")
 			if err := constraint.Restore(ctx); err != nil {
 				return errors.Annotatef(err, "An error occurred while splicing CreateTableStmt Constraints: [%v]", i)
 			}
 		}
+		ctx.Unindent()
+		ctx.WriteLineBreak("")
 		ctx.WritePlain(")")
 	}
 
+	if len(n.Options) != 0 {
+		if err := ctx.Unsupported("table options", format.DialectANSI); err != nil {
+			return err
+		}
+	}
 	for i, option := range n.Options {
 		ctx.WritePlain(" ")
 		if err := option.Restore(ctx); err != nil {
@@ -1017,6 +1102,9 @@ func (n *CreateTableStmt) Restore(ctx *format.RestoreCtx) error {
 	}
 
 	if n.Partition != nil {
+		if err := ctx.Unsupported("PARTITION BY", format.DialectANSI); err != nil {
+			return err
+		}
 		ctx.WritePlain(" ")
 		if err := n.Partition.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while splicing CreateTableStmt Partition")
@@ -1113,6 +1201,9 @@ type DropTableStmt struct {
 
 // Restore implements Node interface.
 func (n *DropTableStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.IsView {
 		ctx.WriteKeyWord("DROP VIEW ")
 	} else {
@@ -1168,6 +1259,12 @@ type DropSequenceStmt struct {
 
 // Restore implements Node interface.
 func (n *DropSequenceStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "DROP SEQUENCE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("DROP SEQUENCE ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -1211,6 +1308,9 @@ type RenameTableStmt struct {
 
 // Restore implements Node interface.
 func (n *RenameTableStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("RENAME TABLE ")
 	for index, table2table := range n.TableToTables {
 		if index != 0 {
@@ -1299,6 +1399,9 @@ type CreateViewStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateViewStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("CREATE ")
 	if n.OrReplace {
 		ctx.WriteKeyWord("OR REPLACE ")
@@ -1387,6 +1490,12 @@ type CreateSequenceStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateSequenceStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "CREATE SEQUENCE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("CREATE ")
 	ctx.WriteKeyWord("SEQUENCE ")
 	if n.IfNotExists {
@@ -1494,6 +1603,9 @@ type CreateIndexStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateIndexStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("CREATE ")
 	switch n.KeyType {
 	case IndexKeyTypeUnique:
@@ -1590,6 +1702,9 @@ type DropIndexStmt struct {
 
 // Restore implements Node interface.
 func (n *DropIndexStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("DROP INDEX ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -1665,6 +1780,9 @@ func (n *LockTablesStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *LockTablesStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("LOCK TABLES ")
 	for i, tl := range n.TableLocks {
 		if i != 0 {
@@ -1691,6 +1809,9 @@ func (n *UnlockTablesStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *UnlockTablesStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("UNLOCK TABLES")
 	return nil
 }
@@ -1721,6 +1842,12 @@ func (n *CleanupTableLockStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *CleanupTableLockStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "ADMIN CLEANUP TABLE LOCK"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("ADMIN CLEANUP TABLE LOCK ")
 	for i, v := range n.Tables {
 		if i != 0 {
@@ -1762,6 +1889,12 @@ func (n *RepairTableStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *RepairTableStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "ADMIN REPAIR TABLE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("ADMIN REPAIR TABLE ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotatef(err, "An error occurred while restore RepairTableStmt.table : [%v]", n.Table)
@@ -2363,6 +2496,9 @@ func (n *AlterOrderItem) Restore(ctx *format.RestoreCtx) error {
 
 // Restore implements Node interface.
 func (n *AlterTableSpec) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	switch n.Tp {
 	case AlterTableSetTiFlashReplica:
 		ctx.WriteKeyWord("SET TIFLASH REPLICA ")
@@ -2929,6 +3065,9 @@ type AlterTableStmt struct {
 
 // Restore implements Node interface.
 func (n *AlterTableStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("ALTER TABLE ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore AlterTableStmt.Table")
@@ -2978,6 +3117,9 @@ type TruncateTableStmt struct {
 
 // Restore implements Node interface.
 func (n *TruncateTableStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("TRUNCATE TABLE ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore TruncateTableStmt.Table")
@@ -3494,6 +3636,12 @@ type RecoverTableStmt struct {
 
 // Restore implements Node interface.
 func (n *RecoverTableStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "RECOVER TABLE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("RECOVER TABLE ")
 	if n.JobID != 0 {
 		ctx.WriteKeyWord("BY JOB ")
@@ -3537,6 +3685,12 @@ type FlashBackTableStmt struct {
 
 // Restore implements Node interface.
 func (n *FlashBackTableStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "FLASHBACK TABLE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("FLASHBACK TABLE ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while splicing RecoverTableStmt Table")
@@ -3661,6 +3815,12 @@ type AlterSequenceStmt struct {
 }
 
 func (n *AlterSequenceStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "ALTER SEQUENCE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("ALTER SEQUENCE ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -3690,3 +3850,881 @@ func (n *AlterSequenceStmt) Accept(v Visitor) (Node, bool) {
 	n.Name = node.(*TableName)
 	return v.Leave(n)
 }
+
+// ProcedureParamMode is the mode of a stored procedure parameter.
+type ProcedureParamMode int
+
+// Stored procedure parameter modes, stored function parameters have no mode.
+const (
+	ProcedureParamModeNone ProcedureParamMode = iota
+	ProcedureParamModeIn
+	ProcedureParamModeOut
+	ProcedureParamModeInOut
+)
+
+// String implements fmt.Stringer interface.
+func (m ProcedureParamMode) String() string {
+	switch m {
+	case ProcedureParamModeIn:
+		return "IN"
+	case ProcedureParamModeOut:
+		return "OUT"
+	case ProcedureParamModeInOut:
+		return "INOUT"
+	}
+	return ""
+}
+
+// StoredParameter is a parameter of a stored procedure or function.
+type StoredParameter struct {
+	node
+
+	Mode ProcedureParamMode
+	Name string
+	Tp   *types.FieldType
+}
+
+// Restore implements Node interface.
+func (n *StoredParameter) Restore(ctx *format.RestoreCtx) error {
+	if n.Mode != ProcedureParamModeNone {
+		ctx.WriteKeyWord(n.Mode.String())
+		ctx.WritePlain(" ")
+	}
+	ctx.WriteName(n.Name)
+	ctx.WritePlain(" ")
+	if err := n.Tp.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while splicing StoredParameter.Tp")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *StoredParameter) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*StoredParameter)
+	return v.Leave(n)
+}
+
+// RoutineCharacteristicType is the type of stored routine characteristics.
+type RoutineCharacteristicType int
+
+// Stored routine characteristic types.
+const (
+	RoutineCharacteristicComment RoutineCharacteristicType = iota + 1
+	RoutineCharacteristicLanguageSQL
+	RoutineCharacteristicDeterministic
+	RoutineCharacteristicSQLDataAccess
+	RoutineCharacteristicSQLSecurity
+)
+
+// RoutineSQLDataAccess is the nature of the data used by a stored routine.
+type RoutineSQLDataAccess int
+
+// Stored routine SQL data access characteristics.
+const (
+	RoutineContainsSQL RoutineSQLDataAccess = iota
+	RoutineNoSQL
+	RoutineReadsSQLData
+	RoutineModifiesSQLData
+)
+
+// String implements fmt.Stringer interface.
+func (a RoutineSQLDataAccess) String() string {
+	switch a {
+	case RoutineContainsSQL:
+		return "CONTAINS SQL"
+	case RoutineNoSQL:
+		return "NO SQL"
+	case RoutineReadsSQLData:
+		return "READS SQL DATA"
+	case RoutineModifiesSQLData:
+		return "MODIFIES SQL DATA"
+	}
+	return ""
+}
+
+// RoutineCharacteristic is a characteristic of stored procedures and functions.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-procedure.html
+type RoutineCharacteristic struct {
+	Tp RoutineCharacteristicType
+	// Comment is valid for RoutineCharacteristicComment.
+	Comment string
+	// Deterministic is valid for RoutineCharacteristicDeterministic.
+	Deterministic bool
+	// DataAccess is valid for RoutineCharacteristicSQLDataAccess.
+	DataAccess RoutineSQLDataAccess
+	// Security is valid for RoutineCharacteristicSQLSecurity.
+	Security model.ViewSecurity
+}
+
+// Restore implements Node interface.
+func (n *RoutineCharacteristic) Restore(ctx *format.RestoreCtx) error {
+	switch n.Tp {
+	case RoutineCharacteristicComment:
+		ctx.WriteKeyWord("COMMENT ")
+		ctx.WriteString(n.Comment)
+	case RoutineCharacteristicLanguageSQL:
+		ctx.WriteKeyWord("LANGUAGE SQL")
+	case RoutineCharacteristicDeterministic:
+		if !n.Deterministic {
+			ctx.WriteKeyWord("NOT ")
+		}
+		ctx.WriteKeyWord("DETERMINISTIC")
+	case RoutineCharacteristicSQLDataAccess:
+		ctx.WriteKeyWord(n.DataAccess.String())
+	case RoutineCharacteristicSQLSecurity:
+		ctx.WriteKeyWord("SQL SECURITY ")
+		ctx.WriteKeyWord(n.Security.String())
+	default:
+		return errors.Errorf("invalid RoutineCharacteristic: %d", n.Tp)
+	}
+	return nil
+}
+
+func restoreDefiner(ctx *format.RestoreCtx, definer *auth.UserIdentity) error {
+	if definer == nil || definer.CurrentUser {
+		return nil
+	}
+	ctx.WriteKeyWord("DEFINER")
+	ctx.WritePlain(" = ")
+	if err := definer.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore Definer")
+	}
+	ctx.WritePlain(" ")
+	return nil
+}
+
+func restoreRoutineCharacteristics(ctx *format.RestoreCtx, characteristics []*RoutineCharacteristic) error {
+	for i, c := range characteristics {
+		ctx.WritePlain(" ")
+		if err := c.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore RoutineCharacteristic: [%v]", i)
+		}
+	}
+	return nil
+}
+
+func restoreStoredParameters(ctx *format.RestoreCtx, params []*StoredParameter) error {
+	ctx.WritePlain("(")
+	for i, p := range params {
+		if i != 0 {
+			ctx.WritePlain(",")
+		}
+		if err := p.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore StoredParameter: [%v]", i)
+		}
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// CreateProcedureStmt is a statement to create a stored procedure.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-procedure.html
+type CreateProcedureStmt struct {
+	ddlNode
+
+	Definer         *auth.UserIdentity
+	IfNotExists     bool
+	Name            *TableName
+	Params          []*StoredParameter
+	Characteristics []*RoutineCharacteristic
+	Body            StmtNode
+}
+
+// Restore implements Node interface.
+func (n *CreateProcedureStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("CREATE ")
+	if err := restoreDefiner(ctx, n.Definer); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("PROCEDURE ")
+	if n.IfNotExists {
+		ctx.WriteKeyWord("IF NOT EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateProcedureStmt.Name")
+	}
+	if err := restoreStoredParameters(ctx, n.Params); err != nil {
+		return err
+	}
+	if err := restoreRoutineCharacteristics(ctx, n.Characteristics); err != nil {
+		return err
+	}
+	ctx.WritePlain(" ")
+	if err := n.Body.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateProcedureStmt.Body")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+// The procedure name is a *TableName for the schema qualifier only, it is
+// not visited because it does not refer to a table.
+func (n *CreateProcedureStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateProcedureStmt)
+	for i, val := range n.Params {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Params[i] = node.(*StoredParameter)
+	}
+	node, ok := n.Body.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Body = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// CreateFunctionStmt is a statement to create a stored function.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-procedure.html
+type CreateFunctionStmt struct {
+	ddlNode
+
+	Definer         *auth.UserIdentity
+	IfNotExists     bool
+	Name            *TableName
+	Params          []*StoredParameter
+	Returns         *types.FieldType
+	Characteristics []*RoutineCharacteristic
+	Body            StmtNode
+}
+
+// Restore implements Node interface.
+func (n *CreateFunctionStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("CREATE ")
+	if err := restoreDefiner(ctx, n.Definer); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("FUNCTION ")
+	if n.IfNotExists {
+		ctx.WriteKeyWord("IF NOT EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateFunctionStmt.Name")
+	}
+	if err := restoreStoredParameters(ctx, n.Params); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord(" RETURNS ")
+	if err := n.Returns.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateFunctionStmt.Returns")
+	}
+	if err := restoreRoutineCharacteristics(ctx, n.Characteristics); err != nil {
+		return err
+	}
+	ctx.WritePlain(" ")
+	if err := n.Body.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateFunctionStmt.Body")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+// The function name is not visited, see CreateProcedureStmt.Accept.
+func (n *CreateFunctionStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateFunctionStmt)
+	for i, val := range n.Params {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Params[i] = node.(*StoredParameter)
+	}
+	node, ok := n.Body.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Body = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// ReturnStmt is the RETURN statement of a stored function body.
+// See https://dev.mysql.com/doc/refman/8.0/en/return.html
+type ReturnStmt struct {
+	stmtNode
+
+	Expr ExprNode
+}
+
+// Restore implements Node interface.
+func (n *ReturnStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("RETURN ")
+	if err := n.Expr.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore ReturnStmt.Expr")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *ReturnStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ReturnStmt)
+	node, ok := n.Expr.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Expr = node.(ExprNode)
+	return v.Leave(n)
+}
+
+// AlterProcedureStmt is a statement to change the characteristics of a stored procedure or function.
+// See https://dev.mysql.com/doc/refman/8.0/en/alter-procedure.html
+// See https://dev.mysql.com/doc/refman/8.0/en/alter-function.html
+type AlterProcedureStmt struct {
+	ddlNode
+
+	IsFunction      bool
+	Name            *TableName
+	Characteristics []*RoutineCharacteristic
+}
+
+// Restore implements Node interface.
+func (n *AlterProcedureStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	if n.IsFunction {
+		ctx.WriteKeyWord("ALTER FUNCTION ")
+	} else {
+		ctx.WriteKeyWord("ALTER PROCEDURE ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore AlterProcedureStmt.Name")
+	}
+	return restoreRoutineCharacteristics(ctx, n.Characteristics)
+}
+
+// Accept implements Node Accept interface.
+func (n *AlterProcedureStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*AlterProcedureStmt)
+	return v.Leave(n)
+}
+
+// DropProcedureStmt is a statement to drop a stored procedure or function.
+// See https://dev.mysql.com/doc/refman/8.0/en/drop-procedure.html
+type DropProcedureStmt struct {
+	ddlNode
+
+	IsFunction bool
+	IfExists   bool
+	Name       *TableName
+}
+
+// Restore implements Node interface.
+func (n *DropProcedureStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	if n.IsFunction {
+		ctx.WriteKeyWord("DROP FUNCTION ")
+	} else {
+		ctx.WriteKeyWord("DROP PROCEDURE ")
+	}
+	if n.IfExists {
+		ctx.WriteKeyWord("IF EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DropProcedureStmt.Name")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DropProcedureStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropProcedureStmt)
+	return v.Leave(n)
+}
+
+// TriggerTiming is the action time of a trigger.
+type TriggerTiming int
+
+// Trigger action times.
+const (
+	TriggerBefore TriggerTiming = iota
+	TriggerAfter
+)
+
+// String implements fmt.Stringer interface.
+func (t TriggerTiming) String() string {
+	switch t {
+	case TriggerBefore:
+		return "BEFORE"
+	case TriggerAfter:
+		return "AFTER"
+	}
+	return ""
+}
+
+// TriggerEvent is the kind of operation that activates a trigger.
+type TriggerEvent int
+
+// Trigger events.
+const (
+	TriggerInsert TriggerEvent = iota
+	TriggerUpdate
+	TriggerDelete
+)
+
+// String implements fmt.Stringer interface.
+func (e TriggerEvent) String() string {
+	switch e {
+	case TriggerInsert:
+		return "INSERT"
+	case TriggerUpdate:
+		return "UPDATE"
+	case TriggerDelete:
+		return "DELETE"
+	}
+	return ""
+}
+
+// TriggerOrder is the FOLLOWS or PRECEDES clause of a trigger.
+type TriggerOrder struct {
+	// Follows is true for FOLLOWS, false for PRECEDES.
+	Follows      bool
+	OtherTrigger model.CIStr
+}
+
+// Restore implements Node interface.
+func (n *TriggerOrder) Restore(ctx *format.RestoreCtx) error {
+	if n.Follows {
+		ctx.WriteKeyWord("FOLLOWS ")
+	} else {
+		ctx.WriteKeyWord("PRECEDES ")
+	}
+	ctx.WriteName(n.OtherTrigger.O)
+	return nil
+}
+
+// CreateTriggerStmt is a statement to create a trigger.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-trigger.html
+type CreateTriggerStmt struct {
+	ddlNode
+
+	Definer     *auth.UserIdentity
+	IfNotExists bool
+	Name        *TableName
+	Timing      TriggerTiming
+	Event       TriggerEvent
+	Table       *TableName
+	Order       *TriggerOrder
+	Body        StmtNode
+}
+
+// Restore implements Node interface.
+func (n *CreateTriggerStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("CREATE ")
+	if err := restoreDefiner(ctx, n.Definer); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("TRIGGER ")
+	if n.IfNotExists {
+		ctx.WriteKeyWord("IF NOT EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateTriggerStmt.Name")
+	}
+	ctx.WritePlain(" ")
+	ctx.WriteKeyWord(n.Timing.String())
+	ctx.WritePlain(" ")
+	ctx.WriteKeyWord(n.Event.String())
+	ctx.WriteKeyWord(" ON ")
+	if err := n.Table.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateTriggerStmt.Table")
+	}
+	ctx.WriteKeyWord(" FOR EACH ROW ")
+	if n.Order != nil {
+		if err := n.Order.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore CreateTriggerStmt.Order")
+		}
+		ctx.WritePlain(" ")
+	}
+	if err := n.Body.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateTriggerStmt.Body")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+// The trigger name is not visited, see CreateProcedureStmt.Accept.
+func (n *CreateTriggerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateTriggerStmt)
+	node, ok := n.Table.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Table = node.(*TableName)
+	node, ok = n.Body.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Body = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// DropTriggerStmt is a statement to drop a trigger.
+// See https://dev.mysql.com/doc/refman/8.0/en/drop-trigger.html
+type DropTriggerStmt struct {
+	ddlNode
+
+	IfExists bool
+	Name     *TableName
+}
+
+// Restore implements Node interface.
+func (n *DropTriggerStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("DROP TRIGGER ")
+	if n.IfExists {
+		ctx.WriteKeyWord("IF EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DropTriggerStmt.Name")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DropTriggerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropTriggerStmt)
+	return v.Leave(n)
+}
+
+// EventSchedule is the schedule of an event, it is either `AT timestamp`
+// or `EVERY interval [STARTS timestamp] [ENDS timestamp]`.
+type EventSchedule struct {
+	node
+
+	// At is set for a one-time event.
+	At ExprNode
+	// Every and EveryUnit are set for a recurring event.
+	Every     ExprNode
+	EveryUnit TimeUnitType
+	Starts    ExprNode
+	Ends      ExprNode
+}
+
+// Restore implements Node interface.
+func (n *EventSchedule) Restore(ctx *format.RestoreCtx) error {
+	if n.At != nil {
+		ctx.WriteKeyWord("AT ")
+		if err := n.At.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore EventSchedule.At")
+		}
+		return nil
+	}
+	ctx.WriteKeyWord("EVERY ")
+	if err := n.Every.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore EventSchedule.Every")
+	}
+	ctx.WritePlain(" ")
+	ctx.WriteKeyWord(n.EveryUnit.String())
+	if n.Starts != nil {
+		ctx.WriteKeyWord(" STARTS ")
+		if err := n.Starts.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore EventSchedule.Starts")
+		}
+	}
+	if n.Ends != nil {
+		ctx.WriteKeyWord(" ENDS ")
+		if err := n.Ends.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore EventSchedule.Ends")
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *EventSchedule) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*EventSchedule)
+	for _, expr := range []*ExprNode{&n.At, &n.Every, &n.Starts, &n.Ends} {
+		if *expr == nil {
+			continue
+		}
+		node, ok := (*expr).Accept(v)
+		if !ok {
+			return n, false
+		}
+		*expr = node.(ExprNode)
+	}
+	return v.Leave(n)
+}
+
+// EventCompletion is the ON COMPLETION clause of an event.
+type EventCompletion int
+
+// Event completion options.
+const (
+	EventCompletionUnspecified EventCompletion = iota
+	EventCompletionPreserve
+	EventCompletionNotPreserve
+)
+
+// EventStatus is the status of an event.
+type EventStatus int
+
+// Event status options.
+const (
+	EventStatusUnspecified EventStatus = iota
+	EventStatusEnable
+	EventStatusDisable
+	EventStatusDisableOnSlave
+)
+
+// String implements fmt.Stringer interface.
+func (s EventStatus) String() string {
+	switch s {
+	case EventStatusEnable:
+		return "ENABLE"
+	case EventStatusDisable:
+		return "DISABLE"
+	case EventStatusDisableOnSlave:
+		return "DISABLE ON SLAVE"
+	}
+	return ""
+}
+
+func restoreEventOptions(ctx *format.RestoreCtx, completion EventCompletion, newName *TableName, status EventStatus, comment string) error {
+	switch completion {
+	case EventCompletionPreserve:
+		ctx.WriteKeyWord(" ON COMPLETION PRESERVE")
+	case EventCompletionNotPreserve:
+		ctx.WriteKeyWord(" ON COMPLETION NOT PRESERVE")
+	}
+	if newName != nil {
+		ctx.WriteKeyWord(" RENAME TO ")
+		if err := newName.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore event new name")
+		}
+	}
+	if status != EventStatusUnspecified {
+		ctx.WritePlain(" ")
+		ctx.WriteKeyWord(status.String())
+	}
+	if comment != "" {
+		ctx.WriteKeyWord(" COMMENT ")
+		ctx.WriteString(comment)
+	}
+	return nil
+}
+
+// CreateEventStmt is a statement to create an event.
+// See https://dev.mysql.com/doc/refman/8.0/en/create-event.html
+type CreateEventStmt struct {
+	ddlNode
+
+	Definer      *auth.UserIdentity
+	IfNotExists  bool
+	Name         *TableName
+	Schedule     *EventSchedule
+	OnCompletion EventCompletion
+	Status       EventStatus
+	Comment      string
+	Body         StmtNode
+}
+
+// Restore implements Node interface.
+func (n *CreateEventStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("CREATE ")
+	if err := restoreDefiner(ctx, n.Definer); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("EVENT ")
+	if n.IfNotExists {
+		ctx.WriteKeyWord("IF NOT EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateEventStmt.Name")
+	}
+	ctx.WriteKeyWord(" ON SCHEDULE ")
+	if err := n.Schedule.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateEventStmt.Schedule")
+	}
+	if err := restoreEventOptions(ctx, n.OnCompletion, nil, n.Status, n.Comment); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord(" DO ")
+	if err := n.Body.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CreateEventStmt.Body")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+// The event name is not visited, see CreateProcedureStmt.Accept.
+func (n *CreateEventStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateEventStmt)
+	node, ok := n.Schedule.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Schedule = node.(*EventSchedule)
+	node, ok = n.Body.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Body = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// AlterEventStmt is a statement to change an event, the unspecified parts are kept nil or zero.
+// See https://dev.mysql.com/doc/refman/8.0/en/alter-event.html
+type AlterEventStmt struct {
+	ddlNode
+
+	Definer      *auth.UserIdentity
+	Name         *TableName
+	Schedule     *EventSchedule
+	OnCompletion EventCompletion
+	NewName      *TableName
+	Status       EventStatus
+	Comment      string
+	Body         StmtNode
+}
+
+// Restore implements Node interface.
+func (n *AlterEventStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("ALTER ")
+	if err := restoreDefiner(ctx, n.Definer); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("EVENT ")
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore AlterEventStmt.Name")
+	}
+	if n.Schedule != nil {
+		ctx.WriteKeyWord(" ON SCHEDULE ")
+		if err := n.Schedule.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore AlterEventStmt.Schedule")
+		}
+	}
+	if err := restoreEventOptions(ctx, n.OnCompletion, n.NewName, n.Status, n.Comment); err != nil {
+		return err
+	}
+	if n.Body != nil {
+		ctx.WriteKeyWord(" DO ")
+		if err := n.Body.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore AlterEventStmt.Body")
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+// The event names are not visited, see CreateProcedureStmt.Accept.
+func (n *AlterEventStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*AlterEventStmt)
+	if n.Schedule != nil {
+		node, ok := n.Schedule.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Schedule = node.(*EventSchedule)
+	}
+	if n.Body != nil {
+		node, ok := n.Body.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Body = node.(StmtNode)
+	}
+	return v.Leave(n)
+}
+
+// DropEventStmt is a statement to drop an event.
+// See https://dev.mysql.com/doc/refman/8.0/en/drop-event.html
+type DropEventStmt struct {
+	ddlNode
+
+	IfExists bool
+	Name     *TableName
+}
+
+// Restore implements Node interface.
+func (n *DropEventStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("DROP EVENT ")
+	if n.IfExists {
+		ctx.WriteKeyWord("IF EXISTS ")
+	}
+	if err := n.Name.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DropEventStmt.Name")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DropEventStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropEventStmt)
+	return v.Leave(n)
+}
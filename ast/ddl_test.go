@@ -56,6 +56,17 @@ func (ts *testDDLSuite) TestDDLVisitorCover(c *C) {
 		{&ReferenceDef{Table: &TableName{}, IndexPartSpecifications: []*IndexPartSpecification{{Column: &ColumnName{}}, {Column: &ColumnName{}}}, OnDelete: &OnDeleteOpt{}, OnUpdate: &OnUpdateOpt{}}, 0, 0},
 		{&AlterTableSpec{NewConstraints: []*Constraint{constraint, constraint}}, 0, 0},
 		{&AlterTableSpec{NewConstraints: []*Constraint{constraint}, NewColumns: []*ColumnDef{{Name: &ColumnName{}}}}, 0, 0},
+		{&CreateProcedureStmt{Name: &TableName{}, Params: []*StoredParameter{{}, {}}, Body: &DoStmt{Exprs: []ExprNode{ce}}}, 1, 1},
+		{&CreateFunctionStmt{Name: &TableName{}, Params: []*StoredParameter{{}}, Body: &ReturnStmt{Expr: ce}}, 1, 1},
+		{&ReturnStmt{Expr: ce}, 1, 1},
+		{&AlterProcedureStmt{Name: &TableName{}}, 0, 0},
+		{&DropProcedureStmt{Name: &TableName{}}, 0, 0},
+		{&CreateTriggerStmt{Name: &TableName{}, Table: &TableName{}, Body: &DoStmt{Exprs: []ExprNode{ce}}}, 1, 1},
+		{&DropTriggerStmt{Name: &TableName{}}, 0, 0},
+		{&CreateEventStmt{Name: &TableName{}, Schedule: &EventSchedule{Every: ce, Starts: ce, Ends: ce}, Body: &DoStmt{Exprs: []ExprNode{ce}}}, 4, 4},
+		{&AlterEventStmt{Name: &TableName{}, Schedule: &EventSchedule{At: ce}, NewName: &TableName{}}, 1, 1},
+		{&AlterEventStmt{Name: &TableName{}}, 0, 0},
+		{&DropEventStmt{Name: &TableName{}}, 0, 0},
 	}
 
 	for _, v := range stmts {
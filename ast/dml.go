@@ -14,12 +14,15 @@
 package ast
 
 import (
+	"strings"
+
 	"github.com/pingcap/errors"
 
 	"github.com/pingcap/parser/auth"
 	"github.com/pingcap/parser/format"
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
 )
 
 var (
@@ -40,6 +43,8 @@ var (
 	_ Node = &HavingClause{}
 	_ Node = &AsOfClause{}
 	_ Node = &Join{}
+	_ Node = &JSONTable{}
+	_ Node = &JSONTableColumn{}
 	_ Node = &Limit{}
 	_ Node = &OnCondition{}
 	_ Node = &OrderByClause{}
@@ -171,6 +176,10 @@ func (n *Join) Restore(ctx *format.RestoreCtx) error {
 	if n.Right == nil {
 		return nil
 	}
+	if !useCommaJoin {
+		// each joined table starts a new line in the pretty-printing mode.
+		ctx.WriteLineBreak("")
+	}
 	if n.NaturalJoin {
 		ctx.WriteKeyWord(" NATURAL")
 	}
@@ -180,9 +189,13 @@ func (n *Join) Restore(ctx *format.RestoreCtx) error {
 	case RightJoin:
 		ctx.WriteKeyWord(" RIGHT")
 	}
-	if n.StraightJoin {
+	switch {
+	case n.StraightJoin && ctx.Dialect != format.DialectANSI:
 		ctx.WriteKeyWord(" STRAIGHT_JOIN ")
-	} else {
+	case ctx.Dialect == format.DialectANSI && n.Tp != LeftJoin && n.Tp != RightJoin && !n.NaturalJoin && n.On == nil && len(n.Using) == 0:
+		// a JOIN without a condition isn't standard SQL.
+		ctx.WriteKeyWord(" CROSS JOIN ")
+	default:
 		if useCommaJoin {
 			ctx.WritePlain(", ")
 		} else {
@@ -305,6 +318,10 @@ func (n *TableName) restorePartitions(ctx *format.RestoreCtx) {
 }
 
 func (n *TableName) restoreIndexHints(ctx *format.RestoreCtx) error {
+	// the index hints don't change the result, they are dropped in DialectANSI.
+	if ctx.Dialect == format.DialectANSI {
+		return nil
+	}
 	for _, value := range n.IndexHints {
 		ctx.WritePlain(" ")
 		if err := value.Restore(ctx); err != nil {
@@ -506,6 +523,9 @@ func (*TableSource) resultSet() {}
 
 // Restore implements Node interface.
 func (n *TableSource) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	needParen := false
 	switch n.Source.(type) {
 	case *SelectStmt, *SetOprStmt:
@@ -547,14 +567,12 @@ func (n *TableSource) Restore(ctx *format.RestoreCtx) error {
 		}
 	} else {
 		if needParen {
-			ctx.WritePlain("(")
-		}
-		if err := n.Source.Restore(ctx); err != nil {
+			if err := restoreInParentheses(ctx, n.Source); err != nil {
+				return errors.Annotate(err, "An error occurred while restore TableSource.Source")
+			}
+		} else if err := n.Source.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore TableSource.Source")
 		}
-		if needParen {
-			ctx.WritePlain(")")
-		}
 		if asName := n.AsName.String(); asName != "" {
 			ctx.WriteKeyWord(" AS ")
 			ctx.WriteName(asName)
@@ -622,6 +640,17 @@ func (n SelectLockType) String() string {
 	return "unsupported select lock type"
 }
 
+// checkDialect returns an error if the lock type can't be expressed in the dialect of ctx.
+func (n SelectLockType) checkDialect(ctx *format.RestoreCtx) error {
+	switch n {
+	case SelectLockNone, SelectLockForUpdate:
+		return nil
+	case SelectLockForUpdateWaitN:
+		return ctx.Unsupported("FOR UPDATE WAIT", format.DialectMySQL57, format.DialectMySQL80, format.DialectANSI)
+	}
+	return ctx.Unsupported(strings.ToUpper(n.String()), format.DialectMySQL57, format.DialectANSI)
+}
+
 // WildCardField is a special type of select field content.
 type WildCardField struct {
 	node
@@ -676,6 +705,9 @@ type SelectField struct {
 
 // Restore implements Node interface.
 func (n *SelectField) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.WildCard != nil {
 		if err := n.WildCard.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SelectField.WildCard")
@@ -788,6 +820,9 @@ type ByItem struct {
 
 // Restore implements Node interface.
 func (n *ByItem) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore ByItem.Expr")
 	}
@@ -823,7 +858,7 @@ func (n *GroupByClause) Restore(ctx *format.RestoreCtx) error {
 	ctx.WriteKeyWord("GROUP BY ")
 	for i, v := range n.Items {
 		if i != 0 {
-			ctx.WritePlain(",")
+			ctx.WriteListSeparator(",")
 		}
 		if err := v.Restore(ctx); err != nil {
 			return errors.Annotatef(err, "An error occurred while restore GroupByClause.Items[%d]", i)
@@ -891,7 +926,7 @@ func (n *OrderByClause) Restore(ctx *format.RestoreCtx) error {
 	ctx.WriteKeyWord("ORDER BY ")
 	for i, item := range n.Items {
 		if i != 0 {
-			ctx.WritePlain(",")
+			ctx.WriteListSeparator(",")
 		}
 		if err := item.Restore(ctx); err != nil {
 			return errors.Annotatef(err, "An error occurred while restore OrderByClause.Items[%d]", i)
@@ -1001,6 +1036,202 @@ func (s *TableSample) Accept(v Visitor) (node Node, ok bool) {
 	return v.Leave(s)
 }
 
+// JSONTableColumnType is the kind of a column in a JSON_TABLE COLUMNS clause.
+type JSONTableColumnType int
+
+const (
+	// JSONTableColumnOrdinality is a `name FOR ORDINALITY` column.
+	JSONTableColumnOrdinality JSONTableColumnType = iota
+	// JSONTableColumnPath is a `name type PATH 'path'` column.
+	JSONTableColumnPath
+	// JSONTableColumnExists is a `name type EXISTS PATH 'path'` column.
+	JSONTableColumnExists
+	// JSONTableColumnNested is a `NESTED PATH 'path' COLUMNS (...)` column.
+	JSONTableColumnNested
+)
+
+// JSONTableResponseType is the action taken by a PATH column on an empty or erroneous value.
+type JSONTableResponseType int
+
+const (
+	// JSONTableResponseNull is `NULL ON EMPTY` or `NULL ON ERROR`.
+	JSONTableResponseNull JSONTableResponseType = iota
+	// JSONTableResponseError is `ERROR ON EMPTY` or `ERROR ON ERROR`.
+	JSONTableResponseError
+	// JSONTableResponseDefault is `DEFAULT 'json' ON EMPTY` or `DEFAULT 'json' ON ERROR`.
+	JSONTableResponseDefault
+)
+
+// JSONTableResponse is the ON EMPTY or ON ERROR clause of a JSON_TABLE PATH column.
+type JSONTableResponse struct {
+	Tp JSONTableResponseType
+	// Default is the JSON string used when Tp is JSONTableResponseDefault.
+	Default string
+}
+
+// Restore implements Node interface.
+func (n *JSONTableResponse) Restore(ctx *format.RestoreCtx) error {
+	switch n.Tp {
+	case JSONTableResponseNull:
+		ctx.WriteKeyWord("NULL")
+	case JSONTableResponseError:
+		ctx.WriteKeyWord("ERROR")
+	case JSONTableResponseDefault:
+		ctx.WriteKeyWord("DEFAULT ")
+		ctx.WriteString(n.Default)
+	default:
+		return errors.Errorf("invalid JSONTableResponseType: %d", n.Tp)
+	}
+	return nil
+}
+
+// JSONTableColumn is a column definition in a JSON_TABLE COLUMNS clause.
+// See https://dev.mysql.com/doc/refman/8.0/en/json-table-functions.html
+type JSONTableColumn struct {
+	node
+
+	Tp JSONTableColumnType
+	// Name is empty for nested columns.
+	Name model.CIStr
+	// Type is only set for PATH and EXISTS PATH columns.
+	Type *types.FieldType
+	// Path is optional for nested columns, but required for all other columns except ordinality ones.
+	Path    string
+	OnEmpty *JSONTableResponse
+	OnError *JSONTableResponse
+	// Columns holds the columns of a nested column.
+	Columns []*JSONTableColumn
+}
+
+// Restore implements Node interface.
+func (n *JSONTableColumn) Restore(ctx *format.RestoreCtx) error {
+	switch n.Tp {
+	case JSONTableColumnOrdinality:
+		ctx.WriteName(n.Name.O)
+		ctx.WriteKeyWord(" FOR ORDINALITY")
+	case JSONTableColumnPath, JSONTableColumnExists:
+		ctx.WriteName(n.Name.O)
+		ctx.WritePlain(" ")
+		if err := n.Type.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore JSONTableColumn.Type")
+		}
+		if n.Tp == JSONTableColumnExists {
+			ctx.WriteKeyWord(" EXISTS")
+		}
+		ctx.WriteKeyWord(" PATH ")
+		ctx.WriteString(n.Path)
+		if n.OnEmpty != nil {
+			ctx.WritePlain(" ")
+			if err := n.OnEmpty.Restore(ctx); err != nil {
+				return errors.Annotate(err, "An error occurred while restore JSONTableColumn.OnEmpty")
+			}
+			ctx.WriteKeyWord(" ON EMPTY")
+		}
+		if n.OnError != nil {
+			ctx.WritePlain(" ")
+			if err := n.OnError.Restore(ctx); err != nil {
+				return errors.Annotate(err, "An error occurred while restore JSONTableColumn.OnError")
+			}
+			ctx.WriteKeyWord(" ON ERROR")
+		}
+	case JSONTableColumnNested:
+		ctx.WriteKeyWord("NESTED PATH ")
+		ctx.WriteString(n.Path)
+		ctx.WriteKeyWord(" COLUMNS ")
+		if err := restoreJSONTableColumns(ctx, n.Columns); err != nil {
+			return errors.Annotate(err, "An error occurred while restore JSONTableColumn.Columns")
+		}
+	default:
+		return errors.Errorf("invalid JSONTableColumnType: %d", n.Tp)
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *JSONTableColumn) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*JSONTableColumn)
+	for i, col := range n.Columns {
+		node, ok := col.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Columns[i] = node.(*JSONTableColumn)
+	}
+	return v.Leave(n)
+}
+
+func restoreJSONTableColumns(ctx *format.RestoreCtx, cols []*JSONTableColumn) error {
+	ctx.WritePlain("(")
+	for i, col := range cols {
+		if i > 0 {
+			ctx.WritePlain(", ")
+		}
+		if err := col.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore column %d", i)
+		}
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// JSONTable is the JSON_TABLE table function, which extracts rows from a JSON document.
+// See https://dev.mysql.com/doc/refman/8.0/en/json-table-functions.html
+type JSONTable struct {
+	node
+
+	Expr    ExprNode
+	Path    string
+	Columns []*JSONTableColumn
+}
+
+func (*JSONTable) resultSet() {}
+
+// Restore implements Node interface.
+func (n *JSONTable) Restore(ctx *format.RestoreCtx) error {
+	if err := ctx.Unsupported("JSON_TABLE", format.DialectMySQL57); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("JSON_TABLE")
+	ctx.WritePlain("(")
+	if err := n.Expr.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore JSONTable.Expr")
+	}
+	ctx.WritePlain(", ")
+	ctx.WriteString(n.Path)
+	ctx.WriteKeyWord(" COLUMNS ")
+	if err := restoreJSONTableColumns(ctx, n.Columns); err != nil {
+		return errors.Annotate(err, "An error occurred while restore JSONTable.Columns")
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *JSONTable) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*JSONTable)
+	node, ok := n.Expr.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Expr = node.(ExprNode)
+	for i, col := range n.Columns {
+		node, ok := col.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Columns[i] = node.(*JSONTableColumn)
+	}
+	return v.Leave(n)
+}
+
 type SelectStmtKind uint8
 
 const (
@@ -1086,13 +1317,17 @@ type SelectStmt struct {
 func (*SelectStmt) resultSet() {}
 
 func (n *WithClause) Restore(ctx *format.RestoreCtx) error {
+	if err := ctx.Unsupported("WITH", format.DialectMySQL57); err != nil {
+		return err
+	}
 	ctx.WriteKeyWord("WITH ")
 	if n.IsRecursive {
 		ctx.WriteKeyWord("RECURSIVE ")
 	}
 	for i, cte := range n.CTEs {
 		if i != 0 {
-			ctx.WritePlain(", ")
+			ctx.WritePlain(",")
+			ctx.WriteLineBreak(" ")
 		}
 		ctx.WriteName(cte.Name.String())
 		if len(cte.ColNameList) > 0 {
@@ -1111,7 +1346,7 @@ func (n *WithClause) Restore(ctx *format.RestoreCtx) error {
 			return err
 		}
 	}
-	ctx.WritePlain(" ")
+	ctx.WriteLineBreak(" ")
 	return nil
 }
 
@@ -1133,6 +1368,9 @@ func (n *WithClause) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *SelectStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.WithBeforeBraces {
 		err := n.With.Restore(ctx)
 		if err != nil {
@@ -1156,97 +1394,73 @@ func (n *SelectStmt) Restore(ctx *format.RestoreCtx) error {
 	ctx.WritePlain(" ")
 	switch n.Kind {
 	case SelectStmtKindSelect:
-		if n.SelectStmtOpts.Priority > 0 {
-			ctx.WriteKeyWord(mysql.Priority2Str[n.SelectStmtOpts.Priority])
-			ctx.WritePlain(" ")
-		}
-
-		if n.SelectStmtOpts.SQLSmallResult {
-			ctx.WriteKeyWord("SQL_SMALL_RESULT ")
-		}
-
-		if n.SelectStmtOpts.SQLBigResult {
-			ctx.WriteKeyWord("SQL_BIG_RESULT ")
-		}
-
-		if n.SelectStmtOpts.SQLBufferResult {
-			ctx.WriteKeyWord("SQL_BUFFER_RESULT ")
-		}
-
-		if !n.SelectStmtOpts.SQLCache {
-			ctx.WriteKeyWord("SQL_NO_CACHE ")
-		}
-
-		if n.SelectStmtOpts.CalcFoundRows {
-			ctx.WriteKeyWord("SQL_CALC_FOUND_ROWS ")
-		}
-
-		if n.TableHints != nil && len(n.TableHints) != 0 {
-			ctx.WritePlain("/*+ ")
-			for i, tableHint := range n.TableHints {
-				if i != 0 {
-					ctx.WritePlain(" ")
-				}
-				if err := tableHint.Restore(ctx); err != nil {
-					return errors.Annotatef(err, "An error occurred while restore SelectStmt.TableHints[%d]", i)
-				}
+		if ctx.Dialect == format.DialectANSI {
+			if err := n.restoreANSIOptions(ctx); err != nil {
+				return err
 			}
-			ctx.WritePlain("*/ ")
-		}
-
-		if n.Distinct {
-			ctx.WriteKeyWord("DISTINCT ")
-		} else if n.SelectStmtOpts.ExplicitAll {
-			ctx.WriteKeyWord("ALL ")
-		}
-		if n.SelectStmtOpts.StraightJoin {
-			ctx.WriteKeyWord("STRAIGHT_JOIN ")
+		} else if err := n.restoreOptions(ctx); err != nil {
+			return err
 		}
 		if n.Fields != nil {
+			// in the pretty-printing mode, each of several fields is on its own line.
+			multiLine := len(n.Fields.Fields) > 1
+			if multiLine {
+				ctx.Indent()
+			}
 			for i, field := range n.Fields.Fields {
 				if i != 0 {
 					ctx.WritePlain(",")
 				}
+				if multiLine {
+					ctx.WriteLineBreak("")
+				}
 				if err := field.Restore(ctx); err != nil {
 					return errors.Annotatef(err, "An error occurred while restore SelectStmt.Fields[%d]", i)
 				}
 			}
+			if multiLine {
+				ctx.Unindent()
+			}
 		}
 
 		if n.From != nil {
-			ctx.WriteKeyWord(" FROM ")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("FROM ")
 			if err := n.From.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SelectStmt.From")
 			}
 		}
 
 		if n.From == nil && n.Where != nil {
-			ctx.WriteKeyWord(" FROM DUAL")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("FROM DUAL")
 		}
 
 		if n.Where != nil {
-			ctx.WriteKeyWord(" WHERE ")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("WHERE ")
 			if err := n.Where.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SelectStmt.Where")
 			}
 		}
 
 		if n.GroupBy != nil {
-			ctx.WritePlain(" ")
+			ctx.WriteLineBreak(" ")
 			if err := n.GroupBy.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SelectStmt.GroupBy")
 			}
 		}
 
 		if n.Having != nil {
-			ctx.WritePlain(" ")
+			ctx.WriteLineBreak(" ")
 			if err := n.Having.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SelectStmt.Having")
 			}
 		}
 
 		if n.WindowSpecs != nil {
-			ctx.WriteKeyWord(" WINDOW ")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("WINDOW ")
 			for i, windowsSpec := range n.WindowSpecs {
 				if i != 0 {
 					ctx.WritePlain(",")
@@ -1272,20 +1486,23 @@ func (n *SelectStmt) Restore(ctx *format.RestoreCtx) error {
 	}
 
 	if n.OrderBy != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.OrderBy.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SelectStmt.OrderBy")
 		}
 	}
 
 	if n.Limit != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Limit.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SelectStmt.Limit")
 		}
 	}
 
 	if n.LockInfo != nil {
+		if err := n.LockInfo.LockType.checkDialect(ctx); err != nil {
+			return err
+		}
 		ctx.WritePlain(" ")
 		switch n.LockInfo.LockType {
 		case SelectLockNone:
@@ -1298,7 +1515,10 @@ func (n *SelectStmt) Restore(ctx *format.RestoreCtx) error {
 	}
 
 	if n.SelectIntoOpt != nil {
-		ctx.WritePlain(" ")
+		if err := ctx.Unsupported("SELECT INTO", format.DialectANSI); err != nil {
+			return err
+		}
+		ctx.WriteLineBreak(" ")
 		if err := n.SelectIntoOpt.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SelectStmt.SelectIntoOpt")
 		}
@@ -1306,6 +1526,71 @@ func (n *SelectStmt) Restore(ctx *format.RestoreCtx) error {
 	return nil
 }
 
+// restoreOptions restores the options between SELECT and the fields.
+func (n *SelectStmt) restoreOptions(ctx *format.RestoreCtx) error {
+	if n.SelectStmtOpts.Priority > 0 {
+		ctx.WriteKeyWord(mysql.Priority2Str[n.SelectStmtOpts.Priority])
+		ctx.WritePlain(" ")
+	}
+
+	if n.SelectStmtOpts.SQLSmallResult {
+		ctx.WriteKeyWord("SQL_SMALL_RESULT ")
+	}
+
+	if n.SelectStmtOpts.SQLBigResult {
+		ctx.WriteKeyWord("SQL_BIG_RESULT ")
+	}
+
+	if n.SelectStmtOpts.SQLBufferResult {
+		ctx.WriteKeyWord("SQL_BUFFER_RESULT ")
+	}
+
+	if !n.SelectStmtOpts.SQLCache {
+		ctx.WriteKeyWord("SQL_NO_CACHE ")
+	}
+
+	if n.SelectStmtOpts.CalcFoundRows {
+		ctx.WriteKeyWord("SQL_CALC_FOUND_ROWS ")
+	}
+
+	if n.TableHints != nil && len(n.TableHints) != 0 {
+		ctx.WritePlain("/*+ ")
+		for i, tableHint := range n.TableHints {
+			if i != 0 {
+				ctx.WritePlain(" ")
+			}
+			if err := tableHint.Restore(ctx); err != nil {
+				return errors.Annotatef(err, "An error occurred while restore SelectStmt.TableHints[%d]", i)
+			}
+		}
+		ctx.WritePlain("*/ ")
+	}
+
+	if n.Distinct {
+		ctx.WriteKeyWord("DISTINCT ")
+	} else if n.SelectStmtOpts.ExplicitAll {
+		ctx.WriteKeyWord("ALL ")
+	}
+	if n.SelectStmtOpts.StraightJoin {
+		ctx.WriteKeyWord("STRAIGHT_JOIN ")
+	}
+	return nil
+}
+
+// restoreANSIOptions restores the options between SELECT and the fields in DialectANSI,
+// the options which only change the performance are dropped.
+func (n *SelectStmt) restoreANSIOptions(ctx *format.RestoreCtx) error {
+	if n.SelectStmtOpts.CalcFoundRows {
+		return ctx.Unsupported("SQL_CALC_FOUND_ROWS", format.DialectANSI)
+	}
+	if n.Distinct {
+		ctx.WriteKeyWord("DISTINCT ")
+	} else if n.SelectStmtOpts.ExplicitAll {
+		ctx.WriteKeyWord("ALL ")
+	}
+	return nil
+}
+
 // Accept implements Node Accept interface.
 func (n *SelectStmt) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -1430,14 +1715,22 @@ func (n *SetOprSelectList) Restore(ctx *format.RestoreCtx) error {
 		switch selectStmt := stmt.(type) {
 		case *SelectStmt:
 			if i != 0 {
-				ctx.WriteKeyWord(" " + selectStmt.AfterSetOperator.String() + " ")
+				ctx.WriteLineBreak(" ")
+				if err := selectStmt.AfterSetOperator.restore(ctx); err != nil {
+					return err
+				}
+				ctx.WriteLineBreak(" ")
 			}
 			if err := selectStmt.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore SetOprSelectList.SelectStmt")
 			}
 		case *SetOprSelectList:
 			if i != 0 {
-				ctx.WriteKeyWord(" " + selectStmt.AfterSetOperator.String() + " ")
+				ctx.WriteLineBreak(" ")
+				if err := selectStmt.AfterSetOperator.restore(ctx); err != nil {
+					return err
+				}
+				ctx.WriteLineBreak(" ")
 			}
 			ctx.WritePlain("(")
 			err := selectStmt.Restore(ctx)
@@ -1503,6 +1796,16 @@ func (s *SetOprType) String() string {
 	return ""
 }
 
+func (s *SetOprType) restore(ctx *format.RestoreCtx) error {
+	if *s != Union && *s != UnionAll {
+		if err := ctx.Unsupported(s.String(), format.DialectMySQL57); err != nil {
+			return err
+		}
+	}
+	ctx.WriteKeyWord(s.String())
+	return nil
+}
+
 // SetOprStmt represents "union/except/intersect statement"
 // See https://dev.mysql.com/doc/refman/5.7/en/union.html
 // See https://mariadb.com/kb/en/intersect/
@@ -1520,6 +1823,9 @@ func (*SetOprStmt) resultSet() {}
 
 // Restore implements Node interface.
 func (n *SetOprStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.With != nil {
 		if err := n.With.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore UnionStmt.With")
@@ -1531,14 +1837,14 @@ func (n *SetOprStmt) Restore(ctx *format.RestoreCtx) error {
 	}
 
 	if n.OrderBy != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.OrderBy.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SetOprStmt.OrderBy")
 		}
 	}
 
 	if n.Limit != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Limit.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore SetOprStmt.Limit")
 		}
@@ -1594,6 +1900,9 @@ type Assignment struct {
 
 // Restore implements Node interface.
 func (n *Assignment) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if err := n.Column.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore Assignment.Column")
 	}
@@ -1687,6 +1996,9 @@ type LoadDataStmt struct {
 
 // Restore implements Node interface.
 func (n *LoadDataStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("LOAD DATA ")
 	if n.IsLocal {
 		ctx.WriteKeyWord("LOCAL ")
@@ -1855,6 +2167,9 @@ type CallStmt struct {
 
 // Restore implements Node interface.
 func (n *CallStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("CALL ")
 
 	if err := n.Procedure.Restore(ctx); err != nil {
@@ -1906,13 +2221,21 @@ type InsertStmt struct {
 
 // Restore implements Node interface.
 func (n *InsertStmt) Restore(ctx *format.RestoreCtx) error {
+	if construct := n.unsupportedInANSI(); construct != "" {
+		if err := ctx.Unsupported(construct, format.DialectANSI); err != nil {
+			return err
+		}
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.IsReplace {
 		ctx.WriteKeyWord("REPLACE ")
 	} else {
 		ctx.WriteKeyWord("INSERT ")
 	}
 
-	if n.TableHints != nil && len(n.TableHints) != 0 {
+	if len(n.TableHints) != 0 && ctx.Dialect != format.DialectANSI {
 		ctx.WritePlain("/*+ ")
 		for i, tableHint := range n.TableHints {
 			if i != 0 {
@@ -1925,10 +2248,10 @@ func (n *InsertStmt) Restore(ctx *format.RestoreCtx) error {
 		ctx.WritePlain("*/ ")
 	}
 
-	if err := n.Priority.Restore(ctx); err != nil {
-		return errors.Trace(err)
-	}
-	if n.Priority != mysql.NoPriority {
+	if n.Priority != mysql.NoPriority && ctx.Dialect != format.DialectANSI {
+		if err := n.Priority.Restore(ctx); err != nil {
+			return errors.Trace(err)
+		}
 		ctx.WritePlain(" ")
 	}
 	if n.IgnoreErr {
@@ -1990,7 +2313,11 @@ func (n *InsertStmt) Restore(ctx *format.RestoreCtx) error {
 			return errors.Errorf("Incorrect type for InsertStmt.Select: %T", v)
 		}
 	}
-	if n.Setlist != nil {
+	if n.Setlist != nil && ctx.Dialect == format.DialectANSI {
+		if err := n.restoreSetlistAsValues(ctx); err != nil {
+			return err
+		}
+	} else if n.Setlist != nil {
 		ctx.WriteKeyWord(" SET ")
 		for i, v := range n.Setlist {
 			if i != 0 {
@@ -2016,6 +2343,48 @@ func (n *InsertStmt) Restore(ctx *format.RestoreCtx) error {
 	return nil
 }
 
+// restoreSetlistAsValues restores `SET a = 1, b = 2` as `(a, b) VALUES (1, 2)`.
+func (n *InsertStmt) restoreSetlistAsValues(ctx *format.RestoreCtx) error {
+	ctx.WritePlain(" (")
+	for i, v := range n.Setlist {
+		if i != 0 {
+			ctx.WritePlain(",")
+		}
+		if err := v.Column.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore InsertStmt.Setlist[%d].Column", i)
+		}
+	}
+	ctx.WritePlain(")")
+	ctx.WriteKeyWord(" VALUES ")
+	ctx.WritePlain("(")
+	for i, v := range n.Setlist {
+		if i != 0 {
+			ctx.WritePlain(",")
+		}
+		if err := v.Expr.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore InsertStmt.Setlist[%d].Expr", i)
+		}
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// unsupportedInANSI returns the first part of the statement which can't be expressed in DialectANSI,
+// or "" if there isn't any.
+func (n *InsertStmt) unsupportedInANSI() string {
+	switch {
+	case n.IsReplace:
+		return "REPLACE"
+	case n.IgnoreErr:
+		return "INSERT IGNORE"
+	case len(n.PartitionNames) != 0:
+		return "PARTITION"
+	case n.OnDuplicate != nil:
+		return "ON DUPLICATE KEY UPDATE"
+	}
+	return ""
+}
+
 // Accept implements Node Accept interface.
 func (n *InsertStmt) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -2095,6 +2464,14 @@ type DeleteStmt struct {
 
 // Restore implements Node interface.
 func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
+	if construct := n.unsupportedInANSI(); construct != "" {
+		if err := ctx.Unsupported(construct, format.DialectANSI); err != nil {
+			return err
+		}
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.With != nil {
 		err := n.With.Restore(ctx)
 		if err != nil {
@@ -2104,7 +2481,7 @@ func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
 
 	ctx.WriteKeyWord("DELETE ")
 
-	if n.TableHints != nil && len(n.TableHints) != 0 {
+	if len(n.TableHints) != 0 && ctx.Dialect != format.DialectANSI {
 		ctx.WritePlain("/*+ ")
 		for i, tableHint := range n.TableHints {
 			if i != 0 {
@@ -2117,13 +2494,13 @@ func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
 		ctx.WritePlain("*/ ")
 	}
 
-	if err := n.Priority.Restore(ctx); err != nil {
-		return errors.Trace(err)
-	}
-	if n.Priority != mysql.NoPriority {
+	if n.Priority != mysql.NoPriority && ctx.Dialect != format.DialectANSI {
+		if err := n.Priority.Restore(ctx); err != nil {
+			return errors.Trace(err)
+		}
 		ctx.WritePlain(" ")
 	}
-	if n.Quick {
+	if n.Quick && ctx.Dialect != format.DialectANSI {
 		ctx.WriteKeyWord("QUICK ")
 	}
 	if n.IgnoreErr {
@@ -2136,7 +2513,8 @@ func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
 				return errors.Annotate(err, "An error occurred while restore DeleteStmt.Tables")
 			}
 
-			ctx.WriteKeyWord(" FROM ")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("FROM ")
 			if err := n.TableRefs.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore DeleteStmt.TableRefs")
 			}
@@ -2146,7 +2524,8 @@ func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
 				return errors.Annotate(err, "An error occurred while restore DeleteStmt.Tables")
 			}
 
-			ctx.WriteKeyWord(" USING ")
+			ctx.WriteLineBreak(" ")
+			ctx.WriteKeyWord("USING ")
 			if err := n.TableRefs.Restore(ctx); err != nil {
 				return errors.Annotate(err, "An error occurred while restore DeleteStmt.TableRefs")
 			}
@@ -2160,21 +2539,22 @@ func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
 	}
 
 	if n.Where != nil {
-		ctx.WriteKeyWord(" WHERE ")
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("WHERE ")
 		if err := n.Where.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore DeleteStmt.Where")
 		}
 	}
 
 	if n.Order != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Order.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore DeleteStmt.Order")
 		}
 	}
 
 	if n.Limit != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Limit.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occurred while restore DeleteStmt.Limit")
 		}
@@ -2183,6 +2563,22 @@ func (n *DeleteStmt) Restore(ctx *format.RestoreCtx) error {
 	return nil
 }
 
+// unsupportedInANSI returns the first part of the statement which can't be expressed in DialectANSI,
+// or "" if there isn't any.
+func (n *DeleteStmt) unsupportedInANSI() string {
+	switch {
+	case n.IsMultiTable:
+		return "multiple-table DELETE"
+	case n.IgnoreErr:
+		return "DELETE IGNORE"
+	case n.Order != nil:
+		return "DELETE ... ORDER BY"
+	case n.Limit != nil:
+		return "DELETE ... LIMIT"
+	}
+	return ""
+}
+
 // Accept implements Node Accept interface.
 func (n *DeleteStmt) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -2255,6 +2651,14 @@ type UpdateStmt struct {
 
 // Restore implements Node interface.
 func (n *UpdateStmt) Restore(ctx *format.RestoreCtx) error {
+	if construct := n.unsupportedInANSI(); construct != "" {
+		if err := ctx.Unsupported(construct, format.DialectANSI); err != nil {
+			return err
+		}
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.With != nil {
 		err := n.With.Restore(ctx)
 		if err != nil {
@@ -2264,7 +2668,7 @@ func (n *UpdateStmt) Restore(ctx *format.RestoreCtx) error {
 
 	ctx.WriteKeyWord("UPDATE ")
 
-	if n.TableHints != nil && len(n.TableHints) != 0 {
+	if len(n.TableHints) != 0 && ctx.Dialect != format.DialectANSI {
 		ctx.WritePlain("/*+ ")
 		for i, tableHint := range n.TableHints {
 			if i != 0 {
@@ -2277,10 +2681,10 @@ func (n *UpdateStmt) Restore(ctx *format.RestoreCtx) error {
 		ctx.WritePlain("*/ ")
 	}
 
-	if err := n.Priority.Restore(ctx); err != nil {
-		return errors.Trace(err)
-	}
-	if n.Priority != mysql.NoPriority {
+	if n.Priority != mysql.NoPriority && ctx.Dialect != format.DialectANSI {
+		if err := n.Priority.Restore(ctx); err != nil {
+			return errors.Trace(err)
+		}
 		ctx.WritePlain(" ")
 	}
 	if n.IgnoreErr {
@@ -2291,39 +2695,35 @@ func (n *UpdateStmt) Restore(ctx *format.RestoreCtx) error {
 		return errors.Annotate(err, "An error occur while restore UpdateStmt.TableRefs")
 	}
 
-	ctx.WriteKeyWord(" SET ")
+	ctx.WriteLineBreak(" ")
+	ctx.WriteKeyWord("SET ")
 	for i, assignment := range n.List {
 		if i != 0 {
 			ctx.WritePlain(", ")
 		}
 
-		if err := assignment.Column.Restore(ctx); err != nil {
-			return errors.Annotatef(err, "An error occur while restore UpdateStmt.List[%d].Column", i)
-		}
-
-		ctx.WritePlain("=")
-
-		if err := assignment.Expr.Restore(ctx); err != nil {
-			return errors.Annotatef(err, "An error occur while restore UpdateStmt.List[%d].Expr", i)
+		if err := assignment.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occur while restore UpdateStmt.List[%d]", i)
 		}
 	}
 
 	if n.Where != nil {
-		ctx.WriteKeyWord(" WHERE ")
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("WHERE ")
 		if err := n.Where.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occur while restore UpdateStmt.Where")
 		}
 	}
 
 	if n.Order != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Order.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occur while restore UpdateStmt.Order")
 		}
 	}
 
 	if n.Limit != nil {
-		ctx.WritePlain(" ")
+		ctx.WriteLineBreak(" ")
 		if err := n.Limit.Restore(ctx); err != nil {
 			return errors.Annotate(err, "An error occur while restore UpdateStmt.Limit")
 		}
@@ -2332,6 +2732,22 @@ func (n *UpdateStmt) Restore(ctx *format.RestoreCtx) error {
 	return nil
 }
 
+// unsupportedInANSI returns the first part of the statement which can't be expressed in DialectANSI,
+// or "" if there isn't any.
+func (n *UpdateStmt) unsupportedInANSI() string {
+	switch {
+	case n.MultipleTable:
+		return "multiple-table UPDATE"
+	case n.IgnoreErr:
+		return "UPDATE IGNORE"
+	case n.Order != nil:
+		return "UPDATE ... ORDER BY"
+	case n.Limit != nil:
+		return "UPDATE ... LIMIT"
+	}
+	return ""
+}
+
 // Accept implements Node Accept interface.
 func (n *UpdateStmt) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -2392,6 +2808,9 @@ type Limit struct {
 
 // Restore implements Node interface.
 func (n *Limit) Restore(ctx *format.RestoreCtx) error {
+	if ctx.Dialect == format.DialectANSI {
+		return n.restoreFetch(ctx)
+	}
 	ctx.WriteKeyWord("LIMIT ")
 	if n.Offset != nil {
 		if err := n.Offset.Restore(ctx); err != nil {
@@ -2405,6 +2824,23 @@ func (n *Limit) Restore(ctx *format.RestoreCtx) error {
 	return nil
 }
 
+// restoreFetch restores `LIMIT o, c` as `OFFSET o ROWS FETCH FIRST c ROWS ONLY`.
+func (n *Limit) restoreFetch(ctx *format.RestoreCtx) error {
+	if n.Offset != nil {
+		ctx.WriteKeyWord("OFFSET ")
+		if err := n.Offset.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore Limit.Offset")
+		}
+		ctx.WriteKeyWord(" ROWS ")
+	}
+	ctx.WriteKeyWord("FETCH FIRST ")
+	if err := n.Count.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore Limit.Count")
+	}
+	ctx.WriteKeyWord(" ROWS ONLY")
+	return nil
+}
+
 // Accept implements Node Accept interface.
 func (n *Limit) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -2526,6 +2962,9 @@ type ShowStmt struct {
 
 // Restore implements Node interface.
 func (n *ShowStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	restoreOptFull := func() {
 		if n.Full {
 			ctx.WriteKeyWord("FULL ")
@@ -2947,12 +3386,35 @@ type SelectIntoOption struct {
 	FileName   string
 	FieldsInfo *FieldsClause
 	LinesInfo  *LinesClause
+	// Vars are the variables of SELECT ... INTO var_list.
+	Vars []*SelectIntoVar
+}
+
+// SelectIntoVar is a variable assigned by SELECT ... INTO var_list.
+type SelectIntoVar struct {
+	Name string
+	// IsUser is true if Name is a user variable, Name is a local variable or a parameter
+	// of a stored program otherwise.
+	IsUser bool
 }
 
 // Restore implements Node interface.
 func (n *SelectIntoOption) Restore(ctx *format.RestoreCtx) error {
+	if n.Tp == SelectIntoVars {
+		ctx.WriteKeyWord("INTO ")
+		for i, v := range n.Vars {
+			if i != 0 {
+				ctx.WritePlain(",")
+			}
+			if v.IsUser {
+				ctx.WritePlain("@")
+			}
+			ctx.WriteName(v.Name)
+		}
+		return nil
+	}
 	if n.Tp != SelectIntoOutfile {
-		// only support SELECT/TABLE/VALUES ... INTO OUTFILE statement now
+		// only support SELECT/TABLE/VALUES ... INTO OUTFILE and INTO var_list statement now
 		return errors.New("Unsupported SelectionInto type")
 	}
 
@@ -3179,6 +3641,12 @@ type SplitSyntaxOption struct {
 }
 
 func (n *SplitRegionStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "SPLIT"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("SPLIT ")
 	if n.SplitSyntaxOpt != nil {
 		if n.SplitSyntaxOpt.HasRegionFor {
@@ -14,8 +14,13 @@
 package ast_test
 
 import (
+	"strings"
+
 	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser"
 	. "github.com/pingcap/parser/ast"
+	. "github.com/pingcap/parser/format"
 )
 
 var _ = Suite(&testDMLSuite{})
@@ -417,3 +422,86 @@ func (ts *testDMLSuite) TestFulltextSearchModifier(c *C) {
 	c.Assert(FulltextSearchModifier(FulltextSearchModifierNaturalLanguageMode).IsNaturalLanguageMode(), IsTrue)
 	c.Assert(FulltextSearchModifier(FulltextSearchModifierNaturalLanguageMode).WithQueryExpansion(), IsFalse)
 }
+
+func (ts *testDMLSuite) TestDialectRestore(c *C) {
+	testCases := []struct {
+		sql     string
+		dialect Dialect
+		// expect is the restored SQL, or the construct which can't be restored if unsupported is true.
+		expect      string
+		unsupported bool
+	}{
+		{"select a from t limit 10, 20", DialectTiDB, "SELECT `a` FROM `t` LIMIT 10,20", false},
+		{"select a from t limit 10, 20", DialectMySQL57, "SELECT `a` FROM `t` LIMIT 10,20", false},
+		{"select a from t limit 10, 20", DialectANSI, `SELECT "a" FROM "t" OFFSET 10 ROWS FETCH FIRST 20 ROWS ONLY`, false},
+		{"select a from t order by a limit 5", DialectANSI, `SELECT "a" FROM "t" ORDER BY "a" FETCH FIRST 5 ROWS ONLY`, false},
+		{"select ifnull(a, 'x'), if(a > 1, 2, 3) from t", DialectMySQL80, "SELECT IFNULL(`a`, _UTF8MB4'x'),IF(`a`>1, 2, 3) FROM `t`", false},
+		{"select ifnull(a, 'x'), if(a > 1, 2, 3) from t", DialectANSI, `SELECT COALESCE("a", _UTF8MB4'x'),CASE WHEN "a">1 THEN 2 ELSE 3 END FROM "t"`, false},
+		{"select concat(a, 'x', b) from t", DialectANSI, `SELECT ("a" || _UTF8MB4'x' || "b") FROM "t"`, false},
+		{"select a <=> b, a % 2, !a from t", DialectANSI, `SELECT "a" IS NOT DISTINCT FROM "b",MOD("a", 2),NOT "a" FROM "t"`, false},
+		{"select straight_join sql_no_cache a from t1 straight_join t2 use index (i)", DialectANSI, `SELECT "a" FROM "t1" CROSS JOIN "t2"`, false},
+		{"select * from t1 straight_join t2 on t1.a = t2.a", DialectANSI, `SELECT * FROM "t1" JOIN "t2" ON "t1"."a"="t2"."a"`, false},
+		{"select * from t1 join t2", DialectANSI, `SELECT * FROM "t1" CROSS JOIN "t2"`, false},
+		{"select * from t1, t2", DialectANSI, `SELECT * FROM ("t1") CROSS JOIN "t2"`, false},
+		{"select * from t1, t2", DialectMySQL80, "SELECT * FROM (`t1`) JOIN `t2`", false},
+		{"select * from t1 join t2 using (a)", DialectANSI, `SELECT * FROM "t1" JOIN "t2" USING ("a")`, false},
+		{"select * from t1 natural join t2", DialectANSI, `SELECT * FROM "t1" NATURAL JOIN "t2"`, false},
+		{"select * from t where a != 1 and b <> 2 and c > all (select 1)", DialectANSI, `SELECT * FROM "t" WHERE "a"<>1 AND "b"<>2 AND "c">ALL (SELECT 1)`, false},
+		{"select * from t where a != 1", DialectMySQL80, "SELECT * FROM `t` WHERE `a`!=1", false},
+		{"insert into t set a = 1, b = 'x'", DialectANSI, `INSERT INTO "t" ("a","b") VALUES (1,_UTF8MB4'x')`, false},
+		{"insert into t set a = 1, b = 'x'", DialectMySQL57, "INSERT INTO `t` SET `a`=1,`b`=_UTF8MB4'x'", false},
+		// the hints are dropped, since they don't change the result.
+		{"select /*+ use_index(t, i) */ a from t use index (i) ignore index (j) where a = 1", DialectANSI, `SELECT "a" FROM "t" WHERE "a"=1`, false},
+		{"update /*+ no_index_merge() */ low_priority t force index (i) set a = 1", DialectANSI, `UPDATE "t" SET "a"=1`, false},
+		{"delete /*+ max_execution_time(10) */ quick from t use index (i) where a = 1", DialectANSI, `DELETE FROM "t" WHERE "a"=1`, false},
+		// the literals, the functions and the types aren't rewritten, see DialectANSI.
+		{"select 'x', cast(a as signed), json_extract(a, '$.b') from t", DialectANSI, `SELECT _UTF8MB4'x',CAST("a" AS SIGNED),JSON_EXTRACT("a", _UTF8MB4'$.b') FROM "t"`, false},
+		{"create table t (id int auto_increment primary key)", DialectANSI, `CREATE TABLE "t" ("id" INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY)`, false},
+		{"with c as (select 1) select * from c", DialectMySQL80, "WITH `c` AS (SELECT 1) SELECT * FROM `c`", false},
+		{"select * from t for update nowait", DialectMySQL80, "SELECT * FROM `t` FOR UPDATE NOWAIT", false},
+		{"select a from t intersect select a from s", DialectMySQL80, "SELECT `a` FROM `t` INTERSECT SELECT `a` FROM `s`", false},
+		{"select * from json_table(@j, '$' columns (a int path '$')) jt", DialectMySQL80, "SELECT * FROM JSON_TABLE(@`j`, '$' COLUMNS (`a` INT PATH '$')) AS `jt`", false},
+
+		{"with c as (select 1) select * from c", DialectMySQL57, "WITH", true},
+		{"select row_number() over () from t", DialectMySQL57, "window function", true},
+		{"select * from t for update nowait", DialectMySQL57, "FOR UPDATE NOWAIT", true},
+		{"select * from t for update wait 2", DialectMySQL80, "FOR UPDATE WAIT", true},
+		{"select a from t intersect select a from s", DialectMySQL57, "INTERSECT", true},
+		{"select * from json_table(@j, '$' columns (a int path '$')) jt", DialectMySQL57, "JSON_TABLE", true},
+		{"create role r", DialectMySQL57, "CREATE ROLE", true},
+		{"split table t between (0) and (100) regions 10", DialectMySQL80, "SPLIT", true},
+		{"flashback table t", DialectMySQL57, "FLASHBACK TABLE", true},
+		{"admin show ddl", DialectANSI, "ADMIN", true},
+		{"create sequence s", DialectMySQL80, "CREATE SEQUENCE", true},
+		{"select a div 2 from t", DialectANSI, "operator DIV", true},
+		{"select a ^ 2 from t", DialectANSI, "operator ^", true},
+		{"select a from t where a regexp 'x'", DialectANSI, "REGEXP", true},
+		{"select @a", DialectANSI, "variable", true},
+		{"select group_concat(a) from t", DialectANSI, "GROUP_CONCAT", true},
+		{"select sql_calc_found_rows a from t", DialectANSI, "SQL_CALC_FOUND_ROWS", true},
+		{"select a from t into outfile 'x'", DialectANSI, "SELECT INTO", true},
+		{"replace into t values (1)", DialectANSI, "REPLACE", true},
+		{"insert into t values (1) on duplicate key update a = 1", DialectANSI, "ON DUPLICATE KEY UPDATE", true},
+		{"update t set a = 1 limit 1", DialectANSI, "UPDATE ... LIMIT", true},
+		{"delete t1 from t1, t2", DialectANSI, "multiple-table DELETE", true},
+		{"create table t (a int) engine = innodb", DialectANSI, "table options", true},
+		{"create table t (a int comment 'x')", DialectANSI, "COMMENT", true},
+	}
+	p := parser.New()
+	p.EnableWindowFunc(true)
+	for _, tc := range testCases {
+		comment := Commentf("%s in %s", tc.sql, tc.dialect)
+		stmt, err := p.ParseOneStmt(tc.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		var sb strings.Builder
+		ctx := NewRestoreCtx(DefaultRestoreFlags, &sb)
+		ctx.Dialect = tc.dialect
+		err = stmt.Restore(ctx)
+		if tc.unsupported {
+			c.Assert(errors.Cause(err), DeepEquals, &UnsupportedError{Construct: tc.expect, Dialect: tc.dialect}, comment)
+			continue
+		}
+		c.Assert(err, IsNil, comment)
+		c.Assert(sb.String(), Equals, tc.expect, comment)
+	}
+}
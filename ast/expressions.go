@@ -173,6 +173,18 @@ func restoreBinaryOpWithSpacesAround(ctx *format.RestoreCtx, op opcode.Op) error
 
 // Restore implements Node interface.
 func (n *BinaryOperationExpr) Restore(ctx *format.RestoreCtx) error {
+	if ctx.Dialect == format.DialectANSI {
+		switch n.Op {
+		case opcode.NullEQ:
+			return n.restoreNullEQ(ctx)
+		case opcode.Mod:
+			return n.restoreMod(ctx)
+		case opcode.IntDiv, opcode.LogicXor, opcode.And, opcode.Or, opcode.Xor, opcode.LeftShift, opcode.RightShift:
+			var sb strings.Builder
+			n.Op.Format(&sb)
+			return ctx.Unsupported("operator "+sb.String(), format.DialectANSI)
+		}
+	}
 	if err := n.L.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred when restore BinaryOperationExpr.L")
 	}
@@ -186,6 +198,33 @@ func (n *BinaryOperationExpr) Restore(ctx *format.RestoreCtx) error {
 	return nil
 }
 
+// restoreNullEQ restores `a <=> b` as `a IS NOT DISTINCT FROM b`.
+func (n *BinaryOperationExpr) restoreNullEQ(ctx *format.RestoreCtx) error {
+	if err := n.L.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred when restore BinaryOperationExpr.L")
+	}
+	ctx.WriteKeyWord(" IS NOT DISTINCT FROM ")
+	if err := n.R.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred when restore BinaryOperationExpr.R")
+	}
+	return nil
+}
+
+// restoreMod restores `a % b` as `MOD(a, b)`.
+func (n *BinaryOperationExpr) restoreMod(ctx *format.RestoreCtx) error {
+	ctx.WriteKeyWord("MOD")
+	ctx.WritePlain("(")
+	if err := n.L.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred when restore BinaryOperationExpr.L")
+	}
+	ctx.WritePlain(", ")
+	if err := n.R.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred when restore BinaryOperationExpr.R")
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
 // Format the ExprNode into a Writer.
 func (n *BinaryOperationExpr) Format(w io.Writer) {
 	n.L.Format(w)
@@ -368,11 +407,9 @@ func (*SubqueryExpr) resultSet() {}
 
 // Restore implements Node interface.
 func (n *SubqueryExpr) Restore(ctx *format.RestoreCtx) error {
-	ctx.WritePlain("(")
-	if err := n.Query.Restore(ctx); err != nil {
+	if err := restoreInParentheses(ctx, n.Query); err != nil {
 		return errors.Annotate(err, "An error occurred while restore SubqueryExpr.Query")
 	}
-	ctx.WritePlain(")")
 	return nil
 }
 
@@ -703,14 +740,17 @@ func (n *PatternInExpr) Restore(ctx *format.RestoreCtx) error {
 		}
 	} else {
 		ctx.WritePlain("(")
+		// the long lists are wrapped in the pretty-printing mode.
+		ctx.Indent()
 		for i, expr := range n.List {
 			if i != 0 {
-				ctx.WritePlain(",")
+				ctx.WriteListSeparator(",")
 			}
 			if err := expr.Restore(ctx); err != nil {
 				return errors.Annotatef(err, "An error occurred while restore PatternInExpr.List[%d]", i)
 			}
 		}
+		ctx.Unindent()
 		ctx.WritePlain(")")
 	}
 	return nil
@@ -877,7 +917,7 @@ type PatternLikeExpr struct {
 	Pattern ExprNode
 	// Not is true, the expression is "not like".
 	Not bool
-
+	// Escape is the escape character, it's 0 if there is none.
 	Escape byte
 
 	PatChars []byte
@@ -900,11 +940,14 @@ func (n *PatternLikeExpr) Restore(ctx *format.RestoreCtx) error {
 		return errors.Annotate(err, "An error occurred while restore PatternLikeExpr.Pattern")
 	}
 
-	escape := string(n.Escape)
-	if escape != "\\" {
+	switch n.Escape {
+	case '\\':
+	case 0:
 		ctx.WriteKeyWord(" ESCAPE ")
-		ctx.WriteString(escape)
-
+		ctx.WriteString("")
+	default:
+		ctx.WriteKeyWord(" ESCAPE ")
+		ctx.WriteString(string(n.Escape))
 	}
 	return nil
 }
@@ -918,7 +961,11 @@ func (n *PatternLikeExpr) Format(w io.Writer) {
 		fmt.Fprint(w, " LIKE ")
 	}
 	n.Pattern.Format(w)
-	if n.Escape != '\\' {
+	switch n.Escape {
+	case '\\':
+	case 0:
+		fmt.Fprint(w, " ESCAPE ''")
+	default:
 		fmt.Fprint(w, " ESCAPE ")
 		fmt.Fprintf(w, "'%c'", n.Escape)
 	}
@@ -1055,6 +1102,9 @@ type PatternRegexpExpr struct {
 
 // Restore implements Node interface.
 func (n *PatternRegexpExpr) Restore(ctx *format.RestoreCtx) error {
+	if err := ctx.Unsupported("REGEXP", format.DialectANSI); err != nil {
+		return err
+	}
 	if err := n.Expr.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore PatternRegexpExpr.Expr")
 	}
@@ -1160,8 +1210,15 @@ type UnaryOperationExpr struct {
 
 // Restore implements Node interface.
 func (n *UnaryOperationExpr) Restore(ctx *format.RestoreCtx) error {
-	if err := n.Op.Restore(ctx); err != nil {
-		return errors.Trace(err)
+	switch {
+	case ctx.Dialect == format.DialectANSI && n.Op == opcode.Not2:
+		ctx.WriteKeyWord("NOT ")
+	case ctx.Dialect == format.DialectANSI && n.Op == opcode.BitNeg:
+		return ctx.Unsupported("operator ~", format.DialectANSI)
+	default:
+		if err := n.Op.Restore(ctx); err != nil {
+			return errors.Trace(err)
+		}
 	}
 	if err := n.V.Restore(ctx); err != nil {
 		return errors.Trace(err)
@@ -1248,6 +1305,9 @@ type VariableExpr struct {
 
 // Restore implements Node interface.
 func (n *VariableExpr) Restore(ctx *format.RestoreCtx) error {
+	if err := ctx.Unsupported("variable", format.DialectANSI); err != nil {
+		return err
+	}
 	if n.IsSystem {
 		ctx.WritePlain("@@")
 		if n.ExplicitScope {
@@ -1443,22 +1503,29 @@ func (n *SetCollationExpr) Accept(v Visitor) (Node, bool) {
 }
 
 type exprTextPositionCleaner struct {
-	oldTextPos []int
+	oldTextPos []textPosition
 	restore    bool
 }
 
+type textPosition struct {
+	offset     int
+	start, end Pos
+}
+
 func (e *exprTextPositionCleaner) BeginRestore() {
 	e.restore = true
 }
 
 func (e *exprTextPositionCleaner) Enter(n Node) (node Node, skipChildren bool) {
 	if e.restore {
-		n.SetOriginTextPosition(e.oldTextPos[0])
+		n.SetOriginTextPosition(e.oldTextPos[0].offset)
+		n.SetPos(e.oldTextPos[0].start, e.oldTextPos[0].end)
 		e.oldTextPos = e.oldTextPos[1:]
 		return n, false
 	}
-	e.oldTextPos = append(e.oldTextPos, n.OriginTextPosition())
+	e.oldTextPos = append(e.oldTextPos, textPosition{n.OriginTextPosition(), n.StartPos(), n.EndPos()})
 	n.SetOriginTextPosition(0)
+	n.SetPos(Pos{}, Pos{})
 	return n, false
 }
 
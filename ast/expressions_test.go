@@ -290,11 +290,18 @@ func (tc *testExpressionsSuite) TestPatternLikeExprRestore(c *C) {
 		{"a not like 't1%'", "`a` NOT LIKE _UTF8MB4't1%'"},
 		{"a not like '%D%v%'", "`a` NOT LIKE _UTF8MB4'%D%v%'"},
 		{"a not like '%t1_|'", "`a` NOT LIKE _UTF8MB4'%t1_|'"},
+		{"a like 't1|_' escape '|'", "`a` LIKE _UTF8MB4't1|_' ESCAPE '|'"},
 	}
 	extractNodeFunc := func(node Node) Node {
 		return node.(*SelectStmt).Fields.Fields[0].Expr
 	}
 	RunNodeRestoreTest(c, testCases, "select %s", extractNodeFunc)
+
+	// a NUL escape is no escape character, which is restored as the empty escape.
+	testCases = []NodeRestoreTestCase{
+		{`a like 't1\_' escape '\0'`, "`a` LIKE _UTF8MB4't1\\_' ESCAPE ''"},
+	}
+	RunNodeRestoreTestWithFlagsStmtChange(c, testCases, "select %s", extractNodeFunc)
 }
 
 func (tc *testExpressionsSuite) TestValuesExpr(c *C) {
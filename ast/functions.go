@@ -381,6 +381,18 @@ func (n *FuncCallExpr) Restore(ctx *format.RestoreCtx) error {
 		}
 		return nil
 	}
+	if ctx.Dialect == format.DialectANSI && n.Schema.L == "" {
+		switch n.FnName.L {
+		case Ifnull:
+			return n.restoreAs(ctx, Coalesce)
+		case Concat:
+			return n.restoreConcat(ctx)
+		case If:
+			if len(n.Args) == 3 {
+				return n.restoreIf(ctx)
+			}
+		}
+	}
 
 	if len(n.Schema.String()) != 0 {
 		ctx.WriteName(n.Schema.O)
@@ -440,7 +452,9 @@ func (n *FuncCallExpr) Restore(ctx *format.RestoreCtx) error {
 			ctx.WritePlain(" ")
 			fallthrough
 		case 2:
-			if expr, isValue := n.Args[1].(ValueExpr); !isValue || expr.GetValue() != nil {
+			// the omitted remstr is a NULL, but a parameter marker has no value either.
+			_, isParam := n.Args[1].(ParamMarkerExpr)
+			if expr, isValue := n.Args[1].(ValueExpr); !isValue || isParam || expr.GetValue() != nil {
 				if err := n.Args[1].Restore(ctx); err != nil {
 					return errors.Annotatef(err, "An error occurred while restore FuncCallExpr.Args[1]")
 				}
@@ -480,6 +494,55 @@ func (n *FuncCallExpr) Restore(ctx *format.RestoreCtx) error {
 	return nil
 }
 
+// restoreAs restores the function as the function of the name with the same arguments.
+func (n *FuncCallExpr) restoreAs(ctx *format.RestoreCtx, name string) error {
+	ctx.WriteKeyWord(name)
+	ctx.WritePlain("(")
+	for i, argv := range n.Args {
+		if i != 0 {
+			ctx.WritePlain(", ")
+		}
+		if err := argv.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore FuncCallExpr.Args %d", i)
+		}
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// restoreConcat restores `CONCAT(a, b)` as `(a || b)`.
+func (n *FuncCallExpr) restoreConcat(ctx *format.RestoreCtx) error {
+	ctx.WritePlain("(")
+	for i, argv := range n.Args {
+		if i != 0 {
+			ctx.WritePlain(" || ")
+		}
+		if err := argv.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore FuncCallExpr.Args %d", i)
+		}
+	}
+	ctx.WritePlain(")")
+	return nil
+}
+
+// restoreIf restores `IF(c, a, b)` as `CASE WHEN c THEN a ELSE b END`.
+func (n *FuncCallExpr) restoreIf(ctx *format.RestoreCtx) error {
+	ctx.WriteKeyWord("CASE WHEN ")
+	if err := n.Args[0].Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore FuncCallExpr.Args[0]")
+	}
+	ctx.WriteKeyWord(" THEN ")
+	if err := n.Args[1].Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore FuncCallExpr.Args[1]")
+	}
+	ctx.WriteKeyWord(" ELSE ")
+	if err := n.Args[2].Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore FuncCallExpr.Args[2]")
+	}
+	ctx.WriteKeyWord(" END")
+	return nil
+}
+
 // Format the ExprNode into a Writer.
 func (n *FuncCallExpr) Format(w io.Writer) {
 	fmt.Fprintf(w, "%s(", n.FnName.L)
@@ -740,6 +803,11 @@ type AggregateFuncExpr struct {
 
 // Restore implements Node interface.
 func (n *AggregateFuncExpr) Restore(ctx *format.RestoreCtx) error {
+	if n.F == AggFuncGroupConcat {
+		if err := ctx.Unsupported("GROUP_CONCAT", format.DialectANSI); err != nil {
+			return err
+		}
+	}
 	ctx.WriteKeyWord(n.F)
 	ctx.WritePlain("(")
 	if n.Distinct {
@@ -856,6 +924,9 @@ type WindowFuncExpr struct {
 
 // Restore implements Node interface.
 func (n *WindowFuncExpr) Restore(ctx *format.RestoreCtx) error {
+	if err := ctx.Unsupported("window function", format.DialectMySQL57); err != nil {
+		return err
+	}
 	ctx.WriteKeyWord(n.F)
 	ctx.WritePlain("(")
 	for i, v := range n.Args {
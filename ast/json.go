@@ -0,0 +1,600 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/auth"
+	"github.com/pingcap/parser/model"
+)
+
+// The JSON encoding of the AST.
+//
+// A struct of package ast, including every node, is encoded as an object:
+//
+//	{"type": "SelectStmt", "start": {...}, "end": {...}, "offset": 0, "text": "...",
+//	 "leadingComments": [...], "trailingComments": [...], "fields": {...}}
+//
+// "type" is the name the struct is registered with, see RegisterJSONType.
+// "start", "end", "offset", "text" and the comments are only written for the nodes,
+// they are the StartPos, EndPos, OriginTextPosition, Text and the attached comments.
+// "fields" holds the exported fields of the struct by their Go names, the fields
+// with zero values are omitted. A node implementing json.Marshaler and
+// json.Unmarshaler, such as the ValueExpr of a parser driver, encodes its "fields"
+// itself.
+//
+// A value of other types held by an interface, such as the HintData of
+// TableOptimizerHint, is encoded as {"type": "int64", "value": ...}. The values of
+// the other types held by the fields are encoded by package encoding/json, an
+// error is encoded as its message.
+//
+// The results of the name resolution, ResultField, model.DBInfo and model.TableInfo,
+// and the compiled regexps aren't encoded.
+
+// EncodeJSON encodes the node n into JSON.
+func EncodeJSON(n Node) ([]byte, error) {
+	e := &jsonEncoder{}
+	if err := e.encode(reflect.ValueOf(&n).Elem()); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// EncodeStmtsJSON encodes the statements, usually returned by Parser.Parse, into a JSON array.
+func EncodeStmtsJSON(stmts []StmtNode) ([]byte, error) {
+	if stmts == nil {
+		stmts = []StmtNode{}
+	}
+	e := &jsonEncoder{}
+	if err := e.encode(reflect.ValueOf(stmts)); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// DecodeJSON decodes the node encoded by EncodeJSON.
+func DecodeJSON(data []byte) (Node, error) {
+	var n Node
+	if err := decodeJSON(data, reflect.ValueOf(&n).Elem()); err != nil {
+		return nil, err
+	}
+	if n != nil {
+		SetFlag(n)
+	}
+	return n, nil
+}
+
+// DecodeStmtsJSON decodes the statements encoded by EncodeStmtsJSON.
+func DecodeStmtsJSON(data []byte) ([]StmtNode, error) {
+	var stmts []StmtNode
+	if err := decodeJSON(data, reflect.ValueOf(&stmts).Elem()); err != nil {
+		return nil, err
+	}
+	for _, stmt := range stmts {
+		if stmt != nil {
+			SetFlag(stmt)
+		}
+	}
+	return stmts, nil
+}
+
+type jsonType struct {
+	name string
+	typ  reflect.Type
+	// object is true if the value is encoded as an object of its fields.
+	object bool
+}
+
+var (
+	jsonTypesByName = make(map[string]*jsonType)
+	jsonTypesByType = make(map[reflect.Type]*jsonType)
+	// jsonObjects maps the struct types encoded as objects to their names.
+	jsonObjects = make(map[reflect.Type]string)
+)
+
+// RegisterJSONType registers the type of v with name, so that the values of the type held
+// by an interface can be encoded into JSON and decoded. The nodes and the structs of
+// package ast are encoded as objects of their fields, the other values are encoded by
+// package encoding/json.
+//
+// The nodes of package ast are registered by their type names, a parser driver should
+// register its ValueExpr and ParamMarkerExpr in the same way. It isn't safe for
+// concurrent use, it should be called in init functions.
+func RegisterJSONType(name string, v interface{}) {
+	t := reflect.TypeOf(v)
+	if _, ok := jsonTypesByName[name]; ok {
+		panic("ast: JSON type " + name + " is registered twice")
+	}
+	st := t
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	jt := &jsonType{
+		name:   name,
+		typ:    t,
+		object: st.Kind() == reflect.Struct && (t.Implements(nodeType) || st.PkgPath() == astPkgPath),
+	}
+	jsonTypesByName[name] = jt
+	jsonTypesByType[t] = jt
+	if jt.object {
+		jsonObjects[st] = name
+	}
+}
+
+var (
+	nodeType      = reflect.TypeOf((*Node)(nil)).Elem()
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+	astPkgPath    = reflect.TypeOf(Pos{}).PkgPath()
+	jsonSkipTypes = map[reflect.Type]bool{
+		reflect.TypeOf(&ResultField{}):     true,
+		reflect.TypeOf(&model.DBInfo{}):    true,
+		reflect.TypeOf(&model.TableInfo{}): true,
+		reflect.TypeOf(&regexp.Regexp{}):   true,
+	}
+)
+
+func init() {
+	for _, v := range []interface{}{
+		// advisor.go
+		&IndexAdviseStmt{}, &MaxIndexNumClause{},
+		// ast.go
+		&OptBinary{},
+		// ddl.go
+		&CharsetOpt{}, &NullString{}, &DatabaseOption{}, &CreateDatabaseStmt{}, &AlterDatabaseStmt{},
+		&DropDatabaseStmt{}, &IndexPartSpecification{}, &ReferenceDef{}, &OnDeleteOpt{}, &OnUpdateOpt{},
+		&ColumnOption{}, &IndexOption{}, &Constraint{}, &ColumnDef{}, &CreateTableStmt{}, &DropTableStmt{},
+		&DropSequenceStmt{}, &RenameTableStmt{}, &TableToTable{}, &CreateViewStmt{}, &CreateSequenceStmt{},
+		&IndexLockAndAlgorithm{}, &CreateIndexStmt{}, &DropIndexStmt{}, &LockTablesStmt{}, &TableLock{},
+		&UnlockTablesStmt{}, &CleanupTableLockStmt{}, &RepairTableStmt{}, &TableOption{}, &SequenceOption{},
+		&ColumnPosition{}, &AlterTableSpec{}, &TiFlashReplicaSpec{}, &AlterOrderItem{}, &AlterTableStmt{},
+		&TruncateTableStmt{}, &SubPartitionDefinition{}, &PartitionDefinitionClauseNone{},
+		&PartitionDefinitionClauseLessThan{}, &PartitionDefinitionClauseIn{},
+		&PartitionDefinitionClauseHistory{}, &PartitionDefinition{}, &PartitionMethod{}, &PartitionOptions{},
+		&RecoverTableStmt{}, &FlashBackTableStmt{}, &PlacementSpec{}, &AlterSequenceStmt{}, &StoredParameter{},
+		&RoutineCharacteristic{}, &CreateProcedureStmt{}, &CreateFunctionStmt{}, &ReturnStmt{},
+		&AlterProcedureStmt{}, &DropProcedureStmt{}, &TriggerOrder{}, &CreateTriggerStmt{}, &DropTriggerStmt{},
+		&EventSchedule{}, &CreateEventStmt{}, &AlterEventStmt{}, &DropEventStmt{},
+		// dml.go
+		&Join{}, &TableName{}, &IndexHint{}, &DeleteTableList{}, &OnCondition{}, &TableSource{},
+		&SelectLockInfo{}, &WildCardField{}, &SelectField{}, &FieldList{}, &TableRefsClause{}, &ByItem{},
+		&GroupByClause{}, &HavingClause{}, &OrderByClause{}, &TableSample{}, &CommonTableExpression{},
+		&WithClause{}, &SelectStmt{}, &SetOprSelectList{}, &SetOprStmt{}, &Assignment{}, &ColumnNameOrUserVar{},
+		&LoadDataStmt{}, &FieldItem{}, &FieldsClause{}, &LinesClause{}, &CallStmt{}, &InsertStmt{},
+		&DeleteStmt{}, &UpdateStmt{}, &Limit{}, &ShowStmt{}, &WindowSpec{}, &SelectIntoOption{}, &SelectIntoVar{},
+		&PartitionByClause{}, &FrameClause{}, &FrameExtent{}, &FrameBound{}, &SplitRegionStmt{}, &SplitOption{},
+		&SplitSyntaxOption{}, &TimestampBound{}, &AsOfClause{}, &JSONTable{}, &JSONTableColumn{},
+		&JSONTableResponse{},
+		// expressions.go
+		&BetweenExpr{}, &BinaryOperationExpr{}, &WhenClause{}, &CaseExpr{}, &SubqueryExpr{},
+		&CompareSubqueryExpr{}, &TableNameExpr{}, &ColumnName{}, &ColumnNameExpr{}, &DefaultExpr{},
+		&ExistsSubqueryExpr{}, &PatternInExpr{}, &IsNullExpr{}, &IsTruthExpr{}, &PatternLikeExpr{},
+		&ParenthesesExpr{}, &PositionExpr{}, &PatternRegexpExpr{}, &RowExpr{}, &UnaryOperationExpr{},
+		&ValuesExpr{}, &VariableExpr{}, &MaxValueExpr{}, &MatchAgainst{}, &SetCollationExpr{},
+		// functions.go
+		&FuncCallExpr{}, &FuncCastExpr{}, &TrimDirectionExpr{}, &AggregateFuncExpr{}, &WindowFuncExpr{},
+		&TimeUnitExpr{}, &GetFormatSelectorExpr{},
+		// misc.go
+		&TypeOpt{}, &FloatOpt{}, &AuthOption{}, &TraceStmt{}, &ExplainForStmt{}, &ExplainStmt{}, &PrepareStmt{},
+		&DeallocateStmt{}, &ExecuteStmt{}, &BeginStmt{}, &BinlogStmt{}, &CommitStmt{}, &RollbackStmt{},
+		&UseStmt{}, &VariableAssignment{}, &FlushStmt{}, &KillStmt{}, &SetStmt{}, &SetConfigStmt{},
+		&SetPwdStmt{}, &ChangeStmt{}, &SetRoleStmt{}, &SetDefaultRoleStmt{}, &UserSpec{}, &TLSOption{},
+		&ResourceOption{}, &PasswordOrLockOption{}, &CreateUserStmt{}, &AlterUserStmt{}, &AlterInstanceStmt{},
+		&DropUserStmt{}, &CreateBindingStmt{}, &DropBindingStmt{}, &StatisticsSpec{}, &CreateStatisticsStmt{},
+		&DropStatisticsStmt{}, &DoStmt{}, &HandleRange{}, &ShowSlow{}, &AdminStmt{}, &RoleOrPriv{}, &PrivElem{},
+		&GrantLevel{}, &RevokeStmt{}, &RevokeRoleStmt{}, &GrantStmt{}, &GrantProxyStmt{}, &GrantRoleStmt{},
+		&ShutdownStmt{}, &DelimiterStmt{}, &RenameUserStmt{}, &UserToUser{}, &BRIEOption{}, &BRIEStmt{},
+		&PurgeImportStmt{}, &CreateImportStmt{}, &StopImportStmt{}, &ResumeImportStmt{}, &ImportTruncate{},
+		&AlterImportStmt{}, &DropImportStmt{}, &ShowImportStmt{}, &Ident{}, &SelectStmtOpts{},
+		&TableOptimizerHint{}, HintTimeRange{}, HintSetVar{}, &HintTable{},
+		// procedure.go
+		&BlockStmt{}, &DeclareVarStmt{}, &ConditionValue{}, &DeclareConditionStmt{}, &DeclareCursorStmt{},
+		&DeclareHandlerStmt{}, &IfBranch{}, &IfStmt{}, &CaseStmtWhenClause{}, &CaseStmt{}, &LoopStmt{},
+		&WhileStmt{}, &RepeatStmt{}, &LeaveStmt{}, &IterateStmt{}, &OpenCursorStmt{}, &FetchCursorStmt{},
+		&CloseCursorStmt{}, &SignalInfoItem{}, &SignalStmt{}, &DiagnosticsItem{}, &GetDiagnosticsStmt{},
+		// stats.go
+		&AnalyzeTableStmt{}, &AnalyzeOpt{}, &DropStatsStmt{}, &LoadStatsStmt{},
+		// unparsed_ast.go
+		&UnparsedStmt{},
+	} {
+		t := reflect.TypeOf(v)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		RegisterJSONType(t.Name(), v)
+	}
+
+	// the values held by TableOptimizerHint.HintData and RoleOrPriv.Node.
+	RegisterJSONType("bool", false)
+	RegisterJSONType("int64", int64(0))
+	RegisterJSONType("uint64", uint64(0))
+	RegisterJSONType("float64", float64(0))
+	RegisterJSONType("string", "")
+	RegisterJSONType("model.CIStr", model.CIStr{})
+	RegisterJSONType("auth.RoleIdentity", &auth.RoleIdentity{})
+}
+
+// jsonObject is the JSON object of a struct or a value held by an interface.
+type jsonObject struct {
+	Type             string          `json:"type"`
+	Start            *Pos            `json:"start,omitempty"`
+	End              *Pos            `json:"end,omitempty"`
+	Offset           int             `json:"offset,omitempty"`
+	Text             string          `json:"text,omitempty"`
+	LeadingComments  []*Comment      `json:"leadingComments,omitempty"`
+	TrailingComments []*Comment      `json:"trailingComments,omitempty"`
+	Fields           json.RawMessage `json:"fields,omitempty"`
+	Value            json.RawMessage `json:"value,omitempty"`
+}
+
+type jsonField struct {
+	index int
+	name  string
+}
+
+var (
+	jsonFieldsMu    sync.RWMutex
+	jsonFieldsCache = make(map[reflect.Type][]jsonField)
+)
+
+// jsonFieldsOf returns the encoded fields of the struct type t.
+func jsonFieldsOf(t reflect.Type) []jsonField {
+	jsonFieldsMu.RLock()
+	cached, ok := jsonFieldsCache[t]
+	jsonFieldsMu.RUnlock()
+	if ok {
+		return cached
+	}
+
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || jsonSkipTypes[f.Type] {
+			continue
+		}
+		fields = append(fields, jsonField{index: i, name: f.Name})
+	}
+	jsonFieldsMu.Lock()
+	jsonFieldsCache[t] = fields
+	jsonFieldsMu.Unlock()
+	return fields
+}
+
+type jsonEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *jsonEncoder) marshal(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.buf.Write(data)
+	return nil
+}
+
+func (e *jsonEncoder) encode(v reflect.Value) error {
+	t := v.Type()
+	if t == errorType {
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		return e.marshal(v.Interface().(error).Error())
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		v = v.Elem()
+		jt, ok := jsonTypesByType[v.Type()]
+		if !ok {
+			return errors.Errorf("ast: can't encode the value of type %s into JSON", v.Type())
+		}
+		if jt.object {
+			if v.Kind() == reflect.Ptr {
+				v = v.Elem()
+			}
+			return e.encodeObject(jt.name, v)
+		}
+		e.buf.WriteString(`{"type":`)
+		if err := e.marshal(jt.name); err != nil {
+			return err
+		}
+		e.buf.WriteString(`,"value":`)
+		if err := e.marshal(v.Interface()); err != nil {
+			return err
+		}
+		e.buf.WriteByte('}')
+		return nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		if name, ok := jsonObjects[t.Elem()]; ok {
+			return e.encodeObject(name, v.Elem())
+		}
+		if t.Implements(nodeType) {
+			return errors.Errorf("ast: node type %s isn't registered for JSON", t)
+		}
+	case reflect.Struct:
+		if name, ok := jsonObjects[t]; ok {
+			return e.encodeObject(name, v)
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		e.buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte(']')
+		return nil
+	}
+	return e.marshal(v.Interface())
+}
+
+// encodeObject encodes the struct v as an object with its type name.
+func (e *jsonEncoder) encodeObject(name string, v reflect.Value) error {
+	obj := jsonObject{Type: name}
+	var x interface{}
+	if v.CanAddr() {
+		x = v.Addr().Interface()
+	}
+	if n, ok := x.(Node); ok {
+		if start := n.StartPos(); start != (Pos{}) {
+			obj.Start = &start
+		}
+		if end := n.EndPos(); end != (Pos{}) {
+			obj.End = &end
+		}
+		obj.Offset = n.OriginTextPosition()
+		obj.Text = n.Text()
+		obj.LeadingComments = n.LeadingComments()
+		obj.TrailingComments = n.TrailingComments()
+	}
+	if m, ok := x.(json.Marshaler); ok {
+		fields, err := m.MarshalJSON()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		obj.Fields = fields
+	} else {
+		fe := &jsonEncoder{}
+		if err := fe.encodeFields(v); err != nil {
+			return err
+		}
+		obj.Fields = fe.buf.Bytes()
+	}
+	return e.marshal(&obj)
+}
+
+func (e *jsonEncoder) encodeFields(v reflect.Value) error {
+	e.buf.WriteByte('{')
+	first := true
+	for _, f := range jsonFieldsOf(v.Type()) {
+		fv := v.Field(f.index)
+		if fv.IsZero() {
+			continue
+		}
+		if !first {
+			e.buf.WriteByte(',')
+		}
+		first = false
+		if err := e.marshal(f.name); err != nil {
+			return err
+		}
+		e.buf.WriteByte(':')
+		if err := e.encode(fv); err != nil {
+			return errors.Annotatef(err, "field %s.%s", v.Type().Name(), f.name)
+		}
+	}
+	e.buf.WriteByte('}')
+	return nil
+}
+
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+// decodeJSON decodes data into v, which must be settable.
+func decodeJSON(data []byte, v reflect.Value) error {
+	t := v.Type()
+	if isJSONNull(data) {
+		v.Set(reflect.Zero(t))
+		return nil
+	}
+	if t == errorType {
+		var msg string
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return errors.Trace(err)
+		}
+		v.Set(reflect.ValueOf(errors.New(msg)))
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		var obj jsonObject
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return errors.Trace(err)
+		}
+		jt, ok := jsonTypesByName[obj.Type]
+		if !ok {
+			return errors.Errorf("ast: unknown JSON type %q", obj.Type)
+		}
+		if !jt.typ.AssignableTo(t) {
+			return errors.Errorf("ast: JSON type %s can't be assigned to %s", obj.Type, t)
+		}
+		if !jt.object {
+			p := reflect.New(jt.typ)
+			if err := json.Unmarshal(obj.Value, p.Interface()); err != nil {
+				return errors.Trace(err)
+			}
+			v.Set(p.Elem())
+			return nil
+		}
+		if jt.typ.Kind() == reflect.Ptr {
+			p := reflect.New(jt.typ.Elem())
+			if err := decodeObject(&obj, p); err != nil {
+				return err
+			}
+			v.Set(p)
+			return nil
+		}
+		p := reflect.New(jt.typ)
+		if err := decodeObject(&obj, p); err != nil {
+			return err
+		}
+		v.Set(p.Elem())
+		return nil
+	case reflect.Ptr:
+		name, ok := jsonObjects[t.Elem()]
+		if !ok {
+			break
+		}
+		obj, err := unmarshalJSONObject(data, name)
+		if err != nil {
+			return err
+		}
+		p := reflect.New(t.Elem())
+		if err := decodeObject(obj, p); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	case reflect.Struct:
+		name, ok := jsonObjects[t]
+		if !ok {
+			break
+		}
+		obj, err := unmarshalJSONObject(data, name)
+		if err != nil {
+			return err
+		}
+		p := reflect.New(t)
+		if err := decodeObject(obj, p); err != nil {
+			return err
+		}
+		v.Set(p.Elem())
+		return nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return errors.Trace(err)
+		}
+		s := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := decodeJSON(item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	p := reflect.New(t)
+	if err := json.Unmarshal(data, p.Interface()); err != nil {
+		return errors.Trace(err)
+	}
+	v.Set(p.Elem())
+	return nil
+}
+
+// unmarshalJSONObject unmarshals the object of a struct whose type name is name.
+func unmarshalJSONObject(data []byte, name string) (*jsonObject, error) {
+	obj := &jsonObject{}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if obj.Type != name {
+		return nil, errors.Errorf("ast: JSON type %q is found where %s is expected", obj.Type, name)
+	}
+	return obj, nil
+}
+
+// decodeObject decodes obj into the struct pointed by p.
+func decodeObject(obj *jsonObject, p reflect.Value) error {
+	x := p.Interface()
+	if u, ok := x.(json.Unmarshaler); ok {
+		if len(obj.Fields) > 0 {
+			if err := u.UnmarshalJSON(obj.Fields); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	} else if len(obj.Fields) > 0 {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(obj.Fields, &fields); err != nil {
+			return errors.Trace(err)
+		}
+		v := p.Elem()
+		for _, f := range jsonFieldsOf(v.Type()) {
+			data, ok := fields[f.name]
+			if !ok {
+				continue
+			}
+			if err := decodeJSON(data, v.Field(f.index)); err != nil {
+				return errors.Annotatef(err, "field %s.%s", obj.Type, f.name)
+			}
+			delete(fields, f.name)
+		}
+		if len(fields) > 0 {
+			unknown := make([]string, 0, len(fields))
+			for name := range fields {
+				unknown = append(unknown, name)
+			}
+			sort.Strings(unknown)
+			return errors.Errorf("ast: unknown field %s.%s", obj.Type, unknown[0])
+		}
+	}
+	if n, ok := x.(Node); ok {
+		var start, end Pos
+		if obj.Start != nil {
+			start = *obj.Start
+		}
+		if obj.End != nil {
+			end = *obj.End
+		}
+		n.SetPos(start, end)
+		n.SetOriginTextPosition(obj.Offset)
+		n.SetText(obj.Text)
+		for _, c := range obj.LeadingComments {
+			n.AttachComment(c, false)
+		}
+		for _, c := range obj.TrailingComments {
+			n.AttachComment(c, true)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	"encoding/json"
+	goast "go/ast"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	. "github.com/pingcap/parser/ast"
+	. "github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/test_driver"
+)
+
+var _ = Suite(&testJSONSuite{})
+
+type testJSONSuite struct {
+}
+
+func (s *testJSONSuite) TestRoundTrip(c *C) {
+	cases := []string{
+		"SELECT /*+ MAX_EXECUTION_TIME(1000), MEMORY_QUOTA(1 MB), USE_INDEX(t1 idx), QB_NAME(qb), SET_VAR(a=1), READ_CONSISTENT_REPLICA() */ DISTINCT a, b AS c, t.* FROM t1 JOIN t2 ON t1.id = t2.id LEFT JOIN t3 USING (id) WHERE a > 1 AND b IN (1, 2.5, -3, 'x', _utf8mb4'y', 1.25e3, NULL, TRUE) GROUP BY a HAVING COUNT(*) > 1 ORDER BY a DESC LIMIT 1, 10 FOR UPDATE",
+		"SELECT x'0A', b'101', 0x1F, 18446744073709551615, 123456789012345678901234567890.5, ?, ? FROM DUAL",
+		"WITH RECURSIVE cte (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM cte WHERE n < 5) SELECT * FROM cte",
+		"SELECT a, ROW_NUMBER() OVER w FROM t WINDOW w AS (PARTITION BY b ORDER BY c ROWS BETWEEN 1 PRECEDING AND CURRENT ROW)",
+		"SELECT CASE WHEN a = 1 THEN 'one' ELSE 'other' END, CAST(a AS CHAR(10)), EXISTS (SELECT 1), a LIKE 'x%' ESCAPE '|', a REGEXP 'b', DATE_ADD(d, INTERVAL 1 DAY), TRIM(LEADING 'x' FROM y)",
+		"INSERT INTO t (a, b) VALUES (1, 'x'), (2, DEFAULT) ON DUPLICATE KEY UPDATE b = VALUES(b)",
+		"UPDATE LOW_PRIORITY t SET a = a + 1, b = 'y' WHERE id = 3 ORDER BY id LIMIT 5",
+		"DELETE t1 FROM t1 JOIN t2 ON t1.a = t2.a WHERE t2.b IS NOT NULL",
+		"CREATE TABLE IF NOT EXISTS t (id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY, name VARCHAR(20) CHARACTER SET utf8mb4 DEFAULT 'x' COMMENT 'n', price DECIMAL(10,2), kind ENUM('a', 'b'), INDEX idx_name (name(10)), FOREIGN KEY (id) REFERENCES p (id) ON DELETE CASCADE) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 PARTITION BY RANGE (id) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN (MAXVALUE))",
+		"CREATE TABLE t (a INT) PARTITION BY LIST (a) (PARTITION p0 VALUES IN (1, 2), PARTITION p1 VALUES IN (3))",
+		"ALTER TABLE t ADD COLUMN c INT AFTER b, DROP INDEX idx, RENAME TO t2, ALGORITHM = INPLACE",
+		"CREATE ALGORITHM = MERGE VIEW v (x) AS SELECT a FROM t",
+		"CREATE USER 'u'@'%' IDENTIFIED BY 'p' REQUIRE SSL",
+		"GRANT SELECT, INSERT ON db.* TO 'u'@'%' WITH GRANT OPTION",
+		"SET @a = 1, SESSION sql_mode = 'ANSI', NAMES utf8mb4",
+		"PREPARE s FROM 'SELECT ?'",
+		"EXECUTE s USING @a",
+		"SHOW CREATE TABLE t",
+		"EXPLAIN FORMAT = 'row' SELECT * FROM t",
+		"ANALYZE TABLE t UPDATE HISTOGRAM ON a WITH 10 BUCKETS",
+		"BEGIN",
+	}
+	for _, sql := range cases {
+		comment := Commentf("for %s", sql)
+		stmts, _, err := parser.New().Parse(sql, "", "")
+		c.Assert(err, IsNil, comment)
+		data, err := EncodeStmtsJSON(stmts)
+		c.Assert(err, IsNil, comment)
+		c.Assert(json.Valid(data), IsTrue, comment)
+
+		decoded, err := DecodeStmtsJSON(data)
+		c.Assert(err, IsNil, comment)
+		c.Assert(decoded, DeepEquals, stmts, comment)
+		c.Assert(restoreStmts(c, decoded), Equals, restoreStmts(c, stmts), comment)
+
+		// the encoding is stable.
+		again, err := EncodeStmtsJSON(decoded)
+		c.Assert(err, IsNil, comment)
+		c.Assert(string(again), Equals, string(data), comment)
+	}
+}
+
+func restoreStmts(c *C, stmts []StmtNode) string {
+	var sb strings.Builder
+	for _, stmt := range stmts {
+		c.Assert(stmt.Restore(NewRestoreCtx(DefaultRestoreFlags|RestoreComments, &sb)), IsNil)
+		sb.WriteString(";")
+	}
+	return sb.String()
+}
+
+func (s *testJSONSuite) TestEncoding(c *C) {
+	p := parser.New()
+	p.KeepComments(true)
+	stmt, err := p.ParseOneStmt("SELECT a -- one\nFROM t WHERE b = 1", "", "")
+	c.Assert(err, IsNil)
+	data, err := EncodeJSON(stmt)
+	c.Assert(err, IsNil)
+
+	var obj struct {
+		Type   string
+		Start  Pos
+		End    Pos
+		Text   string
+		Fields map[string]json.RawMessage
+	}
+	c.Assert(json.Unmarshal(data, &obj), IsNil)
+	c.Assert(obj.Type, Equals, "SelectStmt")
+	c.Assert(obj.Start, Equals, Pos{Line: 1, Col: 1, Offset: 0})
+	c.Assert(obj.End, Equals, Pos{Line: 2, Col: 19, Offset: 34})
+	c.Assert(obj.Text, Equals, "SELECT a -- one\nFROM t WHERE b = 1")
+	c.Assert(obj.Fields["Distinct"], IsNil)
+	c.Assert(string(obj.Fields["Where"]), Matches, `\{"type":"BinaryOperationExpr",.*"fields":\{"Op":7,"L":\{"type":"ColumnNameExpr",.*\},"R":\{"type":"ValueExpr",.*"fields":\{"Kind":"Int64","Value":1,"Type":\{.*\}\}\}\}\}`)
+	c.Assert(string(obj.Fields["Fields"]), Matches, `.*"trailingComments":\[\{"Text":"-- one",.*`)
+
+	n, err := DecodeJSON(data)
+	c.Assert(err, IsNil)
+	var sb strings.Builder
+	c.Assert(n.Restore(NewRestoreCtx(DefaultRestoreFlags|RestoreComments, &sb)), IsNil)
+	c.Assert(sb.String(), Equals, "SELECT `a` -- one\n FROM `t` WHERE `b`=1")
+
+	// the values held by interface{} fields.
+	stmt, err = p.ParseOneStmt("SELECT /*+ MAX_EXECUTION_TIME(10) */ 1", "", "")
+	c.Assert(err, IsNil)
+	data, err = EncodeJSON(stmt)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Matches, `.*"HintData":\{"type":"uint64","value":10\}.*`)
+
+	// the errors are encoded as their messages.
+	stmts, _, err := p.PerfectParse("SELECT 1; SELECT FROM;", "", "")
+	c.Assert(err, IsNil)
+	data, err = EncodeStmtsJSON(stmts)
+	c.Assert(err, IsNil)
+	decoded, err := DecodeStmtsJSON(data)
+	c.Assert(err, IsNil)
+	c.Assert(decoded, HasLen, 2)
+	c.Assert(decoded[1].(*UnparsedStmt).Err.Error(), Equals, stmts[1].(*UnparsedStmt).Err.Error())
+	c.Assert(decoded[1].Text(), Equals, "SELECT FROM;")
+
+	n, err = DecodeJSON([]byte("null"))
+	c.Assert(err, IsNil)
+	c.Assert(n, IsNil)
+}
+
+func (s *testJSONSuite) TestDecodeErrors(c *C) {
+	_, err := DecodeJSON([]byte(`{"type":"NoSuchStmt"}`))
+	c.Assert(err, ErrorMatches, `ast: unknown JSON type "NoSuchStmt"`)
+	_, err = DecodeJSON([]byte(`{"type":"SelectStmt","fields":{"NoSuchField":1}}`))
+	c.Assert(err, ErrorMatches, `ast: unknown field SelectStmt.NoSuchField`)
+	_, err = DecodeJSON([]byte(`{"type":"SelectStmt","fields":{"From":{"type":"TableName"}}}`))
+	c.Assert(err, ErrorMatches, `field SelectStmt.From: ast: JSON type "TableName" is found where TableRefsClause is expected`)
+	_, err = DecodeJSON([]byte(`{"type":"SelectStmt","fields":{"Where":{"type":"TableName"}}}`))
+	c.Assert(err, ErrorMatches, `field SelectStmt.Where: ast: JSON type TableName can't be assigned to ast.ExprNode`)
+	_, err = DecodeJSON([]byte(`{"type":"ValueExpr","fields":{"Kind":"Int64","Value":"x"}}`))
+	c.Assert(err, NotNil)
+
+	// the values which can't be encoded.
+	v := &test_driver.ValueExpr{}
+	v.SetValue(struct{}{})
+	_, err = EncodeJSON(v)
+	c.Assert(err, ErrorMatches, ".*can't encode the value of kind 14 into JSON")
+	_, err = EncodeJSON(&ExecuteStmt{BinaryArgs: []int{1}})
+	c.Assert(err, ErrorMatches, `field ExecuteStmt.BinaryArgs: ast: can't encode the value of type \[\]int into JSON`)
+}
+
+// TestRegisteredTypes checks that every exported struct of package ast can be decoded.
+func (s *testJSONSuite) TestRegisteredTypes(c *C) {
+	fset := token.NewFileSet()
+	pkgs, err := goparser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	c.Assert(err, IsNil)
+	// they aren't encoded as objects.
+	skipped := map[string]bool{"Pos": true, "Comment": true, "ResultField": true, "Prepared": true}
+	for _, f := range pkgs["ast"].Files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*goast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts, ok := spec.(*goast.TypeSpec)
+				if !ok || !ts.Name.IsExported() || ts.Assign != 0 || skipped[ts.Name.Name] {
+					continue
+				}
+				if _, ok := ts.Type.(*goast.StructType); !ok {
+					continue
+				}
+				// HintData can hold any registered type.
+				_, err := DecodeJSON([]byte(`{"type":"TableOptimizerHint","fields":{"HintData":{"type":"` + ts.Name.Name + `"}}}`))
+				c.Assert(err, IsNil, Commentf("for %s", ts.Name.Name))
+			}
+		}
+	}
+}
+
+// TestRegisteredNodes checks that every Node implementation of package ast, which is
+// every exported type with an Accept method, is registered and survives a round trip.
+func (s *testJSONSuite) TestRegisteredNodes(c *C) {
+	fset := token.NewFileSet()
+	pkgs, err := goparser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	c.Assert(err, IsNil)
+	count := 0
+	for _, f := range pkgs["ast"].Files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*goast.FuncDecl)
+			if !ok || fd.Recv == nil || fd.Name.Name != "Accept" {
+				continue
+			}
+			star, ok := fd.Recv.List[0].Type.(*goast.StarExpr)
+			if !ok {
+				continue
+			}
+			name := star.X.(*goast.Ident).Name
+			if !goast.IsExported(name) {
+				continue
+			}
+			comment := Commentf("for %s", name)
+			// a zero node can't be visited, so it's held by HintData, which isn't visited.
+			n, err := DecodeJSON([]byte(`{"type":"TableOptimizerHint","fields":{"HintData":{"type":"` + name + `"}}}`))
+			c.Assert(err, IsNil, comment)
+			hint := n.(*TableOptimizerHint)
+			_, ok = hint.HintData.(Node)
+			c.Assert(ok, IsTrue, comment)
+			c.Assert(reflect.TypeOf(hint.HintData).Elem().Name(), Equals, name, comment)
+			data, err := EncodeJSON(hint)
+			c.Assert(err, IsNil, comment)
+			decoded, err := DecodeJSON(data)
+			c.Assert(err, IsNil, comment)
+			c.Assert(decoded, DeepEquals, n, comment)
+			count++
+		}
+	}
+	// the walk finds the nodes.
+	c.Assert(count > 150, IsTrue)
+}
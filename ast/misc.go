@@ -51,6 +51,7 @@ var (
 	_ StmtNode = &CreateBindingStmt{}
 	_ StmtNode = &DropBindingStmt{}
 	_ StmtNode = &ShutdownStmt{}
+	_ StmtNode = &DelimiterStmt{}
 	_ StmtNode = &RenameUserStmt{}
 
 	_ Node = &PrivElem{}
@@ -137,6 +138,12 @@ type TraceStmt struct {
 
 // Restore implements Node interface.
 func (n *TraceStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "TRACE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("TRACE ")
 	if n.Format != "row" {
 		ctx.WriteKeyWord("FORMAT")
@@ -177,6 +184,9 @@ type ExplainForStmt struct {
 
 // Restore implements Node interface.
 func (n *ExplainForStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("EXPLAIN ")
 	ctx.WriteKeyWord("FORMAT ")
 	ctx.WritePlain("= ")
@@ -211,6 +221,9 @@ type ExplainStmt struct {
 
 // Restore implements Node interface.
 func (n *ExplainStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if showStmt, ok := n.Stmt.(*ShowStmt); ok {
 		ctx.WriteKeyWord("DESC ")
 		if err := showStmt.Table.Restore(ctx); err != nil {
@@ -267,6 +280,9 @@ type PrepareStmt struct {
 
 // Restore implements Node interface.
 func (n *PrepareStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("PREPARE ")
 	ctx.WriteName(n.Name)
 	ctx.WriteKeyWord(" FROM ")
@@ -310,6 +326,9 @@ type DeallocateStmt struct {
 
 // Restore implements Node interface.
 func (n *DeallocateStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("DEALLOCATE PREPARE ")
 	ctx.WriteName(n.Name)
 	return nil
@@ -350,6 +369,9 @@ type ExecuteStmt struct {
 
 // Restore implements Node interface.
 func (n *ExecuteStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("EXECUTE ")
 	ctx.WriteName(n.Name)
 	if len(n.UsingVars) > 0 {
@@ -395,6 +417,9 @@ type BeginStmt struct {
 
 // Restore implements Node interface.
 func (n *BeginStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.Mode == "" {
 		if n.ReadOnly {
 			ctx.WriteKeyWord("START TRANSACTION READ ONLY")
@@ -455,6 +480,9 @@ type BinlogStmt struct {
 
 // Restore implements Node interface.
 func (n *BinlogStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("BINLOG ")
 	ctx.WriteString(n.Str)
 	return nil
@@ -502,6 +530,9 @@ type CommitStmt struct {
 
 // Restore implements Node interface.
 func (n *CommitStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("COMMIT")
 	if err := n.CompletionType.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore CommitStmt.CompletionType")
@@ -529,6 +560,9 @@ type RollbackStmt struct {
 
 // Restore implements Node interface.
 func (n *RollbackStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("ROLLBACK")
 	if err := n.CompletionType.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while restore RollbackStmt.CompletionType")
@@ -556,6 +590,9 @@ type UseStmt struct {
 
 // Restore implements Node interface.
 func (n *UseStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("USE ")
 	ctx.WriteName(n.DBName)
 	return nil
@@ -586,6 +623,11 @@ type VariableAssignment struct {
 	Value    ExprNode
 	IsGlobal bool
 	IsSystem bool
+	// IsLocal is true if Name is a local variable or a parameter of the stored program
+	// the assignment is in.
+	IsLocal bool
+	// TriggerRow is "NEW" or "OLD" if Name is a column of the row of a trigger.
+	TriggerRow string
 
 	// ExtendValue is a way to store extended info.
 	// VariableAssignment should be able to store information for SetCharset/SetPWD Stmt.
@@ -604,7 +646,10 @@ func (n *VariableAssignment) Restore(ctx *format.RestoreCtx) error {
 			ctx.WriteKeyWord("SESSION")
 		}
 		ctx.WritePlain(".")
-	} else if n.Name != SetNames && n.Name != SetCharset {
+	} else if n.TriggerRow != "" {
+		ctx.WriteKeyWord(n.TriggerRow)
+		ctx.WritePlain(".")
+	} else if !n.IsLocal && n.Name != SetNames && n.Name != SetCharset {
 		ctx.WriteKeyWord("@")
 	}
 	if n.Name == SetNames {
@@ -683,6 +728,9 @@ type FlushStmt struct {
 
 // Restore implements Node interface.
 func (n *FlushStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("FLUSH ")
 	if n.NoWriteToBinLog {
 		ctx.WriteKeyWord("NO_WRITE_TO_BINLOG ")
@@ -776,6 +824,9 @@ type KillStmt struct {
 
 // Restore implements Node interface.
 func (n *KillStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("KILL")
 	if n.TiDBExtension {
 		ctx.WriteKeyWord(" TIDB")
@@ -806,6 +857,9 @@ type SetStmt struct {
 
 // Restore implements Node interface.
 func (n *SetStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("SET ")
 	for i, v := range n.Variables {
 		if i != 0 {
@@ -846,6 +900,12 @@ type SetConfigStmt struct {
 }
 
 func (n *SetConfigStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "SET CONFIG"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("SET CONFIG ")
 	if n.Type != "" {
 		ctx.WriteKeyWord(n.Type)
@@ -904,6 +964,9 @@ type SetPwdStmt struct {
 
 // Restore implements Node interface.
 func (n *SetPwdStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("SET PASSWORD")
 	if n.User != nil {
 		ctx.WriteKeyWord(" FOR ")
@@ -941,6 +1004,12 @@ type ChangeStmt struct {
 
 // Restore implements Node interface.
 func (n *ChangeStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "CHANGE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("CHANGE ")
 	ctx.WriteKeyWord(n.NodeType)
 	ctx.WriteKeyWord(" TO NODE_STATE ")
@@ -986,6 +1055,12 @@ type SetRoleStmt struct {
 }
 
 func (n *SetRoleStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := ctx.Unsupported("SET ROLE", format.DialectMySQL57); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("SET ROLE")
 	switch n.SetRoleOpt {
 	case SetRoleDefault:
@@ -1029,6 +1104,12 @@ type SetDefaultRoleStmt struct {
 }
 
 func (n *SetDefaultRoleStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := ctx.Unsupported("SET DEFAULT ROLE", format.DialectMySQL57); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("SET DEFAULT ROLE")
 	switch n.SetRoleOpt {
 	case SetRoleNone:
@@ -1246,6 +1327,14 @@ type CreateUserStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateUserStmt) Restore(ctx *format.RestoreCtx) error {
+	if n.IsCreateRole {
+		if err := ctx.Unsupported("CREATE ROLE", format.DialectMySQL57); err != nil {
+			return err
+		}
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.IsCreateRole {
 		ctx.WriteKeyWord("CREATE ROLE ")
 	} else {
@@ -1332,6 +1421,9 @@ type AlterUserStmt struct {
 
 // Restore implements Node interface.
 func (n *AlterUserStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("ALTER USER ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -1417,6 +1509,9 @@ type AlterInstanceStmt struct {
 
 // Restore implements Node interface.
 func (n *AlterInstanceStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("ALTER INSTANCE")
 	if n.ReloadTLS {
 		ctx.WriteKeyWord(" RELOAD TLS")
@@ -1449,6 +1544,14 @@ type DropUserStmt struct {
 
 // Restore implements Node interface.
 func (n *DropUserStmt) Restore(ctx *format.RestoreCtx) error {
+	if n.IsDropRole {
+		if err := ctx.Unsupported("DROP ROLE", format.DialectMySQL57); err != nil {
+			return err
+		}
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.IsDropRole {
 		ctx.WriteKeyWord("DROP ROLE ")
 	} else {
@@ -1488,6 +1591,12 @@ type CreateBindingStmt struct {
 }
 
 func (n *CreateBindingStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "CREATE BINDING"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("CREATE ")
 	if n.GlobalScope {
 		ctx.WriteKeyWord("GLOBAL ")
@@ -1534,6 +1643,12 @@ type DropBindingStmt struct {
 }
 
 func (n *DropBindingStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "DROP BINDING"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("DROP ")
 	if n.GlobalScope {
 		ctx.WriteKeyWord("GLOBAL ")
@@ -1590,9 +1705,10 @@ type StatisticsSpec struct {
 
 // CreateStatisticsStmt is a statement to create extended statistics.
 // Examples:
-//   CREATE STATISTICS stats1 (cardinality) ON t(a, b, c);
-//   CREATE STATISTICS stats2 (dependency) ON t(a, b);
-//   CREATE STATISTICS stats3 (correlation) ON t(a, b);
+//
+//	CREATE STATISTICS stats1 (cardinality) ON t(a, b, c);
+//	CREATE STATISTICS stats2 (dependency) ON t(a, b);
+//	CREATE STATISTICS stats3 (correlation) ON t(a, b);
 type CreateStatisticsStmt struct {
 	stmtNode
 
@@ -1605,6 +1721,12 @@ type CreateStatisticsStmt struct {
 
 // Restore implements Node interface.
 func (n *CreateStatisticsStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "CREATE STATISTICS"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("CREATE STATISTICS ")
 	if n.IfNotExists {
 		ctx.WriteKeyWord("IF NOT EXISTS ")
@@ -1660,7 +1782,8 @@ func (n *CreateStatisticsStmt) Accept(v Visitor) (Node, bool) {
 
 // DropStatisticsStmt is a statement to drop extended statistics.
 // Examples:
-//   DROP STATISTICS stats1;
+//
+//	DROP STATISTICS stats1;
 type DropStatisticsStmt struct {
 	stmtNode
 
@@ -1669,6 +1792,12 @@ type DropStatisticsStmt struct {
 
 // Restore implements Node interface.
 func (n *DropStatisticsStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "DROP STATISTICS"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("DROP STATISTICS ")
 	ctx.WriteName(n.StatsName)
 	return nil
@@ -1693,6 +1822,9 @@ type DoStmt struct {
 
 // Restore implements Node interface.
 func (n *DoStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("DO ")
 	for i, v := range n.Exprs {
 		if i != 0 {
@@ -1781,6 +1913,7 @@ const (
 )
 
 // ShowSlow is used for the following command:
+//
 //	admin show slow top [ internal | all] N
 //	admin show slow recent N
 type ShowSlow struct {
@@ -1831,6 +1964,12 @@ type AdminStmt struct {
 
 // Restore implements Node interface.
 func (n *AdminStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "ADMIN"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	restoreTables := func() error {
 		for i, v := range n.Tables {
 			if i != 0 {
@@ -2167,6 +2306,9 @@ type RevokeStmt struct {
 
 // Restore implements Node interface.
 func (n *RevokeStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("REVOKE ")
 	for i, v := range n.Privs {
 		if i != 0 {
@@ -2225,6 +2367,12 @@ type RevokeRoleStmt struct {
 
 // Restore implements Node interface.
 func (n *RevokeRoleStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := ctx.Unsupported("REVOKE role", format.DialectMySQL57); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("REVOKE ")
 	for i, role := range n.Roles {
 		if i != 0 {
@@ -2270,6 +2418,9 @@ type GrantStmt struct {
 
 // Restore implements Node interface.
 func (n *GrantStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("GRANT ")
 	for i, v := range n.Privs {
 		if i != 0 && v.Priv != 0 {
@@ -2368,6 +2519,9 @@ func (n *GrantProxyStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *GrantProxyStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("GRANT PROXY ON ")
 	if err := n.LocalUser.Restore(ctx); err != nil {
 		return errors.Annotatef(err, "An error occurred while restore GrantProxyStmt.LocalUser")
@@ -2407,6 +2561,12 @@ func (n *GrantRoleStmt) Accept(v Visitor) (Node, bool) {
 
 // Restore implements Node interface.
 func (n *GrantRoleStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := ctx.Unsupported("GRANT role", format.DialectMySQL57); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("GRANT ")
 	if len(n.Roles) > 0 {
 		for i, role := range n.Roles {
@@ -2449,6 +2609,9 @@ type ShutdownStmt struct {
 
 // Restore implements Node interface.
 func (n *ShutdownStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("SHUTDOWN")
 	return nil
 }
@@ -2463,6 +2626,34 @@ func (n *ShutdownStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// DelimiterStmt is the mysql client command to change the statement delimiter.
+// See https://dev.mysql.com/doc/refman/8.0/en/mysql-commands.html
+type DelimiterStmt struct {
+	stmtNode
+
+	Delimiter string
+}
+
+// Restore implements Node interface.
+func (n *DelimiterStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("DELIMITER ")
+	ctx.WritePlain(n.Delimiter)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DelimiterStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DelimiterStmt)
+	return v.Leave(n)
+}
+
 // RenameUserStmt is a statement to rename a user.
 // See http://dev.mysql.com/doc/refman/5.7/en/rename-user.html
 type RenameUserStmt struct {
@@ -2473,6 +2664,9 @@ type RenameUserStmt struct {
 
 // Restore implements Node interface.
 func (n *RenameUserStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("RENAME USER ")
 	for index, user2user := range n.UserToUsers {
 		if index != 0 {
@@ -2719,6 +2913,12 @@ func (n *BRIEStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *BRIEStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "BACKUP and RESTORE"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord(n.Kind.String())
 
 	switch {
@@ -2808,6 +3008,12 @@ func (n *PurgeImportStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *PurgeImportStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "PURGE IMPORT"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WritePlainf("PURGE IMPORT %d", n.TaskID)
 	return nil
 }
@@ -2860,6 +3066,12 @@ func (n *CreateImportStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *CreateImportStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "CREATE IMPORT"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("CREATE IMPORT ")
 	if n.IfNotExists {
 		ctx.WriteKeyWord("IF NOT EXISTS ")
@@ -2926,6 +3138,12 @@ func (n *StopImportStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *StopImportStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "STOP IMPORT"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("STOP IMPORT ")
 	if n.IfRunning {
 		ctx.WriteKeyWord("IF RUNNING ")
@@ -2948,6 +3166,12 @@ func (n *ResumeImportStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *ResumeImportStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "RESUME IMPORT"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("RESUME IMPORT ")
 	if n.IfNotRunning {
 		ctx.WriteKeyWord("IF NOT RUNNING ")
@@ -2977,6 +3201,12 @@ func (n *AlterImportStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *AlterImportStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "ALTER IMPORT"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("ALTER IMPORT ")
 	ctx.WriteName(n.Name)
 	if n.ErrorHandling != ErrorHandleError {
@@ -3026,6 +3256,12 @@ func (n *DropImportStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *DropImportStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "DROP IMPORT"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("DROP IMPORT ")
 	if n.IfExists {
 		ctx.WriteKeyWord("IF EXISTS ")
@@ -3049,6 +3285,12 @@ func (n *ShowImportStmt) Accept(v Visitor) (Node, bool) {
 }
 
 func (n *ShowImportStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "SHOW IMPORT"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("SHOW IMPORT ")
 	ctx.WriteName(n.Name)
 	if n.ErrorsOnly {
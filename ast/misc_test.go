@@ -78,6 +78,7 @@ func (ts *testMiscSuite) TestMiscVisitorCover(c *C) {
 		&ast.KillStmt{},
 		&ast.DropStatsStmt{Table: &ast.TableName{}},
 		&ast.ShutdownStmt{},
+		&ast.DelimiterStmt{},
 	}
 
 	for _, v := range stmts {
@@ -119,7 +120,8 @@ insert into t_copy select * from t where t.x > 5;
 (select /*+ TIDB_INLJ(t1) */ a from t1 where a=10 and b=1) union (select /*+ TIDB_SMJ(t2) */ a from t2 where a=11 and b=2) order by a limit 10;
 update t1 set col1 = col1 + 1, col2 = col1;
 show create table t;
-load data infile '/tmp/t.csv' into table t fields terminated by 'ab' enclosed by 'b';`
+load data infile '/tmp/t.csv' into table t fields terminated by 'ab' enclosed by 'b';
+select * from t, json_table(t.doc, '$[*]' columns (id for ordinality, nested path '$.a' columns (a int path '$'))) jt;`
 
 	p := parser.New()
 	stmts, _, err := p.Parse(sql, "", "")
@@ -293,6 +295,18 @@ func (ts *testMiscSuite) TestChangeStmtRestore(c *C) {
 	RunNodeRestoreTest(c, testCases, "%s", extractNodeFunc)
 }
 
+func (ts *testMiscSuite) TestDelimiterStmtRestore(c *C) {
+	testCases := []NodeRestoreTestCase{
+		{"DELIMITER $$", "DELIMITER $$"},
+		{"delimiter //", "DELIMITER //"},
+		{"DELIMITER ;", "DELIMITER ;"},
+	}
+	extractNodeFunc := func(node ast.Node) ast.Node {
+		return node.(*ast.DelimiterStmt)
+	}
+	RunNodeRestoreTest(c, testCases, "%s", extractNodeFunc)
+}
+
 func (ts *testMiscSuite) TestBRIESecureText(c *C) {
 	testCases := []struct {
 		input   string
@@ -0,0 +1,1029 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/types"
+)
+
+var (
+	_ StmtNode = &BlockStmt{}
+	_ StmtNode = &DeclareVarStmt{}
+	_ StmtNode = &DeclareConditionStmt{}
+	_ StmtNode = &DeclareCursorStmt{}
+	_ StmtNode = &DeclareHandlerStmt{}
+	_ StmtNode = &IfStmt{}
+	_ StmtNode = &CaseStmt{}
+	_ StmtNode = &LoopStmt{}
+	_ StmtNode = &WhileStmt{}
+	_ StmtNode = &RepeatStmt{}
+	_ StmtNode = &LeaveStmt{}
+	_ StmtNode = &IterateStmt{}
+	_ StmtNode = &OpenCursorStmt{}
+	_ StmtNode = &FetchCursorStmt{}
+	_ StmtNode = &CloseCursorStmt{}
+	_ StmtNode = &SignalStmt{}
+	_ StmtNode = &GetDiagnosticsStmt{}
+
+	_ Node = &IfBranch{}
+	_ Node = &CaseStmtWhenClause{}
+	_ Node = &SignalInfoItem{}
+	_ Node = &DiagnosticsItem{}
+)
+
+// restoreStmtList restores the statements of a compound statement body, every statement is terminated by `;`.
+func restoreStmtList(ctx *format.RestoreCtx, stmts []StmtNode, name string) error {
+	for i, stmt := range stmts {
+		if err := stmt.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore %s[%d]", name, i)
+		}
+		ctx.WritePlain("; ")
+	}
+	return nil
+}
+
+// acceptStmtList visits the statements of a compound statement body.
+func acceptStmtList(v Visitor, stmts []StmtNode) bool {
+	for i, stmt := range stmts {
+		node, ok := stmt.Accept(v)
+		if !ok {
+			return false
+		}
+		stmts[i] = node.(StmtNode)
+	}
+	return true
+}
+
+func restoreLabel(ctx *format.RestoreCtx, label string) {
+	if label != "" {
+		ctx.WriteName(label)
+		ctx.WritePlain(": ")
+	}
+}
+
+func restoreEndLabel(ctx *format.RestoreCtx, label string) {
+	if label != "" {
+		ctx.WritePlain(" ")
+		ctx.WriteName(label)
+	}
+}
+
+// BlockStmt is a BEGIN ... END compound statement of a stored program body.
+// See https://dev.mysql.com/doc/refman/8.0/en/begin-end.html
+type BlockStmt struct {
+	stmtNode
+
+	Label string
+	// Body contains the declarations followed by the statements of the block.
+	Body []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *BlockStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	restoreLabel(ctx, n.Label)
+	ctx.WriteKeyWord("BEGIN ")
+	if err := restoreStmtList(ctx, n.Body, "BlockStmt.Body"); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("END")
+	restoreEndLabel(ctx, n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *BlockStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*BlockStmt)
+	if !acceptStmtList(v, n.Body) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// DeclareVarStmt is a statement to declare local variables.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-local-variable.html
+type DeclareVarStmt struct {
+	stmtNode
+
+	Names   []string
+	Tp      *types.FieldType
+	Default ExprNode
+}
+
+// Restore implements Node interface.
+func (n *DeclareVarStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("DECLARE ")
+	for i, name := range n.Names {
+		if i != 0 {
+			ctx.WritePlain(",")
+		}
+		ctx.WriteName(name)
+	}
+	ctx.WritePlain(" ")
+	if err := n.Tp.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DeclareVarStmt.Tp")
+	}
+	if n.Default != nil {
+		ctx.WriteKeyWord(" DEFAULT ")
+		if err := n.Default.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore DeclareVarStmt.Default")
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DeclareVarStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DeclareVarStmt)
+	if n.Default != nil {
+		node, ok := n.Default.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Default = node.(ExprNode)
+	}
+	return v.Leave(n)
+}
+
+// ConditionValueType is the type of a condition value.
+type ConditionValueType int
+
+// Condition value types.
+const (
+	ConditionValueErrorCode ConditionValueType = iota
+	ConditionValueSQLState
+	ConditionValueName
+	ConditionValueSQLWarning
+	ConditionValueNotFound
+	ConditionValueSQLException
+)
+
+// ConditionValue is a condition used by DECLARE ... CONDITION, DECLARE ... HANDLER, SIGNAL and RESIGNAL.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-handler.html
+type ConditionValue struct {
+	Tp        ConditionValueType
+	ErrorCode uint64
+	SQLState  string
+	Name      string
+}
+
+// Restore implements Node interface.
+func (n *ConditionValue) Restore(ctx *format.RestoreCtx) error {
+	switch n.Tp {
+	case ConditionValueErrorCode:
+		ctx.WritePlainf("%d", n.ErrorCode)
+	case ConditionValueSQLState:
+		ctx.WriteKeyWord("SQLSTATE ")
+		ctx.WriteString(n.SQLState)
+	case ConditionValueName:
+		ctx.WriteName(n.Name)
+	case ConditionValueSQLWarning:
+		ctx.WriteKeyWord("SQLWARNING")
+	case ConditionValueNotFound:
+		ctx.WriteKeyWord("NOT FOUND")
+	case ConditionValueSQLException:
+		ctx.WriteKeyWord("SQLEXCEPTION")
+	default:
+		return errors.Errorf("invalid ConditionValue: %d", n.Tp)
+	}
+	return nil
+}
+
+// DeclareConditionStmt is a statement to declare a named error condition.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-condition.html
+type DeclareConditionStmt struct {
+	stmtNode
+
+	Name      string
+	Condition *ConditionValue
+}
+
+// Restore implements Node interface.
+func (n *DeclareConditionStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("DECLARE ")
+	ctx.WriteName(n.Name)
+	ctx.WriteKeyWord(" CONDITION FOR ")
+	if err := n.Condition.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DeclareConditionStmt.Condition")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DeclareConditionStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DeclareConditionStmt)
+	return v.Leave(n)
+}
+
+// DeclareCursorStmt is a statement to declare a cursor.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-cursor.html
+type DeclareCursorStmt struct {
+	stmtNode
+
+	Name   string
+	Select StmtNode
+}
+
+// Restore implements Node interface.
+func (n *DeclareCursorStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("DECLARE ")
+	ctx.WriteName(n.Name)
+	ctx.WriteKeyWord(" CURSOR FOR ")
+	if err := n.Select.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DeclareCursorStmt.Select")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DeclareCursorStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DeclareCursorStmt)
+	node, ok := n.Select.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Select = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// HandlerAction is the action of a condition handler.
+type HandlerAction int
+
+// Condition handler actions.
+const (
+	HandlerContinue HandlerAction = iota
+	HandlerExit
+	HandlerUndo
+)
+
+// String implements fmt.Stringer interface.
+func (a HandlerAction) String() string {
+	switch a {
+	case HandlerContinue:
+		return "CONTINUE"
+	case HandlerExit:
+		return "EXIT"
+	case HandlerUndo:
+		return "UNDO"
+	}
+	return ""
+}
+
+// DeclareHandlerStmt is a statement to declare a condition handler.
+// See https://dev.mysql.com/doc/refman/8.0/en/declare-handler.html
+type DeclareHandlerStmt struct {
+	stmtNode
+
+	Action     HandlerAction
+	Conditions []*ConditionValue
+	Body       StmtNode
+}
+
+// Restore implements Node interface.
+func (n *DeclareHandlerStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("DECLARE ")
+	ctx.WriteKeyWord(n.Action.String())
+	ctx.WriteKeyWord(" HANDLER FOR ")
+	for i, cond := range n.Conditions {
+		if i != 0 {
+			ctx.WritePlain(",")
+		}
+		if err := cond.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore DeclareHandlerStmt.Conditions[%d]", i)
+		}
+	}
+	ctx.WritePlain(" ")
+	if err := n.Body.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DeclareHandlerStmt.Body")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DeclareHandlerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DeclareHandlerStmt)
+	node, ok := n.Body.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Body = node.(StmtNode)
+	return v.Leave(n)
+}
+
+// IfBranch is an IF or ELSEIF branch of IfStmt.
+type IfBranch struct {
+	node
+
+	Cond ExprNode
+	Body []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *IfBranch) Restore(ctx *format.RestoreCtx) error {
+	if err := n.Cond.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore IfBranch.Cond")
+	}
+	ctx.WriteKeyWord(" THEN ")
+	return restoreStmtList(ctx, n.Body, "IfBranch.Body")
+}
+
+// Accept implements Node Accept interface.
+func (n *IfBranch) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*IfBranch)
+	node, ok := n.Cond.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Cond = node.(ExprNode)
+	if !acceptStmtList(v, n.Body) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// IfStmt is the IF statement of stored programs.
+// See https://dev.mysql.com/doc/refman/8.0/en/if.html
+type IfStmt struct {
+	stmtNode
+
+	// Branches contains the IF branch followed by the ELSEIF branches.
+	Branches []*IfBranch
+	// Else is nil when there is no ELSE branch.
+	Else []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *IfStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	for i, branch := range n.Branches {
+		if i == 0 {
+			ctx.WriteKeyWord("IF ")
+		} else {
+			ctx.WriteKeyWord("ELSEIF ")
+		}
+		if err := branch.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore IfStmt.Branches[%d]", i)
+		}
+	}
+	if n.Else != nil {
+		ctx.WriteKeyWord("ELSE ")
+		if err := restoreStmtList(ctx, n.Else, "IfStmt.Else"); err != nil {
+			return err
+		}
+	}
+	ctx.WriteKeyWord("END IF")
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *IfStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*IfStmt)
+	for i, val := range n.Branches {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Branches[i] = node.(*IfBranch)
+	}
+	if !acceptStmtList(v, n.Else) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// CaseStmtWhenClause is a WHEN clause of CaseStmt.
+type CaseStmtWhenClause struct {
+	node
+
+	Expr ExprNode
+	Body []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *CaseStmtWhenClause) Restore(ctx *format.RestoreCtx) error {
+	ctx.WriteKeyWord("WHEN ")
+	if err := n.Expr.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore CaseStmtWhenClause.Expr")
+	}
+	ctx.WriteKeyWord(" THEN ")
+	return restoreStmtList(ctx, n.Body, "CaseStmtWhenClause.Body")
+}
+
+// Accept implements Node Accept interface.
+func (n *CaseStmtWhenClause) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CaseStmtWhenClause)
+	node, ok := n.Expr.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Expr = node.(ExprNode)
+	if !acceptStmtList(v, n.Body) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// CaseStmt is the CASE statement of stored programs, it is different from the CASE operator.
+// See https://dev.mysql.com/doc/refman/8.0/en/case.html
+type CaseStmt struct {
+	stmtNode
+
+	// Value is nil for the searched CASE statement.
+	Value       ExprNode
+	WhenClauses []*CaseStmtWhenClause
+	// Else is nil when there is no ELSE clause.
+	Else []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *CaseStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("CASE ")
+	if n.Value != nil {
+		if err := n.Value.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore CaseStmt.Value")
+		}
+		ctx.WritePlain(" ")
+	}
+	for i, clause := range n.WhenClauses {
+		if err := clause.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore CaseStmt.WhenClauses[%d]", i)
+		}
+	}
+	if n.Else != nil {
+		ctx.WriteKeyWord("ELSE ")
+		if err := restoreStmtList(ctx, n.Else, "CaseStmt.Else"); err != nil {
+			return err
+		}
+	}
+	ctx.WriteKeyWord("END CASE")
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *CaseStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CaseStmt)
+	if n.Value != nil {
+		node, ok := n.Value.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Value = node.(ExprNode)
+	}
+	for i, val := range n.WhenClauses {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.WhenClauses[i] = node.(*CaseStmtWhenClause)
+	}
+	if !acceptStmtList(v, n.Else) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// LoopStmt is the LOOP statement of stored programs.
+// See https://dev.mysql.com/doc/refman/8.0/en/loop.html
+type LoopStmt struct {
+	stmtNode
+
+	Label string
+	Body  []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *LoopStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	restoreLabel(ctx, n.Label)
+	ctx.WriteKeyWord("LOOP ")
+	if err := restoreStmtList(ctx, n.Body, "LoopStmt.Body"); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("END LOOP")
+	restoreEndLabel(ctx, n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *LoopStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*LoopStmt)
+	if !acceptStmtList(v, n.Body) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// WhileStmt is the WHILE statement of stored programs.
+// See https://dev.mysql.com/doc/refman/8.0/en/while.html
+type WhileStmt struct {
+	stmtNode
+
+	Label string
+	Cond  ExprNode
+	Body  []StmtNode
+}
+
+// Restore implements Node interface.
+func (n *WhileStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	restoreLabel(ctx, n.Label)
+	ctx.WriteKeyWord("WHILE ")
+	if err := n.Cond.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore WhileStmt.Cond")
+	}
+	ctx.WriteKeyWord(" DO ")
+	if err := restoreStmtList(ctx, n.Body, "WhileStmt.Body"); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("END WHILE")
+	restoreEndLabel(ctx, n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *WhileStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*WhileStmt)
+	node, ok := n.Cond.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Cond = node.(ExprNode)
+	if !acceptStmtList(v, n.Body) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// RepeatStmt is the REPEAT statement of stored programs.
+// See https://dev.mysql.com/doc/refman/8.0/en/repeat.html
+type RepeatStmt struct {
+	stmtNode
+
+	Label string
+	Body  []StmtNode
+	Cond  ExprNode
+}
+
+// Restore implements Node interface.
+func (n *RepeatStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	restoreLabel(ctx, n.Label)
+	ctx.WriteKeyWord("REPEAT ")
+	if err := restoreStmtList(ctx, n.Body, "RepeatStmt.Body"); err != nil {
+		return err
+	}
+	ctx.WriteKeyWord("UNTIL ")
+	if err := n.Cond.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore RepeatStmt.Cond")
+	}
+	ctx.WriteKeyWord(" END REPEAT")
+	restoreEndLabel(ctx, n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *RepeatStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*RepeatStmt)
+	if !acceptStmtList(v, n.Body) {
+		return n, false
+	}
+	node, ok := n.Cond.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Cond = node.(ExprNode)
+	return v.Leave(n)
+}
+
+// LeaveStmt is the LEAVE statement of stored programs.
+// See https://dev.mysql.com/doc/refman/8.0/en/leave.html
+type LeaveStmt struct {
+	stmtNode
+
+	Label string
+}
+
+// Restore implements Node interface.
+func (n *LeaveStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("LEAVE ")
+	ctx.WriteName(n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *LeaveStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*LeaveStmt)
+	return v.Leave(n)
+}
+
+// IterateStmt is the ITERATE statement of stored programs.
+// See https://dev.mysql.com/doc/refman/8.0/en/iterate.html
+type IterateStmt struct {
+	stmtNode
+
+	Label string
+}
+
+// Restore implements Node interface.
+func (n *IterateStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("ITERATE ")
+	ctx.WriteName(n.Label)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *IterateStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*IterateStmt)
+	return v.Leave(n)
+}
+
+// OpenCursorStmt is a statement to open a cursor.
+// See https://dev.mysql.com/doc/refman/8.0/en/open.html
+type OpenCursorStmt struct {
+	stmtNode
+
+	Name string
+}
+
+// Restore implements Node interface.
+func (n *OpenCursorStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("OPEN ")
+	ctx.WriteName(n.Name)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *OpenCursorStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*OpenCursorStmt)
+	return v.Leave(n)
+}
+
+// FetchCursorStmt is a statement to fetch the next row of a cursor into variables.
+// See https://dev.mysql.com/doc/refman/8.0/en/fetch.html
+type FetchCursorStmt struct {
+	stmtNode
+
+	Name string
+	Vars []string
+}
+
+// Restore implements Node interface.
+func (n *FetchCursorStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("FETCH ")
+	ctx.WriteName(n.Name)
+	ctx.WriteKeyWord(" INTO ")
+	for i, name := range n.Vars {
+		if i != 0 {
+			ctx.WritePlain(",")
+		}
+		ctx.WriteName(name)
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *FetchCursorStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*FetchCursorStmt)
+	return v.Leave(n)
+}
+
+// CloseCursorStmt is a statement to close a cursor.
+// See https://dev.mysql.com/doc/refman/8.0/en/close.html
+type CloseCursorStmt struct {
+	stmtNode
+
+	Name string
+}
+
+// Restore implements Node interface.
+func (n *CloseCursorStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("CLOSE ")
+	ctx.WriteName(n.Name)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *CloseCursorStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CloseCursorStmt)
+	return v.Leave(n)
+}
+
+// SignalInfoItem is a condition information item assigned by SIGNAL or RESIGNAL,
+// Name is the upper case item name, such as MESSAGE_TEXT.
+type SignalInfoItem struct {
+	node
+
+	Name  string
+	Value ExprNode
+}
+
+// Restore implements Node interface.
+func (n *SignalInfoItem) Restore(ctx *format.RestoreCtx) error {
+	ctx.WriteKeyWord(n.Name)
+	ctx.WritePlain(" = ")
+	if err := n.Value.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore SignalInfoItem.Value")
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *SignalInfoItem) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SignalInfoItem)
+	node, ok := n.Value.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Value = node.(ExprNode)
+	return v.Leave(n)
+}
+
+// SignalStmt is the SIGNAL or RESIGNAL statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/signal.html
+// See https://dev.mysql.com/doc/refman/8.0/en/resignal.html
+type SignalStmt struct {
+	stmtNode
+
+	IsResignal bool
+	// Condition may be nil only for RESIGNAL.
+	Condition *ConditionValue
+	Items     []*SignalInfoItem
+}
+
+// Restore implements Node interface.
+func (n *SignalStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	if n.IsResignal {
+		ctx.WriteKeyWord("RESIGNAL")
+	} else {
+		ctx.WriteKeyWord("SIGNAL")
+	}
+	if n.Condition != nil {
+		ctx.WritePlain(" ")
+		if err := n.Condition.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore SignalStmt.Condition")
+		}
+	}
+	for i, item := range n.Items {
+		if i == 0 {
+			ctx.WriteKeyWord(" SET ")
+		} else {
+			ctx.WritePlain(", ")
+		}
+		if err := item.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore SignalStmt.Items[%d]", i)
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *SignalStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SignalStmt)
+	for i, val := range n.Items {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Items[i] = node.(*SignalInfoItem)
+	}
+	return v.Leave(n)
+}
+
+// DiagnosticsItem is an information item retrieved by GET DIAGNOSTICS,
+// Name is the upper case item name, such as ROW_COUNT or MESSAGE_TEXT.
+type DiagnosticsItem struct {
+	node
+
+	// Target is a *ColumnNameExpr for local variables, or a *VariableExpr for user variables.
+	Target ExprNode
+	Name   string
+}
+
+// Restore implements Node interface.
+func (n *DiagnosticsItem) Restore(ctx *format.RestoreCtx) error {
+	if err := n.Target.Restore(ctx); err != nil {
+		return errors.Annotate(err, "An error occurred while restore DiagnosticsItem.Target")
+	}
+	ctx.WritePlain(" = ")
+	ctx.WriteKeyWord(n.Name)
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *DiagnosticsItem) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DiagnosticsItem)
+	node, ok := n.Target.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Target = node.(ExprNode)
+	return v.Leave(n)
+}
+
+// DiagnosticsArea is the diagnostics area read by GET DIAGNOSTICS.
+type DiagnosticsArea int
+
+// Diagnostics areas.
+const (
+	DiagnosticsAreaCurrent DiagnosticsArea = iota
+	DiagnosticsAreaStacked
+)
+
+// GetDiagnosticsStmt is the GET DIAGNOSTICS statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/get-diagnostics.html
+type GetDiagnosticsStmt struct {
+	stmtNode
+
+	Area DiagnosticsArea
+	// ConditionNumber is nil when statement information items are retrieved.
+	ConditionNumber ExprNode
+	Items           []*DiagnosticsItem
+}
+
+// Restore implements Node interface.
+func (n *GetDiagnosticsStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
+	ctx.WriteKeyWord("GET ")
+	if n.Area == DiagnosticsAreaStacked {
+		ctx.WriteKeyWord("STACKED ")
+	}
+	ctx.WriteKeyWord("DIAGNOSTICS ")
+	if n.ConditionNumber != nil {
+		ctx.WriteKeyWord("CONDITION ")
+		if err := n.ConditionNumber.Restore(ctx); err != nil {
+			return errors.Annotate(err, "An error occurred while restore GetDiagnosticsStmt.ConditionNumber")
+		}
+		ctx.WritePlain(" ")
+	}
+	for i, item := range n.Items {
+		if i != 0 {
+			ctx.WritePlain(", ")
+		}
+		if err := item.Restore(ctx); err != nil {
+			return errors.Annotatef(err, "An error occurred while restore GetDiagnosticsStmt.Items[%d]", i)
+		}
+	}
+	return nil
+}
+
+// Accept implements Node Accept interface.
+func (n *GetDiagnosticsStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*GetDiagnosticsStmt)
+	if n.ConditionNumber != nil {
+		node, ok := n.ConditionNumber.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.ConditionNumber = node.(ExprNode)
+	}
+	for i, val := range n.Items {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Items[i] = node.(*DiagnosticsItem)
+	}
+	return v.Leave(n)
+}
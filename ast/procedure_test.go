@@ -0,0 +1,86 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast_test
+
+import (
+	. "github.com/pingcap/check"
+	. "github.com/pingcap/parser/ast"
+)
+
+var _ = Suite(&testProcedureSuite{})
+
+type testProcedureSuite struct {
+}
+
+func (ts *testProcedureSuite) TestProcedureVisitorCover(c *C) {
+	ce := &checkExpr{}
+	doStmt := func() StmtNode { return &DoStmt{Exprs: []ExprNode{ce}} }
+
+	stmts := []struct {
+		node             Node
+		expectedEnterCnt int
+		expectedLeaveCnt int
+	}{
+		{&BlockStmt{Body: []StmtNode{doStmt(), doStmt()}}, 2, 2},
+		{&DeclareVarStmt{Names: []string{"a"}}, 0, 0},
+		{&DeclareVarStmt{Names: []string{"a"}, Default: ce}, 1, 1},
+		{&DeclareConditionStmt{Name: "c", Condition: &ConditionValue{}}, 0, 0},
+		{&DeclareCursorStmt{Name: "c", Select: &SelectStmt{}}, 0, 0},
+		{&DeclareHandlerStmt{Conditions: []*ConditionValue{{}}, Body: doStmt()}, 1, 1},
+		{&IfStmt{Branches: []*IfBranch{{Cond: ce, Body: []StmtNode{doStmt()}}, {Cond: ce}}, Else: []StmtNode{doStmt()}}, 4, 4},
+		{&CaseStmt{Value: ce, WhenClauses: []*CaseStmtWhenClause{{Expr: ce, Body: []StmtNode{doStmt()}}}}, 3, 3},
+		{&CaseStmt{WhenClauses: []*CaseStmtWhenClause{{Expr: ce}}, Else: []StmtNode{doStmt()}}, 2, 2},
+		{&LoopStmt{Body: []StmtNode{doStmt(), &LeaveStmt{}, &IterateStmt{}}}, 1, 1},
+		{&WhileStmt{Cond: ce, Body: []StmtNode{doStmt()}}, 2, 2},
+		{&RepeatStmt{Body: []StmtNode{doStmt()}, Cond: ce}, 2, 2},
+		{&OpenCursorStmt{}, 0, 0},
+		{&FetchCursorStmt{Vars: []string{"a"}}, 0, 0},
+		{&CloseCursorStmt{}, 0, 0},
+		{&SignalStmt{Condition: &ConditionValue{}, Items: []*SignalInfoItem{{Value: ce}, {Value: ce}}}, 2, 2},
+		{&GetDiagnosticsStmt{ConditionNumber: ce, Items: []*DiagnosticsItem{{Target: ce}}}, 2, 2},
+	}
+
+	for _, v := range stmts {
+		ce.reset()
+		v.node.Accept(checkVisitor{})
+		c.Check(ce.enterCnt, Equals, v.expectedEnterCnt)
+		c.Check(ce.leaveCnt, Equals, v.expectedLeaveCnt)
+		v.node.Accept(visitor1{})
+	}
+}
+
+func (ts *testProcedureSuite) TestCompoundStmtRestore(c *C) {
+	testCases := []NodeRestoreTestCase{
+		{"begin end", "BEGIN END"},
+		{"l: begin select 1; end l", "`l`: BEGIN SELECT 1; END `l`"},
+		{"begin declare a int; set a = 1, b = 2; end", "BEGIN DECLARE `a` INT; SET `a`=1, @@SESSION.`b`=2; END"},
+		{"begin declare a, b int default 1; declare c cursor for select * from t; select a; end", "BEGIN DECLARE `a`,`b` INT DEFAULT 1; DECLARE `c` CURSOR FOR SELECT * FROM `t`; SELECT `a`; END"},
+		{"begin declare e condition for sqlstate value '42S02'; declare exit handler for e, 1062, not found begin end; end", "BEGIN DECLARE `e` CONDITION FOR SQLSTATE '42S02'; DECLARE EXIT HANDLER FOR `e`,1062,NOT FOUND BEGIN END; END"},
+		{"if a then do 1; elseif b then do 2; else do 3; end if", "IF `a` THEN DO 1; ELSEIF `b` THEN DO 2; ELSE DO 3; END IF"},
+		{"case a when 1 then do 1; else do 2; end case", "CASE `a` WHEN 1 THEN DO 1; ELSE DO 2; END CASE"},
+		{"case when a > 1 then do 1; end case", "CASE WHEN `a`>1 THEN DO 1; END CASE"},
+		{"l: loop leave l; end loop", "`l`: LOOP LEAVE `l`; END LOOP `l`"},
+		{"while a do iterate l; end while", "WHILE `a` DO ITERATE `l`; END WHILE"},
+		{"repeat do 1; until a end repeat", "REPEAT DO 1; UNTIL `a` END REPEAT"},
+		{"begin open c; fetch next from c into a, b; close c; end", "BEGIN OPEN `c`; FETCH `c` INTO `a`,`b`; CLOSE `c`; END"},
+		{"signal sqlstate '45000' set message_text = 'm', mysql_errno = 1", "SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = _UTF8MB4'm', MYSQL_ERRNO = 1"},
+		{"resignal", "RESIGNAL"},
+		{"get stacked diagnostics condition 1 @a = returned_sqlstate, b = message_text", "GET STACKED DIAGNOSTICS CONDITION 1 @`a` = RETURNED_SQLSTATE, `b` = MESSAGE_TEXT"},
+		{"get current diagnostics a = number", "GET DIAGNOSTICS `a` = NUMBER"},
+	}
+	extractNodeFunc := func(node Node) Node {
+		return node.(*CreateProcedureStmt).Body
+	}
+	RunNodeRestoreTest(c, testCases, "CREATE PROCEDURE `p`() %s", extractNodeFunc)
+}
@@ -93,6 +93,9 @@ type AnalyzeOpt struct {
 
 // Restore implements Node interface.
 func (n *AnalyzeTableStmt) Restore(ctx *format.RestoreCtx) error {
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	if n.Incremental {
 		ctx.WriteKeyWord("ANALYZE INCREMENTAL TABLE ")
 	} else {
@@ -181,6 +184,12 @@ type DropStatsStmt struct {
 
 // Restore implements Node interface.
 func (n *DropStatsStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "DROP STATS"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("DROP STATS ")
 	if err := n.Table.Restore(ctx); err != nil {
 		return errors.Annotate(err, "An error occurred while add table")
@@ -227,6 +236,12 @@ type LoadStatsStmt struct {
 
 // Restore implements Node interface.
 func (n *LoadStatsStmt) Restore(ctx *format.RestoreCtx) error {
+	if err := restoreTiDBOnly(ctx, "LOAD STATS"); err != nil {
+		return err
+	}
+	restoreLeadingComments(ctx, n)
+	defer restoreTrailingComments(ctx, n)
+
 	ctx.WriteKeyWord("LOAD STATS ")
 	ctx.WriteString(n.Path)
 	return nil
@@ -4,8 +4,16 @@ import (
 	"github.com/pingcap/parser/format"
 )
 
+// UnparsedStmt is a statement which can't be parsed by PerfectParse.
+// Its StartPos and EndPos cover the whole statement.
 type UnparsedStmt struct {
 	node
+
+	// Err is the error reported when parsing the statement.
+	Err error
+	// Expected lists the tokens the parser would have accepted at the position of
+	// the syntax error, it's empty if Err is not a syntax error.
+	Expected []string
 }
 
 func (n *UnparsedStmt) statement() {}
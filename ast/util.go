@@ -13,7 +13,11 @@
 
 package ast
 
-import "math"
+import (
+	"math"
+
+	"github.com/pingcap/parser/format"
+)
 
 // UnspecifiedSize is unspecified size.
 const (
@@ -85,3 +89,24 @@ func (checker *readOnlyChecker) Enter(in Node) (out Node, skipChildren bool) {
 func (checker *readOnlyChecker) Leave(in Node) (out Node, ok bool) {
 	return in, checker.readOnly
 }
+
+// restoreInParentheses restores n in parentheses, n is indented on its own lines in
+// the pretty-printing mode.
+func restoreInParentheses(ctx *format.RestoreCtx, n Node) error {
+	ctx.WritePlain("(")
+	ctx.Indent()
+	ctx.WriteLineBreak("")
+	if err := n.Restore(ctx); err != nil {
+		return err
+	}
+	ctx.Unindent()
+	ctx.WriteLineBreak("")
+	ctx.WritePlain(")")
+	return nil
+}
+
+// restoreTiDBOnly returns an error if construct, which only TiDB supports, can't be restored in the
+// dialect of ctx.
+func restoreTiDBOnly(ctx *format.RestoreCtx, construct string) error {
+	return ctx.Unsupported(construct, format.DialectMySQL57, format.DialectMySQL80, format.DialectANSI)
+}
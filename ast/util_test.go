@@ -15,6 +15,7 @@ package ast_test
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	. "github.com/pingcap/check"
@@ -115,6 +116,38 @@ func (s *testCacheableSuite) TestUnionReadOnly(c *C) {
 func CleanNodeText(node Node) {
 	var cleaner nodeTextCleaner
 	node.Accept(&cleaner)
+	cleanNodePos(reflect.ValueOf(node), make(map[uintptr]struct{}))
+}
+
+// cleanNodePos clears the positions of all the nodes reachable from v,
+// including the ones not visited by Accept.
+func cleanNodePos(v reflect.Value, visited map[uintptr]struct{}) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if _, ok := visited[v.Pointer()]; ok {
+			return
+		}
+		visited[v.Pointer()] = struct{}{}
+		cleanNodePos(v.Elem(), visited)
+	case reflect.Interface:
+		cleanNodePos(v.Elem(), visited)
+	case reflect.Struct:
+		if v.CanAddr() && v.Addr().CanInterface() {
+			if n, ok := v.Addr().Interface().(Node); ok {
+				n.SetPos(Pos{}, Pos{})
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			cleanNodePos(v.Field(i), visited)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			cleanNodePos(v.Index(i), visited)
+		}
+	}
 }
 
 // nodeTextCleaner clean the text of a node and it's child node.
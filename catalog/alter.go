@@ -0,0 +1,317 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+)
+
+// modifyTable applies f to a copy of a base table, which replaces the table if f succeeds.
+func (c *Catalog) modifyTable(tn *ast.TableName, f func(tbl *model.TableInfo) error) error {
+	db, i, err := c.lookupTable(tn)
+	if err != nil {
+		return err
+	}
+	if db.Tables[i].IsView() {
+		return ErrWrongObject.GenWithStackByArgs(db.Name.O, tn.Name.O, "BASE TABLE")
+	}
+	tbl := cloneTable(db.Tables[i])
+	if err := f(tbl); err != nil {
+		return err
+	}
+	db = c.mutableDB(db)
+	db.Tables[i] = tbl
+	return nil
+}
+
+func (c *Catalog) alterTable(stmt *ast.AlterTableStmt) error {
+	var newName *ast.TableName
+	err := c.modifyTable(stmt.Table, func(tbl *model.TableInfo) error {
+		for _, spec := range stmt.Specs {
+			if spec.Tp == ast.AlterTableRenameTable {
+				newName = spec.NewTable
+				continue
+			}
+			if err := alterTableSpec(tbl, spec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || newName == nil {
+		return err
+	}
+	// renaming a table to itself does nothing.
+	db, _ := c.schema(stmt.Table)
+	if newDB, err := c.schema(newName); err == nil && newDB == db && strings.EqualFold(newName.Name.O, stmt.Table.Name.O) {
+		return nil
+	}
+	return c.renameTable(stmt.Table, newName)
+}
+
+// alterTableSpec applies a specification of ALTER TABLE. The ones which don't change
+// the catalog are ignored.
+func alterTableSpec(tbl *model.TableInfo, spec *ast.AlterTableSpec) error {
+	switch spec.Tp {
+	case ast.AlterTableOption:
+		return setTableOptions(tbl, spec.Options)
+	case ast.AlterTableAddColumns:
+		for _, def := range spec.NewColumns {
+			if spec.IfNotExists && findColumn(tbl, def.Name.Name.L) >= 0 {
+				continue
+			}
+			if err := addColumn(tbl, def, spec.Position); err != nil {
+				return err
+			}
+		}
+		for _, constraint := range spec.NewConstraints {
+			if err := addConstraint(tbl, constraint); err != nil {
+				return err
+			}
+		}
+	case ast.AlterTableAddConstraint:
+		return addConstraint(tbl, spec.Constraint)
+	case ast.AlterTableDropColumn:
+		return dropColumn(tbl, spec.OldColumnName.Name, spec.IfExists)
+	case ast.AlterTableModifyColumn:
+		def := spec.NewColumns[0]
+		return changeColumn(tbl, def.Name.Name, def, spec.Position, spec.IfExists)
+	case ast.AlterTableChangeColumn:
+		return changeColumn(tbl, spec.OldColumnName.Name, spec.NewColumns[0], spec.Position, spec.IfExists)
+	case ast.AlterTableRenameColumn:
+		return renameColumn(tbl, spec.OldColumnName.Name, spec.NewColumnName.Name)
+	case ast.AlterTableAlterColumn:
+		def := spec.NewColumns[0]
+		i := findColumn(tbl, def.Name.Name.L)
+		if i < 0 {
+			return ErrColumnNotExists.GenWithStackByArgs(def.Name.Name.O, tbl.Name.O)
+		}
+		if len(def.Options) == 0 {
+			tbl.Columns[i].DefaultValue, tbl.Columns[i].DefaultIsExpr = nil, false
+			return nil
+		}
+		return setDefaultValue(tbl.Columns[i], def.Options[0].Expr)
+	case ast.AlterTableDropPrimaryKey:
+		return dropIndex(tbl, mysql.PrimaryKeyName, false)
+	case ast.AlterTableDropIndex:
+		return dropIndex(tbl, spec.Name, spec.IfExists)
+	case ast.AlterTableDropForeignKey:
+		i := findForeignKey(tbl, spec.Name)
+		if i < 0 {
+			if spec.IfExists {
+				return nil
+			}
+			return ErrCantDropFieldOrKey.GenWithStackByArgs(spec.Name)
+		}
+		tbl.ForeignKeys = append(tbl.ForeignKeys[:i], tbl.ForeignKeys[i+1:]...)
+	case ast.AlterTableDropCheck:
+		i := findCheck(tbl, spec.Constraint.Name)
+		if i < 0 {
+			return ErrCheckConstraintNotFound.GenWithStackByArgs(spec.Constraint.Name)
+		}
+		tbl.Constraints = append(tbl.Constraints[:i], tbl.Constraints[i+1:]...)
+	case ast.AlterTableAlterCheck:
+		i := findCheck(tbl, spec.Constraint.Name)
+		if i < 0 {
+			return ErrCheckConstraintNotFound.GenWithStackByArgs(spec.Constraint.Name)
+		}
+		tbl.Constraints[i].Enforced = spec.Constraint.Enforced
+	case ast.AlterTableRenameIndex:
+		idx := tbl.FindIndexByName(spec.FromKey.L)
+		if idx == nil {
+			return ErrKeyDoesNotExist.GenWithStackByArgs(spec.FromKey.O, tbl.Name.O)
+		}
+		if spec.ToKey.L != spec.FromKey.L && tbl.FindIndexByName(spec.ToKey.L) != nil {
+			return ErrDupKeyName.GenWithStackByArgs(spec.ToKey.O)
+		}
+		idx.Name = spec.ToKey
+	case ast.AlterTableIndexInvisible:
+		idx := tbl.FindIndexByName(spec.IndexName.L)
+		if idx == nil {
+			return ErrKeyDoesNotExist.GenWithStackByArgs(spec.IndexName.O, tbl.Name.O)
+		}
+		idx.Invisible = spec.Visibility == ast.IndexVisibilityInvisible
+	}
+	return nil
+}
+
+func dropColumn(tbl *model.TableInfo, name model.CIStr, ifExists bool) error {
+	i := findColumn(tbl, name.L)
+	if i < 0 {
+		if ifExists {
+			return nil
+		}
+		return ErrCantDropFieldOrKey.GenWithStackByArgs(name.O)
+	}
+	if len(tbl.Columns) == 1 {
+		return ErrCantRemoveAllFields.GenWithStackByArgs()
+	}
+	for _, fk := range tbl.ForeignKeys {
+		if containsName(fk.Cols, name) {
+			return ErrFkColumnCannotDrop.GenWithStackByArgs(name.O, fk.Name.O)
+		}
+	}
+	// like MySQL, the check constraints only on the column are dropped with it.
+	constraints := tbl.Constraints[:0]
+	for _, constraint := range tbl.Constraints {
+		if !containsName(constraint.ConstraintCols, name) {
+			constraints = append(constraints, constraint)
+			continue
+		}
+		if len(constraint.ConstraintCols) > 1 {
+			return ErrDependentByCheckConstraint.GenWithStackByArgs(constraint.Name.O, name.O)
+		}
+	}
+	tbl.Constraints = constraints
+
+	tbl.Columns = append(tbl.Columns[:i], tbl.Columns[i+1:]...)
+	// the column is removed from the indexes, and the indexes left without columns are dropped.
+	indices := tbl.Indices[:0]
+	for _, idx := range tbl.Indices {
+		cols := idx.Columns[:0]
+		for _, ic := range idx.Columns {
+			if ic.Name.L != name.L {
+				cols = append(cols, ic)
+			}
+		}
+		idx.Columns = cols
+		if len(cols) > 0 {
+			indices = append(indices, idx)
+		}
+	}
+	tbl.Indices = indices
+	updateColumns(tbl)
+	return nil
+}
+
+func changeColumn(tbl *model.TableInfo, name model.CIStr, def *ast.ColumnDef, pos *ast.ColumnPosition, ifExists bool) error {
+	i := findColumn(tbl, name.L)
+	if i < 0 {
+		if ifExists {
+			return nil
+		}
+		return ErrColumnNotExists.GenWithStackByArgs(name.O, tbl.Name.O)
+	}
+	newName := def.Name.Name
+	if newName.L != name.L {
+		if err := checkColumnRename(tbl, name, newName); err != nil {
+			return err
+		}
+	}
+	col, constraints, err := newColumn(tbl, def)
+	if err != nil {
+		return err
+	}
+	col.ID = tbl.Columns[i].ID
+	tbl.Columns = append(tbl.Columns[:i], tbl.Columns[i+1:]...)
+	renameColumnRefs(tbl, name, newName)
+	if err := insertColumn(tbl, col, pos, i); err != nil {
+		return err
+	}
+	for _, constraint := range constraints {
+		if err := addConstraint(tbl, constraint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renameColumn(tbl *model.TableInfo, name, newName model.CIStr) error {
+	i := findColumn(tbl, name.L)
+	if i < 0 {
+		return ErrColumnNotExists.GenWithStackByArgs(name.O, tbl.Name.O)
+	}
+	if newName.L != name.L {
+		if err := checkColumnRename(tbl, name, newName); err != nil {
+			return err
+		}
+	}
+	tbl.Columns[i].Name = newName
+	renameColumnRefs(tbl, name, newName)
+	return nil
+}
+
+func checkColumnRename(tbl *model.TableInfo, name, newName model.CIStr) error {
+	if findColumn(tbl, newName.L) >= 0 {
+		return ErrColumnExists.GenWithStackByArgs(newName.O)
+	}
+	for _, constraint := range tbl.Constraints {
+		if containsName(constraint.ConstraintCols, name) {
+			return ErrDependentByCheckConstraint.GenWithStackByArgs(constraint.Name.O, name.O)
+		}
+	}
+	return nil
+}
+
+// renameColumnRefs renames a column in the indexes and the foreign keys.
+func renameColumnRefs(tbl *model.TableInfo, name, newName model.CIStr) {
+	for _, idx := range tbl.Indices {
+		for _, ic := range idx.Columns {
+			if ic.Name.L == name.L {
+				ic.Name = newName
+			}
+		}
+	}
+	for _, fk := range tbl.ForeignKeys {
+		for i := range fk.Cols {
+			if fk.Cols[i].L == name.L {
+				fk.Cols[i] = newName
+			}
+		}
+	}
+}
+
+func dropIndex(tbl *model.TableInfo, name string, ifExists bool) error {
+	lowerName := strings.ToLower(name)
+	for i, idx := range tbl.Indices {
+		if idx.Name.L == lowerName {
+			tbl.Indices = append(tbl.Indices[:i], tbl.Indices[i+1:]...)
+			updateColumns(tbl)
+			return nil
+		}
+	}
+	if ifExists {
+		return nil
+	}
+	return ErrCantDropFieldOrKey.GenWithStackByArgs(name)
+}
+
+func (c *Catalog) createIndex(stmt *ast.CreateIndexStmt) error {
+	constraint := &ast.Constraint{
+		IfNotExists: stmt.IfNotExists,
+		Tp:          ast.ConstraintIndex,
+		Name:        stmt.IndexName,
+		Keys:        stmt.IndexPartSpecifications,
+		Option:      stmt.IndexOption,
+	}
+	switch stmt.KeyType {
+	case ast.IndexKeyTypeUnique:
+		constraint.Tp = ast.ConstraintUniq
+	case ast.IndexKeyTypeFullText:
+		constraint.Tp = ast.ConstraintFulltext
+	}
+	return c.modifyTable(stmt.Table, func(tbl *model.TableInfo) error {
+		return addIndex(tbl, constraint)
+	})
+}
+
+func (c *Catalog) dropIndex(stmt *ast.DropIndexStmt) error {
+	return c.modifyTable(stmt.Table, func(tbl *model.TableInfo) error {
+		return dropIndex(tbl, stmt.IndexName, stmt.IfExists)
+	})
+}
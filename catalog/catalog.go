@@ -0,0 +1,390 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package catalog keeps the schema of some databases in memory by replaying the
+// DDL statements which build it, without a server.
+//
+// The databases and tables are described by model.DBInfo and model.TableInfo, but
+// only the parts which can be told from the statements are filled: the IDs are
+// allocated in order, every object is public, and the primary key is always kept
+// as an index like the others. Partitioning, sequences, and the table options
+// other than the character set, the collation, the comment and AUTO_INCREMENT
+// aren't modeled.
+//
+// Like a server, the statements which don't make sense against the current schema
+// fail with the errors of the same MySQL error codes.
+package catalog
+
+import (
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+)
+
+// Catalog is a set of databases built by DDL statements.
+type Catalog struct {
+	dbs       []*model.DBInfo
+	currentDB string
+	lastID    int64
+	// copied holds the databases copied by the statement being executed, which
+	// can be changed in place.
+	copied map[*model.DBInfo]bool
+}
+
+// New creates an empty Catalog.
+func New() *Catalog {
+	return &Catalog{}
+}
+
+// CurrentDB returns the database selected by USE, or "" if there isn't one.
+func (c *Catalog) CurrentDB() string {
+	return c.currentDB
+}
+
+// DBs returns the databases in the order they are created.
+// The returned infos are shared by the catalog and mustn't be modified.
+func (c *Catalog) DBs() []*model.DBInfo {
+	return c.dbs
+}
+
+// DB returns the database of the name, or nil if there isn't one.
+func (c *Catalog) DB(name string) *model.DBInfo {
+	name = strings.ToLower(name)
+	for _, db := range c.dbs {
+		if db.Name.L == name {
+			return db
+		}
+	}
+	return nil
+}
+
+// Table returns the table or the view of the name, or nil if there isn't one.
+// An empty schema means the current database.
+func (c *Catalog) Table(schema, name string) *model.TableInfo {
+	if schema == "" {
+		schema = c.currentDB
+	}
+	db := c.DB(schema)
+	if db == nil {
+		return nil
+	}
+	if i := findTable(db, name); i >= 0 {
+		return db.Tables[i]
+	}
+	return nil
+}
+
+// Exec applies a statement to the catalog. The statements which don't change the
+// schema are ignored. The catalog is left unchanged if an error is returned.
+func (c *Catalog) Exec(stmt ast.StmtNode) error {
+	next := c.clone()
+	if err := next.exec(stmt); err != nil {
+		return err
+	}
+	next.copied = nil
+	*c = *next
+	return nil
+}
+
+// ExecAll applies the statements in order. It stops at the first error, leaving
+// the changes made by the statements before it.
+func (c *Catalog) ExecAll(stmts []ast.StmtNode) error {
+	for _, stmt := range stmts {
+		if err := c.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clone copies the catalog for a statement. The infos are copied on write, so only
+// the list of the databases is copied here.
+func (c *Catalog) clone() *Catalog {
+	nc := *c
+	nc.dbs = append([]*model.DBInfo(nil), c.dbs...)
+	nc.copied = nil
+	return &nc
+}
+
+// mutableDB returns a copy of db, with its own list of tables, which replaces db
+// in the catalog and can be changed by the statement. The tables are still shared
+// and must be copied before they are changed.
+func (c *Catalog) mutableDB(db *model.DBInfo) *model.DBInfo {
+	if c.copied[db] {
+		return db
+	}
+	ndb := *db
+	ndb.Tables = append([]*model.TableInfo(nil), db.Tables...)
+	for i := range c.dbs {
+		if c.dbs[i] == db {
+			c.dbs[i] = &ndb
+		}
+	}
+	if c.copied == nil {
+		c.copied = make(map[*model.DBInfo]bool)
+	}
+	c.copied[&ndb] = true
+	return &ndb
+}
+
+func (c *Catalog) allocID() int64 {
+	c.lastID++
+	return c.lastID
+}
+
+func (c *Catalog) exec(stmt ast.StmtNode) error {
+	switch x := stmt.(type) {
+	case *ast.CreateDatabaseStmt:
+		return c.createDatabase(x)
+	case *ast.AlterDatabaseStmt:
+		return c.alterDatabase(x)
+	case *ast.DropDatabaseStmt:
+		return c.dropDatabase(x)
+	case *ast.UseStmt:
+		return c.use(x)
+	case *ast.CreateTableStmt:
+		return c.createTable(x)
+	case *ast.CreateViewStmt:
+		return c.createView(x)
+	case *ast.AlterTableStmt:
+		return c.alterTable(x)
+	case *ast.CreateIndexStmt:
+		return c.createIndex(x)
+	case *ast.DropIndexStmt:
+		return c.dropIndex(x)
+	case *ast.DropTableStmt:
+		return c.dropTable(x)
+	case *ast.RenameTableStmt:
+		for _, t2t := range x.TableToTables {
+			if err := c.renameTable(t2t.OldTable, t2t.NewTable); err != nil {
+				return err
+			}
+		}
+	case *ast.TruncateTableStmt:
+		return c.truncateTable(x)
+	}
+	return nil
+}
+
+func (c *Catalog) createDatabase(stmt *ast.CreateDatabaseStmt) error {
+	if c.DB(stmt.Name) != nil {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return ErrDatabaseExists.GenWithStackByArgs(stmt.Name)
+	}
+	db := &model.DBInfo{
+		ID:      c.allocID(),
+		Name:    model.NewCIStr(stmt.Name),
+		Charset: mysql.DefaultCharset,
+		Collate: mysql.DefaultCollationName,
+		State:   model.StatePublic,
+	}
+	if err := setDatabaseOptions(db, stmt.Options); err != nil {
+		return err
+	}
+	c.dbs = append(c.dbs, db)
+	return nil
+}
+
+func (c *Catalog) alterDatabase(stmt *ast.AlterDatabaseStmt) error {
+	name := stmt.Name
+	if stmt.AlterDefaultDatabase {
+		if c.currentDB == "" {
+			return ErrNoDB
+		}
+		name = c.currentDB
+	}
+	db := c.DB(name)
+	if db == nil {
+		return ErrDatabaseNotExists.GenWithStackByArgs(name)
+	}
+	return setDatabaseOptions(c.mutableDB(db), stmt.Options)
+}
+
+func setDatabaseOptions(db *model.DBInfo, options []*ast.DatabaseOption) error {
+	var cs, co string
+	for _, opt := range options {
+		switch opt.Tp {
+		case ast.DatabaseOptionCharset:
+			cs = opt.Value
+		case ast.DatabaseOptionCollate:
+			co = opt.Value
+		}
+	}
+	var err error
+	db.Charset, db.Collate, err = resolveCharset(cs, co, db.Charset, db.Collate)
+	return err
+}
+
+func (c *Catalog) dropDatabase(stmt *ast.DropDatabaseStmt) error {
+	name := strings.ToLower(stmt.Name)
+	for i, db := range c.dbs {
+		if db.Name.L == name {
+			c.dbs = append(c.dbs[:i], c.dbs[i+1:]...)
+			if strings.ToLower(c.currentDB) == name {
+				c.currentDB = ""
+			}
+			return nil
+		}
+	}
+	if stmt.IfExists {
+		return nil
+	}
+	return ErrDatabaseDropExists.GenWithStackByArgs(stmt.Name)
+}
+
+func (c *Catalog) use(stmt *ast.UseStmt) error {
+	db := c.DB(stmt.DBName)
+	if db == nil {
+		return ErrDatabaseNotExists.GenWithStackByArgs(stmt.DBName)
+	}
+	c.currentDB = db.Name.O
+	return nil
+}
+
+// schema returns the database of a table name.
+func (c *Catalog) schema(tn *ast.TableName) (*model.DBInfo, error) {
+	name := tn.Schema.O
+	if name == "" {
+		if c.currentDB == "" {
+			return nil, ErrNoDB
+		}
+		name = c.currentDB
+	}
+	db := c.DB(name)
+	if db == nil {
+		return nil, ErrDatabaseNotExists.GenWithStackByArgs(name)
+	}
+	return db, nil
+}
+
+// lookupTable returns the database of a table name and the offset of the table in it.
+func (c *Catalog) lookupTable(tn *ast.TableName) (*model.DBInfo, int, error) {
+	db, err := c.schema(tn)
+	if err != nil {
+		return nil, -1, err
+	}
+	i := findTable(db, tn.Name.O)
+	if i < 0 {
+		return nil, -1, ErrTableNotExists.GenWithStackByArgs(db.Name.O, tn.Name.O)
+	}
+	return db, i, nil
+}
+
+func findTable(db *model.DBInfo, name string) int {
+	name = strings.ToLower(name)
+	for i, tbl := range db.Tables {
+		if tbl.Name.L == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Catalog) dropTable(stmt *ast.DropTableStmt) error {
+	var unknown []string
+	for _, tn := range stmt.Tables {
+		schema := tn.Schema.O
+		if schema == "" {
+			if c.currentDB == "" {
+				return ErrNoDB
+			}
+			schema = c.currentDB
+		}
+		i := -1
+		db := c.DB(schema)
+		if db != nil {
+			i = findTable(db, tn.Name.O)
+		}
+		if i >= 0 && stmt.IsView && !db.Tables[i].IsView() {
+			return ErrWrongObject.GenWithStackByArgs(schema, tn.Name.O, "VIEW")
+		}
+		if i < 0 || db.Tables[i].IsView() != stmt.IsView {
+			unknown = append(unknown, schema+"."+tn.Name.O)
+			continue
+		}
+		db = c.mutableDB(db)
+		db.Tables = append(db.Tables[:i], db.Tables[i+1:]...)
+	}
+	if len(unknown) > 0 && !stmt.IfExists {
+		return ErrTableDropExists.GenWithStackByArgs(strings.Join(unknown, ","))
+	}
+	return nil
+}
+
+func (c *Catalog) renameTable(from, to *ast.TableName) error {
+	db, i, err := c.lookupTable(from)
+	if err != nil {
+		return err
+	}
+	toDB, err := c.schema(to)
+	if err != nil {
+		return err
+	}
+	if findTable(toDB, to.Name.O) >= 0 {
+		return ErrTableExists.GenWithStackByArgs(to.Name.O)
+	}
+	tbl := *db.Tables[i]
+	tbl.Name = to.Name
+	db = c.mutableDB(db)
+	if db.Name.L == toDB.Name.L {
+		db.Tables[i] = &tbl
+		return nil
+	}
+	db.Tables = append(db.Tables[:i], db.Tables[i+1:]...)
+	toDB = c.mutableDB(toDB)
+	toDB.Tables = append(toDB.Tables, &tbl)
+	return nil
+}
+
+func (c *Catalog) truncateTable(stmt *ast.TruncateTableStmt) error {
+	db, i, err := c.lookupTable(stmt.Table)
+	if err != nil {
+		return err
+	}
+	if db.Tables[i].IsView() {
+		return ErrTableNotExists.GenWithStackByArgs(db.Name.O, stmt.Table.Name.O)
+	}
+	tbl := *db.Tables[i]
+	tbl.AutoIncID = 0
+	db = c.mutableDB(db)
+	db.Tables[i] = &tbl
+	return nil
+}
+
+// resolveCharset returns the character set and the collation specified by cs and co,
+// either of them can be empty. The defaults are returned if both are empty.
+func resolveCharset(cs, co, defaultCharset, defaultCollate string) (string, string, error) {
+	if co != "" {
+		coll, err := charset.GetCollationByName(co)
+		if err != nil {
+			return "", "", ErrUnknownCollation.GenWithStackByArgs(co)
+		}
+		if cs != "" && !strings.EqualFold(coll.CharsetName, cs) {
+			return "", "", ErrCollationCharsetMismatch.GenWithStackByArgs(coll.Name, cs)
+		}
+		return coll.CharsetName, coll.Name, nil
+	}
+	if cs != "" {
+		desc, err := charset.GetCharsetDesc(cs)
+		if err != nil {
+			return "", "", ErrUnknownCharacterSet.GenWithStackByArgs(cs)
+		}
+		return desc.Name, desc.DefaultCollation, nil
+	}
+	return defaultCharset, defaultCollate, nil
+}
@@ -0,0 +1,301 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog_test
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	. "github.com/pingcap/parser/catalog"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testCatalogSuite{})
+
+type testCatalogSuite struct {
+}
+
+func exec(c *C, cat *Catalog, sql string) error {
+	stmts, _, err := parser.New().Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	return cat.ExecAll(stmts)
+}
+
+func columnNames(tbl *model.TableInfo) []string {
+	var names []string
+	for _, col := range tbl.Columns {
+		names = append(names, col.Name.O)
+	}
+	return names
+}
+
+func indexNames(tbl *model.TableInfo) []string {
+	var names []string
+	for _, idx := range tbl.Indices {
+		names = append(names, idx.Name.O)
+	}
+	return names
+}
+
+func (s *testCatalogSuite) TestReplay(c *C) {
+	cat := New()
+	err := exec(c, cat, `
+		CREATE DATABASE shop DEFAULT CHARSET latin1;
+		USE shop;
+		CREATE TABLE users (
+			id BIGINT PRIMARY KEY AUTO_INCREMENT,
+			name VARCHAR(20) NOT NULL DEFAULT '',
+			email VARCHAR(50) COLLATE latin1_bin,
+			UNIQUE KEY (email)
+		) DEFAULT CHARSET utf8mb4 COMMENT 'the users';
+		CREATE TABLE orders (
+			id INT,
+			user_id BIGINT,
+			total DECIMAL(10,2) DEFAULT 0,
+			created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+			CHECK (total >= 0)
+		);
+		ALTER TABLE users ADD COLUMN age INT AFTER name, DROP COLUMN email, ADD INDEX idx_name (name(10), age), RENAME INDEX idx_name TO idx_n;
+		CREATE UNIQUE INDEX uk_id ON orders (id);
+		RENAME TABLE orders TO purchases;
+		CREATE VIEW v AS SELECT u.*, p.total AS t, p.id + 1 FROM users u JOIN purchases p ON u.id = p.user_id;
+		CREATE TABLE users2 LIKE users;
+		DROP TABLE users2;
+	`)
+	c.Assert(err, IsNil)
+	c.Assert(cat.CurrentDB(), Equals, "shop")
+	c.Assert(cat.DBs(), HasLen, 1)
+	db := cat.DB("SHOP")
+	c.Assert(db.Charset, Equals, "latin1")
+	c.Assert(db.Collate, Equals, "latin1_bin")
+	c.Assert(db.Tables, HasLen, 3)
+
+	users := cat.Table("", "users")
+	c.Assert(users.Comment, Equals, "the users")
+	c.Assert(columnNames(users), DeepEquals, []string{"id", "name", "age"})
+	c.Assert(indexNames(users), DeepEquals, []string{"PRIMARY", "idx_n"})
+	id, name, age := users.Columns[0], users.Columns[1], users.Columns[2]
+	c.Assert(mysql.HasPriKeyFlag(id.Flag), IsTrue)
+	c.Assert(mysql.HasNotNullFlag(id.Flag), IsTrue)
+	c.Assert(mysql.HasAutoIncrementFlag(id.Flag), IsTrue)
+	c.Assert(mysql.HasMultipleKeyFlag(name.Flag), IsTrue)
+	c.Assert(name.Charset, Equals, "utf8mb4")
+	c.Assert(name.Collate, Equals, "utf8mb4_bin")
+	c.Assert(name.DefaultValue, Equals, "")
+	c.Assert(age.ID, Equals, int64(4))
+	c.Assert(age.Offset, Equals, 2)
+	idx := users.Indices[1]
+	c.Assert(idx.Columns[0].Length, Equals, 10)
+	c.Assert(idx.Columns[1].Offset, Equals, 2)
+
+	purchases := cat.Table("shop", "PURCHASES")
+	c.Assert(purchases.Name.O, Equals, "purchases")
+	// an index is added for the foreign key.
+	c.Assert(indexNames(purchases), DeepEquals, []string{"fk_user", "uk_id"})
+	c.Assert(mysql.HasUniKeyFlag(purchases.Columns[0].Flag), IsTrue)
+	c.Assert(purchases.Columns[3].DefaultValue, Equals, "CURRENT_TIMESTAMP()")
+	c.Assert(purchases.Columns[3].DefaultIsExpr, IsTrue)
+	c.Assert(purchases.ForeignKeys, HasLen, 1)
+	fk := purchases.ForeignKeys[0]
+	c.Assert(fk.RefTable.O, Equals, "users")
+	c.Assert(fk.Cols, DeepEquals, []model.CIStr{model.NewCIStr("user_id")})
+	c.Assert(fk.RefCols, DeepEquals, []model.CIStr{model.NewCIStr("id")})
+	c.Assert(purchases.Constraints, HasLen, 1)
+	c.Assert(purchases.Constraints[0].Name.O, Equals, "orders_chk_1")
+	c.Assert(purchases.Constraints[0].ExprString, Equals, "`total`>=0")
+
+	v := cat.Table("shop", "v")
+	c.Assert(v.IsView(), IsTrue)
+	c.Assert(columnNames(v), DeepEquals, []string{"id", "name", "age", "t", "p.id + 1"})
+	c.Assert(v.View.SelectStmt, Equals, "SELECT `u`.*,`p`.`total` AS `t`,`p`.`id`+1 FROM `users` AS `u` JOIN `purchases` AS `p` ON `u`.`id`=`p`.`user_id`")
+
+	// the later statements see the changes of the former ones.
+	err = exec(c, cat, `
+		ALTER TABLE purchases DROP FOREIGN KEY fk_user, DROP INDEX fk_user, MODIFY COLUMN id BIGINT FIRST, DROP CHECK orders_chk_1;
+		ALTER TABLE purchases CHANGE COLUMN total amount DECIMAL(12,2) AFTER created, RENAME TO shop.p2;
+		TRUNCATE TABLE p2;
+		DROP VIEW v;
+		DROP DATABASE shop;
+		CREATE DATABASE shop;
+	`)
+	c.Assert(err, IsNil)
+	c.Assert(cat.CurrentDB(), Equals, "")
+	c.Assert(cat.DB("shop").Tables, HasLen, 0)
+}
+
+func (s *testCatalogSuite) TestCreateTable(c *C) {
+	cat := New()
+	err := exec(c, cat, `
+		CREATE DATABASE db;
+		CREATE TABLE db.t (
+			a INT NOT NULL,
+			b VARCHAR(10) CHARACTER SET latin1,
+			c INT AS (a + 1) STORED COMMENT 'c' UNIQUE,
+			d INT CHECK (d > 0) REFERENCES p (id),
+			KEY (a), KEY (a, b), FOREIGN KEY (d) REFERENCES p (id)
+		) AUTO_INCREMENT = 5 COLLATE utf8mb4_general_ci;
+	`)
+	c.Assert(err, IsNil)
+	tbl := cat.Table("db", "t")
+	c.Assert(tbl.Charset, Equals, "utf8mb4")
+	c.Assert(tbl.Collate, Equals, "utf8mb4_general_ci")
+	c.Assert(tbl.AutoIncID, Equals, int64(5))
+	c.Assert(tbl.Columns[1].Charset, Equals, "latin1")
+	c.Assert(tbl.Columns[1].Collate, Equals, "latin1_bin")
+	c.Assert(tbl.Columns[2].GeneratedExprString, Equals, "`a`+1")
+	c.Assert(tbl.Columns[2].GeneratedStored, IsTrue)
+	c.Assert(tbl.Columns[2].Comment, Equals, "c")
+	// the unnamed indexes are named after their first columns.
+	c.Assert(indexNames(tbl), DeepEquals, []string{"c", "a", "a_2", "t_ibfk_1"})
+	c.Assert(tbl.ForeignKeys, HasLen, 1)
+	c.Assert(tbl.Constraints[0].Name.O, Equals, "t_chk_1")
+	c.Assert(tbl.Constraints[0].InColumn, IsTrue)
+
+	// the PRIMARY KEY comes first.
+	c.Assert(exec(c, cat, "ALTER TABLE db.t ADD PRIMARY KEY (a, b)"), IsNil)
+	tbl = cat.Table("db", "t")
+	c.Assert(indexNames(tbl)[0], Equals, "PRIMARY")
+	c.Assert(mysql.HasNotNullFlag(tbl.Columns[1].Flag), IsTrue)
+
+	// the column is removed from the indexes.
+	c.Assert(exec(c, cat, "ALTER TABLE db.t DROP COLUMN a"), IsNil)
+	tbl = cat.Table("db", "t")
+	c.Assert(indexNames(tbl), DeepEquals, []string{"PRIMARY", "c", "a_2", "t_ibfk_1"})
+	c.Assert(tbl.Indices[0].Columns[0].Name.O, Equals, "b")
+	c.Assert(tbl.Indices[0].Columns[0].Offset, Equals, 0)
+}
+
+func (s *testCatalogSuite) TestCopyOnWrite(c *C) {
+	cat := New()
+	c.Assert(exec(c, cat, "CREATE DATABASE a; CREATE DATABASE b; CREATE TABLE a.t (x INT); CREATE TABLE a.u (y INT); CREATE TABLE b.t (z INT)"), IsNil)
+	a, b := cat.DB("a"), cat.DB("b")
+	t, u := cat.Table("a", "t"), cat.Table("a", "u")
+
+	// only the changed database and table are copied.
+	c.Assert(exec(c, cat, "ALTER TABLE a.t ADD COLUMN w INT"), IsNil)
+	c.Assert(cat.DB("b"), Equals, b)
+	c.Assert(cat.DB("a"), Not(Equals), a)
+	c.Assert(cat.Table("a", "u"), Equals, u)
+	c.Assert(columnNames(cat.Table("a", "t")), DeepEquals, []string{"x", "w"})
+
+	// the infos returned before are left as they were.
+	c.Assert(exec(c, cat, "DROP TABLE a.u; RENAME TABLE a.t TO b.v; ALTER DATABASE a CHARSET latin1"), IsNil)
+	c.Assert(a.Tables, DeepEquals, []*model.TableInfo{t, u})
+	c.Assert(a.Charset, Equals, mysql.DefaultCharset)
+	c.Assert(columnNames(t), DeepEquals, []string{"x"})
+	c.Assert(b.Tables, HasLen, 1)
+	c.Assert(cat.DB("a").Tables, HasLen, 0)
+	c.Assert(cat.DB("a").Charset, Equals, "latin1")
+	c.Assert(cat.DB("b").Tables, HasLen, 2)
+}
+
+func (s *testCatalogSuite) TestErrors(c *C) {
+	const setup = `
+		CREATE DATABASE db;
+		USE db;
+		CREATE TABLE t (a INT PRIMARY KEY, b INT, c INT, KEY idx_b (b), CONSTRAINT ck CHECK (b > c));
+		CREATE TABLE f (x INT, z INT, FOREIGN KEY fk (x) REFERENCES t (a));
+		CREATE VIEW v AS SELECT * FROM t;
+	`
+	cases := []struct {
+		sql string
+		err *terror.Error
+		msg string
+	}{
+		{"CREATE DATABASE DB", ErrDatabaseExists, "[schema:1007]Can't create database 'DB'; database exists"},
+		{"DROP DATABASE x", ErrDatabaseDropExists, ""},
+		{"USE x", ErrDatabaseNotExists, "[schema:1049]Unknown database 'x'"},
+		{"CREATE DATABASE x CHARSET latin1 COLLATE utf8mb4_bin", ErrCollationCharsetMismatch, ""},
+		{"CREATE TABLE t (a INT)", ErrTableExists, "[schema:1050]Table 't' already exists"},
+		{"CREATE TABLE x.t (a INT)", ErrDatabaseNotExists, ""},
+		{"CREATE TABLE x (a INT, A INT)", ErrColumnExists, "[ddl:1060]Duplicate column name 'A'"},
+		{"CREATE TABLE x (a INT PRIMARY KEY, b INT PRIMARY KEY)", ErrMultiplePriKey, ""},
+		{"CREATE TABLE x (a INT, KEY k (a), KEY k (a))", ErrDupKeyName, ""},
+		{"CREATE TABLE x (a INT, KEY (b))", ErrKeyColumnDoesNotExist, "[ddl:1072]Key column 'b' doesn't exist in table"},
+		{"CREATE TABLE x (a INT, KEY ((a + 1)))", ErrNotSupportedYet, ""},
+		{"CREATE TABLE x (a INT, CHECK (b > 0))", ErrColumnNotExists, "[ddl:1054]Unknown column 'b' in 'check constraint'"},
+		{"CREATE TABLE x (a INT, CONSTRAINT c CHECK (a > 0), CONSTRAINT c CHECK (a < 9))", ErrCheckConstraintDupName, ""},
+		{"CREATE TABLE x (a INT, CONSTRAINT c FOREIGN KEY (a) REFERENCES t (a), CONSTRAINT c FOREIGN KEY (a) REFERENCES t (a))", ErrFkDupName, ""},
+		{"CREATE TABLE x LIKE y", ErrTableNotExists, "[schema:1146]Table 'db.y' doesn't exist"},
+		{"CREATE TABLE x LIKE v", ErrWrongObject, "[schema:1347]'db.v' is not BASE TABLE"},
+		{"CREATE TABLE x SELECT 1", ErrNotSupportedYet, ""},
+		{"ALTER TABLE t ADD COLUMN d INT, ADD COLUMN b INT", ErrColumnExists, ""},
+		{"ALTER TABLE t ADD COLUMN d INT AFTER x", ErrColumnNotExists, "[ddl:1054]Unknown column 'x' in 't'"},
+		{"ALTER TABLE t ADD PRIMARY KEY (b)", ErrMultiplePriKey, ""},
+		{"ALTER TABLE t DROP COLUMN x", ErrCantDropFieldOrKey, "[ddl:1091]Can't DROP 'x'; check that column/key exists"},
+		{"ALTER TABLE t DROP COLUMN b", ErrDependentByCheckConstraint, ""},
+		{"ALTER TABLE f DROP COLUMN x", ErrFkColumnCannotDrop, ""},
+		{"ALTER TABLE f DROP FOREIGN KEY fk, DROP COLUMN x, DROP COLUMN z", ErrCantRemoveAllFields, ""},
+		{"ALTER TABLE t MODIFY COLUMN x INT", ErrColumnNotExists, ""},
+		{"ALTER TABLE t CHANGE COLUMN b c INT", ErrColumnExists, ""},
+		{"ALTER TABLE t RENAME COLUMN c TO d", ErrDependentByCheckConstraint, ""},
+		{"ALTER TABLE t ALTER COLUMN x SET DEFAULT 1", ErrColumnNotExists, ""},
+		{"ALTER TABLE t DROP INDEX x", ErrCantDropFieldOrKey, ""},
+		{"ALTER TABLE f DROP PRIMARY KEY", ErrCantDropFieldOrKey, "[ddl:1091]Can't DROP 'PRIMARY'; check that column/key exists"},
+		{"ALTER TABLE t DROP FOREIGN KEY x", ErrCantDropFieldOrKey, ""},
+		{"ALTER TABLE t DROP CHECK x", ErrCheckConstraintNotFound, ""},
+		{"ALTER TABLE t RENAME INDEX x TO y", ErrKeyDoesNotExist, "[ddl:1176]Key 'x' doesn't exist in table 't'"},
+		{"ALTER TABLE t ADD INDEX idx_c (c), RENAME INDEX idx_b TO idx_c", ErrDupKeyName, ""},
+		{"ALTER TABLE t RENAME TO f", ErrTableExists, ""},
+		{"ALTER TABLE x ADD COLUMN d INT", ErrTableNotExists, ""},
+		{"ALTER TABLE v ADD COLUMN d INT", ErrWrongObject, ""},
+		{"CREATE INDEX idx_b ON t (c)", ErrDupKeyName, ""},
+		{"DROP INDEX x ON t", ErrCantDropFieldOrKey, ""},
+		{"DROP TABLE t, x, db2.y", ErrTableDropExists, "[schema:1051]Unknown table 'db.x,db2.y'"},
+		{"DROP TABLE v", ErrTableDropExists, ""},
+		{"DROP VIEW t", ErrWrongObject, ""},
+		{"RENAME TABLE t TO t2, x TO y", ErrTableNotExists, ""},
+		{"RENAME TABLE t TO f", ErrTableExists, ""},
+		{"TRUNCATE TABLE v", ErrTableNotExists, ""},
+		{"CREATE VIEW v AS SELECT 1", ErrTableExists, ""},
+		{"CREATE OR REPLACE VIEW t AS SELECT 1", ErrWrongObject, ""},
+		{"CREATE VIEW w (x) AS SELECT 1, 2", ErrViewWrongList, ""},
+		{"CREATE VIEW w AS SELECT a, a FROM t", ErrColumnExists, ""},
+		{"CREATE VIEW w AS SELECT * FROM x", ErrTableNotExists, ""},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		cat := New()
+		c.Assert(exec(c, cat, setup), IsNil, comment)
+		before := cat.DB("db")
+		err := exec(c, cat, ca.sql)
+		c.Assert(terror.ErrorEqual(err, ca.err), IsTrue, Commentf("for %s: %v", ca.sql, err))
+		if ca.msg != "" {
+			c.Assert(err.Error(), Equals, ca.msg, comment)
+		}
+		// the failed statement changes nothing.
+		c.Assert(cat.DB("db"), Equals, before, comment)
+	}
+
+	cat := New()
+	c.Assert(terror.ErrorEqual(exec(c, cat, "CREATE TABLE t (a INT)"), ErrNoDB), IsTrue)
+	// the parser rejects the unknown names, but an AST can be built without it.
+	err := cat.Exec(&ast.CreateDatabaseStmt{Name: "x", Options: []*ast.DatabaseOption{{Tp: ast.DatabaseOptionCharset, Value: "foo"}}})
+	c.Assert(terror.ErrorEqual(err, ErrUnknownCharacterSet), IsTrue)
+	err = cat.Exec(&ast.CreateDatabaseStmt{Name: "x", Options: []*ast.DatabaseOption{{Tp: ast.DatabaseOptionCollate, Value: "foo"}}})
+	c.Assert(terror.ErrorEqual(err, ErrUnknownCollation), IsTrue)
+	c.Assert(exec(c, cat, setup+"DROP TABLE IF EXISTS t, x; DROP DATABASE IF EXISTS x; CREATE TABLE IF NOT EXISTS f (y INT)"), IsNil)
+	c.Assert(cat.Table("db", "t"), IsNil)
+	c.Assert(columnNames(cat.Table("db", "f")), DeepEquals, []string{"x", "z"})
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
+)
+
+var (
+	// ErrNoDB returns for an unqualified name without a current database.
+	ErrNoDB = terror.ClassSchema.NewStd(mysql.ErrNoDB)
+	// ErrDatabaseExists returns for creating a database which exists.
+	ErrDatabaseExists = terror.ClassSchema.NewStd(mysql.ErrDBCreateExists)
+	// ErrDatabaseDropExists returns for dropping a database which doesn't exist.
+	ErrDatabaseDropExists = terror.ClassSchema.NewStd(mysql.ErrDBDropExists)
+	// ErrDatabaseNotExists returns for using a database which doesn't exist.
+	ErrDatabaseNotExists = terror.ClassSchema.NewStd(mysql.ErrBadDB)
+	// ErrTableExists returns for creating a table which exists.
+	ErrTableExists = terror.ClassSchema.NewStd(mysql.ErrTableExists)
+	// ErrTableNotExists returns for using a table which doesn't exist.
+	ErrTableNotExists = terror.ClassSchema.NewStd(mysql.ErrNoSuchTable)
+	// ErrTableDropExists returns for dropping tables which don't exist.
+	ErrTableDropExists = terror.ClassSchema.NewStd(mysql.ErrBadTable)
+	// ErrWrongObject returns for using a table as a view or a view as a table.
+	ErrWrongObject = terror.ClassSchema.NewStd(mysql.ErrWrongObject)
+
+	// ErrUnknownCharacterSet returns for an unknown character set.
+	ErrUnknownCharacterSet = terror.ClassDDL.NewStd(mysql.ErrUnknownCharacterSet)
+	// ErrUnknownCollation returns for an unknown collation.
+	ErrUnknownCollation = terror.ClassDDL.NewStd(mysql.ErrUnknownCollation)
+	// ErrCollationCharsetMismatch returns for a collation which doesn't belong to the character set.
+	ErrCollationCharsetMismatch = terror.ClassDDL.NewStd(mysql.ErrCollationCharsetMismatch)
+	// ErrColumnExists returns for adding a column which exists.
+	ErrColumnExists = terror.ClassDDL.NewStd(mysql.ErrDupFieldName)
+	// ErrColumnNotExists returns for using a column which doesn't exist.
+	ErrColumnNotExists = terror.ClassDDL.NewStd(mysql.ErrBadField)
+	// ErrCantRemoveAllFields returns for dropping the last column of a table.
+	ErrCantRemoveAllFields = terror.ClassDDL.NewStd(mysql.ErrCantRemoveAllFields)
+	// ErrCantDropFieldOrKey returns for dropping a column or a key which doesn't exist.
+	ErrCantDropFieldOrKey = terror.ClassDDL.NewStd(mysql.ErrCantDropFieldOrKey)
+	// ErrFkColumnCannotDrop returns for dropping a column used by a foreign key.
+	ErrFkColumnCannotDrop = terror.ClassDDL.NewStd(mysql.ErrFkColumnCannotDrop)
+	// ErrDupKeyName returns for adding a key or a constraint which exists.
+	ErrDupKeyName = terror.ClassDDL.NewStd(mysql.ErrDupKeyName)
+	// ErrFkDupName returns for adding a foreign key which exists.
+	ErrFkDupName = terror.ClassDDL.NewStd(mysql.ErrFkDupName)
+	// ErrMultiplePriKey returns for adding a primary key to a table which has one.
+	ErrMultiplePriKey = terror.ClassDDL.NewStd(mysql.ErrMultiplePriKey)
+	// ErrKeyColumnDoesNotExist returns for a key on a column which doesn't exist.
+	ErrKeyColumnDoesNotExist = terror.ClassDDL.NewStd(mysql.ErrKeyColumnDoesNotExits)
+	// ErrKeyDoesNotExist returns for using a key which doesn't exist.
+	ErrKeyDoesNotExist = terror.ClassDDL.NewStd(mysql.ErrKeyDoesNotExist)
+	// ErrCheckConstraintDupName returns for adding a check constraint which exists.
+	ErrCheckConstraintDupName = terror.ClassDDL.NewStd(mysql.ErrCheckConstraintDupName)
+	// ErrCheckConstraintNotFound returns for using a check constraint which doesn't exist.
+	ErrCheckConstraintNotFound = terror.ClassDDL.NewStd(mysql.ErrCheckConstraintNotFound)
+	// ErrDependentByCheckConstraint returns for dropping or renaming a column used by a check constraint.
+	ErrDependentByCheckConstraint = terror.ClassDDL.NewStd(mysql.ErrDependentByCheckConstraint)
+	// ErrViewWrongList returns for a view whose column list doesn't match its SELECT.
+	ErrViewWrongList = terror.ClassDDL.NewStd(mysql.ErrViewWrongList)
+	// ErrNotSupportedYet returns for the statements which can't be replayed.
+	ErrNotSupportedYet = terror.ClassDDL.NewStd(mysql.ErrNotSupportedYet)
+)
@@ -0,0 +1,505 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/ast/astutil"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
+)
+
+func (c *Catalog) createTable(stmt *ast.CreateTableStmt) error {
+	db, err := c.schema(stmt.Table)
+	if err != nil {
+		return err
+	}
+	if findTable(db, stmt.Table.Name.O) >= 0 {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return ErrTableExists.GenWithStackByArgs(stmt.Table.Name.O)
+	}
+
+	var tbl *model.TableInfo
+	switch {
+	case stmt.Select != nil:
+		return ErrNotSupportedYet.GenWithStackByArgs("CREATE TABLE ... SELECT")
+	case stmt.ReferTable != nil:
+		referDB, i, err := c.lookupTable(stmt.ReferTable)
+		if err != nil {
+			return err
+		}
+		if referDB.Tables[i].IsView() {
+			return ErrWrongObject.GenWithStackByArgs(referDB.Name.O, stmt.ReferTable.Name.O, "BASE TABLE")
+		}
+		// like MySQL, the foreign keys aren't copied.
+		tbl = cloneTable(referDB.Tables[i])
+		tbl.ForeignKeys = nil
+		tbl.AutoIncID = 0
+	default:
+		tbl = &model.TableInfo{
+			Name:    stmt.Table.Name,
+			Charset: db.Charset,
+			Collate: db.Collate,
+			State:   model.StatePublic,
+		}
+		if err := setTableOptions(tbl, stmt.Options); err != nil {
+			return err
+		}
+		for _, def := range stmt.Cols {
+			if err := addColumn(tbl, def, nil); err != nil {
+				return err
+			}
+		}
+		for _, constraint := range stmt.Constraints {
+			if err := addConstraint(tbl, constraint); err != nil {
+				return err
+			}
+		}
+	}
+	tbl.ID = c.allocID()
+	tbl.Name = stmt.Table.Name
+	db = c.mutableDB(db)
+	db.Tables = append(db.Tables, tbl)
+	return nil
+}
+
+// cloneTable copies a table to be modified.
+func cloneTable(tbl *model.TableInfo) *model.TableInfo {
+	nt := tbl.Clone()
+	nt.Constraints = make([]*model.ConstraintInfo, len(tbl.Constraints))
+	for i, constraint := range tbl.Constraints {
+		nt.Constraints[i] = constraint.Clone()
+	}
+	return nt
+}
+
+func setTableOptions(tbl *model.TableInfo, options []*ast.TableOption) error {
+	var (
+		cs, co  string
+		convert bool
+	)
+	for _, opt := range options {
+		switch opt.Tp {
+		case ast.TableOptionCharset:
+			cs = opt.StrValue
+			convert = opt.UintValue == ast.TableOptionCharsetWithConvertTo
+		case ast.TableOptionCollate:
+			co = opt.StrValue
+		case ast.TableOptionComment:
+			tbl.Comment = opt.StrValue
+		case ast.TableOptionAutoIncrement:
+			tbl.AutoIncID = int64(opt.UintValue)
+		}
+	}
+	var err error
+	tbl.Charset, tbl.Collate, err = resolveCharset(cs, co, tbl.Charset, tbl.Collate)
+	if err != nil {
+		return err
+	}
+	if convert {
+		for _, col := range tbl.Columns {
+			if types.HasCharset(&col.FieldType) {
+				col.Charset, col.Collate = tbl.Charset, tbl.Collate
+			}
+		}
+	}
+	return nil
+}
+
+// newColumn builds a column. The constraints defined along with the column are
+// returned to be added after it is.
+func newColumn(tbl *model.TableInfo, def *ast.ColumnDef) (*model.ColumnInfo, []*ast.Constraint, error) {
+	col := &model.ColumnInfo{
+		Name:      def.Name.Name,
+		FieldType: *def.Tp,
+		State:     model.StatePublic,
+	}
+	var (
+		collate     = col.Collate
+		constraints []*ast.Constraint
+		keys        = []*ast.IndexPartSpecification{{Column: def.Name}}
+		err         error
+	)
+	for _, opt := range def.Options {
+		switch opt.Tp {
+		case ast.ColumnOptionNotNull:
+			col.Flag |= mysql.NotNullFlag
+		case ast.ColumnOptionNull:
+			col.Flag &^= mysql.NotNullFlag
+		case ast.ColumnOptionAutoIncrement:
+			col.Flag |= mysql.AutoIncrementFlag
+		case ast.ColumnOptionOnUpdate:
+			col.Flag |= mysql.OnUpdateNowFlag
+		case ast.ColumnOptionDefaultValue:
+			err = setDefaultValue(col, opt.Expr)
+		case ast.ColumnOptionComment:
+			col.Comment = opt.Expr.(ast.ValueExpr).GetString()
+		case ast.ColumnOptionGenerated:
+			col.GeneratedExprString, err = restore(opt.Expr)
+			col.GeneratedStored = opt.Stored
+		case ast.ColumnOptionCollate:
+			collate = opt.StrValue
+		case ast.ColumnOptionPrimaryKey:
+			constraints = append(constraints, &ast.Constraint{Tp: ast.ConstraintPrimaryKey, Keys: keys})
+		case ast.ColumnOptionUniqKey:
+			constraints = append(constraints, &ast.Constraint{Tp: ast.ConstraintUniqKey, Keys: keys})
+		case ast.ColumnOptionCheck:
+			constraints = append(constraints, &ast.Constraint{
+				Tp:           ast.ConstraintCheck,
+				Name:         opt.ConstraintName,
+				Expr:         opt.Expr,
+				Enforced:     opt.Enforced,
+				InColumn:     true,
+				InColumnName: def.Name.Name.O,
+			})
+		case ast.ColumnOptionReference:
+			// like MySQL, the REFERENCES of a column is ignored.
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if types.HasCharset(&col.FieldType) {
+		col.Charset, col.Collate, err = resolveCharset(col.Charset, collate, tbl.Charset, tbl.Collate)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return col, constraints, nil
+}
+
+// setDefaultValue sets the default value of a column to a literal, or to the SQL
+// text of an expression with DefaultIsExpr set.
+func setDefaultValue(col *model.ColumnInfo, expr ast.ExprNode) error {
+	if v, ok := expr.(ast.ValueExpr); ok {
+		col.DefaultValue, col.DefaultIsExpr = v.GetValue(), false
+		return nil
+	}
+	text, err := restore(expr)
+	if err != nil {
+		return err
+	}
+	col.DefaultValue, col.DefaultIsExpr = text, true
+	return nil
+}
+
+func restore(n ast.Node) (string, error) {
+	var sb strings.Builder
+	if err := n.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func addColumn(tbl *model.TableInfo, def *ast.ColumnDef, pos *ast.ColumnPosition) error {
+	if findColumn(tbl, def.Name.Name.L) >= 0 {
+		return ErrColumnExists.GenWithStackByArgs(def.Name.Name.O)
+	}
+	col, constraints, err := newColumn(tbl, def)
+	if err != nil {
+		return err
+	}
+	tbl.MaxColumnID++
+	col.ID = tbl.MaxColumnID
+	if err := insertColumn(tbl, col, pos, len(tbl.Columns)); err != nil {
+		return err
+	}
+	for _, constraint := range constraints {
+		if err := addConstraint(tbl, constraint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findColumn(tbl *model.TableInfo, name string) int {
+	name = strings.ToLower(name)
+	for i, col := range tbl.Columns {
+		if col.Name.L == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// insertColumn inserts a column at the position, or at offset if the position isn't given.
+func insertColumn(tbl *model.TableInfo, col *model.ColumnInfo, pos *ast.ColumnPosition, offset int) error {
+	if pos != nil {
+		switch pos.Tp {
+		case ast.ColumnPositionFirst:
+			offset = 0
+		case ast.ColumnPositionAfter:
+			offset = findColumn(tbl, pos.RelativeColumn.Name.L)
+			if offset < 0 {
+				return ErrColumnNotExists.GenWithStackByArgs(pos.RelativeColumn.Name.O, tbl.Name.O)
+			}
+			offset++
+		}
+	}
+	tbl.Columns = append(tbl.Columns, nil)
+	copy(tbl.Columns[offset+1:], tbl.Columns[offset:])
+	tbl.Columns[offset] = col
+	updateColumns(tbl)
+	return nil
+}
+
+// updateColumns fixes the offsets and the key flags of the columns after the
+// columns or the indexes change.
+func updateColumns(tbl *model.TableInfo) {
+	for i, col := range tbl.Columns {
+		col.Offset = i
+		col.Flag &^= mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag
+	}
+	for _, idx := range tbl.Indices {
+		for _, ic := range idx.Columns {
+			ic.Offset = findColumn(tbl, ic.Name.L)
+		}
+		first := tbl.Columns[idx.Columns[0].Offset]
+		switch {
+		case idx.Primary:
+			for _, ic := range idx.Columns {
+				tbl.Columns[ic.Offset].Flag |= mysql.PriKeyFlag | mysql.NotNullFlag
+			}
+		case idx.Unique && len(idx.Columns) == 1:
+			first.Flag |= mysql.UniqueKeyFlag
+		default:
+			first.Flag |= mysql.MultipleKeyFlag
+		}
+	}
+}
+
+func addConstraint(tbl *model.TableInfo, constraint *ast.Constraint) error {
+	switch constraint.Tp {
+	case ast.ConstraintPrimaryKey, ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintUniq,
+		ast.ConstraintUniqKey, ast.ConstraintUniqIndex, ast.ConstraintFulltext:
+		return addIndex(tbl, constraint)
+	case ast.ConstraintForeignKey:
+		return addForeignKey(tbl, constraint)
+	case ast.ConstraintCheck:
+		return addCheck(tbl, constraint)
+	}
+	return nil
+}
+
+func addIndex(tbl *model.TableInfo, constraint *ast.Constraint) error {
+	primary := constraint.Tp == ast.ConstraintPrimaryKey
+	name := constraint.Name
+	if primary {
+		for _, idx := range tbl.Indices {
+			if idx.Primary {
+				return ErrMultiplePriKey.GenWithStackByArgs()
+			}
+		}
+		name = mysql.PrimaryKeyName
+	}
+	cols, err := indexColumns(tbl, constraint.Keys)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = uniqueIndexName(tbl, cols[0].Name.O)
+	} else if tbl.FindIndexByName(strings.ToLower(name)) != nil {
+		if constraint.IfNotExists {
+			return nil
+		}
+		return ErrDupKeyName.GenWithStackByArgs(name)
+	}
+
+	tbl.MaxIndexID++
+	idx := &model.IndexInfo{
+		ID:      tbl.MaxIndexID,
+		Name:    model.NewCIStr(name),
+		Columns: cols,
+		State:   model.StatePublic,
+		Primary: primary,
+	}
+	switch constraint.Tp {
+	case ast.ConstraintPrimaryKey, ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		idx.Unique = true
+	}
+	if opt := constraint.Option; opt != nil {
+		idx.Tp = opt.Tp
+		idx.Comment = opt.Comment
+		idx.Invisible = opt.Visibility == ast.IndexVisibilityInvisible
+	}
+	// the primary key comes first like MySQL.
+	if primary {
+		tbl.Indices = append([]*model.IndexInfo{idx}, tbl.Indices...)
+	} else {
+		tbl.Indices = append(tbl.Indices, idx)
+	}
+	updateColumns(tbl)
+	return nil
+}
+
+func indexColumns(tbl *model.TableInfo, keys []*ast.IndexPartSpecification) ([]*model.IndexColumn, error) {
+	cols := make([]*model.IndexColumn, 0, len(keys))
+	for _, key := range keys {
+		if key.Column == nil {
+			return nil, ErrNotSupportedYet.GenWithStackByArgs("index on expressions")
+		}
+		i := findColumn(tbl, key.Column.Name.L)
+		if i < 0 {
+			return nil, ErrKeyColumnDoesNotExist.GenWithStackByArgs(key.Column.Name.O)
+		}
+		length := key.Length
+		if length == 0 {
+			length = types.UnspecifiedLength
+		}
+		cols = append(cols, &model.IndexColumn{Name: tbl.Columns[i].Name, Offset: i, Length: length})
+	}
+	return cols, nil
+}
+
+// uniqueIndexName names an index after its first column like MySQL, adding a
+// suffix if the name is used.
+func uniqueIndexName(tbl *model.TableInfo, base string) string {
+	name := base
+	for i := 2; tbl.FindIndexByName(strings.ToLower(name)) != nil || strings.EqualFold(name, mysql.PrimaryKeyName); i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	return name
+}
+
+func findForeignKey(tbl *model.TableInfo, name string) int {
+	name = strings.ToLower(name)
+	for i, fk := range tbl.ForeignKeys {
+		if fk.Name.L == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func addForeignKey(tbl *model.TableInfo, constraint *ast.Constraint) error {
+	name := constraint.Name
+	if name == "" {
+		for i := len(tbl.ForeignKeys) + 1; name == "" || findForeignKey(tbl, name) >= 0; i++ {
+			name = fmt.Sprintf("%s_ibfk_%d", tbl.Name.O, i)
+		}
+	} else if findForeignKey(tbl, name) >= 0 {
+		return ErrFkDupName.GenWithStackByArgs(name)
+	}
+	cols, err := indexColumns(tbl, constraint.Keys)
+	if err != nil {
+		return err
+	}
+
+	fk := &model.FKInfo{
+		Name:     model.NewCIStr(name),
+		RefTable: constraint.Refer.Table.Name,
+		State:    model.StatePublic,
+	}
+	for _, fk2 := range tbl.ForeignKeys {
+		if fk2.ID > fk.ID {
+			fk.ID = fk2.ID
+		}
+	}
+	fk.ID++
+	for _, col := range cols {
+		fk.Cols = append(fk.Cols, col.Name)
+	}
+	for _, key := range constraint.Refer.IndexPartSpecifications {
+		if key.Column != nil {
+			fk.RefCols = append(fk.RefCols, key.Column.Name)
+		}
+	}
+	if constraint.Refer.OnDelete != nil {
+		fk.OnDelete = int(constraint.Refer.OnDelete.ReferOpt)
+	}
+	if constraint.Refer.OnUpdate != nil {
+		fk.OnUpdate = int(constraint.Refer.OnUpdate.ReferOpt)
+	}
+	tbl.ForeignKeys = append(tbl.ForeignKeys, fk)
+
+	// like MySQL, an index is added for the foreign key if no index starts with its columns.
+	for _, idx := range tbl.Indices {
+		if len(idx.Columns) < len(cols) {
+			continue
+		}
+		covered := true
+		for i, col := range cols {
+			if idx.Columns[i].Name.L != col.Name.L {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return nil
+		}
+	}
+	return addIndex(tbl, &ast.Constraint{Tp: ast.ConstraintIndex, Name: name, Keys: constraint.Keys})
+}
+
+func findCheck(tbl *model.TableInfo, name string) int {
+	name = strings.ToLower(name)
+	for i, constraint := range tbl.Constraints {
+		if constraint.Name.L == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func addCheck(tbl *model.TableInfo, constraint *ast.Constraint) error {
+	name := constraint.Name
+	if name == "" {
+		for i := len(tbl.Constraints) + 1; name == "" || findCheck(tbl, name) >= 0; i++ {
+			name = fmt.Sprintf("%s_chk_%d", tbl.Name.O, i)
+		}
+	} else if findCheck(tbl, name) >= 0 {
+		return ErrCheckConstraintDupName.GenWithStackByArgs(name)
+	}
+	text, err := restore(constraint.Expr)
+	if err != nil {
+		return err
+	}
+
+	tbl.MaxConstraintID++
+	check := &model.ConstraintInfo{
+		ID:         tbl.MaxConstraintID,
+		Name:       model.NewCIStr(name),
+		Enforced:   constraint.Enforced,
+		InColumn:   constraint.InColumn,
+		ExprString: text,
+		State:      model.StatePublic,
+	}
+	for _, cn := range astutil.ColumnNames(constraint.Expr) {
+		i := findColumn(tbl, cn.Name.L)
+		if i < 0 {
+			return ErrColumnNotExists.GenWithStackByArgs(cn.Name.O, "check constraint")
+		}
+		if !containsName(check.ConstraintCols, cn.Name) {
+			check.ConstraintCols = append(check.ConstraintCols, tbl.Columns[i].Name)
+		}
+	}
+	tbl.Constraints = append(tbl.Constraints, check)
+	return nil
+}
+
+func containsName(names []model.CIStr, name model.CIStr) bool {
+	for _, n := range names {
+		if n.L == name.L {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
+)
+
+// createView adds a view. Only the names of its columns are known, their types
+// are left unspecified.
+func (c *Catalog) createView(stmt *ast.CreateViewStmt) error {
+	db, err := c.schema(stmt.ViewName)
+	if err != nil {
+		return err
+	}
+	i := findTable(db, stmt.ViewName.Name.O)
+	if i >= 0 {
+		if !stmt.OrReplace {
+			return ErrTableExists.GenWithStackByArgs(stmt.ViewName.Name.O)
+		}
+		if !db.Tables[i].IsView() {
+			return ErrWrongObject.GenWithStackByArgs(db.Name.O, stmt.ViewName.Name.O, "VIEW")
+		}
+	}
+
+	names, err := c.resultNames(stmt.Select, nil)
+	if err != nil {
+		return err
+	}
+	if len(stmt.Cols) > 0 {
+		if len(stmt.Cols) != len(names) {
+			return ErrViewWrongList.GenWithStackByArgs()
+		}
+		names = stmt.Cols
+	}
+	text, err := restore(stmt.Select)
+	if err != nil {
+		return err
+	}
+
+	view := &model.TableInfo{
+		Name:    stmt.ViewName.Name,
+		Charset: db.Charset,
+		Collate: db.Collate,
+		State:   model.StatePublic,
+		View: &model.ViewInfo{
+			Algorithm:   stmt.Algorithm,
+			Definer:     stmt.Definer,
+			Security:    stmt.Security,
+			SelectStmt:  text,
+			CheckOption: stmt.CheckOption,
+			Cols:        stmt.Cols,
+		},
+	}
+	for _, name := range names {
+		if findColumn(view, name.L) >= 0 {
+			return ErrColumnExists.GenWithStackByArgs(name.O)
+		}
+		view.MaxColumnID++
+		view.Columns = append(view.Columns, &model.ColumnInfo{
+			ID:        view.MaxColumnID,
+			Name:      name,
+			Offset:    len(view.Columns),
+			FieldType: *types.NewFieldType(mysql.TypeUnspecified),
+			State:     model.StatePublic,
+		})
+	}
+	db = c.mutableDB(db)
+	if i >= 0 {
+		view.ID = db.Tables[i].ID
+		db.Tables[i] = view
+	} else {
+		view.ID = c.allocID()
+		db.Tables = append(db.Tables, view)
+	}
+	return nil
+}
+
+// resultNames returns the names of the columns of a result set. The wildcards are
+// expanded by the tables in the catalog and the common table expressions in ctes.
+func (c *Catalog) resultNames(n ast.Node, ctes map[string][]model.CIStr) ([]model.CIStr, error) {
+	switch x := n.(type) {
+	case *ast.SelectStmt:
+		return c.selectNames(x, ctes)
+	case *ast.SetOprStmt:
+		ctes, err := c.withNames(x.With, ctes)
+		if err != nil {
+			return nil, err
+		}
+		return c.resultNames(x.SelectList.Selects[0], ctes)
+	case *ast.SetOprSelectList:
+		return c.resultNames(x.Selects[0], ctes)
+	case *ast.TableName:
+		if names, ok := ctes[x.Name.L]; ok && x.Schema.L == "" {
+			return names, nil
+		}
+		db, i, err := c.lookupTable(x)
+		if err != nil {
+			return nil, err
+		}
+		var names []model.CIStr
+		for _, col := range db.Tables[i].Columns {
+			if !col.Hidden {
+				names = append(names, col.Name)
+			}
+		}
+		return names, nil
+	}
+	return nil, ErrNotSupportedYet.GenWithStackByArgs("the view on a result set like this")
+}
+
+func (c *Catalog) withNames(with *ast.WithClause, ctes map[string][]model.CIStr) (map[string][]model.CIStr, error) {
+	if with == nil {
+		return ctes, nil
+	}
+	scope := make(map[string][]model.CIStr, len(ctes)+len(with.CTEs))
+	for name, names := range ctes {
+		scope[name] = names
+	}
+	for _, cte := range with.CTEs {
+		names := cte.ColNameList
+		if len(names) == 0 {
+			var err error
+			if names, err = c.resultNames(cte.Query.Query, scope); err != nil {
+				return nil, err
+			}
+		}
+		scope[cte.Name.L] = names
+	}
+	return scope, nil
+}
+
+func (c *Catalog) selectNames(sel *ast.SelectStmt, ctes map[string][]model.CIStr) ([]model.CIStr, error) {
+	ctes, err := c.withNames(sel.With, ctes)
+	if err != nil {
+		return nil, err
+	}
+	var sources []*ast.TableSource
+	if sel.From != nil {
+		sources = tableSources(sel.From.TableRefs, sources)
+	}
+	if sel.Fields == nil {
+		return nil, ErrNotSupportedYet.GenWithStackByArgs("the view on a result set like this")
+	}
+
+	var names []model.CIStr
+	for _, field := range sel.Fields.Fields {
+		if field.WildCard == nil {
+			names = append(names, fieldName(field))
+			continue
+		}
+		matched := false
+		for _, src := range sources {
+			name := src.AsName
+			if tn, ok := src.Source.(*ast.TableName); ok && name.L == "" {
+				if field.WildCard.Schema.L != "" && field.WildCard.Schema.L != tn.Schema.L {
+					continue
+				}
+				name = tn.Name
+			}
+			if field.WildCard.Table.L != "" && field.WildCard.Table.L != name.L {
+				continue
+			}
+			srcNames, err := c.resultNames(src.Source, ctes)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, srcNames...)
+			matched = true
+		}
+		if !matched {
+			return nil, ErrTableDropExists.GenWithStackByArgs(field.WildCard.Table.O)
+		}
+	}
+	return names, nil
+}
+
+// tableSources appends the table sources of a FROM clause to sources in order.
+func tableSources(n ast.ResultSetNode, sources []*ast.TableSource) []*ast.TableSource {
+	switch x := n.(type) {
+	case *ast.Join:
+		sources = tableSources(x.Left, sources)
+		if x.Right != nil {
+			sources = tableSources(x.Right, sources)
+		}
+	case *ast.TableSource:
+		sources = append(sources, x)
+	}
+	return sources
+}
+
+// fieldName names a field like MySQL: by its alias, its column name or its text.
+func fieldName(field *ast.SelectField) model.CIStr {
+	if field.AsName.L != "" {
+		return field.AsName
+	}
+	if cn, ok := field.Expr.(*ast.ColumnNameExpr); ok {
+		return cn.Name.Name
+	}
+	if text := field.Text(); text != "" {
+		return model.NewCIStr(text)
+	}
+	text, _ := restore(field.Expr)
+	return model.NewCIStr(text)
+}
@@ -163,6 +163,36 @@ func (s *testCharsetSuite) TestGetCollationByName(c *C) {
 	c.Assert(err, ErrorMatches, "\\[ddl:1273\\]Unknown collation: 'non_exist'")
 }
 
+func (s *testCharsetSuite) TestMergeCollation(c *C) {
+	tests := []struct {
+		co1, co2 string
+		c1, c2   Coercibility
+		co       string
+		c        Coercibility
+		ok       bool
+	}{
+		{"utf8mb4_bin", "utf8mb4_bin", CoercibilityImplicit, CoercibilityCoercible, "utf8mb4_bin", CoercibilityImplicit, true},
+		{"utf8mb4_bin", "latin1_bin", CoercibilityCoercible, CoercibilityImplicit, "latin1_bin", CoercibilityImplicit, true},
+		{"utf8mb4_bin", "latin1_bin", CoercibilityImplicit, CoercibilityImplicit, "utf8mb4_bin", CoercibilityImplicit, true},
+		{"utf8mb4_bin", "binary", CoercibilityImplicit, CoercibilityImplicit, "binary", CoercibilityImplicit, true},
+		{"utf8mb4_bin", "utf8mb4_general_ci", CoercibilityImplicit, CoercibilityImplicit, "utf8mb4_bin", CoercibilityImplicit, true},
+		{"utf8mb4_unicode_ci", "utf8mb4_general_ci", CoercibilityImplicit, CoercibilityImplicit, "utf8mb4_bin", CoercibilityNone, true},
+		{"utf8mb4_unicode_ci", "utf8mb4_general_ci", CoercibilityExplicit, CoercibilityExplicit, "", CoercibilityNone, false},
+		{"utf8mb4_bin", "latin1_bin", CoercibilityIgnorable, CoercibilityCoercible, "latin1_bin", CoercibilityCoercible, true},
+		{"latin1_bin", "ascii_bin", CoercibilityImplicit, CoercibilityImplicit, "", CoercibilityNone, false},
+	}
+	for _, tt := range tests {
+		co, coer, ok := MergeCollation(tt.co1, tt.c1, tt.co2, tt.c2)
+		comment := Commentf("%s %s, %s %s", tt.co1, tt.c1, tt.co2, tt.c2)
+		c.Assert(ok, Equals, tt.ok, comment)
+		if ok {
+			c.Assert(co, Equals, tt.co, comment)
+			c.Assert(coer, Equals, tt.c, comment)
+		}
+	}
+	c.Assert(CoercibilityNumeric.String(), Equals, "NUMERIC")
+}
+
 func BenchmarkGetCharsetDesc(b *testing.B) {
 	b.ResetTimer()
 	charsets := []string{CharsetUTF8, CharsetUTF8MB4, CharsetASCII, CharsetLatin1, CharsetBin}
@@ -0,0 +1,156 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charset
+
+import "strings"
+
+// Coercibility is the coercibility of the collation of a string, which decides the
+// collation of an operation on strings of different collations. The lower one wins.
+// See https://dev.mysql.com/doc/refman/8.0/en/charset-collation-coercibility.html
+type Coercibility int
+
+const (
+	// CoercibilityExplicit is of the strings with a COLLATE clause.
+	CoercibilityExplicit Coercibility = iota
+	// CoercibilityNone is of the concatenation of strings with different collations.
+	CoercibilityNone
+	// CoercibilityImplicit is of the columns and the local variables.
+	CoercibilityImplicit
+	// CoercibilitySysconst is of the system constants like USER() and VERSION().
+	CoercibilitySysconst
+	// CoercibilityCoercible is of the literals.
+	CoercibilityCoercible
+	// CoercibilityNumeric is of the numeric and temporal values.
+	CoercibilityNumeric
+	// CoercibilityIgnorable is of NULL and the expressions derived from NULL.
+	CoercibilityIgnorable
+)
+
+var coercibilityNames = [...]string{
+	CoercibilityExplicit:  "EXPLICIT",
+	CoercibilityNone:      "NONE",
+	CoercibilityImplicit:  "IMPLICIT",
+	CoercibilitySysconst:  "SYSCONST",
+	CoercibilityCoercible: "COERCIBLE",
+	CoercibilityNumeric:   "NUMERIC",
+	CoercibilityIgnorable: "IGNORABLE",
+}
+
+// String implements fmt.Stringer interface, it's the name used in the error messages of MySQL.
+func (c Coercibility) String() string {
+	if c < 0 || int(c) >= len(coercibilityNames) {
+		return "UNKNOWN"
+	}
+	return coercibilityNames[c]
+}
+
+// MergeCollation returns the collation and the coercibility of an operation on two
+// strings, following the rules of MySQL. ok is false if they can't be merged, which
+// is an illegal mix of collations.
+//
+// The result of two strings of the same charset and the same coercibility but
+// different collations is the binary collation of the charset with CoercibilityNone,
+// which can be concatenated but not compared.
+func MergeCollation(co1 string, c1 Coercibility, co2 string, c2 Coercibility) (co string, c Coercibility, ok bool) {
+	co1, co2 = strings.ToLower(co1), strings.ToLower(co2)
+	if co1 == co2 {
+		return co1, minCoercibility(c1, c2), true
+	}
+	cs1, cs2 := collationCharset(co1), collationCharset(co2)
+	if c1 == CoercibilityIgnorable {
+		return co2, c2, true
+	}
+	if c2 == CoercibilityIgnorable {
+		return co1, c1, true
+	}
+
+	if cs1 != cs2 {
+		// the binary strings win over the others of the same coercibility.
+		switch {
+		case c1 < c2:
+			return co1, c1, true
+		case c2 < c1:
+			return co2, c2, true
+		case cs1 == CharsetBin:
+			return co1, c1, true
+		case cs2 == CharsetBin:
+			return co2, c2, true
+		case isSuperset(cs1, cs2):
+			return co1, c1, true
+		case isSuperset(cs2, cs1):
+			return co2, c2, true
+		}
+		return "", CoercibilityNone, false
+	}
+
+	switch {
+	case c1 < c2:
+		return co1, c1, true
+	case c2 < c1:
+		return co2, c2, true
+	case c1 == CoercibilityExplicit:
+		return "", CoercibilityNone, false
+	case isBinCollation(co1):
+		return co1, c1, true
+	case isBinCollation(co2):
+		return co2, c2, true
+	}
+	return binCollation(cs1), CoercibilityNone, true
+}
+
+func minCoercibility(c1, c2 Coercibility) Coercibility {
+	if c1 < c2 {
+		return c1
+	}
+	return c2
+}
+
+func collationCharset(co string) string {
+	if co == CollationBin {
+		return CharsetBin
+	}
+	if collation, ok := collationsNameMap[co]; ok {
+		return collation.CharsetName
+	}
+	if i := strings.IndexByte(co, '_'); i > 0 {
+		return co[:i]
+	}
+	return co
+}
+
+func isBinCollation(co string) bool {
+	return co == CollationBin || strings.HasSuffix(co, "_bin")
+}
+
+func binCollation(cs string) string {
+	if cs == CharsetBin {
+		return CollationBin
+	}
+	return cs + "_bin"
+}
+
+// supersets are the unicode charsets, which win over the others of the same coercibility.
+var supersets = map[string][]string{
+	CharsetUTF8MB4: {CharsetUTF8, CharsetLatin1, CharsetASCII},
+	CharsetUTF8:    {CharsetLatin1, CharsetASCII},
+}
+
+func isSuperset(cs1, cs2 string) bool {
+	for _, cs := range supersets[cs1] {
+		if cs == cs2 {
+			return true
+		}
+	}
+	return false
+}
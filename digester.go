@@ -75,6 +75,167 @@ func NormalizeDigest(sql string) (normalized, digest string) {
 	return
 }
 
+// DigestOptions controls how statements are normalized. The zero value normalizes like Normalize.
+type DigestOptions struct {
+	// KeepHints keeps the optimizer hints, the index hints and STRAIGHT_JOIN.
+	KeepHints bool
+	// KeepComments keeps the comments, line comments are turned into /* */ comments.
+	KeepComments bool
+	// KeepCase keeps the case of keywords and identifiers.
+	KeepCase bool
+	// KeepLists keeps the "?, ?, ?" lists instead of collapsing them into "...".
+	KeepLists bool
+	// KeepLimit keeps the numbers of LIMIT and OFFSET instead of replacing them with "?".
+	KeepLimit bool
+	// ReducePositions replaces the positions of ORDER BY and GROUP BY with "?".
+	ReducePositions bool
+	// StripSchema removes the schema qualifiers of table names, e.g. "db.t" => "t".
+	StripSchema bool
+	// CollapseValues collapses the rows of VALUES which are the same after normalization,
+	// e.g. "values (1, 2), (3, 4)" => "values ( ... )".
+	CollapseValues bool
+}
+
+// NormalizeWithOptions is like Normalize, but normalizes the statement as opts says.
+func NormalizeWithOptions(sql string, opts DigestOptions) (result string) {
+	d := digesterPool.Get().(*sqlDigester)
+	d.opts = opts
+	result = d.doNormalize(sql)
+	d.opts = DigestOptions{}
+	digesterPool.Put(d)
+	return
+}
+
+// NormalizeDigestWithOptions is like NormalizeDigest, but normalizes the statement as opts says.
+func NormalizeDigestWithOptions(sql string, opts DigestOptions) (normalized, digest string) {
+	d := digesterPool.Get().(*sqlDigester)
+	d.opts = opts
+	normalized, digest = d.doNormalizeDigest(sql)
+	d.opts = DigestOptions{}
+	digesterPool.Put(d)
+	return
+}
+
+// NormalizeParams is like Normalize, but also returns the literals removed from the statement.
+// There is one Param for each "?" or "..." in the normalized statement, in order.
+//
+// for example: NormalizeParams('select 1 from b where a in (1, 2)') => 'select ? from `b` where `a` in ( ... )', [[1] [1 2]]
+func NormalizeParams(sql string) (normalized string, params []Param) {
+	d := digesterPool.Get().(*sqlDigester)
+	normalized, params = d.doNormalizeParams(sql)
+	digesterPool.Put(d)
+	return
+}
+
+// InjectParams replaces the "?" and "..." placeholders of a statement normalized by NormalizeParams with params.
+// A "..." is replaced by the comma separated literals of its Param.
+//
+// for example: InjectParams('select ? from `b` where `a` in ( ... )', [[1] [1 2]]) => 'select 1 from `b` where `a` in ( 1, 2 )'
+func InjectParams(normalized string, params []Param) (string, error) {
+	var sb strings.Builder
+	n := 0
+	for i := 0; i < len(normalized); {
+		// copy quoted identifiers as is, they may contain anything.
+		if normalized[i] == '`' {
+			end := strings.IndexByte(normalized[i+1:], '`')
+			if end < 0 {
+				sb.WriteString(normalized[i:])
+				break
+			}
+			sb.WriteString(normalized[i : i+end+2])
+			i += end + 2
+			continue
+		}
+		end := strings.IndexAny(normalized[i:], " `")
+		if end < 0 {
+			end = len(normalized)
+		} else {
+			end += i
+		}
+		word := normalized[i:end]
+		if word == "?" || word == "..." {
+			if n >= len(params) || len(params[n].Literals) == 0 || (word == "?" && len(params[n].Literals) > 1) {
+				return "", ErrWrongArguments.GenWithStackByArgs("InjectParams")
+			}
+			for j, lit := range params[n].Literals {
+				if j > 0 {
+					sb.WriteString(", ")
+				}
+				sb.WriteString(lit.Text)
+			}
+			n++
+		} else {
+			sb.WriteString(word)
+		}
+		if end < len(normalized) && normalized[end] == ' ' {
+			sb.WriteByte(' ')
+			end++
+		}
+		i = end
+	}
+	if n != len(params) {
+		return "", ErrWrongArguments.GenWithStackByArgs("InjectParams")
+	}
+	return sb.String(), nil
+}
+
+// LiteralType is the type of a literal removed by NormalizeParams.
+type LiteralType int
+
+// LiteralType types.
+const (
+	LiteralInt LiteralType = iota
+	LiteralDecimal
+	LiteralFloat
+	LiteralHex
+	LiteralBit
+	LiteralString
+	LiteralNull
+	LiteralParamMarker
+	LiteralStar
+)
+
+var literalTypeNames = []string{
+	LiteralInt:         "int",
+	LiteralDecimal:     "decimal",
+	LiteralFloat:       "float",
+	LiteralHex:         "hex",
+	LiteralBit:         "bit",
+	LiteralString:      "string",
+	LiteralNull:        "null",
+	LiteralParamMarker: "param marker",
+	LiteralStar:        "star",
+}
+
+// String implements fmt.Stringer interface.
+func (t LiteralType) String() string {
+	if t >= 0 && int(t) < len(literalTypeNames) {
+		return literalTypeNames[t]
+	}
+	return "unknown"
+}
+
+// Literal is a literal removed from a statement by NormalizeParams.
+type Literal struct {
+	Type LiteralType
+	// Text is the literal as written in the statement, including quotes and unary sign,
+	// and the blanks between them, e.g. "- 1".
+	Text string
+	// Offset is the byte offset of Text in the statement.
+	Offset int
+}
+
+// Param is the value of a "?" or "..." placeholder in a normalized statement.
+type Param struct {
+	// Literals holds one literal for "?", and all the elements of the collapsed list for "...".
+	Literals []Literal
+}
+
+// Count returns the number of values the placeholder stands for.
+func (p Param) Count() int {
+	return len(p.Literals)
+}
+
 var digesterPool = sync.Pool{
 	New: func() interface{} {
 		return &sqlDigester{
@@ -90,6 +251,18 @@ type sqlDigester struct {
 	lexer  *Scanner
 	hasher hash2.Hash
 	tokens tokenDeque
+	// params is set if the literals are to be kept, see NormalizeParams.
+	params bool
+	opts   DigestOptions
+
+	// inTableRef is set after the keywords which are followed by table names, see DigestOptions.StripSchema.
+	inTableRef bool
+	// the state of the VALUES rows, see DigestOptions.CollapseValues.
+	inValues     bool
+	valuesDepth  int
+	rowStart     int
+	prevRowStart int
+	prevRowEnd   int
 }
 
 func (d *sqlDigester) doDigestNormalized(normalized string) (result string) {
@@ -121,6 +294,19 @@ func (d *sqlDigester) doNormalize(sql string) (result string) {
 	return
 }
 
+func (d *sqlDigester) doNormalizeParams(sql string) (normalized string, params []Param) {
+	d.params = true
+	for _, token := range d.normalize(sql) {
+		if token.tok == genericSymbol || token.tok == genericSymbolList {
+			params = append(params, Param{Literals: token.lits})
+		}
+	}
+	d.params = false
+	normalized = d.buffer.String()
+	d.buffer.Reset()
+	return
+}
+
 func (d *sqlDigester) doNormalizeDigest(sql string) (normalized, digest string) {
 	d.normalize(sql)
 	normalized = d.buffer.String()
@@ -138,12 +324,19 @@ const (
 	// genericSymbolList presents parameter holder lists ("?, ?, ...") in statement
 	// it can be any value as long as it is not repeated with other tokens.
 	genericSymbolList = -2
+	// commentSymbol presents the comments kept by DigestOptions.
+	commentSymbol = -3
 )
 
-func (d *sqlDigester) normalize(sql string) {
+// normalize writes the normalized sql to d.buffer, and returns the tokens written if d.params is set.
+func (d *sqlDigester) normalize(sql string) (tokens []token) {
 	d.lexer.reset(sql)
+	d.lexer.keepComments = d.opts.KeepHints || d.opts.KeepComments
+	d.inTableRef, d.inValues = false, false
+	comments := 0
 	for {
 		tok, pos, lit := d.lexer.scan()
+		comments = d.pushComments(sql, comments)
 		if tok == invalid {
 			break
 		}
@@ -153,12 +346,19 @@ func (d *sqlDigester) normalize(sql string) {
 		if pos.Offset == len(sql) {
 			break
 		}
-		currTok := token{tok, strings.ToLower(lit)}
+		currTok := token{tok: tok, lit: strings.ToLower(lit)}
+		if d.opts.KeepCase {
+			currTok.orig = lit
+		}
 
-		if d.reduceOptimizerHint(&currTok) {
+		if !d.opts.KeepHints && d.reduceOptimizerHint(&currTok) {
 			continue
 		}
 
+		if d.params {
+			currTok.offset = pos.Offset
+			currTok.text = sql[pos.Offset:d.lexer.r.pos().Offset]
+		}
 		d.reduceLit(&currTok)
 
 		if currTok.tok == identifier {
@@ -175,27 +375,150 @@ func (d *sqlDigester) normalize(sql string) {
 			}
 		}
 	APPEND:
+		if d.opts.StripSchema {
+			d.reduceSchema(currTok)
+		}
+		if d.opts.CollapseValues && d.reduceValues(currTok) {
+			continue
+		}
 		d.tokens.pushBack(currTok)
 	}
+	d.pushComments(sql, comments)
+	d.lexer.keepComments = false
 	d.lexer.reset("")
 	for i, token := range d.tokens {
 		if i > 0 {
 			d.buffer.WriteRune(' ')
 		}
+		lit := token.lit
+		if token.orig != "" && token.tok != genericSymbol && token.tok != genericSymbolList {
+			lit = token.orig
+		}
 		if token.tok == singleAtIdentifier {
 			d.buffer.WriteString("@")
-			d.buffer.WriteString(token.lit)
+			d.buffer.WriteString(lit)
 		} else if token.tok == underscoreCS {
 			d.buffer.WriteString("(_charset)")
 		} else if token.tok == identifier || token.tok == quotedIdentifier {
 			d.buffer.WriteByte('`')
-			d.buffer.WriteString(token.lit)
+			d.buffer.WriteString(lit)
 			d.buffer.WriteByte('`')
 		} else {
-			d.buffer.WriteString(token.lit)
+			d.buffer.WriteString(lit)
 		}
 	}
+	if d.params {
+		tokens = append(tokens, d.tokens...)
+	}
 	d.tokens.reset()
+	return
+}
+
+// pushComments pushes the comments scanned since the first n ones, if they are kept by the options.
+func (d *sqlDigester) pushComments(sql string, n int) int {
+	for _, c := range d.lexer.comments[n:] {
+		text := sql[c.start.Offset:c.end.Offset]
+		if strings.HasPrefix(text, "/*+") {
+			if !d.opts.KeepHints {
+				continue
+			}
+		} else if !d.opts.KeepComments {
+			continue
+		}
+		// "-- x" or "# x" => "/* x */"
+		if strings.HasPrefix(text, "#") {
+			text = "/* " + strings.TrimSpace(text[1:]) + " */"
+		} else if strings.HasPrefix(text, "--") {
+			text = "/* " + strings.TrimSpace(text[2:]) + " */"
+		}
+		d.tokens.pushBack(token{tok: commentSymbol, lit: text})
+	}
+	return len(d.lexer.comments)
+}
+
+// reduceSchema removes the schema before currTok in "db . t" table names and "db . t . c" column names.
+func (d *sqlDigester) reduceSchema(currTok token) {
+	defer func() {
+		switch {
+		case currTok.lit == "from" || currTok.lit == "join" || currTok.lit == "into" || currTok.lit == "update" ||
+			currTok.lit == "table" || currTok.lit == "tables":
+			d.inTableRef = true
+		case isName(currTok) || currTok.lit == "." || currTok.lit == "," || currTok.lit == "as":
+		default:
+			d.inTableRef = false
+		}
+	}()
+
+	// "db . t . c" => "t . c"
+	last := d.tokens.back(4)
+	if len(last) == 4 && isName(last[0]) && last[1].lit == "." && isName(last[2]) && last[3].lit == "." {
+		t, dot := last[2], last[3]
+		d.tokens.popBack(4)
+		d.tokens.pushBack(t)
+		d.tokens.pushBack(dot)
+		return
+	}
+	// "from db . t" => "from t"
+	last = d.tokens.back(2)
+	if d.inTableRef && len(last) == 2 && isName(last[0]) && last[1].lit == "." {
+		if before := d.tokens.back(3); len(before) < 3 || before[0].lit != "." {
+			d.tokens.popBack(2)
+		}
+	}
+}
+
+func isName(t token) bool {
+	return t.tok == identifier || t.tok == quotedIdentifier
+}
+
+// reduceValues drops currTok and the row it ends if the row is the same as the previous one in VALUES.
+func (d *sqlDigester) reduceValues(currTok token) (reduced bool) {
+	if currTok.lit == "values" || currTok.lit == "value" {
+		d.inValues, d.valuesDepth, d.prevRowStart = true, 0, -1
+		return
+	}
+	if !d.inValues {
+		return
+	}
+	switch currTok.lit {
+	case "(":
+		if d.valuesDepth == 0 {
+			d.rowStart = len(d.tokens)
+		}
+		d.valuesDepth++
+	case ")":
+		d.valuesDepth--
+		if d.valuesDepth > 0 {
+			return
+		}
+		d.tokens.pushBack(currTok)
+		row := d.tokens[d.rowStart:]
+		if d.prevRowStart >= 0 && sameTokens(d.tokens[d.prevRowStart:d.prevRowEnd], row) {
+			// drop the row and the comma before it.
+			d.tokens.popBack(len(row) + 1)
+		} else {
+			d.prevRowStart, d.prevRowEnd = d.rowStart, len(d.tokens)
+		}
+		reduced = true
+	case ",":
+	default:
+		if d.valuesDepth == 0 {
+			d.inValues = false
+		}
+	}
+	return
+}
+
+func sameTokens(a, b []token) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].tok != b[i].tok || a[i].lit != b[i].lit {
+			return false
+		}
+	}
+	return true
 }
 
 func (d *sqlDigester) reduceOptimizerHint(tok *token) (reduced bool) {
@@ -229,6 +552,7 @@ func (d *sqlDigester) reduceOptimizerHint(tok *token) (reduced bool) {
 	// ignore straight_join
 	if tok.lit == "straight_join" {
 		tok.lit = "join"
+		tok.orig = ""
 		return
 	}
 	return
@@ -241,6 +565,9 @@ func (d *sqlDigester) reduceLit(currTok *token) {
 	// count(*) => count(?)
 	if currTok.lit == "*" {
 		if d.isStarParam() {
+			if d.params {
+				currTok.lits = []Literal{d.literal(*currTok)}
+			}
 			currTok.tok = genericSymbol
 			currTok.lit = "?"
 		}
@@ -249,12 +576,20 @@ func (d *sqlDigester) reduceLit(currTok *token) {
 
 	// "-x" or "+x" => "x"
 	if d.isPrefixByUnary(currTok.tok) {
-		d.tokens.popBack(1)
+		sign := d.tokens.popBack(1)
+		if d.params {
+			// the text runs from the sign, including the blanks after it like in "- 1".
+			currTok.text = d.lexer.r.s[sign[0].offset : currTok.offset+len(currTok.text)]
+			currTok.offset = sign[0].offset
+		}
 	}
 
 	// "?, ?, ?, ?" => "..."
 	last2 := d.tokens.back(2)
-	if d.isGenericList(last2) {
+	if !d.opts.KeepLists && d.isGenericList(last2) {
+		if d.params {
+			currTok.lits = append(last2[0].lits, d.literal(*currTok))
+		}
 		d.tokens.popBack(2)
 		currTok.tok = genericSymbolList
 		currTok.lit = "..."
@@ -263,16 +598,51 @@ func (d *sqlDigester) reduceLit(currTok *token) {
 
 	// order by n => order by n
 	if currTok.tok == intLit {
-		if d.isOrderOrGroupBy() {
+		if !d.opts.ReducePositions && d.isOrderOrGroupBy() {
+			return
+		}
+		// limit n => limit n
+		if d.opts.KeepLimit && d.isLimit() {
 			return
 		}
 	}
 
 	// 2 => ?
+	if d.params {
+		currTok.lits = []Literal{d.literal(*currTok)}
+	}
 	currTok.tok = genericSymbol
 	currTok.lit = "?"
 }
 
+// literal returns the Literal of a literal token which is not reduced yet.
+func (d *sqlDigester) literal(t token) Literal {
+	lit := Literal{Text: t.text, Offset: t.offset}
+	switch t.tok {
+	case intLit:
+		lit.Type = LiteralInt
+	case decLit:
+		lit.Type = LiteralDecimal
+	case floatLit:
+		lit.Type = LiteralFloat
+	case hexLit:
+		lit.Type = LiteralHex
+	case bitLit:
+		lit.Type = LiteralBit
+	case stringLit:
+		lit.Type = LiteralString
+	case paramMarker:
+		lit.Type = LiteralParamMarker
+	default:
+		if t.lit == "*" {
+			lit.Type = LiteralStar
+		} else {
+			lit.Type = LiteralNull
+		}
+	}
+	return lit
+}
+
 func (d *sqlDigester) isPrefixByUnary(currTok int) (isUnary bool) {
 	if !d.isNumLit(currTok) {
 		return
@@ -345,6 +715,17 @@ func (d *sqlDigester) isOrderOrGroupBy() (orderOrGroupBy bool) {
 	return
 }
 
+func (d *sqlDigester) isLimit() (limit bool) {
+	last := d.tokens.back(1)
+	if len(last) == 1 && (last[0].lit == "limit" || last[0].lit == "offset") {
+		return true
+	}
+	// limit m, n
+	last = d.tokens.back(3)
+	limit = len(last) == 3 && last[0].lit == "limit" && last[1].tok == intLit && d.isComma(last[2])
+	return
+}
+
 func (d *sqlDigester) isStarParam() (starParam bool) {
 	last := d.tokens.back(1)
 	if last == nil {
@@ -384,6 +765,12 @@ func (d *sqlDigester) isComma(tok token) (isComma bool) {
 type token struct {
 	tok int
 	lit string
+	// orig is the literal before lowercased, it's only set for DigestOptions.KeepCase.
+	orig string
+	// the fields below are only set if the literals are kept, see NormalizeParams.
+	offset int
+	text   string
+	lits   []Literal
 }
 
 type tokenDeque []token
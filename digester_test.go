@@ -14,8 +14,11 @@
 package parser_test
 
 import (
+	"strings"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/terror"
 )
 
 var _ = Suite(&testSQLDigestSuite{})
@@ -95,6 +98,100 @@ func (s *testSQLDigestSuite) TestNormalizeDigest(c *C) {
 	}
 }
 
+func (s *testSQLDigestSuite) TestNormalizeWithOptions(c *C) {
+	tests := []struct {
+		sql    string
+		opts   parser.DigestOptions
+		expect string
+	}{
+		{"select /*+ use_index(t, i) */ * from t straight_join s force index (i)", parser.DigestOptions{}, "select * from `t` join `s`"},
+		{"select /*+ use_index(t, i) */ * from t straight_join s force index (i)", parser.DigestOptions{KeepHints: true}, "select /*+ use_index(t, i) */ * from `t` straight_join `s` force index ( `i` )"},
+		{"select /* c1 */ 1 -- c2\n from t # c3", parser.DigestOptions{}, "select ? from `t`"},
+		{"select /* c1 */ 1 -- c2\n from t # c3", parser.DigestOptions{KeepComments: true}, "select /* c1 */ ? /* c2 */ from `t` /* c3 */"},
+		{"select /*+ hint */ 1 /* c */", parser.DigestOptions{KeepComments: true}, "select ? /* c */"},
+		{"SELECT A FROM T", parser.DigestOptions{KeepCase: true}, "SELECT `A` FROM `T`"},
+		{"select * from t where a in (1, 2)", parser.DigestOptions{KeepLists: true}, "select * from `t` where `a` in ( ? , ? )"},
+		{"select * from t limit 10", parser.DigestOptions{KeepLimit: true}, "select * from `t` limit 10"},
+		{"select * from t limit 10, 20", parser.DigestOptions{KeepLimit: true}, "select * from `t` limit 10 , 20"},
+		{"select * from t limit 10 offset 20", parser.DigestOptions{KeepLimit: true}, "select * from `t` limit 10 offset 20"},
+		{"select * from t where a = 1 limit 10", parser.DigestOptions{}, "select * from `t` where `a` = ? limit ?"},
+		{"select a, b from t order by 1, 2", parser.DigestOptions{ReducePositions: true}, "select `a` , `b` from `t` order by ..."},
+		{"select db.t.a, t.b from db.t join `other`.s on t.a = s.a", parser.DigestOptions{StripSchema: true}, "select `t` . `a` , `t` . `b` from `t` join `s` on `t` . `a` = `s` . `a`"},
+		{"update db.t set a = 1", parser.DigestOptions{StripSchema: true}, "update `t` set `a` = ?"},
+		{"insert into t values (1, 'a'), (2, 'b'), (3, now())", parser.DigestOptions{CollapseValues: true}, "insert into `t` values ( ... ) , ( ? , now ( ) )"},
+		{"insert into t values (1), (2) on duplicate key update a = values(a)", parser.DigestOptions{CollapseValues: true}, "insert into `t` values ( ? ) on duplicate key update `a` = values ( `a` )"},
+		{"insert into t values (1), (2)", parser.DigestOptions{}, "insert into `t` values ( ? ) , ( ? )"},
+	}
+	for _, test := range tests {
+		comment := Commentf("sql: %s, opts: %+v", test.sql, test.opts)
+		normalized := parser.NormalizeWithOptions(test.sql, test.opts)
+		c.Assert(normalized, Equals, test.expect, comment)
+
+		normalized2, digest := parser.NormalizeDigestWithOptions(test.sql, test.opts)
+		c.Assert(normalized2, Equals, normalized, comment)
+		c.Assert(digest, Equals, parser.DigestNormalized(normalized), comment)
+	}
+	// the options don't leak into the pooled digester.
+	c.Assert(parser.Normalize("SELECT A FROM T"), Equals, "select `a` from `t`")
+}
+
+// params formats the params like "int:1 string:'a'", with the literals of a "..." joined by ",".
+func params(ps []parser.Param) string {
+	var list []string
+	for _, p := range ps {
+		var lits []string
+		for _, lit := range p.Literals {
+			lits = append(lits, lit.Type.String()+":"+lit.Text)
+		}
+		list = append(list, strings.Join(lits, ","))
+	}
+	return strings.Join(list, " ")
+}
+
+func (s *testSQLDigestSuite) TestNormalizeParams(c *C) {
+	tests := []struct {
+		sql        string
+		normalized string
+		params     string
+		injected   string
+	}{
+		{"SELECT 1", "select ?", "int:1", "select 1"},
+		{"select * from b where id = 'a''b' and c is null", "select * from `b` where `id` = ? and `c` is ?", "string:'a''b' null:null", "select * from `b` where `id` = 'a''b' and `c` is null"},
+		{"select 1 from b where id in (1, 3, '3', -4)", "select ? from `b` where `id` in ( ... )", "int:1 int:1,int:3,string:'3',int:-4", "select 1 from `b` where `id` in ( 1, 3, '3', -4 )"},
+		{"select 1 from b where id in (1, a, 4)", "select ? from `b` where `id` in ( ? , `a` , ? )", "int:1 int:1 int:4", "select 1 from `b` where `id` in ( 1 , `a` , 4 )"},
+		{"select -1.5 + 2e3, 0x1f, b'01' from c order by 2", "select ? + ... from `c` order by 2", "decimal:-1.5 float:2e3,hex:0x1f,bit:b'01'", "select -1.5 + 2e3, 0x1f, b'01' from `c` order by 2"},
+		{"select count(*) from `t ?` limit ?, ?", "select count ( ? ) from `t ?` limit ...", "star:* param marker:?,param marker:?", "select count ( * ) from `t ?` limit ?, ?"},
+		{"select a from t", "select `a` from `t`", "", "select `a` from `t`"},
+		{"select a from t where a = - 1 and b = +  2.5", "select `a` from `t` where `a` = ? and `b` = ?", "int:- 1 decimal:+  2.5", "select `a` from `t` where `a` = - 1 and `b` = +  2.5"},
+	}
+	for _, test := range tests {
+		comment := Commentf("sql: %s", test.sql)
+		normalized, ps := parser.NormalizeParams(test.sql)
+		c.Assert(normalized, Equals, test.normalized, comment)
+		c.Assert(normalized, Equals, parser.Normalize(test.sql), comment)
+		c.Assert(params(ps), Equals, test.params, comment)
+		for _, p := range ps {
+			for _, lit := range p.Literals {
+				c.Assert(test.sql[lit.Offset:lit.Offset+len(lit.Text)], Equals, lit.Text, comment)
+			}
+		}
+		injected, err := parser.InjectParams(normalized, ps)
+		c.Assert(err, IsNil, comment)
+		c.Assert(injected, Equals, test.injected, comment)
+	}
+
+	_, ps := parser.NormalizeParams("select 1 from t where a in (1, 2, 3)")
+	c.Assert(ps, HasLen, 2)
+	c.Assert(ps[1].Count(), Equals, 3)
+
+	_, err := parser.InjectParams("select ? , ?", ps[:1])
+	c.Assert(terror.ErrorEqual(err, parser.ErrWrongArguments), IsTrue)
+	_, err = parser.InjectParams("select ?", ps)
+	c.Assert(terror.ErrorEqual(err, parser.ErrWrongArguments), IsTrue)
+	_, err = parser.InjectParams("select ?", ps[1:])
+	c.Assert(terror.ErrorEqual(err, parser.ErrWrongArguments), IsTrue)
+}
+
 func (s *testSQLDigestSuite) TestDigestHashEqForSimpleSQL(c *C) {
 	sqlGroups := [][]string{
 		{"select * from b where id = 1", "select * from b where id = '1'", "select * from b where id =2"},
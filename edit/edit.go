@@ -0,0 +1,393 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package edit turns the changes made to an AST into text edits of the SQL it's
+// parsed from, so that the text which isn't changed stays byte-for-byte the same.
+//
+// The nodes are recorded by Record before the AST is modified. Edits then compares
+// the AST with the recording: a node which restores to the same text is kept as it
+// is in the source, and a changed node is replaced by its restored text, unless the
+// changes are in its children only, in which case the children are compared instead,
+// or the changes are next to some of its children, in which case only the source of
+// those children is replaced.
+package edit
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+)
+
+// Edit replaces the bytes of the source from Start to End with Text.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
+// Apply applies the edits to sql. The edits can be in any order, but they can't overlap.
+func Apply(sql string, edits []Edit) (string, error) {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+	var sb strings.Builder
+	last := 0
+	for _, e := range sorted {
+		if e.Start < last || e.End < e.Start || e.End > len(sql) {
+			return "", errors.Errorf("invalid edit [%d, %d) of %d bytes, after %d", e.Start, e.End, len(sql), last)
+		}
+		sb.WriteString(sql[last:e.Start])
+		sb.WriteString(e.Text)
+		last = e.End
+	}
+	sb.WriteString(sql[last:])
+	return sb.String(), nil
+}
+
+// Recording is the state of an AST before it's modified.
+type Recording struct {
+	root  ast.Node
+	sql   string
+	flags format.RestoreFlags
+	nodes map[ast.Node]*recordedNode
+}
+
+type recordedNode struct {
+	text     string
+	children []ast.Node
+	// start and end are the offsets of the node in the source, end is 0 if they are unknown
+	// or if the source there doesn't match text.
+	start, end int
+}
+
+// Record records root and its descendants, sql is the source root is parsed from and
+// the changed nodes are restored with flags. The positions of the nodes must be the
+// ones set by the parser, a node whose source doesn't match its text isn't edited
+// in place.
+func Record(root ast.Node, sql string, flags format.RestoreFlags) (*Recording, error) {
+	r := &Recording{root: root, sql: sql, flags: flags, nodes: make(map[ast.Node]*recordedNode)}
+	text, err := restore(root, flags)
+	if err != nil {
+		return nil, err
+	}
+	r.record(root, text)
+	return r, nil
+}
+
+func (r *Recording) record(n ast.Node, text string) {
+	rn := &recordedNode{text: text, children: children(n)}
+	if end := n.EndPos(); end != (ast.Pos{}) && matches(r.sql, n.StartPos().Offset, end.Offset, text) {
+		rn.start, rn.end = n.StartPos().Offset, end.Offset
+	}
+	r.nodes[n] = rn
+	for _, child := range rn.children {
+		// some nodes can't be restored on their own, they are compared by their parents.
+		if text, err := restore(child, r.flags); err == nil {
+			r.record(child, text)
+		}
+	}
+}
+
+// matches tells whether the source from start to end can be the source of a node
+// restored to text. The restored text differs from the source by the case, the quotes
+// and the synonyms, e.g. IN for FROM in SHOW TABLES, so only the first and the last
+// normalized tokens are compared.
+func matches(sql string, start, end int, text string) bool {
+	if start < 0 || end <= start || end > len(sql) {
+		return false
+	}
+	source, restored := tokens(sql[start:end]), tokens(text)
+	if len(source) == 0 || len(restored) == 0 {
+		return false
+	}
+	return source[0] == restored[0] && source[len(source)-1] == restored[len(restored)-1]
+}
+
+// tokens returns the normalized tokens of sql. The charsets of the strings are removed
+// as Restore writes the ones which are implicit in the source, and a list of values,
+// which Normalize may shorten to "...", is compared as a single value.
+func tokens(sql string) []string {
+	normalized := strings.ReplaceAll(parser.Normalize(sql), "(_charset) ", "")
+	return strings.Fields(strings.ReplaceAll(normalized, "...", "?"))
+}
+
+// Edits returns the edits which turn the source of the recorded AST into the source of root,
+// which is the recorded root or the node replacing it.
+func (r *Recording) Edits(root ast.Node) ([]Edit, error) {
+	rn := r.nodes[r.root]
+	if rn.end == 0 {
+		return nil, errors.New("the position of the recorded root is unknown")
+	}
+	var edits []Edit
+	if root != r.root {
+		text, err := restore(root, r.flags)
+		if err != nil {
+			return nil, err
+		}
+		return append(edits, Edit{Start: rn.start, End: rn.end, Text: text}), nil
+	}
+	return r.diff(root, rn, edits)
+}
+
+// diff appends the edits of n, which is a recorded node.
+func (r *Recording) diff(n ast.Node, rn *recordedNode, edits []Edit) ([]Edit, error) {
+	text, err := restore(n, r.flags)
+	if err != nil {
+		return nil, err
+	}
+	if text == rn.text {
+		return edits, nil
+	}
+
+	anchors, located := r.locate(children(n), rn.children, rn.text)
+	if located {
+		// compare the children if n only differs in them.
+		if slots, ok := r.slots(anchors, rn, text); ok {
+			for _, s := range slots {
+				if s.cur == s.old {
+					if edits, err = r.diff(s.cur, r.nodes[s.old], edits); err != nil {
+						return nil, err
+					}
+				} else {
+					edits = append(edits, Edit{Start: s.rn.start, End: s.rn.end, Text: s.text})
+				}
+			}
+			return edits, nil
+		}
+		if e, ok := region(anchors, rn.text, text); ok {
+			return append(edits, e), nil
+		}
+	}
+	if rn.end == 0 {
+		return nil, errors.Errorf("the position of the changed node %T is unknown", n)
+	}
+	return append(edits, Edit{Start: rn.start, End: rn.end, Text: text}), nil
+}
+
+// anchor is a recorded descendant of a node located in the recorded text of the node.
+type anchor struct {
+	old ast.Node
+	rn  *recordedNode
+	// cur is the node in the place of old now, it's nil if the structure has changed.
+	cur ast.Node
+	// pos is the offset of the node in the recorded text of the ancestor.
+	pos int
+}
+
+// locate returns the anchors of the recorded nodes in the order of their positions
+// in text, the recorded text of their ancestor. current are the nodes in the places
+// of recorded now.
+//
+// The nodes are visited in the order of Accept, which isn't always the order their
+// ancestor restores them in, e.g. the count and the offset of LIMIT, so a node is
+// only placed where its text is the only occurrence left out of the nodes placed
+// before, and the anchors must be in the order of their source offsets as well.
+func (r *Recording) locate(current, recorded []ast.Node, text string) ([]anchor, bool) {
+	anchors, ok := r.flatten(current, recorded, text, nil)
+	if !ok || !place(anchors, text) {
+		return nil, false
+	}
+	sort.Slice(anchors, func(i, j int) bool {
+		return anchors[i].pos < anchors[j].pos
+	})
+	for i := 1; i < len(anchors); i++ {
+		if anchors[i].rn.start < anchors[i-1].rn.end {
+			return nil, false
+		}
+	}
+	return anchors, true
+}
+
+// flatten appends the recorded nodes to anchors. A node which its ancestor restores
+// differently, e.g. a FieldList in a SelectStmt, is replaced by its children, and so
+// is a node without position.
+func (r *Recording) flatten(current, recorded []ast.Node, text string, anchors []anchor) ([]anchor, bool) {
+	if len(current) != len(recorded) {
+		current = nil
+	}
+	for i, old := range recorded {
+		rn, recorded := r.nodes[old]
+		if !recorded {
+			return nil, false
+		}
+		var cur ast.Node
+		if current != nil {
+			cur = current[i]
+		}
+		if rn.end != 0 && rn.text != "" && strings.Contains(text, rn.text) {
+			anchors = append(anchors, anchor{old: old, rn: rn, cur: cur})
+			continue
+		}
+		// the nodes without positions are replaced by their children too.
+		if len(rn.children) == 0 {
+			return nil, false
+		}
+		var curChildren []ast.Node
+		if cur == old {
+			curChildren = children(cur)
+		}
+		var ok bool
+		if anchors, ok = r.flatten(curChildren, rn.children, text, anchors); !ok {
+			return nil, false
+		}
+	}
+	return anchors, true
+}
+
+// place sets the positions of the anchors in text, ok is false if any of them is ambiguous.
+// An anchor is placed once its text occurs only once out of the anchors placed before.
+func place(anchors []anchor, text string) (ok bool) {
+	placed := make([]bool, len(anchors))
+	for left := len(anchors); left > 0; {
+		progress := false
+		for i := range anchors {
+			if placed[i] {
+				continue
+			}
+			pos, n := -1, 0
+			sub := anchors[i].rn.text
+			for off := 0; off < len(text); {
+				p := strings.Index(text[off:], sub)
+				if p < 0 {
+					break
+				}
+				p += off
+				if !overlaps(anchors, placed, p, p+len(sub)) {
+					pos, n = p, n+1
+				}
+				off = p + 1
+			}
+			if n == 1 {
+				anchors[i].pos, placed[i] = pos, true
+				left--
+				progress = true
+			}
+		}
+		if !progress {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps tells whether [start, end) overlaps any of the placed anchors.
+func overlaps(anchors []anchor, placed []bool, start, end int) bool {
+	for i, a := range anchors {
+		if placed[i] && start < a.pos+len(a.rn.text) && a.pos < end {
+			return true
+		}
+	}
+	return false
+}
+
+// slot is an anchor which has changed.
+type slot struct {
+	anchor
+	text string
+}
+
+// slots returns the changed anchors, ok is false if the node changes not only in them.
+func (r *Recording) slots(anchors []anchor, rn *recordedNode, text string) (slots []slot, ok bool) {
+	// rebuild the text of the node from its recorded text, by replacing the text of each anchor.
+	var sb strings.Builder
+	last := 0
+	for _, a := range anchors {
+		if a.cur == nil {
+			return nil, false
+		}
+		curText, err := restore(a.cur, r.flags)
+		if err != nil {
+			return nil, false
+		}
+		sb.WriteString(rn.text[last:a.pos])
+		sb.WriteString(curText)
+		last = a.pos + len(a.rn.text)
+		if a.cur != a.old || curText != a.rn.text {
+			slots = append(slots, slot{anchor: a, text: curText})
+		}
+	}
+	sb.WriteString(rn.text[last:])
+	return slots, sb.String() == text
+}
+
+// region returns the edit replacing the anchors which cover the text changed from old to text,
+// ok is false if the text between the anchors has changed.
+func region(anchors []anchor, old, text string) (e Edit, ok bool) {
+	prefix := 0
+	for prefix < len(old) && prefix < len(text) && old[prefix] == text[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(text)-prefix && old[len(old)-1-suffix] == text[len(text)-1-suffix] {
+		suffix++
+	}
+	first, last := -1, -1
+	for i, a := range anchors {
+		if first < 0 && a.pos <= prefix && prefix <= a.pos+len(a.rn.text) {
+			first = i
+		}
+		if first >= 0 && len(old)-suffix <= a.pos+len(a.rn.text) {
+			last = i
+			break
+		}
+	}
+	if first < 0 || last < 0 {
+		return e, false
+	}
+	start, end := anchors[first].pos, anchors[last].pos+len(anchors[last].rn.text)
+	return Edit{
+		Start: anchors[first].rn.start,
+		End:   anchors[last].rn.end,
+		Text:  text[start : len(text)-(len(old)-end)],
+	}, true
+}
+
+func restore(n ast.Node, flags format.RestoreFlags) (string, error) {
+	var sb strings.Builder
+	if err := n.Restore(format.NewRestoreCtx(flags, &sb)); err != nil {
+		return "", errors.Trace(err)
+	}
+	return sb.String(), nil
+}
+
+// children returns the children of n in the order they are visited.
+func children(n ast.Node) []ast.Node {
+	collector := &childCollector{}
+	n.Accept(collector)
+	return collector.children
+}
+
+// childCollector collects the nodes visited right under the first one.
+type childCollector struct {
+	depth    int
+	children []ast.Node
+}
+
+func (c *childCollector) Enter(n ast.Node) (ast.Node, bool) {
+	c.depth++
+	if c.depth == 2 {
+		c.children = append(c.children, n)
+		return n, true
+	}
+	return n, false
+}
+
+func (c *childCollector) Leave(n ast.Node) (ast.Node, bool) {
+	c.depth--
+	return n, true
+}
@@ -0,0 +1,249 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit_test
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/edit"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/opcode"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testEditSuite{})
+
+type testEditSuite struct{}
+
+func (s *testEditSuite) TestEdits(c *C) {
+	table := []struct {
+		sql    string
+		modify func(stmt ast.StmtNode)
+		edits  []edit.Edit
+		result string
+	}{
+		{
+			"SELECT  a ,b\nFROM   t /* keep */ WHERE a = 1 and c in (1,2) -- tail",
+			func(stmt ast.StmtNode) {
+				sel := stmt.(*ast.SelectStmt)
+				sel.Fields.Fields[1].Expr.(*ast.ColumnNameExpr).Name.Name = model.NewCIStr("bb")
+				sel.Where.(*ast.BinaryOperationExpr).L.(*ast.BinaryOperationExpr).R = ast.NewValueExpr(2, "", "")
+			},
+			[]edit.Edit{{Start: 11, End: 12, Text: "`bb`"}, {Start: 43, End: 44, Text: "2"}},
+			"SELECT  a ,`bb`\nFROM   t /* keep */ WHERE a = 2 and c in (1,2) -- tail",
+		},
+		{
+			"select  * from t  where a=1",
+			func(stmt ast.StmtNode) {
+				sel := stmt.(*ast.SelectStmt)
+				sel.Fields.Fields = []*ast.SelectField{
+					{Expr: &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: model.NewCIStr("a")}}},
+					{Expr: &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: model.NewCIStr("b")}}},
+				}
+			},
+			[]edit.Edit{{Start: 8, End: 9, Text: "`a`,`b`"}},
+			"select  `a`,`b` from t  where a=1",
+		},
+		{
+			"select a,  b, c from t",
+			func(stmt ast.StmtNode) {
+				sel := stmt.(*ast.SelectStmt)
+				sel.Fields.Fields = append(sel.Fields.Fields[:1], sel.Fields.Fields[2])
+			},
+			[]edit.Edit{{Start: 11, End: 15, Text: "`c`"}},
+			"select a,  `c` from t",
+		},
+		{
+			"update t set a = 1 where b = 2 -- the change",
+			func(stmt ast.StmtNode) {
+				upd := stmt.(*ast.UpdateStmt)
+				upd.Where = &ast.BinaryOperationExpr{Op: opcode.LogicAnd, L: upd.Where, R: &ast.BinaryOperationExpr{
+					Op: opcode.GT, L: &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: model.NewCIStr("c")}}, R: ast.NewValueExpr(0, "", ""),
+				}}
+			},
+			[]edit.Edit{{Start: 25, End: 30, Text: "`b`=2 AND `c`>0"}},
+			"update t set a = 1 where `b`=2 AND `c`>0 -- the change",
+		},
+		{
+			"DELETE   FROM t",
+			func(stmt ast.StmtNode) {
+				stmt.(*ast.DeleteStmt).Where = &ast.IsNullExpr{Expr: &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: model.NewCIStr("a")}}}
+			},
+			[]edit.Edit{{Start: 14, End: 15, Text: "`t` WHERE `a` IS NULL"}},
+			"DELETE   FROM `t` WHERE `a` IS NULL",
+		},
+		{
+			"select a  from t",
+			func(stmt ast.StmtNode) {
+				stmt.(*ast.SelectStmt).Distinct = true
+			},
+			[]edit.Edit{{Start: 7, End: 8, Text: "DISTINCT `a`"}},
+			"select DISTINCT `a`  from t",
+		},
+		// the offset of LIMIT is restored before the count, which is visited first.
+		{
+			"select a from t limit 1, 1",
+			func(stmt ast.StmtNode) {
+				stmt.(*ast.SelectStmt).Limit.Count = ast.NewValueExpr(5, "", "")
+			},
+			[]edit.Edit{{Start: 16, End: 26, Text: "LIMIT 1,5"}},
+			"select a from t LIMIT 1,5",
+		},
+		{
+			"select a from t limit 1 offset 1",
+			func(stmt ast.StmtNode) {
+				stmt.(*ast.SelectStmt).Limit.Count = ast.NewValueExpr(5, "", "")
+			},
+			[]edit.Edit{{Start: 16, End: 32, Text: "LIMIT 1,5"}},
+			"select a from t LIMIT 1,5",
+		},
+		{
+			"select a from t limit 10, 1",
+			func(stmt ast.StmtNode) {
+				stmt.(*ast.SelectStmt).Limit.Count = ast.NewValueExpr(5, "", "")
+			},
+			[]edit.Edit{{Start: 26, End: 27, Text: "5"}},
+			"select a from t limit 10, 5",
+		},
+		{
+			"select 1 from t where x",
+			func(stmt ast.StmtNode) {},
+			nil,
+			"select 1 from t where x",
+		},
+	}
+	for _, tt := range table {
+		comment := Commentf("sql: %s", tt.sql)
+		stmt, err := parser.New().ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		rec, err := edit.Record(stmt, tt.sql, format.DefaultRestoreFlags)
+		c.Assert(err, IsNil, comment)
+		tt.modify(stmt)
+		edits, err := rec.Edits(stmt)
+		c.Assert(err, IsNil, comment)
+		c.Assert(edits, DeepEquals, tt.edits, comment)
+		result, err := edit.Apply(tt.sql, edits)
+		c.Assert(err, IsNil, comment)
+		c.Assert(result, Equals, tt.result, comment)
+
+		// the result has the same AST.
+		stmt2, err := parser.New().ParseOneStmt(result, "", "")
+		c.Assert(err, IsNil, comment)
+		rec2, err := edit.Record(stmt2, result, format.DefaultRestoreFlags)
+		c.Assert(err, IsNil, comment)
+		edits, err = rec2.Edits(stmt)
+		c.Assert(err, IsNil, comment)
+		c.Assert(edits, HasLen, 1, comment)
+		c.Assert(edits[0].Start, Equals, 0, comment)
+	}
+}
+
+func (s *testEditSuite) TestReplaceRoot(c *C) {
+	sql := "/* c */ select 1; select 2"
+	stmts, _, err := parser.New().Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	rec, err := edit.Record(stmts[1], sql, format.DefaultRestoreFlags)
+	c.Assert(err, IsNil)
+	edits, err := rec.Edits(&ast.ShowStmt{Tp: ast.ShowDatabases})
+	c.Assert(err, IsNil)
+	result, err := edit.Apply(sql, edits)
+	c.Assert(err, IsNil)
+	c.Assert(result, Equals, "/* c */ select 1; SHOW DATABASES")
+}
+
+func (s *testEditSuite) TestReplaceStatement(c *C) {
+	table := []struct {
+		sql    string
+		modify func(stmt ast.StmtNode) ast.StmtNode
+		result string
+	}{
+		{
+			"INSERT INTO t (a) VALUES (1); -- insert",
+			func(stmt ast.StmtNode) ast.StmtNode {
+				return &ast.DeleteStmt{TableRefs: stmt.(*ast.InsertStmt).Table}
+			},
+			"DELETE FROM `t`; -- insert",
+		},
+		{
+			"CREATE TABLE t (id INT) ENGINE=InnoDB",
+			func(stmt ast.StmtNode) ast.StmtNode {
+				stmt.(*ast.CreateTableStmt).Options = nil
+				return stmt
+			},
+			"CREATE TABLE `t` (`id` INT)",
+		},
+		{
+			"SHOW TABLES FROM db",
+			func(stmt ast.StmtNode) ast.StmtNode {
+				stmt.(*ast.ShowStmt).DBName = "db2"
+				return stmt
+			},
+			"SHOW TABLES IN `db2`",
+		},
+		{
+			"UPDATE t SET a = 1",
+			func(stmt ast.StmtNode) ast.StmtNode {
+				return &ast.SelectStmt{
+					SelectStmtOpts: &ast.SelectStmtOpts{SQLCache: true},
+					Fields:         &ast.FieldList{Fields: []*ast.SelectField{{WildCard: &ast.WildCardField{}}}},
+					From:           stmt.(*ast.UpdateStmt).TableRefs,
+				}
+			},
+			"SELECT * FROM `t`",
+		},
+	}
+	for _, tt := range table {
+		comment := Commentf("sql: %s", tt.sql)
+		stmt, err := parser.New().ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		rec, err := edit.Record(stmt, tt.sql, format.DefaultRestoreFlags)
+		c.Assert(err, IsNil, comment)
+		edits, err := rec.Edits(tt.modify(stmt))
+		c.Assert(err, IsNil, comment)
+		result, err := edit.Apply(tt.sql, edits)
+		c.Assert(err, IsNil, comment)
+		c.Assert(result, Equals, tt.result, comment)
+	}
+
+	// a node whose source doesn't match its text isn't edited.
+	sql := "SHOW TABLES FROM db"
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil)
+	stmt.SetPos(ast.Pos{Line: 1, Col: 6, Offset: 5}, stmt.EndPos())
+	rec, err := edit.Record(stmt, sql, format.DefaultRestoreFlags)
+	c.Assert(err, IsNil)
+	_, err = rec.Edits(&ast.ShowStmt{Tp: ast.ShowDatabases})
+	c.Assert(err, ErrorMatches, "the position of the recorded root is unknown")
+}
+
+func (s *testEditSuite) TestApply(c *C) {
+	result, err := edit.Apply("abcdef", []edit.Edit{{Start: 4, End: 5, Text: "E"}, {Start: 0, End: 0, Text: "<"}, {Start: 1, End: 3, Text: ""}})
+	c.Assert(err, IsNil)
+	c.Assert(result, Equals, "<adEf")
+
+	_, err = edit.Apply("abcdef", []edit.Edit{{Start: 0, End: 3}, {Start: 2, End: 4}})
+	c.Assert(err, ErrorMatches, `invalid edit \[2, 4\) of 6 bytes, after 3`)
+	_, err = edit.Apply("abcdef", []edit.Edit{{Start: 3, End: 7}})
+	c.Assert(err, NotNil)
+	_, err = edit.Apply("abcdef", []edit.Edit{{Start: 3, End: 2}})
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,359 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/ast"
+	pformat "github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/opcode"
+)
+
+const fingerprintFlags = pformat.RestoreStringSingleQuotes | pformat.RestoreKeyWordLowercase |
+	pformat.RestoreNameLowercase | pformat.RestoreNameBackQuotes
+
+// Fingerprint generates the fingerprint of a statement.
+// Unlike Normalize, which works on the tokens, it works on the AST, so statements which only differ in
+// the following ways have the same fingerprint:
+//   - literals, e.g. 'a = 1' and 'a = 2',
+//   - the order of operands of commutative operators, e.g. 'a = 1 and b = 2' and 'b = 2 and a = 1',
+//   - table aliases, e.g. 'select x.a from t x' and 'select t.a from t',
+//   - redundant parentheses, e.g. '(a) + (b * c)' and 'a + b * c',
+//   - literal lists, e.g. 'a in (1, 2)', 'a in (1)' and 'a = 1'.
+//
+// Fingerprint rewrites the node in place, it shouldn't be used after.
+func Fingerprint(node ast.StmtNode) (fingerprint string, err error) {
+	aliases := &aliasCollector{
+		scopes:  []map[string]string{{}},
+		sources: make(map[*ast.TableSource]string),
+		ignored: make(map[*ast.TableSource]bool),
+	}
+	aliases.selects = map[*ast.SelectStmt]map[string]string{nil: aliases.scopes[0]}
+	node.Accept(aliases)
+
+	f := &fingerprinter{
+		aliases: aliases,
+		scopes:  []map[string]string{aliases.scopes[0]},
+		kept:    make(map[ast.ExprNode]bool),
+	}
+	node.Accept(f)
+	if f.err != nil {
+		return "", f.err
+	}
+	return restoreFingerprint(node)
+}
+
+// FingerprintDigest parses a statement, and returns its Fingerprint and the digest of the fingerprint.
+func FingerprintDigest(sql string) (fingerprint, digest string, err error) {
+	p := fingerprintParserPool.Get().(*Parser)
+	stmt, err := p.ParseOneStmt(sql, "", "")
+	fingerprintParserPool.Put(p)
+	if err != nil {
+		return "", "", err
+	}
+	if fingerprint, err = Fingerprint(stmt); err != nil {
+		return "", "", err
+	}
+	return fingerprint, DigestNormalized(fingerprint), nil
+}
+
+var fingerprintParserPool = sync.Pool{
+	New: func() interface{} {
+		return New()
+	},
+}
+
+func restoreFingerprint(node ast.Node) (string, error) {
+	var sb strings.Builder
+	if err := node.Restore(pformat.NewRestoreCtx(fingerprintFlags, &sb)); err != nil {
+		return "", errors.Trace(err)
+	}
+	return sb.String(), nil
+}
+
+// aliasCollector names the table sources of each query, in order, with canonical names.
+type aliasCollector struct {
+	scopes  []map[string]string
+	selects map[*ast.SelectStmt]map[string]string
+	sources map[*ast.TableSource]string
+	ignored map[*ast.TableSource]bool
+}
+
+func (c *aliasCollector) Enter(in ast.Node) (ast.Node, bool) {
+	switch n := in.(type) {
+	case *ast.SelectStmt:
+		scope := make(map[string]string)
+		c.selects[n] = scope
+		c.scopes = append(c.scopes, scope)
+	case *ast.InsertStmt:
+		// the target of INSERT can't have an alias.
+		if n.Table != nil && n.Table.TableRefs != nil {
+			if ts, ok := n.Table.TableRefs.Left.(*ast.TableSource); ok {
+				c.ignored[ts] = true
+			}
+		}
+	case *ast.TableSource:
+		if c.ignored[n] {
+			return in, false
+		}
+		alias := "_" + strconv.Itoa(len(c.sources)+1)
+		c.sources[n] = alias
+		scope := c.scopes[len(c.scopes)-1]
+		if n.AsName.L != "" {
+			scope[n.AsName.L] = alias
+		} else if tn, ok := n.Source.(*ast.TableName); ok {
+			scope[tn.Name.L] = alias
+			if tn.Schema.L != "" {
+				scope[tn.Schema.L+"."+tn.Name.L] = alias
+			}
+		}
+	}
+	return in, false
+}
+
+func (c *aliasCollector) Leave(in ast.Node) (ast.Node, bool) {
+	if _, ok := in.(*ast.SelectStmt); ok {
+		c.scopes = c.scopes[:len(c.scopes)-1]
+	}
+	return in, true
+}
+
+// fingerprinter rewrites a statement to its canonical form.
+type fingerprinter struct {
+	aliases *aliasCollector
+	scopes  []map[string]string
+	// kept are the literals which aren't parameters, e.g. the positions in ORDER BY 1.
+	kept map[ast.ExprNode]bool
+	err  error
+}
+
+func (f *fingerprinter) Enter(in ast.Node) (ast.Node, bool) {
+	switch n := in.(type) {
+	case *ast.SelectStmt:
+		f.scopes = append(f.scopes, f.aliases.selects[n])
+	case *ast.ByItem:
+		if v, ok := n.Expr.(ast.ValueExpr); ok {
+			if _, isInt := v.GetValue().(int64); isInt {
+				f.kept[n.Expr] = true
+			}
+		}
+	case *ast.FuncCallExpr:
+		switch n.FnName.L {
+		case ast.Trim:
+			// the NULL standing for the omitted remstr of TRIM(LEADING FROM a) isn't
+			// written, but a parameter would be.
+			if len(n.Args) >= 2 {
+				if v, ok := n.Args[1].(ast.ValueExpr); ok && v.GetValue() == nil {
+					f.kept[n.Args[1]] = true
+				}
+			}
+		case ast.WeightString:
+			// the type of WEIGHT_STRING(a AS CHAR(10)) is held by the arguments, like
+			// the type of CAST it isn't a parameter.
+			if len(n.Args) == 3 {
+				f.kept[n.Args[1]] = true
+				f.kept[n.Args[2]] = true
+			}
+		case ast.Convert, ast.CharFunc:
+			// so is the charset of CONVERT(a USING utf8mb4) and CHAR(65 USING utf8mb4).
+			if len(n.Args) >= 2 {
+				f.kept[n.Args[len(n.Args)-1]] = true
+			}
+		}
+	case *ast.AggregateFuncExpr:
+		// and the separator of GROUP_CONCAT.
+		if strings.ToLower(n.F) == ast.AggFuncGroupConcat && len(n.Args) >= 2 {
+			f.kept[n.Args[len(n.Args)-1]] = true
+		}
+	}
+	return in, false
+}
+
+func (f *fingerprinter) Leave(in ast.Node) (ast.Node, bool) {
+	switch n := in.(type) {
+	case *ast.SelectStmt:
+		f.scopes = f.scopes[:len(f.scopes)-1]
+	case *ast.TableSource:
+		if alias, ok := f.aliases.sources[n]; ok {
+			n.AsName = model.NewCIStr(alias)
+		}
+	case *ast.ColumnName:
+		f.rename(&n.Schema, &n.Table)
+	case *ast.SelectField:
+		if n.WildCard != nil {
+			f.rename(&n.WildCard.Schema, &n.WildCard.Table)
+		}
+	case *ast.DeleteStmt:
+		if n.Tables != nil {
+			for _, tn := range n.Tables.Tables {
+				f.rename(&tn.Schema, &tn.Name)
+			}
+		}
+	case *ast.ParenthesesExpr:
+		return n.Expr, true
+	case ast.ParamMarkerExpr:
+	case ast.ValueExpr:
+		if !f.kept[n] {
+			return ast.NewParamMarkerExpr(n.OriginTextPosition()), true
+		}
+	case *ast.UnaryOperationExpr:
+		if _, ok := n.V.(ast.ParamMarkerExpr); ok && (n.Op == opcode.Minus || n.Op == opcode.Plus) {
+			return n.V, true
+		}
+		n.V = parenthesize(n.V)
+	case *ast.BinaryOperationExpr:
+		return f.binary(n), true
+	case *ast.PatternInExpr:
+		if n.Sel == nil && isParamList(n.List) {
+			n.List = n.List[:1]
+		}
+		if n.Sel == nil && len(n.List) == 1 {
+			op := opcode.EQ
+			if n.Not {
+				op = opcode.NE
+			}
+			return f.binary(&ast.BinaryOperationExpr{Op: op, L: n.Expr, R: n.List[0]}), true
+		}
+		n.Expr = parenthesize(n.Expr)
+	case *ast.IsNullExpr:
+		n.Expr = parenthesize(n.Expr)
+	case *ast.IsTruthExpr:
+		n.Expr = parenthesize(n.Expr)
+	case *ast.PatternLikeExpr:
+		n.Expr, n.Pattern = parenthesize(n.Expr), parenthesize(n.Pattern)
+	case *ast.PatternRegexpExpr:
+		n.Expr, n.Pattern = parenthesize(n.Expr), parenthesize(n.Pattern)
+	case *ast.BetweenExpr:
+		n.Expr, n.Left, n.Right = parenthesize(n.Expr), parenthesize(n.Left), parenthesize(n.Right)
+	case *ast.CompareSubqueryExpr:
+		n.L = parenthesize(n.L)
+	}
+	return in, true
+}
+
+// rename replaces a table qualifier by the canonical name of the table source it refers to.
+func (f *fingerprinter) rename(schema, table *model.CIStr) {
+	if table.L == "" {
+		return
+	}
+	key := table.L
+	if schema.L != "" {
+		key = schema.L + "." + table.L
+	}
+	for i := len(f.scopes) - 1; i >= 0; i-- {
+		if alias, ok := f.scopes[i][key]; ok {
+			*schema = model.CIStr{}
+			*table = model.NewCIStr(alias)
+			return
+		}
+	}
+}
+
+// binary sorts the operands of commutative operators, flattening the chains of associative operators.
+func (f *fingerprinter) binary(n *ast.BinaryOperationExpr) ast.ExprNode {
+	if !isCommutative(n.Op) {
+		n.L, n.R = parenthesize(n.L), parenthesize(n.R)
+		return n
+	}
+	var operands []ast.ExprNode
+	if isAssociative(n.Op) {
+		operands = flatten(n.Op, n, operands)
+	} else {
+		operands = []ast.ExprNode{n.L, n.R}
+	}
+	keys := make([]string, len(operands))
+	for i := range operands {
+		operands[i] = parenthesize(operands[i])
+		key, err := restoreFingerprint(operands[i])
+		if err != nil && f.err == nil {
+			f.err = err
+		}
+		keys[i] = key
+	}
+	sort.Sort(&operandSorter{operands: operands, keys: keys})
+
+	expr := operands[0]
+	for _, operand := range operands[1:] {
+		expr = &ast.BinaryOperationExpr{Op: n.Op, L: expr, R: operand}
+	}
+	return expr
+}
+
+func flatten(op opcode.Op, expr ast.ExprNode, operands []ast.ExprNode) []ast.ExprNode {
+	if n, ok := expr.(*ast.BinaryOperationExpr); ok && n.Op == op {
+		operands = flatten(op, n.L, operands)
+		return flatten(op, n.R, operands)
+	}
+	return append(operands, expr)
+}
+
+type operandSorter struct {
+	operands []ast.ExprNode
+	keys     []string
+}
+
+func (s *operandSorter) Len() int {
+	return len(s.operands)
+}
+
+func (s *operandSorter) Less(i, j int) bool {
+	return s.keys[i] < s.keys[j]
+}
+
+func (s *operandSorter) Swap(i, j int) {
+	s.operands[i], s.operands[j] = s.operands[j], s.operands[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+func isCommutative(op opcode.Op) bool {
+	switch op {
+	case opcode.EQ, opcode.NE, opcode.NullEQ:
+		return true
+	}
+	return isAssociative(op)
+}
+
+func isAssociative(op opcode.Op) bool {
+	switch op {
+	case opcode.LogicAnd, opcode.LogicOr, opcode.LogicXor, opcode.And, opcode.Or, opcode.Xor, opcode.Plus, opcode.Mul:
+		return true
+	}
+	return false
+}
+
+func isParamList(list []ast.ExprNode) bool {
+	for _, expr := range list {
+		if _, ok := expr.(ast.ParamMarkerExpr); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parenthesize puts the operands of operators in parentheses if they are operators too,
+// so the fingerprint doesn't depend on the parentheses in the statement.
+func parenthesize(expr ast.ExprNode) ast.ExprNode {
+	switch expr.(type) {
+	case *ast.BinaryOperationExpr, *ast.UnaryOperationExpr, *ast.IsNullExpr, *ast.IsTruthExpr,
+		*ast.PatternLikeExpr, *ast.PatternRegexpExpr, *ast.PatternInExpr, *ast.BetweenExpr, *ast.CompareSubqueryExpr:
+		return &ast.ParenthesesExpr{Expr: expr}
+	}
+	return expr
+}
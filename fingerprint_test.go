@@ -0,0 +1,95 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+)
+
+var _ = Suite(&testFingerprintSuite{})
+
+type testFingerprintSuite struct {
+}
+
+func (s *testFingerprintSuite) TestFingerprint(c *C) {
+	tests := []struct {
+		sql    string
+		expect string
+	}{
+		{"SELECT a FROM t WHERE b = 'x'", "select `a` from `t` as `_1` where ?=`b`"},
+		{"select -1, 2.5 + a from t order by 1", "select ?,?+`a` from `t` as `_1` order by 1"},
+		{"select * from t where a in (1, 2, 3) and b not in (1) and c in (d, 1)", "select * from `t` as `_1` where (?!=`b`) and (?=`a`) and (`c` in (`d`,?))"},
+		{"select x.a, y.* from t as x join test.s y on x.a = y.a", "select `_1`.`a`,`_2`.* from `t` as `_1` join `test`.`s` as `_2` on `_1`.`a`=`_2`.`a`"},
+		{"select a from t where exists (select 1 from t where t.a = a)", "select `a` from `t` as `_1` where exists (select ? from `t` as `_2` where `_2`.`a`=`a`)"},
+		{"select (a + b) * c, a + b * c from t", "select (`a`+`b`)*`c`,(`b`*`c`)+`a` from `t` as `_1`"},
+		{"delete x from t as x where x.a is null", "delete `_1` from `t` as `_1` where `_1`.`a` is null"},
+		{"insert into t values (1, 'a')", "insert into `t` values (?,?)"},
+		{"select trim(leading from a) from t", "select trim(leading from `a`) from `t` as `_1`"},
+		{"select trim(leading 'x' from a), trim(a) from t", "select trim(leading ? from `a`),trim(`a`) from `t` as `_1`"},
+		{"select weight_string(a as char(10)), weight_string('x' as binary(4)) from t", "select weight_string(`a` as char(10)),weight_string(? as binary(4)) from `t` as `_1`"},
+		{"select convert(a using utf8mb4), convert('x' using latin1) from t", "select convert(`a` using 'utf8mb4'),convert(? using 'latin1') from `t` as `_1`"},
+		{"select char(65 using utf8mb4), char(66) from t", "select char_func(?, 'utf8mb4'),char_func(?, null) from `t` as `_1`"},
+		{"select group_concat(a separator ';'), group_concat(b) from t", "select group_concat(`a` separator ';'),group_concat(`b` separator ',') from `t` as `_1`"},
+	}
+	for _, test := range tests {
+		fingerprint, digest, err := parser.FingerprintDigest(test.sql)
+		c.Assert(err, IsNil, Commentf("sql: %s", test.sql))
+		c.Assert(fingerprint, Equals, test.expect, Commentf("sql: %s", test.sql))
+		c.Assert(digest, Equals, parser.DigestNormalized(fingerprint))
+
+		// the fingerprint is a statement too.
+		_, err = parser.New().ParseOneStmt(fingerprint, "", "")
+		c.Assert(err, IsNil, Commentf("fingerprint: %s", fingerprint))
+	}
+}
+
+func (s *testFingerprintSuite) TestFingerprintEqual(c *C) {
+	groups := [][]string{
+		{"select * from t where a = 1 and b = 2", "select * from t where b = 3 and a = 4", "SELECT * FROM t WHERE (b = 3) AND ((a = '4'))"},
+		{"select * from t where a = 1 or b = 2 or c = 3", "select * from t where c = 1 or (b = 2 or a = 3)", "select * from t where 1 = c or a = 2 or b = 3"},
+		{"select x.a from t x join s y on x.a = y.a", "select t.a from t join s on s.a = t.a", "select p.a from t p join s q on q.a = p.a"},
+		{"select a from t where a in (1)", "select a from t where a = 1", "select a from t where a in (1, 2, 3)"},
+		{"select a + 1 from t", "select (a) + (1) from t", "select 2 + a from t"},
+	}
+	for _, group := range groups {
+		_, expect, err := parser.FingerprintDigest(group[0])
+		c.Assert(err, IsNil)
+		for _, sql := range group[1:] {
+			_, digest, err := parser.FingerprintDigest(sql)
+			c.Assert(err, IsNil)
+			c.Assert(digest, Equals, expect, Commentf("sql: %s", sql))
+		}
+	}
+
+	different := []string{
+		"select * from t where a = 1",
+		"select * from t where a = 1 and b = 2",
+		"select * from t where a in (select a from s)",
+		"select (a + b) * c from t",
+		"select a + b * c from t",
+		"select * from t order by 1",
+		"select * from t order by 2",
+	}
+	digests := make(map[string]string)
+	for _, sql := range different {
+		_, digest, err := parser.FingerprintDigest(sql)
+		c.Assert(err, IsNil)
+		c.Assert(digests[digest], Equals, "", Commentf("sql: %s", sql))
+		digests[digest] = sql
+	}
+
+	_, _, err := parser.FingerprintDigest("select * from")
+	c.Assert(err, NotNil)
+}
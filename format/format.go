@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -221,6 +222,8 @@ const (
 
 	RestoreStringWithoutCharset
 	RestoreStringWithoutDefaultCharset
+
+	RestoreComments
 )
 
 const (
@@ -289,16 +292,194 @@ func (rf RestoreFlags) HasStringWithoutCharset() bool {
 	return rf.has(RestoreStringWithoutCharset)
 }
 
+// HasCommentsFlag returns a boolean indicating whether `rf` has `RestoreComments` flag.
+func (rf RestoreFlags) HasCommentsFlag() bool {
+	return rf.has(RestoreComments)
+}
+
+// PrettyConfig configures the pretty-printing mode of `RestoreCtx`.
+type PrettyConfig struct {
+	// Indent is one level of indentation, such as "  " or "\t".
+	Indent string
+	// LineBreak ends the lines, it's "\n" if empty.
+	LineBreak string
+	// MaxWidth is the line width beyond which long lists, such as the values of IN,
+	// are wrapped. The lists are never wrapped if it's 0.
+	MaxWidth int
+}
+
+// DefaultPrettyConfig indents the lines by 2 spaces and wraps the lines wider than 80 characters.
+var DefaultPrettyConfig = PrettyConfig{Indent: "  ", LineBreak: "\n", MaxWidth: 80}
+
+// Dialect is the SQL dialect which `Restore` writes.
+type Dialect int
+
+// The dialects which `Restore` writes.
+const (
+	// DialectTiDB is the dialect parsed by the parser, it's the default.
+	DialectTiDB Dialect = iota
+	DialectMySQL57
+	DialectMySQL80
+	// DialectANSI is the standard SQL. The names are written in double quotes instead of back quotes
+	// and the strings in single quotes, and the MySQL extensions of the statements, the clauses
+	// and the operators are rewritten or rejected. The hints, such as the index hints and the
+	// optimizer hints, are dropped since they don't change the result.
+	//
+	// It's best-effort below the expressions: the literals are written by the driver, with their
+	// charset introducers such as _UTF8MB4, and the function names and the data types, such as
+	// JSON_EXTRACT and CAST(a AS SIGNED), are written as they are.
+	DialectANSI
+)
+
+var dialectNames = []string{
+	DialectTiDB:    "TiDB",
+	DialectMySQL57: "MySQL 5.7",
+	DialectMySQL80: "MySQL 8.0",
+	DialectANSI:    "ANSI SQL",
+}
+
+// String implements fmt.Stringer interface.
+func (d Dialect) String() string {
+	if d >= 0 && int(d) < len(dialectNames) {
+		return dialectNames[d]
+	}
+	return "unknown"
+}
+
+// UnsupportedError is returned by `Restore` if a construct can't be expressed in the dialect.
+type UnsupportedError struct {
+	Construct string
+	Dialect   Dialect
+}
+
+// Error implements error interface.
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s can't be expressed in %s", e.Construct, e.Dialect)
+}
+
 // RestoreCtx is `Restore` context to hold flags and writer.
 type RestoreCtx struct {
 	Flags     RestoreFlags
 	In        io.Writer
 	DefaultDB string
+	// Dialect is the dialect which the nodes are written in.
+	Dialect Dialect
+	// Pretty enables the pretty-printing mode if it isn't nil. In this mode the
+	// major clauses start new lines, and the nested parts are indented.
+	Pretty *PrettyConfig
+
+	// The following fields are only used in the pretty-printing mode.
+	// indent is the indentation level of the following lines.
+	indent int
+	// column is the width of the current line.
+	column int
+	// spaces is the number of spaces which are written before the next text,
+	// they are dropped by a line break.
+	spaces int
+	// lineStart is true if nothing is written after the indentation of the current line.
+	lineStart bool
 }
 
 // NewRestoreCtx returns a new `RestoreCtx`.
 func NewRestoreCtx(flags RestoreFlags, in io.Writer) *RestoreCtx {
-	return &RestoreCtx{flags, in, ""}
+	return &RestoreCtx{Flags: flags, In: in}
+}
+
+// NewPrettyRestoreCtx returns a new `RestoreCtx` in the pretty-printing mode.
+func NewPrettyRestoreCtx(flags RestoreFlags, in io.Writer, config PrettyConfig) *RestoreCtx {
+	return &RestoreCtx{Flags: flags, In: in, Pretty: &config}
+}
+
+// Unsupported returns an `UnsupportedError` of construct if the dialect of `ctx` is one of dialects,
+// otherwise it returns nil.
+func (ctx *RestoreCtx) Unsupported(construct string, dialects ...Dialect) error {
+	for _, d := range dialects {
+		if ctx.Dialect == d {
+			return &UnsupportedError{Construct: construct, Dialect: d}
+		}
+	}
+	return nil
+}
+
+// IsPretty returns a boolean indicating whether `ctx` is in the pretty-printing mode.
+func (ctx *RestoreCtx) IsPretty() bool {
+	return ctx.Pretty != nil
+}
+
+// write writes the text into writer. If layout is true, the spaces around text are
+// only for the layout, they are dropped at the start or end of a line in the
+// pretty-printing mode.
+func (ctx *RestoreCtx) write(text string, layout bool) {
+	if ctx.Pretty == nil {
+		fmt.Fprint(ctx.In, text)
+		return
+	}
+	spaces := 0
+	if layout {
+		if ctx.lineStart {
+			text = strings.TrimLeft(text, " ")
+		}
+		trimmed := strings.TrimRight(text, " ")
+		spaces = len(text) - len(trimmed)
+		text = trimmed
+	}
+	if text != "" {
+		text = strings.Repeat(" ", ctx.spaces) + text
+		fmt.Fprint(ctx.In, text)
+		if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+			ctx.column = utf8.RuneCountInString(text[i+1:])
+		} else {
+			ctx.column += utf8.RuneCountInString(text)
+		}
+		ctx.spaces = 0
+		ctx.lineStart = false
+	}
+	ctx.spaces += spaces
+}
+
+// WriteLineBreak starts a new line at the current indentation in the pretty-printing
+// mode, otherwise it writes sep.
+func (ctx *RestoreCtx) WriteLineBreak(sep string) {
+	if ctx.Pretty == nil {
+		fmt.Fprint(ctx.In, sep)
+		return
+	}
+	ctx.spaces = 0
+	if ctx.lineStart {
+		return
+	}
+	lineBreak := ctx.Pretty.LineBreak
+	if lineBreak == "" {
+		lineBreak = "\n"
+	}
+	indent := strings.Repeat(ctx.Pretty.Indent, ctx.indent)
+	fmt.Fprint(ctx.In, lineBreak, indent)
+	ctx.column = utf8.RuneCountInString(indent)
+	ctx.lineStart = true
+}
+
+// WriteListSeparator writes sep between the items of a list. In the pretty-printing
+// mode, sep is followed by a space, or by a line break if the line is wider than MaxWidth.
+func (ctx *RestoreCtx) WriteListSeparator(sep string) {
+	ctx.write(sep, true)
+	if ctx.Pretty == nil {
+		return
+	}
+	if ctx.Pretty.MaxWidth > 0 && ctx.column >= ctx.Pretty.MaxWidth {
+		ctx.WriteLineBreak("")
+	} else {
+		ctx.write(" ", true)
+	}
+}
+
+// Indent increases the indentation of the following lines in the pretty-printing mode.
+func (ctx *RestoreCtx) Indent() {
+	ctx.indent++
+}
+
+// Unindent decreases the indentation of the following lines in the pretty-printing mode.
+func (ctx *RestoreCtx) Unindent() {
+	ctx.indent--
 }
 
 // WriteKeyWord writes the `keyWord` into writer.
@@ -310,29 +491,32 @@ func (ctx *RestoreCtx) WriteKeyWord(keyWord string) {
 	case ctx.Flags.HasKeyWordLowercaseFlag():
 		keyWord = strings.ToLower(keyWord)
 	}
-	fmt.Fprint(ctx.In, keyWord)
+	ctx.write(keyWord, true)
 }
 
 // WriteString writes the string into writer
 // `str` may be wrapped in quotes and escaped according to RestoreFlags.
+// In DialectANSI, the strings are always in single quotes and the backslashes aren't escaped.
 func (ctx *RestoreCtx) WriteString(str string) {
-	if ctx.Flags.HasStringEscapeBackslashFlag() {
+	ansi := ctx.Dialect == DialectANSI
+	if ctx.Flags.HasStringEscapeBackslashFlag() && !ansi {
 		str = strings.Replace(str, `\`, `\\`, -1)
 	}
 	quotes := ""
 	switch {
-	case ctx.Flags.HasStringSingleQuotesFlag():
+	case ctx.Flags.HasStringSingleQuotesFlag(), ansi && ctx.Flags.HasStringDoubleQuotesFlag():
 		str = strings.Replace(str, `'`, `''`, -1)
 		quotes = `'`
 	case ctx.Flags.HasStringDoubleQuotesFlag():
 		str = strings.Replace(str, `"`, `""`, -1)
 		quotes = `"`
 	}
-	fmt.Fprint(ctx.In, quotes, str, quotes)
+	ctx.write(quotes+str+quotes, false)
 }
 
 // WriteName writes the name into writer
 // `name` maybe wrapped in quotes and escaped according to RestoreFlags.
+// In DialectANSI, the names are always in double quotes if they are quoted.
 func (ctx *RestoreCtx) WriteName(name string) {
 	switch {
 	case ctx.Flags.HasNameUppercaseFlag():
@@ -342,22 +526,22 @@ func (ctx *RestoreCtx) WriteName(name string) {
 	}
 	quotes := ""
 	switch {
-	case ctx.Flags.HasNameDoubleQuotesFlag():
+	case ctx.Flags.HasNameDoubleQuotesFlag(), ctx.Dialect == DialectANSI && ctx.Flags.HasNameBackQuotesFlag():
 		name = strings.Replace(name, `"`, `""`, -1)
 		quotes = `"`
 	case ctx.Flags.HasNameBackQuotesFlag():
 		name = strings.Replace(name, "`", "``", -1)
 		quotes = "`"
 	}
-	fmt.Fprint(ctx.In, quotes, name, quotes)
+	ctx.write(quotes+name+quotes, false)
 }
 
 // WritePlain writes the plain text into writer without any handling.
 func (ctx *RestoreCtx) WritePlain(plainText string) {
-	fmt.Fprint(ctx.In, plainText)
+	ctx.write(plainText, true)
 }
 
 // WritePlainf write the plain text into writer without any handling.
 func (ctx *RestoreCtx) WritePlainf(format string, a ...interface{}) {
-	fmt.Fprintf(ctx.In, format, a...)
+	ctx.write(fmt.Sprintf(format, a...), true)
 }
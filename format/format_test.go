@@ -95,3 +95,59 @@ func (s *testRestoreCtxSuite) TestRestoreCtx(c *C) {
 		c.Assert(sb.String(), Equals, testCase.expect, Commentf("case: %#v", testCase))
 	}
 }
+
+func (s *testRestoreCtxSuite) TestPrettyRestoreCtx(c *C) {
+	write := func(ctx *RestoreCtx) {
+		ctx.WriteKeyWord("select ")
+		ctx.Indent()
+		ctx.WriteLineBreak("")
+		ctx.WriteString("a ")
+		ctx.WriteListSeparator(",")
+		ctx.WriteName("b")
+		ctx.WriteListSeparator(",")
+		ctx.WritePlain("1234")
+		ctx.Unindent()
+		ctx.WriteLineBreak(" ")
+		ctx.WriteKeyWord("from ")
+		ctx.WriteName("t")
+	}
+	var sb strings.Builder
+	write(NewRestoreCtx(DefaultRestoreFlags, &sb))
+	c.Assert(sb.String(), Equals, "SELECT 'a ',`b`,1234 FROM `t`")
+
+	sb.Reset()
+	write(NewPrettyRestoreCtx(DefaultRestoreFlags, &sb, DefaultPrettyConfig))
+	c.Assert(sb.String(), Equals, "SELECT\n  'a ', `b`, 1234\nFROM `t`")
+
+	sb.Reset()
+	write(NewPrettyRestoreCtx(DefaultRestoreFlags, &sb, PrettyConfig{Indent: "\t", LineBreak: "\r\n", MaxWidth: 8}))
+	c.Assert(sb.String(), Equals, "SELECT\r\n\t'a ', `b`,\r\n\t1234\r\nFROM `t`")
+}
+
+func (s *testRestoreCtxSuite) TestDialect(c *C) {
+	write := func(ctx *RestoreCtx) {
+		ctx.WriteString(`it's \n`)
+		ctx.WritePlain(" ")
+		ctx.WriteName("na`\"me")
+	}
+	var sb strings.Builder
+	ctx := NewRestoreCtx(DefaultRestoreFlags|RestoreStringEscapeBackslash, &sb)
+	write(ctx)
+	c.Assert(sb.String(), Equals, "'it''s \\\\n' `na``\"me`")
+	c.Assert(ctx.Unsupported("X", DialectANSI), IsNil)
+
+	sb.Reset()
+	ctx = NewRestoreCtx(RestoreStringDoubleQuotes|RestoreStringEscapeBackslash|RestoreNameBackQuotes, &sb)
+	ctx.Dialect = DialectANSI
+	write(ctx)
+	c.Assert(sb.String(), Equals, "'it''s \\n' \"na`\"\"me\"")
+
+	err := ctx.Unsupported("X", DialectMySQL57, DialectANSI)
+	c.Assert(err, DeepEquals, &UnsupportedError{Construct: "X", Dialect: DialectANSI})
+	c.Assert(err.Error(), Equals, "X can't be expressed in ANSI SQL")
+	c.Assert(ctx.Unsupported("X", DialectMySQL57), IsNil)
+
+	c.Assert(DialectTiDB.String(), Equals, "TiDB")
+	c.Assert(DialectMySQL80.String(), Equals, "MySQL 8.0")
+	c.Assert(Dialect(-1).String(), Equals, "unknown")
+}
@@ -137,6 +137,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"regexp"
 	"runtime"
 	"sort"
 	"strings"
@@ -413,6 +414,29 @@ type %[1]sXError struct {
 	minArg-- // eg: [-13, 42], minArg -14 maps -13 to 1 so zero cell values -> empty.
 	mustFormat(f, "\n%sMaxDepth = 200\n", *oPref)
 	mustFormat(f, "%sTabOfs   = %d\n", *oPref, minArg)
+
+	// The types of the values built by the rules having actions, NodeTypes holds
+	// the one of each rule.
+	nodeTypes := map[string]int{"": 0}
+	var typeNames []string
+	for _, rule := range p.Rules {
+		if rule.Parent == nil && hasAction(rule) && rule.Sym.Type != "" {
+			if _, ok := nodeTypes[rule.Sym.Type]; !ok {
+				nodeTypes[rule.Sym.Type] = 0
+				typeNames = append(typeNames, rule.Sym.Type)
+			}
+		}
+	}
+	sort.Strings(typeNames)
+	if len(typeNames) >= 0x80 {
+		log.Fatalf("too many %%union types: %d", len(typeNames))
+	}
+	mustFormat(f, "\n%sPassThrough = 0x80\n", *oPref)
+	mustFormat(f, "%sTypeNone = 0\n", *oPref)
+	for i, typ := range typeNames {
+		nodeTypes[typ] = i + 1
+		mustFormat(f, "%sType%s%s = %d\n", *oPref, strings.ToUpper(typ[:1]), typ[1:], i+1)
+	}
 	mustFormat(f, "%u)")
 
 	// ---------------------------------------------------------- Variables
@@ -445,6 +469,22 @@ type %[1]sXError struct {
 	}
 	mustFormat(f, "%u}\n")
 
+	// NodeTypes table, the types of the values built by the rules having actions,
+	// the rules returning one of their values as it is are flagged by PassThrough.
+	mustFormat(f, "\n%sNodeTypes = []uint8{%i\n", *oPref)
+	for _, rule := range p.Rules {
+		typ := ""
+		if rule.Parent == nil && hasAction(rule) {
+			typ = rule.Sym.Type
+		}
+		if typ != "" && isPassThrough(rule) {
+			mustFormat(f, "%d | %sPassThrough,\n", nodeTypes[typ], *oPref)
+			continue
+		}
+		mustFormat(f, "%d,\n", nodeTypes[typ])
+	}
+	mustFormat(f, "%u}\n")
+
 	// XError table
 	mustFormat(f, "\n%[1]sXErrors = map[%[1]sXError]string{%i\n", *oPref)
 	for _, xerr := range p.XErrors {
@@ -662,6 +702,7 @@ yynewstate:
 				msg = "syntax error"
 			}
 			// ignore goyacc error message
+			%[1]sSyntaxError(parser, yystate)
 			yylex.AppendError(yylex.Errorf(""))
 			Nerrs++
 			fallthrough
@@ -730,31 +771,17 @@ yynewstate:
 	if %[1]sDebug >= 2 {
 		__yyfmt__.Printf("reduce using rule %%v (%%s), and goto state %%d\n", r, %[1]sSymNames[x], yystate)
 	}
+	%[1]sSetSpan(parser, yyS[yyp:yypt+1], yychar >= 0)
 
 	switch r {%i
 `,
 		*oPref, errSym, *oDlvalf, *oDlval, *oParserType)
 	for r, rule := range p.Rules {
-		if rule.Action == nil {
+		if !hasAction(rule) {
 			continue
 		}
 
 		action := rule.Action.Values
-		if len(action) == 0 {
-			continue
-		}
-
-		if len(action) == 1 {
-			part := action[0]
-			if part.Type == parser.ActionValueGo {
-				src := part.Src
-				src = src[1 : len(src)-1] // Remove lead '{' and trail '}'
-				if strings.TrimSpace(src) == "" {
-					continue
-				}
-			}
-		}
-
 		components := rule.Components
 		typ := rule.Sym.Type
 		max := len(components)
@@ -792,6 +819,9 @@ yynewstate:
 
 
 	%[1]sSetOffset(parser.yyVAL, parser.yyVAL.offset)
+	if typ := %[1]sNodeTypes[r]; typ != %[1]sTypeNone {
+		%[1]sSetNodePos(parser, typ)
+	}
 
 	if yyEx != nil && yyEx.Reduced(r, exState, parser.yyVAL) {
 		return -1
@@ -805,6 +835,43 @@ yynewstate:
 	return nil
 }
 
+// hasAction returns true if the rule has a non-empty semantic action.
+func hasAction(rule *y.Rule) bool {
+	if rule.Action == nil {
+		return false
+	}
+
+	action := rule.Action.Values
+	if len(action) == 0 {
+		return false
+	}
+
+	if len(action) == 1 {
+		part := action[0]
+		if part.Type == parser.ActionValueGo {
+			src := part.Src
+			src = src[1 : len(src)-1] // Remove lead '{' and trail '}'
+			if strings.TrimSpace(src) == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// passThroughAction matches the actions returning one of the values of the rule as it
+// is, like `{ $$ = $2 }`, the value may have a type assertion.
+var passThroughAction = regexp.MustCompile(`^\{\s*\$\$\s*=\s*\$\d+(\.\([\w.*\[\]]+\))?\s*\}$`)
+
+// isPassThrough returns true if the action of the rule returns one of its values as it is.
+func isPassThrough(rule *y.Rule) bool {
+	var src strings.Builder
+	for _, part := range rule.Action.Values {
+		src.WriteString(part.Src)
+	}
+	return passThroughAction.MatchString(src.String())
+}
+
 func injectImport(src string) string {
 	const inj = `
 
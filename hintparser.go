@@ -116,6 +116,15 @@ const (
 
 	yyhintMaxDepth = 200
 	yyhintTabOfs   = -172
+
+	yyhintPassThrough     = 0x80
+	yyhintTypeNone        = 0
+	yyhintTypeHint        = 1
+	yyhintTypeHints       = 2
+	yyhintTypeIdent       = 3
+	yyhintTypeModelIdents = 4
+	yyhintTypeNumber      = 5
+	yyhintTypeTable       = 6
 )
 
 var (
@@ -524,6 +533,181 @@ var (
 		{75, 1},
 	}
 
+	yyhintNodeTypes = []uint8{
+		0,
+		0,
+		2,
+		2,
+		2 | yyhintPassThrough,
+		2,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		1,
+		2,
+		2,
+		2,
+		1,
+		3,
+		0,
+		0,
+		0,
+		4,
+		4 | yyhintPassThrough,
+		4,
+		4,
+		0,
+		1,
+		1,
+		1,
+		6,
+		6,
+		1,
+		1,
+		0,
+		1,
+		1,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		3,
+		5,
+		5,
+		1,
+		1,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+		0,
+	}
+
 	yyhintXErrors = map[yyhintXError]string{}
 
 	yyhintParseTab = [255][]uint16{
@@ -976,6 +1160,7 @@ yynewstate:
 				msg = "syntax error"
 			}
 			// ignore goyacc error message
+			yyhintSyntaxError(parser, yystate)
 			yylex.AppendError(yylex.Errorf(""))
 			Nerrs++
 			fallthrough
@@ -1044,6 +1229,7 @@ yynewstate:
 	if yyhintDebug >= 2 {
 		__yyfmt__.Printf("reduce using rule %v (%s), and goto state %d\n", r, yyhintSymNames[x], yystate)
 	}
+	yyhintSetSpan(parser, yyS[yyp:yypt+1], yychar >= 0)
 
 	switch r {
 	case 1:
@@ -1322,6 +1508,9 @@ yynewstate:
 	}
 
 	yyhintSetOffset(parser.yyVAL, parser.yyVAL.offset)
+	if typ := yyhintNodeTypes[r]; typ != yyhintTypeNone {
+		yyhintSetNodePos(parser, typ)
+	}
 
 	if yyEx != nil && yyEx.Reduced(r, exState, parser.yyVAL) {
 		return -1
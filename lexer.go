@@ -73,6 +73,27 @@ type Scanner struct {
 
 	// true if a dot follows an identifier
 	identifierDot bool
+
+	// delimiter is the active statement delimiter, it is changed by the
+	// `DELIMITER` directive the same way as the mysql client does.
+	// Empty means the default delimiter `;`.
+	delimiter string
+	// atStmtStart is true if no token of the current statement has been
+	// returned yet, a `DELIMITER` directive is only recognized there.
+	atStmtStart bool
+	// pendingDelimiter is true if a `DELIMITER` directive has just been
+	// returned, the directive is terminated by the line end instead of a
+	// delimiter, so the next Lex returns an empty delimiter to end it.
+	pendingDelimiter bool
+
+	// keepComments is true if the comments should be recorded in comments.
+	keepComments bool
+	comments     []scannedComment
+}
+
+// scannedComment is the span of a comment, which isn't an optimizer hint or a `/*!` section.
+type scannedComment struct {
+	start, end Pos
 }
 
 // Errors returns the errors and warns during a scan.
@@ -89,6 +110,17 @@ func (s *Scanner) reset(sql string) {
 	s.stmtStartPos = 0
 	s.inBangComment = false
 	s.lastKeyword = 0
+	s.delimiter = ""
+	s.atStmtStart = true
+	s.pendingDelimiter = false
+	s.comments = s.comments[:0]
+}
+
+// seek moves the scanner to the position p, which must be the start of a statement.
+func (s *Scanner) seek(p Pos) {
+	s.r.p = p
+	s.stmtStartPos = p.Offset
+	s.lastScanOffset = p.Offset
 }
 
 func (s *Scanner) stmtText() string {
@@ -151,7 +183,17 @@ func (s *Scanner) getNextToken() int {
 // return 0 tells parser that scanner meets EOF,
 // return invalid tells parser that scanner meets illegal character.
 func (s *Scanner) Lex(v *yySymType) int {
+	if s.pendingDelimiter {
+		s.pendingDelimiter = false
+		s.atStmtStart = true
+		v.offset = s.r.pos().Offset
+		v.startPos, v.endPos = s.r.pos(), s.r.pos()
+		v.ident = ""
+		return int(';')
+	}
 	tok, pos, lit := s.scan()
+	v.startPos, v.endPos = pos, s.r.pos()
+	s.atStmtStart = s.isDelimiter(tok, lit)
 	s.lastScanOffset = pos.Offset
 	s.lastKeyword3 = s.lastKeyword2
 	s.lastKeyword2 = s.lastKeyword
@@ -183,6 +225,7 @@ func (s *Scanner) Lex(v *yySymType) int {
 	}
 	if tok == as && s.getNextToken() == of {
 		_, pos, lit = s.scan()
+		v.endPos = s.r.pos()
 		v.ident = fmt.Sprintf("%s %s", v.ident, lit)
 		s.lastKeyword = asof
 		s.lastScanOffset = pos.Offset
@@ -208,6 +251,9 @@ func (s *Scanner) Lex(v *yySymType) int {
 		v.item = nil
 	case quotedIdentifier:
 		tok = identifier
+	case delimiterDirective:
+		s.SetDelimiter(lit)
+		s.pendingDelimiter = true
 	}
 
 	if tok == unicode.ReplacementChar {
@@ -217,9 +263,9 @@ func (s *Scanner) Lex(v *yySymType) int {
 	return tok
 }
 
-// SetSQLMode sets the SQL mode for scanner.
+// SetSQLMode sets the SQL mode for scanner, the combination modes such as ModeANSI are expanded.
 func (s *Scanner) SetSQLMode(mode mysql.SQLMode) {
-	s.sqlMode = mode
+	s.sqlMode = mode.Expand()
 }
 
 // GetSQLMode return the SQL mode of scanner.
@@ -227,6 +273,33 @@ func (s *Scanner) GetSQLMode() mysql.SQLMode {
 	return s.sqlMode
 }
 
+// SetDelimiter sets the active statement delimiter of the scanner.
+func (s *Scanner) SetDelimiter(delimiter string) {
+	if delimiter == ";" {
+		delimiter = ""
+	}
+	s.delimiter = delimiter
+}
+
+// GetDelimiter returns the active statement delimiter of the scanner.
+func (s *Scanner) GetDelimiter() string {
+	if s.delimiter == "" {
+		return ";"
+	}
+	return s.delimiter
+}
+
+// isDelimiter returns true if the token returned by scan() is the active delimiter.
+// A custom delimiter is returned as token ';' whose literal is the delimiter itself.
+func (s *Scanner) isDelimiter(tok int, lit string) bool {
+	return tok == int(';') && lit == s.GetDelimiter()
+}
+
+// atDelimiter returns true if the reader stands at a custom delimiter.
+func (s *Scanner) atDelimiter() bool {
+	return s.delimiter != "" && strings.HasPrefix(s.r.s[s.r.p.Offset:], s.delimiter)
+}
+
 // EnableWindowFunc controls whether the scanner recognize the keywords of window function.
 func (s *Scanner) EnableWindowFunc(val bool) {
 	s.supportWindowFunc = val
@@ -238,12 +311,14 @@ func (s *Scanner) InheritScanner(sql string) *Scanner {
 		r:                 reader{s: sql},
 		sqlMode:           s.sqlMode,
 		supportWindowFunc: s.supportWindowFunc,
+		delimiter:         s.delimiter,
+		atStmtStart:       true,
 	}
 }
 
 // NewScanner returns a new scanner object.
 func NewScanner(s string) *Scanner {
-	return &Scanner{r: reader{s: s}}
+	return &Scanner{r: reader{s: s}, atStmtStart: true}
 }
 
 func (s *Scanner) skipWhitespace() rune {
@@ -262,6 +337,19 @@ func (s *Scanner) scan() (tok int, pos Pos, lit string) {
 		return 0, pos, ""
 	}
 
+	if s.atDelimiter() {
+		for s.r.p.Offset < pos.Offset+len(s.delimiter) {
+			s.r.readByte()
+		}
+		return int(';'), pos, s.r.data(&pos)
+	}
+
+	if s.atStmtStart && (ch0 == 'd' || ch0 == 'D') {
+		if tok, lit, ok := s.scanDelimiterDirective(); ok {
+			return tok, pos, lit
+		}
+	}
+
 	if !s.r.eof() && isIdentExtend(ch0) {
 		return scanIdentifier(s)
 	}
@@ -335,12 +423,21 @@ func startWithBb(s *Scanner) (tok int, pos Pos, lit string) {
 }
 
 func startWithSharp(s *Scanner) (tok int, pos Pos, lit string) {
+	pos = s.r.pos()
 	s.r.incAsLongAs(func(ch rune) bool {
 		return ch != '\n'
 	})
+	s.addComment(pos)
 	return s.scan()
 }
 
+// addComment records the comment from start to the current position if comments are kept.
+func (s *Scanner) addComment(start Pos) {
+	if s.keepComments {
+		s.comments = append(s.comments, scannedComment{start: start, end: s.r.pos()})
+	}
+}
+
 func startWithDash(s *Scanner) (tok int, pos Pos, lit string) {
 	pos = s.r.pos()
 	if strings.HasPrefix(s.r.s[pos.Offset:], "--") {
@@ -349,6 +446,7 @@ func startWithDash(s *Scanner) (tok int, pos Pos, lit string) {
 			s.r.incAsLongAs(func(ch rune) bool {
 				return ch != '\n'
 			})
+			s.addComment(pos)
 			return s.scan()
 		}
 	}
@@ -441,6 +539,7 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 					s.lastHintPos = pos
 					return hintComment, pos, s.r.data(&pos)
 				} else {
+					s.addComment(pos)
 					return s.scan()
 				}
 			case 0:
@@ -512,11 +611,55 @@ func startWithAt(s *Scanner) (tok int, pos Pos, lit string) {
 
 func scanIdentifier(s *Scanner) (int, Pos, string) {
 	pos := s.r.pos()
-	s.r.incAsLongAs(isIdentChar)
+	s.scanIdentChars()
 	s.identifierDot = s.r.peek() == '.'
 	return identifier, pos, s.r.data(&pos)
 }
 
+// scanIdentChars reads identifier chars, it stops in front of a custom
+// delimiter, e.g. `END$$` is scanned as `END` followed by the delimiter `$$`.
+func (s *Scanner) scanIdentChars() {
+	if s.delimiter == "" {
+		s.r.incAsLongAs(isIdentChar)
+		return
+	}
+	s.r.incAsLongAs(func(ch rune) bool {
+		return isIdentChar(ch) && !s.atDelimiter()
+	})
+}
+
+// scanDelimiterDirective scans the mysql client command `DELIMITER str`.
+// The directive must be the first token of a statement and ends at the line end.
+// See https://dev.mysql.com/doc/refman/8.0/en/mysql-commands.html
+func (s *Scanner) scanDelimiterDirective() (tok int, lit string, ok bool) {
+	const keyword = "DELIMITER"
+	str := s.r.s[s.r.p.Offset:]
+	if len(str) <= len(keyword) || !strings.EqualFold(str[:len(keyword)], keyword) {
+		return 0, "", false
+	}
+	if ch := str[len(keyword)]; ch != ' ' && ch != '\t' {
+		return 0, "", false
+	}
+	pos := s.r.pos()
+	s.r.incAsLongAs(func(ch rune) bool {
+		return ch != '\n'
+	})
+	arg := strings.TrimSpace(s.r.data(&pos)[len(keyword):])
+	if len(arg) > 0 && (arg[0] == '\'' || arg[0] == '"' || arg[0] == '`') {
+		if end := strings.IndexByte(arg[1:], arg[0]); end >= 0 {
+			arg = arg[1 : end+1]
+		}
+	} else if end := strings.IndexAny(arg, " \t"); end >= 0 {
+		arg = arg[:end]
+	}
+	// the mysql client rejects an empty delimiter or a delimiter containing a backslash.
+	if arg == "" || strings.ContainsRune(arg, '\\') {
+		s.errs = append(s.errs, ParseErrorWith(s.r.data(&pos), s.r.p.Line))
+		return unicode.ReplacementChar, "", true
+	}
+	return delimiterDirective, arg, true
+}
+
 func scanIdentifierOrString(s *Scanner) (tok int, lit string) {
 	ch1 := s.r.peek()
 	switch ch1 {
@@ -715,8 +858,8 @@ func startWithNumber(s *Scanner) (tok int, pos Pos, lit string) {
 	}
 
 	// Identifiers may begin with a digit but unless quoted may not consist solely of digits.
-	if !s.r.eof() && isIdentChar(ch0) {
-		s.r.incAsLongAs(isIdentChar)
+	if !s.r.eof() && isIdentChar(ch0) && !s.atDelimiter() {
+		s.scanIdentChars()
 		return identifier, pos, s.r.data(&pos)
 	}
 	lit = s.r.data(&pos)
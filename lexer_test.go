@@ -52,7 +52,7 @@ type testCaseItem struct {
 
 func (s *testLexerSuite) TestSingleCharOther(c *C) {
 	table := []testCaseItem{
-		{"AT", identifier},
+		{"AT", at},
 		{"?", paramMarker},
 		{"PLACEHOLDER", identifier},
 		{"=", eq},
@@ -627,3 +627,44 @@ func (s *testLexerSuite) TestFeatureIDs(c *C) {
 		c.Assert(nextChar, Equals, t.nextChar, comment)
 	}
 }
+
+func (s *testLexerSuite) TestDelimiter(c *C) {
+	scanner := NewScanner("DELIMITER $$\nSELECT a;$$ SELECT 1$$\ndelimiter '//'\nEND//")
+	expects := []struct {
+		tok   int
+		ident string
+	}{
+		{delimiterDirective, "$$"},
+		{';', ""},
+		{selectKwd, "SELECT"},
+		{identifier, "a"},
+		{';', ";"},
+		{';', "$$"},
+		{selectKwd, "SELECT"},
+		{intLit, "1"},
+		{';', "$$"},
+		{delimiterDirective, "//"},
+		{';', ""},
+		{end, "END"},
+		{';', "//"},
+		{0, ""},
+	}
+	for _, expect := range expects {
+		var v yySymType
+		tok := scanner.Lex(&v)
+		c.Assert(tok, Equals, expect.tok)
+		c.Assert(v.ident, Equals, expect.ident)
+	}
+	c.Assert(scanner.GetDelimiter(), Equals, "//")
+
+	// DELIMITER is only recognized at the start of a statement.
+	scanner.reset("SELECT 1 DELIMITER $$")
+	for _, tok := range []int{selectKwd, intLit, identifier, identifier, 0} {
+		var v yySymType
+		c.Assert(scanner.Lex(&v), Equals, tok)
+	}
+
+	scanner.reset("DELIMITER \\\\")
+	var v yySymType
+	c.Assert(scanner.Lex(&v), Equals, invalid)
+}
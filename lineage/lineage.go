@@ -0,0 +1,378 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lineage finds the tables a statement reads and writes, and the columns of
+// the tables each column it outputs is computed from.
+//
+// The names are bound by the resolver package, and the columns are followed through
+// the joins, the derived tables, the common table expressions, the set operations
+// and the subqueries down to the columns of the tables and the views.
+package lineage
+
+import (
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/resolver"
+)
+
+// Table is a table or a view.
+type Table struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+}
+
+// Column is a column of a table, or of the result set of a query if its Table is empty.
+type Column struct {
+	Table Table  `json:"table"`
+	Name  string `json:"name"`
+}
+
+// ColumnLineage is an output column and the columns it's computed from.
+type ColumnLineage struct {
+	Column  Column   `json:"column"`
+	Sources []Column `json:"sources"`
+}
+
+// Graph is the lineage of a statement.
+type Graph struct {
+	// Reads are the tables whose rows are read.
+	Reads []Table `json:"reads"`
+	// Writes are the tables whose rows are written, or the view or the table created.
+	Writes []Table `json:"writes"`
+	// Columns are the columns of the result set of a query, or the columns written,
+	// in order. The sources of a column are only the ones its value comes from, the
+	// columns in the conditions, the grouping and the ordering aren't sources.
+	Columns []*ColumnLineage `json:"columns"`
+}
+
+// Analyze returns the lineage of a SELECT, a set operation, an INSERT, a REPLACE, an
+// UPDATE, a DELETE, a CREATE VIEW or a CREATE TABLE ... SELECT. The graph of the other
+// statements is empty.
+func Analyze(stmt ast.StmtNode, schema resolver.Schema) (*Graph, error) {
+	res, err := resolver.Resolve(stmt, schema)
+	if err != nil {
+		return nil, err
+	}
+	a := &analyzer{
+		schema:   schema,
+		res:      res,
+		fields:   make(map[*ast.ResultField][]Column),
+		visiting: make(map[*ast.ResultField]bool),
+		graph:    &Graph{},
+	}
+	switch x := stmt.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		a.reads(x)
+		a.outputs(Table{}, x)
+	case *ast.InsertStmt:
+		a.insert(x)
+	case *ast.UpdateStmt:
+		a.update(x)
+	case *ast.DeleteStmt:
+		a.delete(x)
+	case *ast.CreateViewStmt:
+		a.reads(x.Select)
+		a.graph.Writes = append(a.graph.Writes, a.table(x.ViewName))
+		a.outputs(a.table(x.ViewName), x.Select)
+	case *ast.CreateTableStmt:
+		if x.Select != nil {
+			a.reads(x.Select)
+			a.graph.Writes = append(a.graph.Writes, a.table(x.Table))
+			a.outputs(a.table(x.Table), x.Select)
+		}
+	}
+	return a.graph, nil
+}
+
+type analyzer struct {
+	schema resolver.Schema
+	res    *resolver.Result
+	// fields are the sources of the fields already followed.
+	fields   map[*ast.ResultField][]Column
+	visiting map[*ast.ResultField]bool
+	// values are the sources of the values inserted, by the lower-case names of the
+	// columns, which VALUES(col) in ON DUPLICATE KEY UPDATE refers to.
+	values map[string][]Column
+	graph  *Graph
+}
+
+func (a *analyzer) table(tn *ast.TableName) Table {
+	schema := tn.Schema.O
+	if schema == "" {
+		schema = a.schema.CurrentDB()
+	}
+	name := tn.Name.O
+	if tn.TableInfo != nil {
+		name = tn.TableInfo.Name.O
+	}
+	return Table{Schema: schema, Name: name}
+}
+
+// reads adds the tables read in a node. The tables of the common table expressions
+// aren't tables, which the resolver doesn't set the TableInfo of.
+func (a *analyzer) reads(n ast.Node) {
+	v := &tableCollector{}
+	n.Accept(v)
+	for _, tn := range v.tables {
+		a.graph.Reads = appendTable(a.graph.Reads, a.table(tn))
+	}
+}
+
+type tableCollector struct {
+	tables []*ast.TableName
+}
+
+func (v *tableCollector) Enter(n ast.Node) (ast.Node, bool) {
+	if tn, ok := n.(*ast.TableName); ok && tn.TableInfo != nil {
+		v.tables = append(v.tables, tn)
+	}
+	return n, false
+}
+
+func (v *tableCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// outputs adds the columns of the result of a query to the graph, as the columns of a table.
+func (a *analyzer) outputs(tbl Table, query ast.Node) {
+	for i, f := range a.res.Fields {
+		a.addColumn(Column{Table: tbl, Name: f.Column.Name.O}, a.queryColumn(query, i))
+	}
+}
+
+func (a *analyzer) addColumn(col Column, sources []Column) {
+	for _, cl := range a.graph.Columns {
+		if cl.Column == col {
+			cl.Sources = appendColumns(cl.Sources, sources...)
+			return
+		}
+	}
+	a.graph.Columns = append(a.graph.Columns, &ColumnLineage{Column: col, Sources: appendColumns(nil, sources...)})
+}
+
+func (a *analyzer) insert(stmt *ast.InsertStmt) {
+	tn := stmt.Table.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+	tbl := a.table(tn)
+	a.graph.Writes = append(a.graph.Writes, tbl)
+	// the table inserted into isn't read.
+	if stmt.Select != nil {
+		a.reads(stmt.Select)
+	}
+
+	var cols []*model.ColumnInfo
+	for _, cn := range stmt.Columns {
+		cols = append(cols, a.res.Names[cn].Column)
+	}
+	if len(stmt.Columns) == 0 && (len(stmt.Lists) > 0 || stmt.Select != nil) {
+		for _, col := range tn.TableInfo.Columns {
+			if !col.Hidden {
+				cols = append(cols, col)
+			}
+		}
+	}
+	a.values = make(map[string][]Column, len(cols))
+	for i, col := range cols {
+		var sources []Column
+		if stmt.Select != nil {
+			sources = a.queryColumn(stmt.Select, i)
+		}
+		for _, row := range stmt.Lists {
+			if i < len(row) {
+				sources = appendColumns(sources, a.exprSources(row[i])...)
+			}
+		}
+		a.values[col.Name.L] = sources
+		a.addColumn(Column{Table: tbl, Name: col.Name.O}, sources)
+	}
+	for _, row := range stmt.Lists {
+		for _, expr := range row {
+			a.reads(expr)
+		}
+	}
+	a.assignments(stmt.Setlist)
+	// the row of the table inserted into is read if it's updated from its columns.
+	for _, as := range stmt.OnDuplicate {
+		for _, src := range a.exprSources(as.Expr) {
+			if strings.EqualFold(src.Table.Schema, tbl.Schema) && strings.EqualFold(src.Table.Name, tbl.Name) {
+				a.graph.Reads = appendTable(a.graph.Reads, tbl)
+			}
+		}
+	}
+	a.assignments(stmt.OnDuplicate)
+}
+
+// assignments adds the columns assigned, and adds the tables read in the expressions.
+func (a *analyzer) assignments(list []*ast.Assignment) {
+	for _, as := range list {
+		a.reads(as.Expr)
+		f := a.res.Names[as.Column]
+		if f.Table == nil {
+			continue
+		}
+		tbl := Table{Schema: f.DBName.O, Name: f.Table.Name.O}
+		a.graph.Writes = appendTable(a.graph.Writes, tbl)
+		a.addColumn(Column{Table: tbl, Name: f.Column.Name.O}, a.exprSources(as.Expr))
+	}
+}
+
+func (a *analyzer) update(stmt *ast.UpdateStmt) {
+	a.reads(stmt)
+	a.assignments(stmt.List)
+}
+
+func (a *analyzer) delete(stmt *ast.DeleteStmt) {
+	a.reads(stmt)
+	if stmt.Tables == nil {
+		tn := stmt.TableRefs.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+		a.graph.Writes = append(a.graph.Writes, a.table(tn))
+		return
+	}
+	for _, tn := range stmt.Tables.Tables {
+		a.graph.Writes = appendTable(a.graph.Writes, a.table(tn))
+	}
+}
+
+// field returns the sources of a field.
+func (a *analyzer) field(f *ast.ResultField) []Column {
+	if f == nil {
+		return nil
+	}
+	if f.Table != nil {
+		return []Column{{Table: Table{Schema: f.DBName.O, Name: f.Table.Name.O}, Name: f.Column.Name.O}}
+	}
+	if sources, ok := a.fields[f]; ok {
+		return sources
+	}
+	// a recursive common table expression refers to itself.
+	if a.visiting[f] {
+		return nil
+	}
+	a.visiting[f] = true
+	var sources []Column
+	if query, ok := a.res.Derived[f]; ok {
+		sources = a.queryColumn(query, f.Column.Offset)
+	} else if f.Expr != nil {
+		sources = a.exprSources(f.Expr)
+	}
+	delete(a.visiting, f)
+	a.fields[f] = sources
+	return sources
+}
+
+// queryColumn returns the sources of a column of the result of a query, which are
+// the ones of the column in all the query blocks of a set operation.
+func (a *analyzer) queryColumn(query ast.Node, i int) []Column {
+	switch x := query.(type) {
+	case *ast.SelectStmt:
+		if x.Kind == ast.SelectStmtKindValues {
+			var sources []Column
+			for _, row := range x.Lists {
+				if i < len(row.Values) {
+					sources = appendColumns(sources, a.exprSources(row.Values[i])...)
+				}
+			}
+			return sources
+		}
+		if fields := a.res.Queries[x]; i < len(fields) {
+			return a.exprSources(fields[i].Expr)
+		}
+	case *ast.SetOprStmt:
+		return a.queryColumn(x.SelectList, i)
+	case *ast.SetOprSelectList:
+		var sources []Column
+		for _, sel := range x.Selects {
+			sources = appendColumns(sources, a.queryColumn(sel, i)...)
+		}
+		return sources
+	}
+	return nil
+}
+
+// queryWidth returns the number of the columns of the result of a query.
+func (a *analyzer) queryWidth(query ast.Node) int {
+	switch x := query.(type) {
+	case *ast.SelectStmt:
+		return len(a.res.Queries[x])
+	case *ast.SetOprStmt:
+		return a.queryWidth(x.SelectList)
+	case *ast.SetOprSelectList:
+		return a.queryWidth(x.Selects[0])
+	}
+	return 0
+}
+
+// exprSources returns the sources of the value of an expression.
+func (a *analyzer) exprSources(expr ast.ExprNode) []Column {
+	v := &sourceCollector{a: a}
+	expr.Accept(v)
+	return v.sources
+}
+
+type sourceCollector struct {
+	a       *analyzer
+	sources []Column
+}
+
+func (v *sourceCollector) Enter(n ast.Node) (ast.Node, bool) {
+	switch x := n.(type) {
+	case *ast.ColumnNameExpr:
+		v.sources = appendColumns(v.sources, v.a.field(x.Refer)...)
+		return n, true
+	case *ast.SubqueryExpr:
+		for i := 0; i < v.a.queryWidth(x.Query); i++ {
+			v.sources = appendColumns(v.sources, v.a.queryColumn(x.Query, i)...)
+		}
+		return n, true
+	case *ast.ValuesExpr:
+		// VALUES(col) is the value inserted into col.
+		if v.a.values != nil && x.Column != nil {
+			v.sources = appendColumns(v.sources, v.a.values[x.Column.Name.Name.L]...)
+			return n, true
+		}
+	case *ast.WindowFuncExpr:
+		// the partitioning and the ordering of the window aren't sources.
+		for _, arg := range x.Args {
+			arg.Accept(v)
+		}
+		return n, true
+	}
+	return n, false
+}
+
+func (v *sourceCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+func appendTable(tables []Table, tbl Table) []Table {
+	for _, t := range tables {
+		if strings.EqualFold(t.Schema, tbl.Schema) && strings.EqualFold(t.Name, tbl.Name) {
+			return tables
+		}
+	}
+	return append(tables, tbl)
+}
+
+func appendColumns(cols []Column, added ...Column) []Column {
+outer:
+	for _, col := range added {
+		for _, c := range cols {
+			if c == col {
+				continue outer
+			}
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
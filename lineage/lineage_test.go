@@ -0,0 +1,236 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lineage_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/catalog"
+	"github.com/pingcap/parser/lineage"
+	"github.com/pingcap/parser/resolver"
+	"github.com/pingcap/parser/terror"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testLineageSuite{})
+
+type testLineageSuite struct {
+	schema resolver.Schema
+}
+
+func (s *testLineageSuite) SetUpSuite(c *C) {
+	cat := catalog.New()
+	stmts, _, err := parser.New().Parse(`
+		CREATE DATABASE test;
+		CREATE DATABASE other;
+		CREATE TABLE other.o (id INT, x INT);
+		USE test;
+		CREATE TABLE t (a INT, b INT, c INT);
+		CREATE TABLE s (a INT, d INT);
+		CREATE TABLE u (a INT, b INT, e INT);
+		CREATE VIEW v (va, vb) AS SELECT a, b FROM t;
+	`, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(cat.ExecAll(stmts), IsNil)
+	s.schema = cat
+}
+
+func tableString(tbl lineage.Table) string {
+	return tbl.Schema + "." + tbl.Name
+}
+
+func columnString(col lineage.Column) string {
+	if col.Table.Name == "" {
+		return col.Name
+	}
+	return tableString(col.Table) + "." + col.Name
+}
+
+func tableStrings(tables []lineage.Table) []string {
+	list := []string{}
+	for _, tbl := range tables {
+		list = append(list, tableString(tbl))
+	}
+	return list
+}
+
+// columnStrings formats the columns like "col<-source1,source2".
+func columnStrings(cols []*lineage.ColumnLineage) []string {
+	list := []string{}
+	for _, cl := range cols {
+		var sources []string
+		for _, src := range cl.Sources {
+			sources = append(sources, columnString(src))
+		}
+		list = append(list, columnString(cl.Column)+"<-"+strings.Join(sources, ","))
+	}
+	return list
+}
+
+func (s *testLineageSuite) TestAnalyze(c *C) {
+	table := []struct {
+		sql     string
+		reads   []string
+		writes  []string
+		columns []string
+	}{
+		{
+			"select a, b + c as bc, 1 from t",
+			[]string{"test.t"}, []string{},
+			[]string{"a<-test.t.a", "bc<-test.t.b,test.t.c", "1<-"},
+		},
+		{
+			"select * from t join s using (a) where d > 0",
+			[]string{"test.t", "test.s"}, []string{},
+			[]string{"a<-test.t.a", "b<-test.t.b", "c<-test.t.c", "d<-test.s.d"},
+		},
+		{
+			"select t.b, o.x from t left join other.o o on t.a = o.id",
+			[]string{"test.t", "other.o"}, []string{},
+			[]string{"b<-test.t.b", "x<-other.o.x"},
+		},
+		{
+			"select dt.y from (select a + d as y from s) dt",
+			[]string{"test.s"}, []string{},
+			[]string{"y<-test.s.a,test.s.d"},
+		},
+		{
+			"select a, (select max(d) from s where s.a = t.a) m from t where b in (select e from u)",
+			[]string{"test.s", "test.t", "test.u"}, []string{},
+			[]string{"a<-test.t.a", "m<-test.s.d"},
+		},
+		{
+			"with c (k, w) as (select a, b from t union all select a, e from u) select k, w from c",
+			[]string{"test.t", "test.u"}, []string{},
+			[]string{"k<-test.t.a,test.u.a", "w<-test.t.b,test.u.e"},
+		},
+		{
+			"with recursive c (n, m) as (select a, b from t union all select n + 1, m from c where n < 10) select * from c",
+			[]string{"test.t"}, []string{},
+			[]string{"n<-test.t.a", "m<-test.t.b"},
+		},
+		{
+			"select a, b from t union select a, d from s union select id, x from other.o",
+			[]string{"test.t", "test.s", "other.o"}, []string{},
+			[]string{"a<-test.t.a,test.s.a,other.o.id", "b<-test.t.b,test.s.d,other.o.x"},
+		},
+		{
+			"select * from (select d from s union select e from u) dt order by d",
+			[]string{"test.s", "test.u"}, []string{},
+			[]string{"d<-test.s.d,test.u.e"},
+		},
+		{
+			"select va, sum(vb) over (partition by va) from v",
+			[]string{"test.v"}, []string{},
+			[]string{"va<-test.v.va", "sum(vb) over (partition by va)<-test.v.vb"},
+		},
+		{
+			"insert into u (e, a) select d, a from s where d > 0",
+			[]string{"test.s"}, []string{"test.u"},
+			[]string{"test.u.e<-test.s.d", "test.u.a<-test.s.a"},
+		},
+		{
+			"insert into s select a, b from t union select a, e from u",
+			[]string{"test.t", "test.u"}, []string{"test.s"},
+			[]string{"test.s.a<-test.t.a,test.u.a", "test.s.d<-test.t.b,test.u.e"},
+		},
+		{
+			"replace into s values (1, (select max(x) from other.o))",
+			[]string{"other.o"}, []string{"test.s"},
+			[]string{"test.s.a<-", "test.s.d<-other.o.x"},
+		},
+		{
+			"insert into s (a) values (1) on duplicate key update d = d + 1",
+			[]string{"test.s"}, []string{"test.s"},
+			[]string{"test.s.a<-", "test.s.d<-test.s.d"},
+		},
+		{
+			"insert into t (a, b) select a, d from s on duplicate key update b = values(a) + c",
+			[]string{"test.s", "test.t"}, []string{"test.t"},
+			[]string{"test.t.a<-test.s.a", "test.t.b<-test.s.d,test.s.a,test.t.c"},
+		},
+		{
+			"insert into t (a, b) values (1, 2) on duplicate key update b = values(b)",
+			[]string{}, []string{"test.t"},
+			[]string{"test.t.a<-", "test.t.b<-"},
+		},
+		{
+			"update t join s on t.a = s.a set t.b = s.d, t.c = 0 where s.d > 0",
+			[]string{"test.t", "test.s"}, []string{"test.t"},
+			[]string{"test.t.b<-test.s.d", "test.t.c<-"},
+		},
+		{
+			"delete t from t join s using (a) where d is null",
+			[]string{"test.t", "test.s"}, []string{"test.t"},
+			[]string{},
+		},
+		{
+			"delete from s where a in (select a from u)",
+			[]string{"test.s", "test.u"}, []string{"test.s"},
+			[]string{},
+		},
+		{
+			"create view w (p, q) as select t.a, s.d from t join s using (a)",
+			[]string{"test.t", "test.s"}, []string{"test.w"},
+			[]string{"test.w.p<-test.t.a", "test.w.q<-test.s.d"},
+		},
+		{
+			"create table other.n select a, concat(b, e) be from u",
+			[]string{"test.u"}, []string{"other.n"},
+			[]string{"other.n.a<-test.u.a", "other.n.be<-test.u.b,test.u.e"},
+		},
+		{
+			"create table n (id int)",
+			[]string{}, []string{},
+			[]string{},
+		},
+	}
+	for _, tt := range table {
+		comment := Commentf("sql: %s", tt.sql)
+		stmt, err := parser.New().ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		g, err := lineage.Analyze(stmt, s.schema)
+		c.Assert(err, IsNil, comment)
+		c.Assert(tableStrings(g.Reads), DeepEquals, tt.reads, comment)
+		c.Assert(tableStrings(g.Writes), DeepEquals, tt.writes, comment)
+		c.Assert(columnStrings(g.Columns), DeepEquals, tt.columns, comment)
+	}
+}
+
+func (s *testLineageSuite) TestJSON(c *C) {
+	stmt, err := parser.New().ParseOneStmt("insert into s select id, x from other.o", "", "")
+	c.Assert(err, IsNil)
+	g, err := lineage.Analyze(stmt, s.schema)
+	c.Assert(err, IsNil)
+	data, err := json.Marshal(g)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"reads":[{"schema":"other","name":"o"}],"writes":[{"schema":"test","name":"s"}],`+
+		`"columns":[{"column":{"table":{"schema":"test","name":"s"},"name":"a"},"sources":[{"table":{"schema":"other","name":"o"},"name":"id"}]},`+
+		`{"column":{"table":{"schema":"test","name":"s"},"name":"d"},"sources":[{"table":{"schema":"other","name":"o"},"name":"x"}]}]}`)
+}
+
+func (s *testLineageSuite) TestErrors(c *C) {
+	stmt, err := parser.New().ParseOneStmt("select z from t", "", "")
+	c.Assert(err, IsNil)
+	_, err = lineage.Analyze(stmt, s.schema)
+	c.Assert(terror.ErrorEqual(err, resolver.ErrUnknownColumn), IsTrue)
+}
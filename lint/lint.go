@@ -0,0 +1,232 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lint checks statements against a set of rules.
+//
+// The rules are kept in a Registry, which reports the problems found by them as
+// Findings. The findings of a statement are suppressed by a comment in it like
+// `/* lint:ignore select-star, update-without-where */`, or `-- lint:ignore` for all
+// the rules. The comments are only seen if the statements are parsed with
+// Parser.KeepComments(true).
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+)
+
+// Severity is the severity of a problem.
+type Severity int
+
+// Severity levels.
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+var severityNames = []string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+// String implements fmt.Stringer interface.
+func (s Severity) String() string {
+	if s >= 0 && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return "unknown"
+}
+
+// Meta is the metadata of a rule.
+type Meta struct {
+	// Name identifies the rule, e.g. "select-star".
+	Name        string
+	Severity    Severity
+	Description string
+}
+
+// Rule checks statements for a kind of problem.
+type Rule interface {
+	// Meta returns the metadata of the rule.
+	Meta() Meta
+	// Check reports the problems of ctx.Stmt by ctx.Report.
+	Check(ctx *Context)
+}
+
+// Context is the statement checked by a rule.
+type Context struct {
+	Stmt ast.StmtNode
+
+	meta     Meta
+	findings []Finding
+}
+
+// Report reports a problem of node described by message, suggestion tells how to fix
+// it and may be empty. The arguments are in the same order as those of Reportf.
+func (ctx *Context) Report(node ast.Node, suggestion, message string) {
+	start, end := node.StartPos(), node.EndPos()
+	if end == (ast.Pos{}) {
+		start, end = ctx.Stmt.StartPos(), ctx.Stmt.EndPos()
+	}
+	ctx.findings = append(ctx.findings, Finding{
+		Rule:       ctx.meta.Name,
+		Severity:   ctx.meta.Severity,
+		Message:    message,
+		Suggestion: suggestion,
+		Start:      start,
+		End:        end,
+	})
+}
+
+// Reportf is like Report, but formats the message like fmt.Sprintf.
+func (ctx *Context) Reportf(node ast.Node, suggestion, format string, args ...interface{}) {
+	ctx.Report(node, suggestion, fmt.Sprintf(format, args...))
+}
+
+// Finding is a problem reported by a rule.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+	// Suggestion tells how to fix the problem, it's empty if there is no suggestion.
+	Suggestion string
+	// Start and End are the positions of the node which has the problem.
+	Start, End ast.Pos
+}
+
+// String implements fmt.Stringer interface.
+func (f Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", f.Start.Line, f.Start.Col, f.Severity, f.Message, f.Rule)
+}
+
+// Registry is a set of rules.
+type Registry struct {
+	rules []Rule
+	names map[string]Rule
+}
+
+// NewRegistry returns a registry with rules.
+func NewRegistry(rules ...Rule) *Registry {
+	r := &Registry{names: make(map[string]Rule)}
+	for _, rule := range rules {
+		r.Register(rule)
+	}
+	return r
+}
+
+// NewDefaultRegistry returns a registry with the rules of DefaultRules.
+func NewDefaultRegistry() *Registry {
+	return NewRegistry(DefaultRules()...)
+}
+
+// Register adds a rule, it panics if there is a rule of the same name.
+func (r *Registry) Register(rule Rule) {
+	name := rule.Meta().Name
+	if _, ok := r.names[name]; ok {
+		panic("lint: Register called twice for rule " + name)
+	}
+	r.names[name] = rule
+	r.rules = append(r.rules, rule)
+}
+
+// Unregister removes the rule of the name, if any.
+func (r *Registry) Unregister(name string) {
+	if _, ok := r.names[name]; !ok {
+		return
+	}
+	delete(r.names, name)
+	for i, rule := range r.rules {
+		if rule.Meta().Name == name {
+			r.rules = append(r.rules[:i], r.rules[i+1:]...)
+			break
+		}
+	}
+}
+
+// Rule returns the rule of the name, or nil.
+func (r *Registry) Rule(name string) Rule {
+	return r.names[name]
+}
+
+// Rules returns the rules in the order they are registered.
+func (r *Registry) Rules() []Rule {
+	return r.rules
+}
+
+// Lint checks stmts against the rules, and returns the findings ordered by statement
+// and then by rule.
+func (r *Registry) Lint(stmts []ast.StmtNode) []Finding {
+	var findings []Finding
+	for _, stmt := range stmts {
+		ignored, all := suppressions(stmt)
+		if all {
+			continue
+		}
+		for _, rule := range r.rules {
+			meta := rule.Meta()
+			if ignored[meta.Name] {
+				continue
+			}
+			ctx := &Context{Stmt: stmt, meta: meta}
+			rule.Check(ctx)
+			findings = append(findings, ctx.findings...)
+		}
+	}
+	return findings
+}
+
+const ignoreDirective = "lint:ignore"
+
+// suppressions returns the rules ignored by the comments in stmt, all is true if
+// all the rules are ignored.
+func suppressions(stmt ast.StmtNode) (ignored map[string]bool, all bool) {
+	collector := &commentCollector{}
+	stmt.Accept(collector)
+	ignored = make(map[string]bool)
+	for _, c := range collector.comments {
+		i := strings.Index(c.Text, ignoreDirective)
+		if i < 0 {
+			continue
+		}
+		text := strings.TrimSuffix(c.Text[i+len(ignoreDirective):], "*/")
+		names := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})
+		if len(names) == 0 {
+			return nil, true
+		}
+		for _, name := range names {
+			ignored[name] = true
+		}
+	}
+	return ignored, false
+}
+
+// commentCollector collects the comments attached to the nodes.
+type commentCollector struct {
+	comments []*ast.Comment
+}
+
+func (c *commentCollector) Enter(n ast.Node) (ast.Node, bool) {
+	c.comments = append(c.comments, n.LeadingComments()...)
+	c.comments = append(c.comments, n.TrailingComments()...)
+	return n, false
+}
+
+func (c *commentCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
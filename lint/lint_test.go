@@ -0,0 +1,141 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint_test
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	. "github.com/pingcap/parser/lint"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testLintSuite{})
+
+type testLintSuite struct{}
+
+func parse(c *C, sql string) []ast.StmtNode {
+	p := parser.New()
+	p.KeepComments(true)
+	stmts, _, err := p.Parse(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	return stmts
+}
+
+func findings(fs []Finding) []string {
+	list := []string{}
+	for _, f := range fs {
+		list = append(list, f.String())
+	}
+	return list
+}
+
+func (s *testLintSuite) TestDefaultRules(c *C) {
+	table := []struct {
+		sql      string
+		findings []string
+	}{
+		{"select a from t where exists (select * from s)", []string{}},
+		{"select * from t", []string{"1:8: warning: SELECT * is used (select-star)"}},
+		{"select a from (select t.* from t) x", []string{"1:23: warning: SELECT * is used (select-star)"}},
+		{"update t set a = 1", []string{"1:1: error: UPDATE has no WHERE clause (update-without-where)"}},
+		{"update t set a = 1 where b = 2", []string{}},
+		{"delete from t", []string{"1:1: error: DELETE has no WHERE clause (delete-without-where)"}},
+		{"create table t (a int)", []string{"1:14: warning: table t has no primary key (missing-primary-key)"}},
+		{"create table t (a int primary key)", []string{}},
+		{"create table t (a int, primary key (a))", []string{}},
+		{"create table t like s", []string{}},
+		{"select a from t, s where t.b = s.b", []string{}},
+		{"select a from t x, s, u where x.b = s.b and 1 = 1 and (u.c + 1 = x.c or u.c is null)", []string{}},
+		{"update t, s set t.a = s.a where t.b = s.b", []string{}},
+		{"select a from t, s where b = c", []string{}},
+		{"select a from t, s where t.b = 1 and s.b = 2", []string{"1:18: warning: join has no join condition (missing-join-condition)"}},
+		{"select a from t, s, u where t.b = s.b", []string{"1:21: warning: join has no join condition (missing-join-condition)"}},
+		{"select a from t join s on t.b = s.b join u using (b)", []string{}},
+		{"select a from t cross join s", []string{"1:28: warning: join has no join condition (missing-join-condition)"}},
+		{"select\n  *\nfrom t, s", []string{"2:3: warning: SELECT * is used (select-star)", "3:9: warning: join has no join condition (missing-join-condition)"}},
+	}
+	registry := NewDefaultRegistry()
+	for _, tt := range table {
+		c.Assert(findings(registry.Lint(parse(c, tt.sql))), DeepEquals, tt.findings, Commentf("sql: %s", tt.sql))
+	}
+}
+
+func (s *testLintSuite) TestFinding(c *C) {
+	fs := NewDefaultRegistry().Lint(parse(c, "select 1;\ndelete from t"))
+	c.Assert(fs, HasLen, 1)
+	c.Assert(fs[0].Rule, Equals, RuleDeleteWithoutWhere)
+	c.Assert(fs[0].Severity, Equals, SeverityError)
+	c.Assert(fs[0].Suggestion, Equals, "add a WHERE clause, or use TRUNCATE TABLE to delete all the rows")
+	c.Assert(fs[0].Start, Equals, ast.Pos{Line: 2, Col: 1, Offset: 10})
+	c.Assert(fs[0].End, Equals, ast.Pos{Line: 2, Col: 14, Offset: 23})
+}
+
+func (s *testLintSuite) TestSuppression(c *C) {
+	table := []struct {
+		sql      string
+		findings []string
+	}{
+		{"/* lint:ignore */ update t set a = (select * from s)", []string{}},
+		{"update t set a = (select * from s) -- lint:ignore update-without-where", []string{"1:26: warning: SELECT * is used (select-star)"}},
+		{"select * /* lint:ignore select-star, missing-join-condition */ from t, s", []string{}},
+		{"# lint:ignore select-star\nselect * from t;\nselect * from t", []string{"3:8: warning: SELECT * is used (select-star)"}},
+		{"select * from t /* no lint:ignoring here */", []string{"1:8: warning: SELECT * is used (select-star)"}},
+	}
+	registry := NewDefaultRegistry()
+	for _, tt := range table {
+		c.Assert(findings(registry.Lint(parse(c, tt.sql))), DeepEquals, tt.findings, Commentf("sql: %s", tt.sql))
+	}
+}
+
+// limitRule reports the SELECT statements without LIMIT.
+type limitRule struct{}
+
+func (limitRule) Meta() Meta {
+	return Meta{Name: "select-without-limit", Severity: SeverityInfo}
+}
+
+func (limitRule) Check(ctx *Context) {
+	if stmt, ok := ctx.Stmt.(*ast.SelectStmt); ok && stmt.Limit == nil {
+		ctx.Reportf(stmt, "add a LIMIT clause", "SELECT from %d tables has no LIMIT", 1)
+	}
+}
+
+func (s *testLintSuite) TestRegistry(c *C) {
+	registry := NewRegistry(limitRule{})
+	registry.Register(DefaultRules()[0])
+	c.Assert(registry.Rules(), HasLen, 2)
+	c.Assert(registry.Rule(RuleSelectStar), NotNil)
+	c.Assert(registry.Rule(RuleDeleteWithoutWhere), IsNil)
+	c.Assert(findings(registry.Lint(parse(c, "select * from t"))), DeepEquals, []string{
+		"1:1: info: SELECT from 1 tables has no LIMIT (select-without-limit)",
+		"1:8: warning: SELECT * is used (select-star)",
+	})
+	c.Assert(func() { registry.Register(limitRule{}) }, PanicMatches, "lint: Register called twice for rule select-without-limit")
+
+	registry.Unregister("select-without-limit")
+	registry.Unregister("no-such-rule")
+	c.Assert(registry.Rules(), HasLen, 1)
+	c.Assert(registry.Rule("select-without-limit"), IsNil)
+	c.Assert(findings(registry.Lint(parse(c, "select * from t limit 1"))), DeepEquals, []string{"1:8: warning: SELECT * is used (select-star)"})
+
+	c.Assert(SeverityWarning.String(), Equals, "warning")
+	c.Assert(Severity(10).String(), Equals, "unknown")
+}
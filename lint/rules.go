@@ -0,0 +1,248 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/opcode"
+)
+
+// Names of the rules in DefaultRules.
+const (
+	RuleSelectStar         = "select-star"
+	RuleUpdateWithoutWhere = "update-without-where"
+	RuleDeleteWithoutWhere = "delete-without-where"
+	RuleMissingPrimaryKey  = "missing-primary-key"
+	RuleMissingJoinCond    = "missing-join-condition"
+)
+
+// DefaultRules returns the starter rules.
+func DefaultRules() []Rule {
+	return []Rule{
+		selectStar{},
+		updateWithoutWhere{},
+		deleteWithoutWhere{},
+		missingPrimaryKey{},
+		missingJoinCond{},
+	}
+}
+
+// inspector calls itself for each node, the children are skipped if it returns false.
+type inspector func(ast.Node) bool
+
+func (f inspector) Enter(n ast.Node) (ast.Node, bool) {
+	return n, !f(n)
+}
+
+func (f inspector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// selectStar reports `SELECT *`, except in EXISTS subqueries where the fields don't matter.
+type selectStar struct{}
+
+func (selectStar) Meta() Meta {
+	return Meta{
+		Name:        RuleSelectStar,
+		Severity:    SeverityWarning,
+		Description: "SELECT * returns columns the query may not need, and changes when the table does.",
+	}
+}
+
+func (selectStar) Check(ctx *Context) {
+	ctx.Stmt.Accept(inspector(func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.ExistsSubqueryExpr:
+			return false
+		case *ast.SelectField:
+			if x.WildCard != nil {
+				ctx.Report(x, "list the columns explicitly", "SELECT * is used")
+			}
+		}
+		return true
+	}))
+}
+
+// updateWithoutWhere reports UPDATE statements which update all the rows.
+type updateWithoutWhere struct{}
+
+func (updateWithoutWhere) Meta() Meta {
+	return Meta{
+		Name:        RuleUpdateWithoutWhere,
+		Severity:    SeverityError,
+		Description: "UPDATE without WHERE updates all the rows of the table.",
+	}
+}
+
+func (updateWithoutWhere) Check(ctx *Context) {
+	if stmt, ok := ctx.Stmt.(*ast.UpdateStmt); ok && stmt.Where == nil {
+		ctx.Report(stmt, "add a WHERE clause", "UPDATE has no WHERE clause")
+	}
+}
+
+// deleteWithoutWhere reports DELETE statements which delete all the rows.
+type deleteWithoutWhere struct{}
+
+func (deleteWithoutWhere) Meta() Meta {
+	return Meta{
+		Name:        RuleDeleteWithoutWhere,
+		Severity:    SeverityError,
+		Description: "DELETE without WHERE deletes all the rows of the table.",
+	}
+}
+
+func (deleteWithoutWhere) Check(ctx *Context) {
+	if stmt, ok := ctx.Stmt.(*ast.DeleteStmt); ok && stmt.Where == nil {
+		ctx.Report(stmt, "add a WHERE clause, or use TRUNCATE TABLE to delete all the rows", "DELETE has no WHERE clause")
+	}
+}
+
+// missingPrimaryKey reports CREATE TABLE statements which define no primary key.
+type missingPrimaryKey struct{}
+
+func (missingPrimaryKey) Meta() Meta {
+	return Meta{
+		Name:        RuleMissingPrimaryKey,
+		Severity:    SeverityWarning,
+		Description: "A table without a primary key can't be replicated or updated by row efficiently.",
+	}
+}
+
+func (missingPrimaryKey) Check(ctx *Context) {
+	stmt, ok := ctx.Stmt.(*ast.CreateTableStmt)
+	// the columns of CREATE TABLE ... LIKE and CREATE TABLE ... SELECT come from elsewhere.
+	if !ok || stmt.ReferTable != nil || stmt.Select != nil {
+		return
+	}
+	for _, col := range stmt.Cols {
+		for _, opt := range col.Options {
+			if opt.Tp == ast.ColumnOptionPrimaryKey {
+				return
+			}
+		}
+	}
+	for _, cons := range stmt.Constraints {
+		if cons.Tp == ast.ConstraintPrimaryKey {
+			return
+		}
+	}
+	ctx.Reportf(stmt.Table, "add a PRIMARY KEY", "table %s has no primary key", stmt.Table.Name.O)
+}
+
+// missingJoinCond reports the joins without join conditions, such as `FROM t1, t2`,
+// unless the WHERE clause has a predicate on the columns of both sides, such as
+// `FROM t1, t2 WHERE t1.id = t2.id`. The explicit `t1 CROSS JOIN t2` is reported too,
+// since the AST doesn't tell it apart from `t1 JOIN t2`.
+type missingJoinCond struct{}
+
+func (missingJoinCond) Meta() Meta {
+	return Meta{
+		Name:        RuleMissingJoinCond,
+		Severity:    SeverityWarning,
+		Description: "A join without a join condition is a cross join, which is easily written by mistake.",
+	}
+}
+
+func (missingJoinCond) Check(ctx *Context) {
+	// wheres maps the joins of the FROM clauses to their WHERE clauses.
+	wheres := make(map[*ast.Join]ast.ExprNode)
+	ctx.Stmt.Accept(inspector(func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectStmt:
+			if n.From != nil {
+				setWhere(wheres, n.From.TableRefs, n.Where)
+			}
+		case *ast.UpdateStmt:
+			if n.TableRefs != nil {
+				setWhere(wheres, n.TableRefs.TableRefs, n.Where)
+			}
+		case *ast.DeleteStmt:
+			if n.TableRefs != nil {
+				setWhere(wheres, n.TableRefs.TableRefs, n.Where)
+			}
+		case *ast.Join:
+			if n.Right != nil && n.Tp == ast.CrossJoin && n.On == nil && len(n.Using) == 0 && !n.NaturalJoin &&
+				!joinedBy(wheres[n], tableNames(n.Left), tableNames(n.Right)) {
+				ctx.Report(n.Right, "use JOIN ... ON with the join condition", "join has no join condition")
+			}
+		}
+		return true
+	}))
+}
+
+// setWhere maps j and the joins it's made of to where.
+func setWhere(wheres map[*ast.Join]ast.ExprNode, j *ast.Join, where ast.ExprNode) {
+	if j == nil || where == nil {
+		return
+	}
+	wheres[j] = where
+	if left, ok := j.Left.(*ast.Join); ok {
+		setWhere(wheres, left, where)
+	}
+	if right, ok := j.Right.(*ast.Join); ok {
+		setWhere(wheres, right, where)
+	}
+}
+
+// tableNames returns the names of the tables of rs in lower case, by which their columns
+// are qualified.
+func tableNames(rs ast.ResultSetNode) map[string]bool {
+	names := make(map[string]bool)
+	var collect func(ast.ResultSetNode)
+	collect = func(rs ast.ResultSetNode) {
+		switch rs := rs.(type) {
+		case *ast.Join:
+			collect(rs.Left)
+			if rs.Right != nil {
+				collect(rs.Right)
+			}
+		case *ast.TableSource:
+			if rs.AsName.L != "" {
+				names[rs.AsName.L] = true
+			} else {
+				collect(rs.Source)
+			}
+		case *ast.TableName:
+			names[rs.Name.L] = true
+		}
+	}
+	collect(rs)
+	return names
+}
+
+// joinedBy tells whether a conjunct of where refers to a column of the left tables and
+// another column of the right tables. A column without a table may be of either side.
+func joinedBy(where ast.ExprNode, left, right map[string]bool) bool {
+	if where == nil {
+		return false
+	}
+	if e, ok := where.(*ast.BinaryOperationExpr); ok && e.Op == opcode.LogicAnd {
+		return joinedBy(e.L, left, right) || joinedBy(e.R, left, right)
+	}
+	var cols []*ast.ColumnName
+	where.Accept(inspector(func(n ast.Node) bool {
+		if col, ok := n.(*ast.ColumnName); ok {
+			cols = append(cols, col)
+		}
+		return true
+	}))
+	for i, l := range cols {
+		for j, r := range cols {
+			if i != j && (l.Table.L == "" || left[l.Table.L]) && (r.Table.L == "" || right[r.Table.L]) {
+				return true
+			}
+		}
+	}
+	return false
+}
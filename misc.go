@@ -162,6 +162,7 @@ var tokenMap = map[string]int{
 	"AS":                       as,
 	"ASC":                      asc,
 	"ASCII":                    ascii,
+	"AT":                       at,
 	"AUTO_ID_CACHE":            autoIdCache,
 	"AUTO_INCREMENT":           autoIncrement,
 	"AUTO_RANDOM":              autoRandom,
@@ -171,6 +172,7 @@ var tokenMap = map[string]int{
 	"BACKEND":                  backend,
 	"BACKUP":                   backup,
 	"BACKUPS":                  backups,
+	"BEFORE":                   before,
 	"BEGIN":                    begin,
 	"BETWEEN":                  between,
 	"BERNOULLI":                bernoulli,
@@ -203,6 +205,7 @@ var tokenMap = map[string]int{
 	"CASCADED":                 cascaded,
 	"CASE":                     caseKwd,
 	"CAST":                     cast,
+	"CATALOG_NAME":             catalogName,
 	"CAUSAL":                   causal,
 	"CHAIN":                    chain,
 	"CHANGE":                   change,
@@ -213,9 +216,11 @@ var tokenMap = map[string]int{
 	"CHECKPOINT":               checkpoint,
 	"CHECKSUM":                 checksum,
 	"CIPHER":                   cipher,
+	"CLASS_ORIGIN":             classOrigin,
 	"CLEANUP":                  cleanup,
 	"CLIENT":                   client,
 	"CLIENT_ERRORS_SUMMARY":    clientErrorsSummary,
+	"CLOSE":                    closeKwd,
 	"CLUSTERED":                clustered,
 	"CMSKETCH":                 cmSketch,
 	"COALESCE":                 coalesce,
@@ -224,20 +229,28 @@ var tokenMap = map[string]int{
 	"COLUMN_FORMAT":            columnFormat,
 	"COLUMN":                   column,
 	"COLUMNS":                  columns,
+	"COLUMN_NAME":              columnName,
 	"COMMENT":                  comment,
 	"COMMIT":                   commit,
 	"COMMITTED":                committed,
 	"COMPACT":                  compact,
+	"COMPLETION":               completion,
 	"COMPRESSED":               compressed,
 	"COMPRESSION":              compression,
 	"CONCURRENCY":              concurrency,
+	"CONDITION":                condition,
 	"CONFIG":                   config,
 	"CONNECTION":               connection,
 	"CONSISTENCY":              consistency,
 	"CONSISTENT":               consistent,
 	"CONSTRAINT":               constraint,
 	"CONSTRAINTS":              constraints,
+	"CONSTRAINT_CATALOG":       constraintCatalog,
+	"CONSTRAINT_NAME":          constraintName,
+	"CONSTRAINT_SCHEMA":        constraintSchema,
+	"CONTAINS":                 contains,
 	"CONTEXT":                  context,
+	"CONTINUE":                 continueKwd,
 	"CONVERT":                  convert,
 	"COPY":                     copyKwd,
 	"CORRELATION":              correlation,
@@ -257,6 +270,8 @@ var tokenMap = map[string]int{
 	"CURRENT_TIMESTAMP":        currentTs,
 	"CURRENT_USER":             currentUser,
 	"CURRENT":                  current,
+	"CURSOR":                   cursor,
+	"CURSOR_NAME":              cursorName,
 	"CURTIME":                  curTime,
 	"CYCLE":                    cycle,
 	"DATA":                     data,
@@ -275,6 +290,7 @@ var tokenMap = map[string]int{
 	"DEALLOCATE":               deallocate,
 	"DEC":                      decimalType,
 	"DECIMAL":                  decimalType,
+	"DECLARE":                  declare,
 	"DEFAULT":                  defaultKwd,
 	"DEFINER":                  definer,
 	"DELAY_KEY_WRITE":          delayKeyWrite,
@@ -284,6 +300,8 @@ var tokenMap = map[string]int{
 	"DEPTH":                    depth,
 	"DESC":                     desc,
 	"DESCRIBE":                 describe,
+	"DETERMINISTIC":            deterministic,
+	"DIAGNOSTICS":              diagnostics,
 	"DIRECTORY":                directory,
 	"DISABLE":                  disable,
 	"DISCARD":                  discard,
@@ -298,11 +316,15 @@ var tokenMap = map[string]int{
 	"DUAL":                     dual,
 	"DUPLICATE":                duplicate,
 	"DYNAMIC":                  dynamic,
+	"EACH":                     each,
 	"ELSE":                     elseKwd,
+	"ELSEIF":                   elseIfKwd,
+	"EMPTY":                    emptyKwd,
 	"ENABLE":                   enable,
 	"ENCLOSED":                 enclosed,
 	"ENCRYPTION":               encryption,
 	"END":                      end,
+	"ENDS":                     ends,
 	"ENFORCED":                 enforced,
 	"ENGINE":                   engine,
 	"ENGINES":                  engines,
@@ -313,6 +335,7 @@ var tokenMap = map[string]int{
 	"ESCAPED":                  escaped,
 	"EVENT":                    event,
 	"EVENTS":                   events,
+	"EVERY":                    every,
 	"EVOLVE":                   evolve,
 	"EXACT":                    exact,
 	"EXCEPT":                   except,
@@ -320,6 +343,7 @@ var tokenMap = map[string]int{
 	"EXCLUSIVE":                exclusive,
 	"EXECUTE":                  execute,
 	"EXISTS":                   exists,
+	"EXIT":                     exit,
 	"EXPANSION":                expansion,
 	"EXPIRE":                   expire,
 	"EXPLAIN":                  explain,
@@ -338,22 +362,26 @@ var tokenMap = map[string]int{
 	"FLUSH":                    flush,
 	"FOLLOWER":                 follower,
 	"FOLLOWING":                following,
+	"FOLLOWS":                  follows,
 	"FOR":                      forKwd,
 	"FORCE":                    force,
 	"FOREIGN":                  foreign,
 	"FORMAT":                   format,
+	"FOUND":                    found,
 	"FROM":                     from,
 	"FULL":                     full,
 	"FULLTEXT":                 fulltext,
 	"FUNCTION":                 function,
 	"GENERAL":                  general,
 	"GENERATED":                generated,
+	"GET":                      get,
 	"GET_FORMAT":               getFormat,
 	"GLOBAL":                   global,
 	"GRANT":                    grant,
 	"GRANTS":                   grants,
 	"GROUP_CONCAT":             groupConcat,
 	"GROUP":                    group,
+	"HANDLER":                  handler,
 	"HASH":                     hash,
 	"HAVING":                   having,
 	"HIGH_PRIORITY":            highPriority,
@@ -376,6 +404,7 @@ var tokenMap = map[string]int{
 	"INDEXES":                  indexes,
 	"INFILE":                   infile,
 	"INNER":                    inner,
+	"INOUT":                    inout,
 	"INPLACE":                  inplace,
 	"INSERT_METHOD":            insertMethod,
 	"INSERT":                   insert,
@@ -399,12 +428,14 @@ var tokenMap = map[string]int{
 	"IS":                       is,
 	"ISOLATION":                isolation,
 	"ISSUER":                   issuer,
+	"ITERATE":                  iterate,
 	"JOB":                      job,
 	"JOBS":                     jobs,
 	"JOIN":                     join,
 	"JSON_ARRAYAGG":            jsonArrayagg,
 	"JSON_OBJECTAGG":           jsonObjectAgg,
 	"JSON":                     jsonType,
+	"JSON_TABLE":               jsonTable,
 	"KEY_BLOCK_SIZE":           keyBlockSize,
 	"KEY":                      key,
 	"KEYS":                     keys,
@@ -417,6 +448,7 @@ var tokenMap = map[string]int{
 	"LEADER":                   leader,
 	"LEADING":                  leading,
 	"LEARNER":                  learner,
+	"LEAVE":                    leave,
 	"LEFT":                     left,
 	"LESS":                     less,
 	"LEVEL":                    level,
@@ -436,6 +468,7 @@ var tokenMap = map[string]int{
 	"LONG":                     long,
 	"LONGBLOB":                 longblobType,
 	"LONGTEXT":                 longtextType,
+	"LOOP":                     loop,
 	"LOW_PRIORITY":             lowPriority,
 	"MASTER":                   master,
 	"MATCH":                    match,
@@ -454,6 +487,7 @@ var tokenMap = map[string]int{
 	"MEDIUMTEXT":               mediumtextType,
 	"MEMORY":                   memory,
 	"MERGE":                    merge,
+	"MESSAGE_TEXT":             messageText,
 	"MICROSECOND":              microsecond,
 	"MIN_ROWS":                 minRows,
 	"MIN":                      min,
@@ -463,12 +497,15 @@ var tokenMap = map[string]int{
 	"MINVALUE":                 minValue,
 	"MOD":                      mod,
 	"MODE":                     mode,
+	"MODIFIES":                 modifies,
 	"MODIFY":                   modify,
 	"MONTH":                    month,
+	"MYSQL_ERRNO":              mysqlErrno,
 	"NAMES":                    names,
 	"NATIONAL":                 national,
 	"NATURAL":                  natural,
 	"NCHAR":                    ncharType,
+	"NESTED":                   nested,
 	"NEVER":                    never,
 	"NEXT_ROW_ID":              next_row_id,
 	"NEXT":                     next,
@@ -489,6 +526,7 @@ var tokenMap = map[string]int{
 	"NOWAIT":                   nowait,
 	"NULL":                     null,
 	"NULLS":                    nulls,
+	"NUMBER":                   number,
 	"NUMERIC":                  numericType,
 	"NVARCHAR":                 nvarcharType,
 	"OF":                       of,
@@ -507,6 +545,8 @@ var tokenMap = map[string]int{
 	"OPTIONALLY":               optionally,
 	"OR":                       or,
 	"ORDER":                    order,
+	"ORDINALITY":               ordinality,
+	"OUT":                      out,
 	"OUTER":                    outer,
 	"OUTFILE":                  outfile,
 	"PACK_KEYS":                packKeys,
@@ -517,6 +557,7 @@ var tokenMap = map[string]int{
 	"PARTITIONING":             partitioning,
 	"PARTITIONS":               partitions,
 	"PASSWORD":                 password,
+	"PATH":                     pathKwd,
 	"PERCENT":                  percent,
 	"PER_DB":                   per_db,
 	"PER_TABLE":                per_table,
@@ -525,6 +566,7 @@ var tokenMap = map[string]int{
 	"PLUGINS":                  plugins,
 	"POLICY":                   policy,
 	"POSITION":                 position,
+	"PRECEDES":                 precedes,
 	"PRE_SPLIT_REGIONS":        preSplitRegions,
 	"PRECEDING":                preceding,
 	"PRECISION":                precisionType,
@@ -547,6 +589,7 @@ var tokenMap = map[string]int{
 	"RANGE":                    rangeKwd,
 	"RATE_LIMIT":               rateLimit,
 	"READ":                     read,
+	"READS":                    reads,
 	"REAL":                     realType,
 	"REBUILD":                  rebuild,
 	"RECENT":                   recent,
@@ -572,11 +615,15 @@ var tokenMap = map[string]int{
 	"REQUIRE":                  require,
 	"REQUIRED":                 required,
 	"RESET":                    reset,
+	"RESIGNAL":                 resignal,
 	"RESPECT":                  respect,
 	"RESTART":                  restart,
 	"RESTORE":                  restore,
 	"RESTORES":                 restores,
 	"RESTRICT":                 restrict,
+	"RETURN":                   returnKwd,
+	"RETURNED_SQLSTATE":        returnedSQLState,
+	"RETURNS":                  returns,
 	"REVERSE":                  reverse,
 	"REVOKE":                   revoke,
 	"RIGHT":                    right,
@@ -594,8 +641,10 @@ var tokenMap = map[string]int{
 	"S3":                       s3,
 	"SAMPLES":                  samples,
 	"SAN":                      san,
+	"SCHEDULE":                 schedule,
 	"SCHEMA":                   database,
 	"SCHEMAS":                  databases,
+	"SCHEMA_NAME":              schemaName,
 	"SECOND_MICROSECOND":       secondMicrosecond,
 	"SECOND":                   second,
 	"SECONDARY_ENGINE":         secondaryEngine,
@@ -616,6 +665,7 @@ var tokenMap = map[string]int{
 	"SHARED":                   shared,
 	"SHOW":                     show,
 	"SHUTDOWN":                 shutdown,
+	"SIGNAL":                   signal,
 	"SIGNED":                   signed,
 	"SIMPLE":                   simple,
 	"SKIP":                     skip,
@@ -628,6 +678,9 @@ var tokenMap = map[string]int{
 	"SOURCE":                   source,
 	"SPATIAL":                  spatial,
 	"SPLIT":                    split,
+	"SQLEXCEPTION":             sqlexception,
+	"SQLSTATE":                 sqlstate,
+	"SQLWARNING":               sqlwarning,
 	"SQL_BIG_RESULT":           sqlBigResult,
 	"SQL_BUFFER_RESULT":        sqlBufferResult,
 	"SQL_CACHE":                sqlCache,
@@ -644,9 +697,11 @@ var tokenMap = map[string]int{
 	"SQL_TSI_YEAR":             sqlTsiYear,
 	"SQL":                      sql,
 	"SSL":                      ssl,
+	"STACKED":                  stacked,
 	"STALENESS":                staleness,
 	"START":                    start,
 	"STARTING":                 starting,
+	"STARTS":                   starts,
 	"STATISTICS":               statistics,
 	"STATS_AUTO_RECALC":        statsAutoRecalc,
 	"STATS_BUCKETS":            statsBuckets,
@@ -670,6 +725,7 @@ var tokenMap = map[string]int{
 	"STRICT":                   strict,
 	"STRICT_FORMAT":            strictFormat,
 	"STRONG":                   strong,
+	"SUBCLASS_ORIGIN":          subclassOrigin,
 	"SUBDATE":                  subDate,
 	"SUBJECT":                  subject,
 	"SUBPARTITION":             subpartition,
@@ -687,6 +743,7 @@ var tokenMap = map[string]int{
 	"TABLES":                   tables,
 	"TABLESAMPLE":              tableSample,
 	"TABLESPACE":               tablespace,
+	"TABLE_NAME":               tableName,
 	"TELEMETRY":                telemetry,
 	"TELEMETRY_ID":             telemetryID,
 	"TEMPORARY":                temporary,
@@ -730,12 +787,14 @@ var tokenMap = map[string]int{
 	"UNBOUNDED":                unbounded,
 	"UNCOMMITTED":              uncommitted,
 	"UNDEFINED":                undefined,
+	"UNDO":                     undo,
 	"UNICODE":                  unicodeSym,
 	"UNION":                    union,
 	"UNIQUE":                   unique,
 	"UNKNOWN":                  unknown,
 	"UNLOCK":                   unlock,
 	"UNSIGNED":                 unsigned,
+	"UNTIL":                    until,
 	"UPDATE":                   update,
 	"USAGE":                    usage,
 	"USE":                      use,
@@ -764,6 +823,7 @@ var tokenMap = map[string]int{
 	"WEIGHT_STRING":            weightString,
 	"WHEN":                     when,
 	"WHERE":                    where,
+	"WHILE":                    while,
 	"WIDTH":                    width,
 	"WITH":                     with,
 	"WITHOUT":                  without,
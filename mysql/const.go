@@ -309,6 +309,22 @@ var DefaultLengthOfTimeFraction = map[int]int{
 // See https://dev.mysql.com/doc/refman/5.7/en/sql-mode.html
 type SQLMode int
 
+// Expand returns m with the modes which the combination modes in m stand for, e.g. ModeANSI stands for
+// ModeRealAsFloat, ModePipesAsConcat, ModeANSIQuotes, ModeIgnoreSpace and ModeOnlyFullGroupBy.
+// See CombinationSQLMode.
+func (m SQLMode) Expand() SQLMode {
+	expanded := m
+	for name, parts := range CombinationSQLMode {
+		if m&Str2SQLMode[name] == 0 {
+			continue
+		}
+		for _, part := range parts {
+			expanded |= Str2SQLMode[part]
+		}
+	}
+	return expanded
+}
+
 // HasNoZeroDateMode detects if 'NO_ZERO_DATE' mode is set in SQLMode
 func (m SQLMode) HasNoZeroDateMode() bool {
 	return m&ModeNoZeroDate == ModeNoZeroDate
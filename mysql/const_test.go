@@ -102,3 +102,16 @@ func (s *testConstSuite) TestSQLMode(c *C) {
 		c.Assert(int(ca.code), Equals, ca.value)
 	}
 }
+
+func (s *testConstSuite) TestExpandSQLMode(c *C) {
+	c.Assert(SQLMode(ModeNone).Expand(), Equals, SQLMode(ModeNone))
+	c.Assert(ModeNoBackslashEscapes.Expand(), Equals, ModeNoBackslashEscapes)
+	c.Assert((ModeANSI | ModeNoBackslashEscapes).Expand(), Equals,
+		ModeANSI|ModeNoBackslashEscapes|ModeRealAsFloat|ModePipesAsConcat|ModeANSIQuotes|ModeIgnoreSpace|ModeOnlyFullGroupBy)
+	c.Assert(ModeTraditional.Expand(), Equals, ModeTraditional|ModeStrictTransTables|ModeStrictAllTables|ModeNoZeroInDate|
+		ModeNoZeroDate|ModeErrorForDivisionByZero|ModeNoAutoCreateUser|ModeNoEngineSubstitution)
+
+	mode, err := GetSQLMode(FormatSQLModeStr("ansi"))
+	c.Assert(err, IsNil)
+	c.Assert(ModeANSI.Expand(), Equals, mode)
+}
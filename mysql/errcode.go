@@ -942,11 +942,14 @@ const (
 	ErrFunctionalIndexOnField                                = 3762
 	ErrFKIncompatibleColumns                                 = 3780
 	ErrFunctionalIndexRowValueIsNotAllowed                   = 3800
+	ErrCheckConstraintNotFound                               = 3821
+	ErrCheckConstraintDupName                                = 3822
 	ErrDependentByFunctionalIndex                            = 3837
 	ErrInvalidJsonValueForFuncIndex                          = 3903
 	ErrJsonValueOutOfRangeForFuncIndex                       = 3904
 	ErrFunctionalIndexDataIsTooLong                          = 3907
 	ErrFunctionalIndexNotApplicable                          = 3909
+	ErrDependentByCheckConstraint                            = 3959
 
 	// MariaDB errors.
 	ErrOnlyOneDefaultPartionAllowed         = 4030
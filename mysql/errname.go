@@ -949,11 +949,14 @@ var MySQLErrName = map[uint16]*ErrMessage{
 	ErrFunctionalIndexOnField:                                Message("Functional index on a column is not supported. Consider using a regular index instead", nil),
 	ErrFKIncompatibleColumns:                                 Message("Referencing column '%s' in foreign key constraint '%s' are incompatible", nil),
 	ErrFunctionalIndexRowValueIsNotAllowed:                   Message("Expression of functional index '%s' cannot refer to a row value", nil),
+	ErrCheckConstraintNotFound:                               Message("Check constraint '%-.192s' is not found in the table.", nil),
+	ErrCheckConstraintDupName:                                Message("Duplicate check constraint name '%s'.", nil),
 	ErrDependentByFunctionalIndex:                            Message("Column '%s' has a functional index dependency and cannot be dropped or renamed", nil),
 	ErrInvalidJsonValueForFuncIndex:                          Message("Invalid JSON value for CAST for functional index '%s'", nil),
 	ErrJsonValueOutOfRangeForFuncIndex:                       Message("Out of range JSON value for CAST for functional index '%s'", nil),
 	ErrFunctionalIndexDataIsTooLong:                          Message("Data too long for functional index '%s'", nil),
 	ErrFunctionalIndexNotApplicable:                          Message("Cannot use functional index '%s' due to type or collation conversion", nil),
+	ErrDependentByCheckConstraint:                            Message("Check constraint '%-.192s' uses column '%-.192s', hence column cannot be dropped or renamed.", nil),
 
 	// MariaDB errors.
 	ErrOnlyOneDefaultPartionAllowed:         Message("Only one DEFAULT partition allowed", nil),
@@ -236,6 +236,11 @@ func (o Op) IsKeyword() bool {
 
 // Restore the Op into a Writer
 func (o Op) Restore(ctx *format.RestoreCtx) error {
+	// `!=` isn't standard SQL.
+	if o == NE && ctx.Dialect == format.DialectANSI {
+		ctx.WritePlain("<>")
+		return nil
+	}
 	info := &ops[o]
 	if info.isKeyword {
 		ctx.WriteKeyWord(info.literal)
@@ -41,6 +41,8 @@ import (
 
 %union {
 	offset int // offset
+	startPos Pos
+	endPos Pos
 	item interface{}
 	ident string
 	expr ast.ExprNode
@@ -62,6 +64,7 @@ import (
 	doubleAtIdentifier "identifier with double leading at"
 	invalid            "a special token never used by parser, used by lexer to indicate error"
 	hintComment        "an optimizer hint"
+	delimiterDirective "a DELIMITER directive"
 	andand             "&&"
 	pipes              "||"
 
@@ -78,6 +81,7 @@ import (
 	and               "AND"
 	as                "AS"
 	asc               "ASC"
+	before            "BEFORE"
 	between           "BETWEEN"
 	bigIntType        "BIGINT"
 	binaryType        "BINARY"
@@ -93,7 +97,9 @@ import (
 	check             "CHECK"
 	collate           "COLLATE"
 	column            "COLUMN"
+	condition         "CONDITION"
 	constraint        "CONSTRAINT"
+	continueKwd       "CONTINUE"
 	convert           "CONVERT"
 	create            "CREATE"
 	cross             "CROSS"
@@ -103,6 +109,7 @@ import (
 	currentTs         "CURRENT_TIMESTAMP"
 	currentUser       "CURRENT_USER"
 	currentRole       "CURRENT_ROLE"
+	cursor            "CURSOR"
 	database          "DATABASE"
 	databases         "DATABASES"
 	dayHour           "DAY_HOUR"
@@ -110,22 +117,27 @@ import (
 	dayMinute         "DAY_MINUTE"
 	daySecond         "DAY_SECOND"
 	decimalType       "DECIMAL"
+	declare           "DECLARE"
 	defaultKwd        "DEFAULT"
 	delayed           "DELAYED"
 	deleteKwd         "DELETE"
 	denseRank         "DENSE_RANK"
 	desc              "DESC"
 	describe          "DESCRIBE"
+	deterministic     "DETERMINISTIC"
 	distinct          "DISTINCT"
 	distinctRow       "DISTINCTROW"
 	div               "DIV"
 	doubleType        "DOUBLE"
 	drop              "DROP"
 	dual              "DUAL"
+	each              "EACH"
 	elseKwd           "ELSE"
+	elseIfKwd         "ELSEIF"
 	enclosed          "ENCLOSED"
 	escaped           "ESCAPED"
 	exists            "EXISTS"
+	exit              "EXIT"
 	explain           "EXPLAIN"
 	except            "EXCEPT"
 	falseKwd          "FALSE"
@@ -138,6 +150,7 @@ import (
 	from              "FROM"
 	fulltext          "FULLTEXT"
 	generated         "GENERATED"
+	get               "GET"
 	grant             "GRANT"
 	group             "GROUP"
 	groups            "GROUPS"
@@ -152,10 +165,17 @@ import (
 	index             "INDEX"
 	infile            "INFILE"
 	inner             "INNER"
+	inout             "INOUT"
 	integerType       "INTEGER"
 	intersect         "INTERSECT"
 	interval          "INTERVAL"
 	into              "INTO"
+	iterate           "ITERATE"
+	jsonTable         "JSON_TABLE"
+	leave             "LEAVE"
+	loop              "LOOP"
+	modifies          "MODIFIES"
+	out               "OUT"
 	outfile           "OUTFILE"
 	is                "IS"
 	insert            "INSERT"
@@ -217,6 +237,7 @@ import (
 	rangeKwd          "RANGE"
 	rank              "RANK"
 	read              "READ"
+	reads             "READS"
 	realType          "REAL"
 	recursive         "RECURSIVE"
 	references        "REFERENCES"
@@ -226,7 +247,9 @@ import (
 	repeat            "REPEAT"
 	replace           "REPLACE"
 	require           "REQUIRE"
+	resignal          "RESIGNAL"
 	restrict          "RESTRICT"
+	returnKwd         "RETURN"
 	revoke            "REVOKE"
 	right             "RIGHT"
 	rlike             "RLIKE"
@@ -237,9 +260,13 @@ import (
 	selectKwd         "SELECT"
 	set               "SET"
 	show              "SHOW"
+	signal            "SIGNAL"
 	smallIntType      "SMALLINT"
 	spatial           "SPATIAL"
 	sql               "SQL"
+	sqlexception      "SQLEXCEPTION"
+	sqlstate          "SQLSTATE"
+	sqlwarning        "SQLWARNING"
 	sqlBigResult      "SQL_BIG_RESULT"
 	sqlCalcFoundRows  "SQL_CALC_FOUND_ROWS"
 	sqlSmallResult    "SQL_SMALL_RESULT"
@@ -259,10 +286,12 @@ import (
 	trailing          "TRAILING"
 	trigger           "TRIGGER"
 	trueKwd           "TRUE"
+	undo              "UNDO"
 	unique            "UNIQUE"
 	union             "UNION"
 	unlock            "UNLOCK"
 	unsigned          "UNSIGNED"
+	until             "UNTIL"
 	update            "UPDATE"
 	usage             "USAGE"
 	use               "USE"
@@ -279,6 +308,7 @@ import (
 	virtual           "VIRTUAL"
 	when              "WHEN"
 	where             "WHERE"
+	while             "WHILE"
 	write             "WRITE"
 	window            "WINDOW"
 	with              "WITH"
@@ -298,6 +328,7 @@ import (
 	always                "ALWAYS"
 	any                   "ANY"
 	ascii                 "ASCII"
+	at                    "AT"
 	autoIdCache           "AUTO_ID_CACHE"
 	autoIncrement         "AUTO_INCREMENT"
 	autoRandom            "AUTO_RANDOM"
@@ -321,19 +352,24 @@ import (
 	cache                 "CACHE"
 	capture               "CAPTURE"
 	cascaded              "CASCADED"
+	catalogName           "CATALOG_NAME"
 	causal                "CAUSAL"
 	chain                 "CHAIN"
 	charsetKwd            "CHARSET"
 	checkpoint            "CHECKPOINT"
 	checksum              "CHECKSUM"
 	cipher                "CIPHER"
+	classOrigin           "CLASS_ORIGIN"
 	cleanup               "CLEANUP"
 	client                "CLIENT"
 	clientErrorsSummary   "CLIENT_ERRORS_SUMMARY"
+	closeKwd              "CLOSE"
 	coalesce              "COALESCE"
 	collation             "COLLATION"
 	columnFormat          "COLUMN_FORMAT"
 	columns               "COLUMNS"
+	columnName            "COLUMN_NAME"
+	completion            "COMPLETION"
 	config                "CONFIG"
 	comment               "COMMENT"
 	commit                "COMMIT"
@@ -346,6 +382,10 @@ import (
 	consistency           "CONSISTENCY"
 	consistent            "CONSISTENT"
 	constraints           "CONSTRAINTS"
+	constraintCatalog     "CONSTRAINT_CATALOG"
+	constraintName        "CONSTRAINT_NAME"
+	constraintSchema      "CONSTRAINT_SCHEMA"
+	contains              "CONTAINS"
 	context               "CONTEXT"
 	cpu                   "CPU"
 	csvBackslashEscape    "CSV_BACKSLASH_ESCAPE"
@@ -357,6 +397,7 @@ import (
 	csvTrimLastSeparators "CSV_TRIM_LAST_SEPARATORS"
 	current               "CURRENT"
 	clustered             "CLUSTERED"
+	cursorName            "CURSOR_NAME"
 	cycle                 "CYCLE"
 	data                  "DATA"
 	datetimeType          "DATETIME"
@@ -365,6 +406,7 @@ import (
 	deallocate            "DEALLOCATE"
 	definer               "DEFINER"
 	delayKeyWrite         "DELAY_KEY_WRITE"
+	diagnostics           "DIAGNOSTICS"
 	directory             "DIRECTORY"
 	disable               "DISABLE"
 	discard               "DISCARD"
@@ -372,9 +414,11 @@ import (
 	do                    "DO"
 	duplicate             "DUPLICATE"
 	dynamic               "DYNAMIC"
+	emptyKwd              "EMPTY"
 	enable                "ENABLE"
 	encryption            "ENCRYPTION"
 	end                   "END"
+	ends                  "ENDS"
 	enforced              "ENFORCED"
 	engine                "ENGINE"
 	engines               "ENGINES"
@@ -383,6 +427,7 @@ import (
 	escape                "ESCAPE"
 	event                 "EVENT"
 	events                "EVENTS"
+	every                 "EVERY"
 	evolve                "EVOLVE"
 	exchange              "EXCHANGE"
 	exclusive             "EXCLUSIVE"
@@ -397,12 +442,15 @@ import (
 	fixed                 "FIXED"
 	flush                 "FLUSH"
 	following             "FOLLOWING"
+	follows               "FOLLOWS"
 	format                "FORMAT"
+	found                 "FOUND"
 	full                  "FULL"
 	function              "FUNCTION"
 	general               "GENERAL"
 	global                "GLOBAL"
 	grants                "GRANTS"
+	handler               "HANDLER"
 	hash                  "HASH"
 	histogram             "HISTOGRAM"
 	history               "HISTORY"
@@ -448,6 +496,7 @@ import (
 	mb                    "MB"
 	memory                "MEMORY"
 	merge                 "MERGE"
+	messageText           "MESSAGE_TEXT"
 	microsecond           "MICROSECOND"
 	minRows               "MIN_ROWS"
 	minute                "MINUTE"
@@ -455,9 +504,11 @@ import (
 	mode                  "MODE"
 	modify                "MODIFY"
 	month                 "MONTH"
+	mysqlErrno            "MYSQL_ERRNO"
 	names                 "NAMES"
 	national              "NATIONAL"
 	ncharType             "NCHAR"
+	nested                "NESTED"
 	never                 "NEVER"
 	next                  "NEXT"
 	nextval               "NEXTVAL"
@@ -470,6 +521,7 @@ import (
 	nonclustered          "NONCLUSTERED"
 	none                  "NONE"
 	nowait                "NOWAIT"
+	number                "NUMBER"
 	nvarcharType          "NVARCHAR"
 	nulls                 "NULLS"
 	off                   "OFF"
@@ -479,6 +531,7 @@ import (
 	only                  "ONLY"
 	open                  "OPEN"
 	optional              "OPTIONAL"
+	ordinality            "ORDINALITY"
 	packKeys              "PACK_KEYS"
 	pageSym               "PAGE"
 	parser                "PARSER"
@@ -486,12 +539,14 @@ import (
 	partitioning          "PARTITIONING"
 	partitions            "PARTITIONS"
 	password              "PASSWORD"
+	pathKwd               "PATH"
 	percent               "PERCENT"
 	per_db                "PER_DB"
 	per_table             "PER_TABLE"
 	pipesAsOr
 	plugins               "PLUGINS"
 	policy                "POLICY"
+	precedes              "PRECEDES"
 	preSplitRegions       "PRE_SPLIT_REGIONS"
 	preceding             "PRECEDING"
 	prepare               "PREPARE"
@@ -525,6 +580,8 @@ import (
 	restore               "RESTORE"
 	restores              "RESTORES"
 	resume                "RESUME"
+	returnedSQLState      "RETURNED_SQLSTATE"
+	returns               "RETURNS"
 	reverse               "REVERSE"
 	role                  "ROLE"
 	rollback              "ROLLBACK"
@@ -533,6 +590,8 @@ import (
 	rowFormat             "ROW_FORMAT"
 	rtree                 "RTREE"
 	san                   "SAN"
+	schedule              "SCHEDULE"
+	schemaName            "SCHEMA_NAME"
 	second                "SECOND"
 	secondaryEngine       "SECONDARY_ENGINE"
 	secondaryLoad         "SECONDARY_LOAD"
@@ -569,13 +628,16 @@ import (
 	sqlTsiSecond          "SQL_TSI_SECOND"
 	sqlTsiWeek            "SQL_TSI_WEEK"
 	sqlTsiYear            "SQL_TSI_YEAR"
+	stacked               "STACKED"
 	start                 "START"
+	starts                "STARTS"
 	statsAutoRecalc       "STATS_AUTO_RECALC"
 	statsPersistent       "STATS_PERSISTENT"
 	statsSamplePages      "STATS_SAMPLE_PAGES"
 	status                "STATUS"
 	storage               "STORAGE"
 	strictFormat          "STRICT_FORMAT"
+	subclassOrigin        "SUBCLASS_ORIGIN"
 	subject               "SUBJECT"
 	subpartition          "SUBPARTITION"
 	subpartitions         "SUBPARTITIONS"
@@ -587,6 +649,7 @@ import (
 	tableChecksum         "TABLE_CHECKSUM"
 	tables                "TABLES"
 	tablespace            "TABLESPACE"
+	tableName             "TABLE_NAME"
 	temporary             "TEMPORARY"
 	temptable             "TEMPTABLE"
 	textType              "TEXT"
@@ -782,6 +845,7 @@ import (
 
 %token not2
 %type	<expr>
+	DiagnosticsTarget      "GET DIAGNOSTICS target variable"
 	Expression             "expression"
 	MaxValueOrExpression   "maxvalue or expression"
 	BoolPri                "boolean primary expression"
@@ -823,11 +887,40 @@ import (
 	AlterSequenceStmt      "Alter sequence statement"
 	AnalyzeTableStmt       "Analyze table statement"
 	BeginTransactionStmt   "BEGIN TRANSACTION statement"
+	StartTransactionStmt   "START TRANSACTION statement"
 	BinlogStmt             "Binlog base64 statement"
 	BRIEStmt               "BACKUP or RESTORE statement"
 	CommitStmt             "COMMIT statement"
 	CreateTableStmt        "CREATE TABLE statement"
 	CreateViewStmt         "CREATE VIEW  statement"
+	CreateProcedureStmt    "CREATE PROCEDURE statement"
+	CreateFunctionStmt     "CREATE FUNCTION statement"
+	CreateTriggerStmt      "CREATE TRIGGER statement"
+	CreateEventStmt        "CREATE EVENT statement"
+	AlterProcedureStmt     "ALTER PROCEDURE or ALTER FUNCTION statement"
+	AlterEventStmt         "ALTER EVENT statement"
+	DropProcedureStmt      "DROP PROCEDURE or DROP FUNCTION statement"
+	DropTriggerStmt        "DROP TRIGGER statement"
+	DropEventStmt          "DROP EVENT statement"
+	ReturnStmt             "RETURN statement"
+	RoutineBody            "stored program body"
+	RoutineStmt            "statement allowed in stored program body"
+	LabeledCompoundStmt    "compound statement with optional label"
+	LabelableStmt          "compound statement which can be labeled"
+	BlockStmt              "BEGIN ... END compound statement"
+	ProcedureDecl          "stored program declaration"
+	IfStmt                 "IF statement"
+	CaseStmt               "CASE statement"
+	LoopStmt               "LOOP statement"
+	WhileStmt              "WHILE statement"
+	RepeatStmt             "REPEAT statement"
+	LeaveStmt              "LEAVE statement"
+	IterateStmt            "ITERATE statement"
+	OpenCursorStmt         "OPEN statement"
+	FetchCursorStmt        "FETCH statement"
+	CloseCursorStmt        "CLOSE statement"
+	SignalStmt             "SIGNAL or RESIGNAL statement"
+	GetDiagnosticsStmt     "GET DIAGNOSTICS statement"
 	CreateUserStmt         "CREATE User statement"
 	CreateRoleStmt         "CREATE Role statement"
 	CreateDatabaseStmt     "Create Database Statement"
@@ -850,6 +943,7 @@ import (
 	DropBindingStmt        "DROP BINDING  statement"
 	DeallocateStmt         "Deallocate prepared statement"
 	DeleteFromStmt         "DELETE FROM statement"
+	DelimiterStmt          "DELIMITER directive"
 	DeleteWithoutUsingStmt "Normal DELETE statement"
 	DeleteWithUsingStmt    "DELETE USING statement"
 	EmptyStmt              "empty statement"
@@ -1018,10 +1112,16 @@ import (
 	IndexPartSpecificationList             "List of index column name or expression"
 	IndexPartSpecificationListOpt          "Optional list of index column name or expression"
 	InsertValues                           "Rest part of INSERT/REPLACE INTO statement"
+	JSONTable                              "JSON_TABLE table function"
+	JSONTableColumn                        "JSON_TABLE column definition"
+	JSONTableColumnList                    "JSON_TABLE column definition list"
+	JSONTableOnEmptyOnErrorOpt             "JSON_TABLE ON EMPTY and ON ERROR clauses or empty"
+	JSONTableResponse                      "JSON_TABLE ON EMPTY or ON ERROR response"
 	JoinTable                              "join table"
 	JoinType                               "join type"
 	KillOrKillTiDB                         "Kill or Kill TiDB"
 	LocationLabelList                      "location label name list"
+	LikeEscapeOpt                          "like escape option"
 	LikeTableWithOrWithoutParen            "LIKE table_name or ( LIKE table_name )"
 	LimitClause                            "LIMIT clause"
 	LimitOption                            "Limit option could be integer or parameter marker."
@@ -1108,6 +1208,9 @@ import (
 	SelectStmtFromTable                    "SELECT statement from table"
 	SelectStmtGroup                        "SELECT statement optional GROUP BY clause"
 	SelectStmtIntoOption                   "SELECT statement into clause"
+	SelectStmtInto                         "SELECT statement INTO"
+	SelectIntoVar                          "SELECT INTO variable"
+	SelectIntoVarList                      "SELECT INTO variable list"
 	SequenceOption                         "Create sequence option"
 	SequenceOptionList                     "Create sequence option list"
 	SetRoleOpt                             "Set role options"
@@ -1181,6 +1284,53 @@ import (
 	VariableAssignment                     "set variable value"
 	VariableAssignmentList                 "set variable value list"
 	ViewAlgorithm                          "view algorithm"
+	ProcedureParamListOpt                  "stored procedure parameter list opt"
+	ProcedureParamList                     "stored procedure parameter list"
+	ProcedureParam                         "stored procedure parameter"
+	ProcedureParamMode                     "stored procedure parameter mode"
+	FunctionParamListOpt                   "stored function parameter list opt"
+	FunctionParamList                      "stored function parameter list"
+	FunctionParam                          "stored function parameter"
+	RoutineCharacteristicListOpt           "stored routine characteristic list opt"
+	RoutineCharacteristicList              "stored routine characteristic list"
+	RoutineCharacteristic                  "stored routine characteristic"
+	TriggerTiming                          "trigger action time"
+	TriggerEvent                           "trigger event"
+	TriggerOrderOpt                        "trigger order opt"
+	EventSchedule                          "event schedule"
+	EventStartsOpt                         "event schedule STARTS opt"
+	EventEndsOpt                           "event schedule ENDS opt"
+	EventCompletionOpt                     "event ON COMPLETION opt"
+	EventCompletion                        "event ON COMPLETION"
+	EventStatusOpt                         "event status opt"
+	AlterEventDefinerOpt                   "ALTER EVENT definer opt"
+	AlterEventScheduleOpt                  "ALTER EVENT schedule opt"
+	AlterEventRenameOpt                    "ALTER EVENT rename opt"
+	AlterEventBodyOpt                      "ALTER EVENT body opt"
+	ProcedureStmtListOpt                   "compound statement body opt"
+	ProcedureStmtList                      "compound statement body"
+	ProcedureDeclListOpt                   "stored program declaration list opt"
+	ProcedureDeclList                      "stored program declaration list"
+	DeclareDefaultOpt                      "DECLARE variable DEFAULT opt"
+	HandlerAction                          "condition handler action"
+	HandlerConditionValueList              "condition handler condition value list"
+	HandlerConditionValue                  "condition handler condition value"
+	DeclareConditionValue                  "DECLARE CONDITION condition value"
+	SQLStateValue                          "SQLSTATE value"
+	ValueKwdOpt                            "VALUE or empty"
+	ElseIfListOpt                          "ELSEIF branch list opt"
+	ProcedureElseOpt                       "compound statement ELSE opt"
+	CaseStmtWhenClauseList                 "CASE statement WHEN clause list"
+	CaseStmtWhenClause                     "CASE statement WHEN clause"
+	SignalConditionValue                   "SIGNAL condition value"
+	SignalInfoOpt                          "SIGNAL SET opt"
+	SignalInfoItemList                     "SIGNAL information item list"
+	SignalInfoItem                         "SIGNAL information item"
+	DiagnosticsAreaOpt                     "diagnostics area opt"
+	StatementInfoItemList                  "statement information item list"
+	StatementInfoItem                      "statement information item"
+	ConditionInfoItemList                  "condition information item list"
+	ConditionInfoItem                      "condition information item"
 	ViewCheckOption                        "view check option"
 	ViewDefiner                            "view definer"
 	ViewName                               "view name"
@@ -1280,44 +1430,48 @@ import (
 	PlacementSpecList                      "Placement rules specifications"
 
 %type	<ident>
-	AsOpt             "AS or EmptyString"
-	KeyOrIndex        "{KEY|INDEX}"
-	ColumnKeywordOpt  "Column keyword or empty"
-	PrimaryOpt        "Optional primary keyword"
-	NowSym            "CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP"
-	NowSymFunc        "CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP/NOW"
-	DefaultKwdOpt     "optional DEFAULT keyword"
-	DatabaseSym       "DATABASE or SCHEMA"
-	ExplainSym        "EXPLAIN or DESCRIBE or DESC"
-	RegexpSym         "REGEXP or RLIKE"
-	IntoOpt           "INTO or EmptyString"
-	ValueSym          "Value or Values"
-	NotSym            "Not token"
-	Char              "{CHAR|CHARACTER}"
-	NChar             "{NCHAR|NATIONAL CHARACTER|NATIONAL CHAR}"
-	Varchar           "{VARCHAR|VARCHARACTER|CHARACTER VARYING|CHAR VARYING}"
-	NVarchar          "{NATIONAL VARCHAR|NATIONAL VARCHARACTER|NVARCHAR|NCHAR VARCHAR|NATIONAL CHARACTER VARYING|NATIONAL CHAR VARYING|NCHAR VARYING}"
-	Year              "{YEAR|SQL_TSI_YEAR}"
-	DeallocateSym     "Deallocate or drop"
-	OuterOpt          "optional OUTER clause"
-	CrossOpt          "Cross join option"
-	TablesTerminalSym "{TABLE|TABLES}"
-	IsolationLevel    "Isolation level"
-	ShowIndexKwd      "Show index/indexs/key keyword"
-	DistinctKwd       "DISTINCT/DISTINCTROW keyword"
-	FromOrIn          "From or In"
-	OptTable          "Optional table keyword"
-	OptInteger        "Optional Integer keyword"
-	CharsetKw         "charset or charater set"
-	CommaOpt          "optional comma"
-	logAnd            "logical and operator"
-	logOr             "logical or operator"
-	LinearOpt         "linear or empty"
-	FieldsOrColumns   "Fields or columns"
-	StorageMedia      "{DISK|MEMORY|DEFAULT}"
-	EncryptionOpt     "Encryption option 'Y' or 'N'"
-	FirstOrNext       "FIRST or NEXT"
-	RowOrRows         "ROW or ROWS"
+	AsOpt              "AS or EmptyString"
+	KeyOrIndex         "{KEY|INDEX}"
+	ColumnKeywordOpt   "Column keyword or empty"
+	PrimaryOpt         "Optional primary keyword"
+	NowSym             "CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP"
+	NowSymFunc         "CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP/NOW"
+	DefaultKwdOpt      "optional DEFAULT keyword"
+	DatabaseSym        "DATABASE or SCHEMA"
+	ExplainSym         "EXPLAIN or DESCRIBE or DESC"
+	RegexpSym          "REGEXP or RLIKE"
+	IntoOpt            "INTO or EmptyString"
+	ValueSym           "Value or Values"
+	NotSym             "Not token"
+	Char               "{CHAR|CHARACTER}"
+	NChar              "{NCHAR|NATIONAL CHARACTER|NATIONAL CHAR}"
+	Varchar            "{VARCHAR|VARCHARACTER|CHARACTER VARYING|CHAR VARYING}"
+	NVarchar           "{NATIONAL VARCHAR|NATIONAL VARCHARACTER|NVARCHAR|NCHAR VARCHAR|NATIONAL CHARACTER VARYING|NATIONAL CHAR VARYING|NCHAR VARYING}"
+	Year               "{YEAR|SQL_TSI_YEAR}"
+	DeallocateSym      "Deallocate or drop"
+	OuterOpt           "optional OUTER clause"
+	CrossOpt           "Cross join option"
+	TablesTerminalSym  "{TABLE|TABLES}"
+	IsolationLevel     "Isolation level"
+	ShowIndexKwd       "Show index/indexs/key keyword"
+	DistinctKwd        "DISTINCT/DISTINCTROW keyword"
+	FromOrIn           "From or In"
+	OptTable           "Optional table keyword"
+	OptInteger         "Optional Integer keyword"
+	CharsetKw          "charset or charater set"
+	CommaOpt           "optional comma"
+	logAnd             "logical and operator"
+	logOr              "logical or operator"
+	LinearOpt          "linear or empty"
+	FieldsOrColumns    "Fields or columns"
+	StorageMedia       "{DISK|MEMORY|DEFAULT}"
+	EncryptionOpt      "Encryption option 'Y' or 'N'"
+	FirstOrNext        "FIRST or NEXT"
+	RowOrRows          "ROW or ROWS"
+	EventCommentOpt    "event COMMENT opt"
+	EndLabelOpt        "end label opt"
+	FetchCursorName    "FETCH cursor name"
+	SignalInfoItemName "SIGNAL information item name"
 
 %type	<ident>
 	ODBCDateTimeType                "ODBC type keywords for date and time literals"
@@ -1343,7 +1497,6 @@ import (
 	FieldTerminator                 "Field terminator"
 	FlashbackToNewName              "Flashback to new name"
 	HashString                      "Hashed string"
-	LikeEscapeOpt                   "like escape option"
 	LinesTerminated                 "Lines terminated by"
 	OptCharset                      "Optional Character setting"
 	OptCollate                      "Optional Collate setting"
@@ -1372,6 +1525,7 @@ import (
 %precedence lowerThanSetKeyword
 %precedence set
 %precedence selectKwd
+%precedence into
 %precedence lowerThanSelectStmt
 %precedence lowerThanInsertValues
 %precedence insertValues
@@ -1998,6 +2152,8 @@ AlterTableSpec:
 	{
 		oldColName := &ast.ColumnName{Name: model.NewCIStr($3)}
 		newColName := &ast.ColumnName{Name: model.NewCIStr($5)}
+		parser.setNodePos(oldColName, yyS[yypt-2:yypt-1])
+		parser.setNodePos(newColName, yyS[yypt:yypt+1])
 		$$ = &ast.AlterTableSpec{
 			Tp:            ast.AlterTableRenameColumn,
 			OldColumnName: oldColName,
@@ -2642,7 +2798,11 @@ BeginTransactionStmt:
 			Mode: ast.Optimistic,
 		}
 	}
-|	"START" "TRANSACTION"
+|	StartTransactionStmt
+
+/* START TRANSACTION is apart as BEGIN starts a compound statement in stored programs. */
+StartTransactionStmt:
+	"START" "TRANSACTION"
 	{
 		$$ = &ast.BeginStmt{}
 	}
@@ -3956,196 +4116,1114 @@ PartDefValuesOpt:
 	{
 		$$ = &ast.PartitionDefinitionClauseIn{}
 	}
-|	"VALUES" "IN" '(' MaxValueOrExpressionList ')'
+|	"VALUES" "IN" '(' MaxValueOrExpressionList ')'
+	{
+		exprs := $4.([]ast.ExprNode)
+		values := make([][]ast.ExprNode, 0, len(exprs))
+		for _, expr := range exprs {
+			if row, ok := expr.(*ast.RowExpr); ok {
+				values = append(values, row.Values)
+			} else {
+				values = append(values, []ast.ExprNode{expr})
+			}
+		}
+		$$ = &ast.PartitionDefinitionClauseIn{Values: values}
+	}
+|	"HISTORY"
+	{
+		$$ = &ast.PartitionDefinitionClauseHistory{Current: false}
+	}
+|	"CURRENT"
+	{
+		$$ = &ast.PartitionDefinitionClauseHistory{Current: true}
+	}
+
+DuplicateOpt:
+	{
+		$$ = ast.OnDuplicateKeyHandlingError
+	}
+|	"IGNORE"
+	{
+		$$ = ast.OnDuplicateKeyHandlingIgnore
+	}
+|	"REPLACE"
+	{
+		$$ = ast.OnDuplicateKeyHandlingReplace
+	}
+
+AsOpt:
+	{}
+|	"AS"
+	{}
+
+CreateTableSelectOpt:
+	/* empty */
+	{
+		$$ = &ast.CreateTableStmt{}
+	}
+|	SetOprStmt1
+	{
+		$$ = &ast.CreateTableStmt{Select: $1.(ast.ResultSetNode)}
+	}
+
+CreateViewSelectOpt:
+	SetOprStmt1
+|	'(' SetOprStmt1 ')'
+	{
+		$$ = $2
+	}
+
+LikeTableWithOrWithoutParen:
+	"LIKE" TableName
+	{
+		$$ = $2
+	}
+|	'(' "LIKE" TableName ')'
+	{
+		$$ = $3
+	}
+
+/*******************************************************************
+ *
+ *  Create View Statement
+ *
+ *  Example:
+ *      CREATE VIEW OR REPLACE ALGORITHM = MERGE DEFINER="root@localhost" SQL SECURITY = definer view_name (col1,col2)
+ *          as select Col1,Col2 from table WITH LOCAL CHECK OPTION
+ *******************************************************************/
+CreateViewStmt:
+	"CREATE" OrReplace ViewAlgorithm ViewDefiner ViewSQLSecurity "VIEW" ViewName ViewFieldList "AS" CreateViewSelectOpt ViewCheckOption
+	{
+		startOffset := parser.startOffset(&yyS[yypt-1])
+		selStmt := $10.(ast.StmtNode)
+		selStmt.SetText(strings.TrimSpace(parser.src[startOffset:]))
+		x := &ast.CreateViewStmt{
+			OrReplace: $2.(bool),
+			ViewName:  $7.(*ast.TableName),
+			Select:    selStmt,
+			Algorithm: model.AlgorithmUndefined,
+			Definer:   $4.(*auth.UserIdentity),
+			Security:  $5.(model.ViewSecurity),
+		}
+		if $3 != nil {
+			x.Algorithm = $3.(model.ViewAlgorithm)
+		}
+		if $8 != nil {
+			x.Cols = $8.([]model.CIStr)
+		}
+		if $11 != nil {
+			x.CheckOption = $11.(model.ViewCheckOption)
+			endOffset := parser.startOffset(&yyS[yypt])
+			selStmt.SetText(strings.TrimSpace(parser.src[startOffset:endOffset]))
+		} else {
+			x.CheckOption = model.CheckOptionCascaded
+		}
+		$$ = x
+	}
+
+OrReplace:
+	/* EMPTY */
+	{
+		$$ = false
+	}
+|	"OR" "REPLACE"
+	{
+		$$ = true
+	}
+
+ViewAlgorithm:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+|	"ALGORITHM" "=" "UNDEFINED"
+	{
+		$$ = model.AlgorithmUndefined
+	}
+|	"ALGORITHM" "=" "MERGE"
+	{
+		$$ = model.AlgorithmMerge
+	}
+|	"ALGORITHM" "=" "TEMPTABLE"
+	{
+		$$ = model.AlgorithmTemptable
+	}
+
+ViewDefiner:
+	/* EMPTY */
+	{
+		$$ = &auth.UserIdentity{CurrentUser: true}
+	}
+|	"DEFINER" "=" Username
+	{
+		$$ = $3
+	}
+
+ViewSQLSecurity:
+	/* EMPTY */
+	{
+		$$ = model.SecurityDefiner
+	}
+|	"SQL" "SECURITY" "DEFINER"
+	{
+		$$ = model.SecurityDefiner
+	}
+|	"SQL" "SECURITY" "INVOKER"
+	{
+		$$ = model.SecurityInvoker
+	}
+
+ViewName:
+	TableName
+
+ViewFieldList:
+	/* Empty */
+	{
+		$$ = nil
+	}
+|	'(' ColumnList ')'
+	{
+		$$ = $2.([]model.CIStr)
+	}
+
+ColumnList:
+	Identifier
+	{
+		$$ = []model.CIStr{model.NewCIStr($1)}
+	}
+|	ColumnList ',' Identifier
+	{
+		$$ = append($1.([]model.CIStr), model.NewCIStr($3))
+	}
+
+ViewCheckOption:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+|	"WITH" "CASCADED" "CHECK" "OPTION"
+	{
+		$$ = model.CheckOptionCascaded
+	}
+|	"WITH" "LOCAL" "CHECK" "OPTION"
+	{
+		$$ = model.CheckOptionLocal
+	}
+
+/*******************************************************************************************
+ * Stored programs
+ * See https://dev.mysql.com/doc/refman/8.0/en/create-procedure.html
+ * See https://dev.mysql.com/doc/refman/8.0/en/create-trigger.html
+ * See https://dev.mysql.com/doc/refman/8.0/en/create-event.html
+ *
+ * The CREATE statements share the `OrReplace ViewAlgorithm ViewDefiner` prefix with
+ * CREATE VIEW to avoid grammar conflicts, OR REPLACE and ALGORITHM are rejected.
+ *******************************************************************************************/
+CreateProcedureStmt:
+	"CREATE" OrReplace ViewAlgorithm ViewDefiner "PROCEDURE" IfNotExists TableName '(' ProcedureParamListOpt ')' RoutineCharacteristicListOpt RoutineBody
+	{
+		if $2.(bool) || $3 != nil {
+			yylex.AppendError(yylex.Errorf("OR REPLACE and ALGORITHM are only supported by CREATE VIEW"))
+			return 1
+		}
+		parser.routine.reset()
+		$$ = &ast.CreateProcedureStmt{
+			Definer:         $4.(*auth.UserIdentity),
+			IfNotExists:     $6.(bool),
+			Name:            $7.(*ast.TableName),
+			Params:          $9.([]*ast.StoredParameter),
+			Characteristics: $11.([]*ast.RoutineCharacteristic),
+			Body:            $12,
+		}
+	}
+
+CreateFunctionStmt:
+	"CREATE" OrReplace ViewAlgorithm ViewDefiner "FUNCTION" IfNotExists TableName '(' FunctionParamListOpt ')' "RETURNS" Type RoutineCharacteristicListOpt RoutineBody
+	{
+		if $2.(bool) || $3 != nil {
+			yylex.AppendError(yylex.Errorf("OR REPLACE and ALGORITHM are only supported by CREATE VIEW"))
+			return 1
+		}
+		parser.routine.reset()
+		$$ = &ast.CreateFunctionStmt{
+			Definer:         $4.(*auth.UserIdentity),
+			IfNotExists:     $6.(bool),
+			Name:            $7.(*ast.TableName),
+			Params:          $9.([]*ast.StoredParameter),
+			Returns:         $12.(*types.FieldType),
+			Characteristics: $13.([]*ast.RoutineCharacteristic),
+			Body:            $14,
+		}
+	}
+
+ProcedureParamListOpt:
+	/* EMPTY */
+	{
+		$$ = []*ast.StoredParameter{}
+	}
+|	ProcedureParamList
+
+ProcedureParamList:
+	ProcedureParam
+	{
+		$$ = []*ast.StoredParameter{$1.(*ast.StoredParameter)}
+	}
+|	ProcedureParamList ',' ProcedureParam
+	{
+		$$ = append($1.([]*ast.StoredParameter), $3.(*ast.StoredParameter))
+	}
+
+ProcedureParam:
+	ProcedureParamMode Identifier Type
+	{
+		parser.routine.addParam($2)
+		$$ = &ast.StoredParameter{Mode: $1.(ast.ProcedureParamMode), Name: $2, Tp: $3.(*types.FieldType)}
+	}
+
+ProcedureParamMode:
+	/* EMPTY */
+	{
+		$$ = ast.ProcedureParamModeIn
+	}
+|	"IN"
+	{
+		$$ = ast.ProcedureParamModeIn
+	}
+|	"OUT"
+	{
+		$$ = ast.ProcedureParamModeOut
+	}
+|	"INOUT"
+	{
+		$$ = ast.ProcedureParamModeInOut
+	}
+
+FunctionParamListOpt:
+	/* EMPTY */
+	{
+		$$ = []*ast.StoredParameter{}
+	}
+|	FunctionParamList
+
+FunctionParamList:
+	FunctionParam
+	{
+		$$ = []*ast.StoredParameter{$1.(*ast.StoredParameter)}
+	}
+|	FunctionParamList ',' FunctionParam
+	{
+		$$ = append($1.([]*ast.StoredParameter), $3.(*ast.StoredParameter))
+	}
+
+FunctionParam:
+	Identifier Type
+	{
+		parser.routine.addParam($1)
+		$$ = &ast.StoredParameter{Name: $1, Tp: $2.(*types.FieldType)}
+	}
+
+RoutineCharacteristicListOpt:
+	/* EMPTY */
+	{
+		$$ = []*ast.RoutineCharacteristic{}
+	}
+|	RoutineCharacteristicList
+
+RoutineCharacteristicList:
+	RoutineCharacteristic
+	{
+		$$ = []*ast.RoutineCharacteristic{$1.(*ast.RoutineCharacteristic)}
+	}
+|	RoutineCharacteristicList RoutineCharacteristic
+	{
+		$$ = append($1.([]*ast.RoutineCharacteristic), $2.(*ast.RoutineCharacteristic))
+	}
+
+RoutineCharacteristic:
+	"COMMENT" stringLit
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicComment, Comment: $2}
+	}
+|	"LANGUAGE" "SQL"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicLanguageSQL}
+	}
+|	"DETERMINISTIC"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicDeterministic, Deterministic: true}
+	}
+|	NotSym "DETERMINISTIC"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicDeterministic, Deterministic: false}
+	}
+|	"CONTAINS" "SQL"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicSQLDataAccess, DataAccess: ast.RoutineContainsSQL}
+	}
+|	"NO" "SQL"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicSQLDataAccess, DataAccess: ast.RoutineNoSQL}
+	}
+|	"READS" "SQL" "DATA"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicSQLDataAccess, DataAccess: ast.RoutineReadsSQLData}
+	}
+|	"MODIFIES" "SQL" "DATA"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicSQLDataAccess, DataAccess: ast.RoutineModifiesSQLData}
+	}
+|	"SQL" "SECURITY" "DEFINER"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicSQLSecurity, Security: model.SecurityDefiner}
+	}
+|	"SQL" "SECURITY" "INVOKER"
+	{
+		$$ = &ast.RoutineCharacteristic{Tp: ast.RoutineCharacteristicSQLSecurity, Security: model.SecurityInvoker}
+	}
+
+/* The body of stored programs is a single statement, it is the last part of the CREATE statement. */
+RoutineBody:
+	RoutineStmt
+	{
+		startOffset := parser.startOffset(&yyS[yypt])
+		endOffset := parser.endOffset(&parser.yylval)
+		$1.SetText(parser.src[startOffset:endOffset])
+		$$ = $1
+	}
+
+ReturnStmt:
+	"RETURN" Expression
+	{
+		$$ = &ast.ReturnStmt{Expr: $2}
+	}
+
+AlterProcedureStmt:
+	"ALTER" "PROCEDURE" TableName RoutineCharacteristicListOpt
+	{
+		$$ = &ast.AlterProcedureStmt{
+			Name:            $3.(*ast.TableName),
+			Characteristics: $4.([]*ast.RoutineCharacteristic),
+		}
+	}
+|	"ALTER" "FUNCTION" TableName RoutineCharacteristicListOpt
+	{
+		$$ = &ast.AlterProcedureStmt{
+			IsFunction:      true,
+			Name:            $3.(*ast.TableName),
+			Characteristics: $4.([]*ast.RoutineCharacteristic),
+		}
+	}
+
+DropProcedureStmt:
+	"DROP" "PROCEDURE" IfExists TableName
+	{
+		$$ = &ast.DropProcedureStmt{IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+|	"DROP" "FUNCTION" IfExists TableName
+	{
+		$$ = &ast.DropProcedureStmt{IsFunction: true, IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+
+CreateTriggerStmt:
+	"CREATE" OrReplace ViewAlgorithm ViewDefiner "TRIGGER" IfNotExists TableName TriggerTiming TriggerEvent "ON" TableName "FOR" "EACH" "ROW" TriggerOrderOpt RoutineBody
+	{
+		if $2.(bool) || $3 != nil {
+			yylex.AppendError(yylex.Errorf("OR REPLACE and ALGORITHM are only supported by CREATE VIEW"))
+			return 1
+		}
+		parser.routine.reset()
+		x := &ast.CreateTriggerStmt{
+			Definer:     $4.(*auth.UserIdentity),
+			IfNotExists: $6.(bool),
+			Name:        $7.(*ast.TableName),
+			Timing:      $8.(ast.TriggerTiming),
+			Event:       $9.(ast.TriggerEvent),
+			Table:       $11.(*ast.TableName),
+			Body:        $16,
+		}
+		if $15 != nil {
+			x.Order = $15.(*ast.TriggerOrder)
+		}
+		$$ = x
+	}
+
+TriggerTiming:
+	"BEFORE"
+	{
+		$$ = ast.TriggerBefore
+	}
+|	"AFTER"
+	{
+		$$ = ast.TriggerAfter
+	}
+
+TriggerEvent:
+	"INSERT"
+	{
+		$$ = ast.TriggerInsert
+	}
+|	"UPDATE"
+	{
+		$$ = ast.TriggerUpdate
+	}
+|	"DELETE"
+	{
+		$$ = ast.TriggerDelete
+	}
+
+/* TriggerOrderOpt is reduced right before the body, which can set the columns of NEW and OLD. */
+TriggerOrderOpt:
+	/* EMPTY */
+	{
+		parser.routine.trigger = true
+		$$ = nil
+	}
+|	"FOLLOWS" Identifier
+	{
+		parser.routine.trigger = true
+		$$ = &ast.TriggerOrder{Follows: true, OtherTrigger: model.NewCIStr($2)}
+	}
+|	"PRECEDES" Identifier
+	{
+		parser.routine.trigger = true
+		$$ = &ast.TriggerOrder{OtherTrigger: model.NewCIStr($2)}
+	}
+
+DropTriggerStmt:
+	"DROP" "TRIGGER" IfExists TableName
+	{
+		$$ = &ast.DropTriggerStmt{IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+
+CreateEventStmt:
+	"CREATE" OrReplace ViewAlgorithm ViewDefiner "EVENT" IfNotExists TableName "ON" "SCHEDULE" EventSchedule EventCompletionOpt EventStatusOpt EventCommentOpt "DO" RoutineBody
+	{
+		if $2.(bool) || $3 != nil {
+			yylex.AppendError(yylex.Errorf("OR REPLACE and ALGORITHM are only supported by CREATE VIEW"))
+			return 1
+		}
+		$$ = &ast.CreateEventStmt{
+			Definer:      $4.(*auth.UserIdentity),
+			IfNotExists:  $6.(bool),
+			Name:         $7.(*ast.TableName),
+			Schedule:     $10.(*ast.EventSchedule),
+			OnCompletion: $11.(ast.EventCompletion),
+			Status:       $12.(ast.EventStatus),
+			Comment:      $13,
+			Body:         $15,
+		}
+	}
+
+EventSchedule:
+	"AT" Expression
+	{
+		$$ = &ast.EventSchedule{At: $2}
+	}
+|	"EVERY" Expression TimeUnit EventStartsOpt EventEndsOpt
+	{
+		x := &ast.EventSchedule{Every: $2, EveryUnit: $3.(ast.TimeUnitType)}
+		if $4 != nil {
+			x.Starts = $4.(ast.ExprNode)
+		}
+		if $5 != nil {
+			x.Ends = $5.(ast.ExprNode)
+		}
+		$$ = x
+	}
+
+EventStartsOpt:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+|	"STARTS" Expression
+	{
+		$$ = $2
+	}
+
+EventEndsOpt:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+|	"ENDS" Expression
+	{
+		$$ = $2
+	}
+
+EventCompletionOpt:
+	/* EMPTY */
+	{
+		$$ = ast.EventCompletionUnspecified
+	}
+|	EventCompletion
+
+EventCompletion:
+	"ON" "COMPLETION" "PRESERVE"
+	{
+		$$ = ast.EventCompletionPreserve
+	}
+|	"ON" "COMPLETION" NotSym "PRESERVE"
+	{
+		$$ = ast.EventCompletionNotPreserve
+	}
+
+EventStatusOpt:
+	/* EMPTY */
+	{
+		$$ = ast.EventStatusUnspecified
+	}
+|	"ENABLE"
+	{
+		$$ = ast.EventStatusEnable
+	}
+|	"DISABLE"
+	{
+		$$ = ast.EventStatusDisable
+	}
+|	"DISABLE" "ON" "SLAVE"
+	{
+		$$ = ast.EventStatusDisableOnSlave
+	}
+
+EventCommentOpt:
+	/* EMPTY */
+	{
+		$$ = ""
+	}
+|	"COMMENT" stringLit
+	{
+		$$ = $2
+	}
+
+AlterEventStmt:
+	"ALTER" AlterEventDefinerOpt "EVENT" TableName AlterEventScheduleOpt AlterEventRenameOpt EventStatusOpt EventCommentOpt AlterEventBodyOpt
+	{
+		x := $5.(*ast.AlterEventStmt)
+		if $2 != nil {
+			x.Definer = $2.(*auth.UserIdentity)
+		}
+		x.Name = $4.(*ast.TableName)
+		if $6 != nil {
+			x.NewName = $6.(*ast.TableName)
+		}
+		x.Status = $7.(ast.EventStatus)
+		x.Comment = $8
+		if $9 != nil {
+			x.Body = $9.(ast.StmtNode)
+		}
+		$$ = x
+	}
+
+AlterEventDefinerOpt:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+|	"DEFINER" "=" Username
+	{
+		$$ = $3
+	}
+
+/* ON SCHEDULE and ON COMPLETION are both optional and start with ON, they are combined to avoid conflicts. */
+AlterEventScheduleOpt:
+	/* EMPTY */
+	{
+		$$ = &ast.AlterEventStmt{}
+	}
+|	"ON" "SCHEDULE" EventSchedule EventCompletionOpt
+	{
+		$$ = &ast.AlterEventStmt{Schedule: $3.(*ast.EventSchedule), OnCompletion: $4.(ast.EventCompletion)}
+	}
+|	EventCompletion
+	{
+		$$ = &ast.AlterEventStmt{OnCompletion: $1.(ast.EventCompletion)}
+	}
+
+AlterEventRenameOpt:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+|	"RENAME" "TO" TableName
+	{
+		$$ = $3
+	}
+
+AlterEventBodyOpt:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+|	"DO" RoutineBody
+	{
+		$$ = $2
+	}
+
+DropEventStmt:
+	"DROP" "EVENT" IfExists TableName
+	{
+		$$ = &ast.DropEventStmt{IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+
+/*******************************************************************************************
+ * Compound statements of stored program bodies
+ * See https://dev.mysql.com/doc/refman/8.0/en/sql-compound-statements.html
+ *******************************************************************************************/
+LabeledCompoundStmt:
+	LabelableStmt
+|	identifier ':' LabelableStmt EndLabelOpt
+	{
+		if $4 != "" && !strings.EqualFold($1, $4) {
+			yylex.AppendError(yylex.Errorf("End-label %s without match", $4))
+			return 1
+		}
+		switch x := $3.(type) {
+		case *ast.BlockStmt:
+			x.Label = $1
+		case *ast.LoopStmt:
+			x.Label = $1
+		case *ast.WhileStmt:
+			x.Label = $1
+		case *ast.RepeatStmt:
+			x.Label = $1
+		}
+		$$ = $3
+	}
+
+LabelableStmt:
+	BlockStmt
+|	LoopStmt
+|	WhileStmt
+|	RepeatStmt
+
+EndLabelOpt:
+	/* EMPTY */
+	{
+		$$ = ""
+	}
+|	identifier
+
+BlockStmt:
+	"BEGIN" ProcedureDeclListOpt ProcedureStmtListOpt "END"
+	{
+		decls := $2.([]ast.StmtNode)
+		if len(decls) > 0 {
+			parser.routine.popBlock()
+		}
+		$$ = &ast.BlockStmt{Body: append(decls, $3.([]ast.StmtNode)...)}
+	}
+
+/* Every statement of a compound statement body is terminated by `;`. */
+ProcedureStmtListOpt:
+	/* EMPTY */
+	{
+		$$ = []ast.StmtNode{}
+	}
+|	ProcedureStmtList
+
+ProcedureStmtList:
+	RoutineStmt ';'
+	{
+		$1.SetText(parser.src[parser.startOffset(&yyS[yypt-1]):parser.endOffset(&yyS[yypt])])
+		$$ = []ast.StmtNode{$1}
+	}
+|	ProcedureStmtList RoutineStmt ';'
+	{
+		$2.SetText(parser.src[parser.startOffset(&yyS[yypt-1]):parser.endOffset(&yyS[yypt])])
+		$$ = append($1.([]ast.StmtNode), $2)
+	}
+
+ProcedureDeclListOpt:
+	/* EMPTY */
+	{
+		$$ = []ast.StmtNode{}
+	}
+|	ProcedureDeclList
+
+/* The variables declared are in scope from the declaration to the END of the block. */
+ProcedureDeclList:
+	ProcedureDecl ';'
+	{
+		$1.SetText(parser.src[parser.startOffset(&yyS[yypt-1]):parser.endOffset(&yyS[yypt])])
+		parser.routine.pushBlock()
+		parser.routine.declare($1)
+		$$ = []ast.StmtNode{$1}
+	}
+|	ProcedureDeclList ProcedureDecl ';'
+	{
+		$2.SetText(parser.src[parser.startOffset(&yyS[yypt-1]):parser.endOffset(&yyS[yypt])])
+		parser.routine.declare($2)
+		$$ = append($1.([]ast.StmtNode), $2)
+	}
+
+ProcedureDecl:
+	"DECLARE" IdentList Type DeclareDefaultOpt
+	{
+		idents := $2.([]model.CIStr)
+		names := make([]string, 0, len(idents))
+		for _, ident := range idents {
+			names = append(names, ident.O)
+		}
+		x := &ast.DeclareVarStmt{Names: names, Tp: $3.(*types.FieldType)}
+		if $4 != nil {
+			x.Default = $4.(ast.ExprNode)
+		}
+		$$ = x
+	}
+|	"DECLARE" Identifier "CONDITION" "FOR" DeclareConditionValue
+	{
+		$$ = &ast.DeclareConditionStmt{Name: $2, Condition: $5.(*ast.ConditionValue)}
+	}
+|	"DECLARE" Identifier "CURSOR" "FOR" SetOprStmt1
+	{
+		startOffset := parser.startOffset(&yyS[yypt])
+		$5.SetText(parser.src[startOffset:yyS[yypt].endPos.Offset])
+		$$ = &ast.DeclareCursorStmt{Name: $2, Select: $5}
+	}
+|	"DECLARE" HandlerAction "HANDLER" "FOR" HandlerConditionValueList RoutineStmt
+	{
+		startOffset := parser.startOffset(&yyS[yypt])
+		endOffset := parser.endOffset(&parser.yylval)
+		$6.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.DeclareHandlerStmt{
+			Action:     $2.(ast.HandlerAction),
+			Conditions: $5.([]*ast.ConditionValue),
+			Body:       $6,
+		}
+	}
+
+DeclareDefaultOpt:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+|	"DEFAULT" Expression
+	{
+		$$ = $2
+	}
+
+HandlerAction:
+	"CONTINUE"
+	{
+		$$ = ast.HandlerContinue
+	}
+|	"EXIT"
+	{
+		$$ = ast.HandlerExit
+	}
+|	"UNDO"
+	{
+		$$ = ast.HandlerUndo
+	}
+
+HandlerConditionValueList:
+	HandlerConditionValue
+	{
+		$$ = []*ast.ConditionValue{$1.(*ast.ConditionValue)}
+	}
+|	HandlerConditionValueList ',' HandlerConditionValue
+	{
+		$$ = append($1.([]*ast.ConditionValue), $3.(*ast.ConditionValue))
+	}
+
+HandlerConditionValue:
+	DeclareConditionValue
+|	Identifier
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionValueName, Name: $1}
+	}
+|	"SQLWARNING"
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionValueSQLWarning}
+	}
+|	NotSym "FOUND"
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionValueNotFound}
+	}
+|	"SQLEXCEPTION"
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionValueSQLException}
+	}
+
+DeclareConditionValue:
+	intLit
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionValueErrorCode, ErrorCode: getUint64FromNUM($1)}
+	}
+|	SQLStateValue
+
+SQLStateValue:
+	"SQLSTATE" ValueKwdOpt stringLit
+	{
+		if len($3) != 5 || strings.HasPrefix($3, "00") {
+			yylex.AppendError(yylex.Errorf("Bad SQLSTATE: '%s'", $3))
+			return 1
+		}
+		$$ = &ast.ConditionValue{Tp: ast.ConditionValueSQLState, SQLState: $3}
+	}
+
+ValueKwdOpt:
+	{}
+|	"VALUE"
+	{}
+
+IfStmt:
+	"IF" Expression "THEN" ProcedureStmtList ElseIfListOpt ProcedureElseOpt "END" "IF"
+	{
+		branches := []*ast.IfBranch{{Cond: $2, Body: $4.([]ast.StmtNode)}}
+		x := &ast.IfStmt{Branches: append(branches, $5.([]*ast.IfBranch)...)}
+		if $6 != nil {
+			x.Else = $6.([]ast.StmtNode)
+		}
+		$$ = x
+	}
+
+ElseIfListOpt:
+	/* EMPTY */
+	{
+		$$ = []*ast.IfBranch{}
+	}
+|	ElseIfListOpt "ELSEIF" Expression "THEN" ProcedureStmtList
+	{
+		$$ = append($1.([]*ast.IfBranch), &ast.IfBranch{Cond: $3, Body: $5.([]ast.StmtNode)})
+	}
+
+ProcedureElseOpt:
+	/* EMPTY */
+	{
+		$$ = nil
+	}
+|	"ELSE" ProcedureStmtList
+	{
+		$$ = $2
+	}
+
+CaseStmt:
+	"CASE" ExpressionOpt CaseStmtWhenClauseList ProcedureElseOpt "END" "CASE"
+	{
+		x := &ast.CaseStmt{WhenClauses: $3.([]*ast.CaseStmtWhenClause)}
+		if $2 != nil {
+			x.Value = $2.(ast.ExprNode)
+		}
+		if $4 != nil {
+			x.Else = $4.([]ast.StmtNode)
+		}
+		$$ = x
+	}
+
+CaseStmtWhenClauseList:
+	CaseStmtWhenClause
 	{
-		exprs := $4.([]ast.ExprNode)
-		values := make([][]ast.ExprNode, 0, len(exprs))
-		for _, expr := range exprs {
-			if row, ok := expr.(*ast.RowExpr); ok {
-				values = append(values, row.Values)
-			} else {
-				values = append(values, []ast.ExprNode{expr})
-			}
-		}
-		$$ = &ast.PartitionDefinitionClauseIn{Values: values}
+		$$ = []*ast.CaseStmtWhenClause{$1.(*ast.CaseStmtWhenClause)}
 	}
-|	"HISTORY"
+|	CaseStmtWhenClauseList CaseStmtWhenClause
 	{
-		$$ = &ast.PartitionDefinitionClauseHistory{Current: false}
+		$$ = append($1.([]*ast.CaseStmtWhenClause), $2.(*ast.CaseStmtWhenClause))
 	}
-|	"CURRENT"
+
+CaseStmtWhenClause:
+	"WHEN" Expression "THEN" ProcedureStmtList
 	{
-		$$ = &ast.PartitionDefinitionClauseHistory{Current: true}
+		$$ = &ast.CaseStmtWhenClause{Expr: $2, Body: $4.([]ast.StmtNode)}
 	}
 
-DuplicateOpt:
+LoopStmt:
+	"LOOP" ProcedureStmtList "END" "LOOP"
 	{
-		$$ = ast.OnDuplicateKeyHandlingError
+		$$ = &ast.LoopStmt{Body: $2.([]ast.StmtNode)}
 	}
-|	"IGNORE"
+
+WhileStmt:
+	"WHILE" Expression "DO" ProcedureStmtList "END" "WHILE"
 	{
-		$$ = ast.OnDuplicateKeyHandlingIgnore
+		$$ = &ast.WhileStmt{Cond: $2, Body: $4.([]ast.StmtNode)}
 	}
-|	"REPLACE"
+
+RepeatStmt:
+	"REPEAT" ProcedureStmtList "UNTIL" Expression "END" "REPEAT"
 	{
-		$$ = ast.OnDuplicateKeyHandlingReplace
+		$$ = &ast.RepeatStmt{Body: $2.([]ast.StmtNode), Cond: $4}
 	}
 
-AsOpt:
-	{}
-|	"AS"
-	{}
+LeaveStmt:
+	"LEAVE" Identifier
+	{
+		$$ = &ast.LeaveStmt{Label: $2}
+	}
 
-CreateTableSelectOpt:
-	/* empty */
+IterateStmt:
+	"ITERATE" Identifier
 	{
-		$$ = &ast.CreateTableStmt{}
+		$$ = &ast.IterateStmt{Label: $2}
 	}
-|	SetOprStmt1
+
+OpenCursorStmt:
+	"OPEN" Identifier
 	{
-		$$ = &ast.CreateTableStmt{Select: $1.(ast.ResultSetNode)}
+		$$ = &ast.OpenCursorStmt{Name: $2}
 	}
 
-CreateViewSelectOpt:
-	SetOprStmt1
-|	'(' SetOprStmt1 ')'
+FetchCursorStmt:
+	"FETCH" FetchCursorName "INTO" IdentList
 	{
-		$$ = $2
+		idents := $4.([]model.CIStr)
+		vars := make([]string, 0, len(idents))
+		for _, ident := range idents {
+			vars = append(vars, ident.O)
+		}
+		$$ = &ast.FetchCursorStmt{Name: $2, Vars: vars}
 	}
 
-LikeTableWithOrWithoutParen:
-	"LIKE" TableName
+/* NEXT is also a valid cursor name, so the optional NEXT FROM can't be an empty rule. */
+FetchCursorName:
+	Identifier
+|	"FROM" Identifier
 	{
 		$$ = $2
 	}
-|	'(' "LIKE" TableName ')'
+|	"NEXT" "FROM" Identifier
 	{
 		$$ = $3
 	}
 
-/*******************************************************************
- *
- *  Create View Statement
- *
- *  Example:
- *      CREATE VIEW OR REPLACE ALGORITHM = MERGE DEFINER="root@localhost" SQL SECURITY = definer view_name (col1,col2)
- *          as select Col1,Col2 from table WITH LOCAL CHECK OPTION
- *******************************************************************/
-CreateViewStmt:
-	"CREATE" OrReplace ViewAlgorithm ViewDefiner ViewSQLSecurity "VIEW" ViewName ViewFieldList "AS" CreateViewSelectOpt ViewCheckOption
+CloseCursorStmt:
+	"CLOSE" Identifier
 	{
-		startOffset := parser.startOffset(&yyS[yypt-1])
-		selStmt := $10.(ast.StmtNode)
-		selStmt.SetText(strings.TrimSpace(parser.src[startOffset:]))
-		x := &ast.CreateViewStmt{
-			OrReplace: $2.(bool),
-			ViewName:  $7.(*ast.TableName),
-			Select:    selStmt,
-			Algorithm: $3.(model.ViewAlgorithm),
-			Definer:   $4.(*auth.UserIdentity),
-			Security:  $5.(model.ViewSecurity),
-		}
-		if $8 != nil {
-			x.Cols = $8.([]model.CIStr)
-		}
-		if $11 != nil {
-			x.CheckOption = $11.(model.ViewCheckOption)
-			endOffset := parser.startOffset(&yyS[yypt])
-			selStmt.SetText(strings.TrimSpace(parser.src[startOffset:endOffset]))
-		} else {
-			x.CheckOption = model.CheckOptionCascaded
-		}
-		$$ = x
+		$$ = &ast.CloseCursorStmt{Name: $2}
 	}
 
-OrReplace:
-	/* EMPTY */
+SignalStmt:
+	"SIGNAL" SignalConditionValue SignalInfoOpt
 	{
-		$$ = false
+		$$ = &ast.SignalStmt{Condition: $2.(*ast.ConditionValue), Items: $3.([]*ast.SignalInfoItem)}
 	}
-|	"OR" "REPLACE"
+|	"RESIGNAL" SignalInfoOpt
 	{
-		$$ = true
+		$$ = &ast.SignalStmt{IsResignal: true, Items: $2.([]*ast.SignalInfoItem)}
+	}
+|	"RESIGNAL" SignalConditionValue SignalInfoOpt
+	{
+		$$ = &ast.SignalStmt{IsResignal: true, Condition: $2.(*ast.ConditionValue), Items: $3.([]*ast.SignalInfoItem)}
 	}
 
-ViewAlgorithm:
+SignalConditionValue:
+	SQLStateValue
+|	Identifier
+	{
+		$$ = &ast.ConditionValue{Tp: ast.ConditionValueName, Name: $1}
+	}
+
+SignalInfoOpt:
 	/* EMPTY */
 	{
-		$$ = model.AlgorithmUndefined
+		$$ = []*ast.SignalInfoItem{}
 	}
-|	"ALGORITHM" "=" "UNDEFINED"
+|	"SET" SignalInfoItemList
 	{
-		$$ = model.AlgorithmUndefined
+		$$ = $2
 	}
-|	"ALGORITHM" "=" "MERGE"
+
+SignalInfoItemList:
+	SignalInfoItem
 	{
-		$$ = model.AlgorithmMerge
+		$$ = []*ast.SignalInfoItem{$1.(*ast.SignalInfoItem)}
 	}
-|	"ALGORITHM" "=" "TEMPTABLE"
+|	SignalInfoItemList ',' SignalInfoItem
 	{
-		$$ = model.AlgorithmTemptable
+		$$ = append($1.([]*ast.SignalInfoItem), $3.(*ast.SignalInfoItem))
 	}
 
-ViewDefiner:
-	/* EMPTY */
+SignalInfoItem:
+	SignalInfoItemName eq Expression
 	{
-		$$ = &auth.UserIdentity{CurrentUser: true}
+		$$ = &ast.SignalInfoItem{Name: strings.ToUpper($1), Value: $3}
 	}
-|	"DEFINER" "=" Username
+
+SignalInfoItemName:
+	"CLASS_ORIGIN"
+|	"SUBCLASS_ORIGIN"
+|	"MESSAGE_TEXT"
+|	"MYSQL_ERRNO"
+|	"CONSTRAINT_CATALOG"
+|	"CONSTRAINT_SCHEMA"
+|	"CONSTRAINT_NAME"
+|	"CATALOG_NAME"
+|	"SCHEMA_NAME"
+|	"TABLE_NAME"
+|	"COLUMN_NAME"
+|	"CURSOR_NAME"
+
+GetDiagnosticsStmt:
+	"GET" DiagnosticsAreaOpt "DIAGNOSTICS" StatementInfoItemList
 	{
-		$$ = $3
+		$$ = &ast.GetDiagnosticsStmt{Area: $2.(ast.DiagnosticsArea), Items: $4.([]*ast.DiagnosticsItem)}
+	}
+|	"GET" DiagnosticsAreaOpt "DIAGNOSTICS" "CONDITION" Expression ConditionInfoItemList
+	{
+		$$ = &ast.GetDiagnosticsStmt{Area: $2.(ast.DiagnosticsArea), ConditionNumber: $5, Items: $6.([]*ast.DiagnosticsItem)}
 	}
 
-ViewSQLSecurity:
+DiagnosticsAreaOpt:
 	/* EMPTY */
 	{
-		$$ = model.SecurityDefiner
+		$$ = ast.DiagnosticsAreaCurrent
 	}
-|	"SQL" "SECURITY" "DEFINER"
+|	"CURRENT"
 	{
-		$$ = model.SecurityDefiner
+		$$ = ast.DiagnosticsAreaCurrent
 	}
-|	"SQL" "SECURITY" "INVOKER"
+|	"STACKED"
 	{
-		$$ = model.SecurityInvoker
+		$$ = ast.DiagnosticsAreaStacked
 	}
 
-ViewName:
-	TableName
+StatementInfoItemList:
+	StatementInfoItem
+	{
+		$$ = []*ast.DiagnosticsItem{$1.(*ast.DiagnosticsItem)}
+	}
+|	StatementInfoItemList ',' StatementInfoItem
+	{
+		$$ = append($1.([]*ast.DiagnosticsItem), $3.(*ast.DiagnosticsItem))
+	}
 
-ViewFieldList:
-	/* Empty */
+StatementInfoItem:
+	DiagnosticsTarget eq "NUMBER"
 	{
-		$$ = nil
+		$$ = &ast.DiagnosticsItem{Target: $1, Name: "NUMBER"}
 	}
-|	'(' ColumnList ')'
+|	DiagnosticsTarget eq "ROW_COUNT"
 	{
-		$$ = $2.([]model.CIStr)
+		$$ = &ast.DiagnosticsItem{Target: $1, Name: "ROW_COUNT"}
 	}
 
-ColumnList:
-	Identifier
+ConditionInfoItemList:
+	ConditionInfoItem
 	{
-		$$ = []model.CIStr{model.NewCIStr($1)}
+		$$ = []*ast.DiagnosticsItem{$1.(*ast.DiagnosticsItem)}
 	}
-|	ColumnList ',' Identifier
+|	ConditionInfoItemList ',' ConditionInfoItem
 	{
-		$$ = append($1.([]model.CIStr), model.NewCIStr($3))
+		$$ = append($1.([]*ast.DiagnosticsItem), $3.(*ast.DiagnosticsItem))
 	}
 
-ViewCheckOption:
-	/* EMPTY */
+ConditionInfoItem:
+	DiagnosticsTarget eq SignalInfoItemName
 	{
-		$$ = nil
+		$$ = &ast.DiagnosticsItem{Target: $1, Name: strings.ToUpper($3)}
 	}
-|	"WITH" "CASCADED" "CHECK" "OPTION"
+|	DiagnosticsTarget eq "RETURNED_SQLSTATE"
 	{
-		$$ = model.CheckOptionCascaded
+		$$ = &ast.DiagnosticsItem{Target: $1, Name: "RETURNED_SQLSTATE"}
 	}
-|	"WITH" "LOCAL" "CHECK" "OPTION"
+
+DiagnosticsTarget:
+	Identifier
 	{
-		$$ = model.CheckOptionLocal
+		$$ = &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: model.NewCIStr($1)}}
 	}
+|	UserVariable
 
 /******************************************************************
  * Do statement
@@ -4171,9 +5249,10 @@ DeleteWithoutUsingStmt:
 		tn := $7.(*ast.TableName)
 		tn.IndexHints = $10.([]*ast.IndexHint)
 		tn.PartitionNames = $8.([]model.CIStr)
-		join := &ast.Join{Left: &ast.TableSource{Source: tn, AsName: $9.(model.CIStr)}, Right: nil}
+		ts := &ast.TableSource{Source: tn, AsName: $9.(model.CIStr)}
+		parser.setNodePos(ts, yyS[yypt-6:yypt-2])
 		x := &ast.DeleteStmt{
-			TableRefs: &ast.TableRefsClause{TableRefs: join},
+			TableRefs: parser.singleTableRefs(ts),
 			Priority:  $3.(mysql.PriorityEnum),
 			Quick:     $4.(bool),
 			IgnoreErr: $5.(bool),
@@ -5249,11 +6328,17 @@ PredicateExpr:
 	}
 |	BitExpr LikeOrNotOp SimpleExpr LikeEscapeOpt
 	{
-		escape := $4
-		if len(escape) > 1 {
-			yylex.AppendError(ErrWrongArguments.GenWithStackByArgs("ESCAPE"))
-			return 1
-		} else if len(escape) == 0 {
+		// an explicit empty escape means no escape character, and so does
+		// an omitted one without backslash escapes.
+		escape := "\x00"
+		if $4 != nil {
+			if e := $4.(string); len(e) > 1 {
+				yylex.AppendError(ErrWrongArguments.GenWithStackByArgs("ESCAPE"))
+				return 1
+			} else if len(e) == 1 {
+				escape = e
+			}
+		} else if !parser.lexer.GetSQLMode().HasNoBackslashEscapesMode() {
 			escape = "\\"
 		}
 		$$ = &ast.PatternLikeExpr{
@@ -5276,7 +6361,7 @@ RegexpSym:
 LikeEscapeOpt:
 	%prec empty
 	{
-		$$ = "\\"
+		$$ = nil
 	}
 |	"ESCAPE" stringLit
 	{
@@ -5906,6 +6991,39 @@ UnReservedKeyword:
 |	"CLUSTERED"
 |	"NONCLUSTERED"
 |	"PRESERVE"
+|	"AT"
+|	"COMPLETION"
+|	"CONTAINS"
+|	"ENDS"
+|	"EVERY"
+|	"FOLLOWS"
+|	"PRECEDES"
+|	"RETURNS"
+|	"SCHEDULE"
+|	"STARTS"
+|	"CATALOG_NAME"
+|	"CLASS_ORIGIN"
+|	"CLOSE"
+|	"COLUMN_NAME"
+|	"CONSTRAINT_CATALOG"
+|	"CONSTRAINT_NAME"
+|	"CONSTRAINT_SCHEMA"
+|	"CURSOR_NAME"
+|	"DIAGNOSTICS"
+|	"FOUND"
+|	"HANDLER"
+|	"MESSAGE_TEXT"
+|	"MYSQL_ERRNO"
+|	"NUMBER"
+|	"RETURNED_SQLSTATE"
+|	"SCHEMA_NAME"
+|	"STACKED"
+|	"SUBCLASS_ORIGIN"
+|	"TABLE_NAME"
+|	"EMPTY"
+|	"NESTED"
+|	"ORDINALITY"
+|	"PATH"
 
 TiDBKeyword:
 	"ADMIN"
@@ -6073,7 +7191,8 @@ InsertIntoStmt:
 		x.IgnoreErr = $4.(bool)
 		// Wraps many layers here so that it can be processed the same way as select statement.
 		ts := &ast.TableSource{Source: $6.(*ast.TableName)}
-		x.Table = &ast.TableRefsClause{TableRefs: &ast.Join{Left: ts}}
+		parser.setNodePos(ts, yyS[yypt-3:yypt-2])
+		x.Table = parser.singleTableRefs(ts)
 		if $9 != nil {
 			x.OnDuplicate = $9.([]*ast.Assignment)
 		}
@@ -6204,7 +7323,8 @@ ReplaceIntoStmt:
 		x.IsReplace = true
 		x.Priority = $2.(mysql.PriorityEnum)
 		ts := &ast.TableSource{Source: $4.(*ast.TableName)}
-		x.Table = &ast.TableRefsClause{TableRefs: &ast.Join{Left: ts}}
+		parser.setNodePos(ts, yyS[yypt-2:yypt-1])
+		x.Table = parser.singleTableRefs(ts)
 		x.PartitionNames = $5.([]model.CIStr)
 		$$ = x
 	}
@@ -7807,6 +8927,23 @@ ShutdownStmt:
 		$$ = &ast.ShutdownStmt{}
 	}
 
+/*******************************************************************
+ *
+ *  Delimiter Directive
+ *
+ *  Example:
+ *      DELIMITER $$
+ *
+ *  The directive is recognized by the lexer at the start of a statement,
+ *  its argument becomes the active delimiter like the mysql client does.
+ *  See https://dev.mysql.com/doc/refman/8.0/en/mysql-commands.html
+ *******************************************************************/
+DelimiterStmt:
+	delimiterDirective
+	{
+		$$ = &ast.DelimiterStmt{Delimiter: $1}
+	}
+
 SelectStmtBasic:
 	"SELECT" SelectStmtOpts SelectStmtFieldList
 	{
@@ -7821,13 +8958,32 @@ SelectStmtBasic:
 		}
 		$$ = st
 	}
+|	"SELECT" SelectStmtOpts SelectStmtFieldList SelectStmtInto
+	{
+		st := &ast.SelectStmt{
+			SelectStmtOpts: $2.(*ast.SelectStmtOpts),
+			Distinct:       $2.(*ast.SelectStmtOpts).Distinct,
+			Fields:         $3.(*ast.FieldList),
+			Kind:           ast.SelectStmtKindSelect,
+			SelectIntoOpt:  $4.(*ast.SelectIntoOption),
+		}
+		if st.SelectStmtOpts.TableHints != nil {
+			st.TableHints = st.SelectStmtOpts.TableHints
+		}
+		// the rules after SelectStmtBasic don't set the text of the last field when INTO is before them.
+		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
+		if lastField.Expr != nil && lastField.AsName.O == "" {
+			lastField.SetText(parser.src[lastField.Offset:parser.endOffset(&yyS[yypt])])
+		}
+		$$ = st
+	}
 
 SelectStmtFromDualTable:
 	SelectStmtBasic FromDual WhereClauseOptional
 	{
 		st := $1.(*ast.SelectStmt)
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
-		if lastField.Expr != nil && lastField.AsName.O == "" {
+		if lastField.Expr != nil && lastField.AsName.O == "" && st.SelectIntoOpt == nil {
 			lastEnd := yyS[yypt-1].offset - 1
 			lastField.SetText(parser.src[lastField.Offset:lastEnd])
 		}
@@ -7842,7 +8998,7 @@ SelectStmtFromTable:
 		st := $1.(*ast.SelectStmt)
 		st.From = $3.(*ast.TableRefsClause)
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
-		if lastField.Expr != nil && lastField.AsName.O == "" {
+		if lastField.Expr != nil && lastField.AsName.O == "" && st.SelectIntoOpt == nil {
 			lastEnd := parser.endOffset(&yyS[yypt-5])
 			lastField.SetText(parser.src[lastField.Offset:lastEnd])
 		}
@@ -7941,7 +9097,7 @@ SelectStmt:
 			st.LockInfo = $6.(*ast.SelectLockInfo)
 		}
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
-		if lastField.Expr != nil && lastField.AsName.O == "" {
+		if lastField.Expr != nil && lastField.AsName.O == "" && st.SelectIntoOpt == nil {
 			src := parser.src
 			var lastEnd int
 			if $2 != nil {
@@ -7977,6 +9133,10 @@ SelectStmt:
 			st.Limit = $5.(*ast.Limit)
 		}
 		if $7 != nil {
+			if st.SelectIntoOpt != nil {
+				yylex.AppendError(yylex.Errorf("Multiple INTO clauses in one query block"))
+				return 1
+			}
 			st.SelectIntoOpt = $7.(*ast.SelectIntoOption)
 		}
 		$$ = st
@@ -7997,6 +9157,10 @@ SelectStmt:
 			st.LockInfo = $5.(*ast.SelectLockInfo)
 		}
 		if $6 != nil {
+			if st.SelectIntoOpt != nil {
+				yylex.AppendError(yylex.Errorf("Multiple INTO clauses in one query block"))
+				return 1
+			}
 			st.SelectIntoOpt = $6.(*ast.SelectIntoOption)
 		}
 		$$ = st
@@ -8014,6 +9178,10 @@ SelectStmt:
 			st.Limit = $3.(*ast.Limit)
 		}
 		if $5 != nil {
+			if st.SelectIntoOpt != nil {
+				yylex.AppendError(yylex.Errorf("Multiple INTO clauses in one query block"))
+				return 1
+			}
 			st.SelectIntoOpt = $5.(*ast.SelectIntoOption)
 		}
 		$$ = st
@@ -8025,7 +9193,8 @@ SelectStmt:
 			Fields: &ast.FieldList{Fields: []*ast.SelectField{{WildCard: &ast.WildCardField{}}}},
 		}
 		ts := &ast.TableSource{Source: $2.(*ast.TableName)}
-		st.From = &ast.TableRefsClause{TableRefs: &ast.Join{Left: ts}}
+		parser.setNodePos(ts, yyS[yypt-4:yypt-3])
+		st.From = parser.singleTableRefs(ts)
 		if $3 != nil {
 			st.OrderBy = $3.(*ast.OrderByClause)
 		}
@@ -8462,6 +9631,90 @@ TableFactor:
 		j.ExplicitParens = true
 		$$ = $2
 	}
+|	JSONTable TableAsName
+	{
+		$$ = &ast.TableSource{Source: $1.(*ast.JSONTable), AsName: $2.(model.CIStr)}
+	}
+
+JSONTable:
+	"JSON_TABLE" '(' Expression ',' stringLit "COLUMNS" '(' JSONTableColumnList ')' ')'
+	{
+		$$ = &ast.JSONTable{Expr: $3, Path: $5, Columns: $8.([]*ast.JSONTableColumn)}
+	}
+
+JSONTableColumnList:
+	JSONTableColumn
+	{
+		$$ = []*ast.JSONTableColumn{$1.(*ast.JSONTableColumn)}
+	}
+|	JSONTableColumnList ',' JSONTableColumn
+	{
+		$$ = append($1.([]*ast.JSONTableColumn), $3.(*ast.JSONTableColumn))
+	}
+
+JSONTableColumn:
+	Identifier "FOR" "ORDINALITY"
+	{
+		$$ = &ast.JSONTableColumn{Tp: ast.JSONTableColumnOrdinality, Name: model.NewCIStr($1)}
+	}
+|	Identifier Type "PATH" stringLit JSONTableOnEmptyOnErrorOpt
+	{
+		col := $5.(*ast.JSONTableColumn)
+		col.Tp = ast.JSONTableColumnPath
+		col.Name = model.NewCIStr($1)
+		col.Type = $2.(*types.FieldType)
+		col.Path = $4
+		$$ = col
+	}
+|	Identifier Type "EXISTS" "PATH" stringLit
+	{
+		$$ = &ast.JSONTableColumn{
+			Tp:   ast.JSONTableColumnExists,
+			Name: model.NewCIStr($1),
+			Type: $2.(*types.FieldType),
+			Path: $5,
+		}
+	}
+|	"NESTED" "PATH" stringLit "COLUMNS" '(' JSONTableColumnList ')'
+	{
+		$$ = &ast.JSONTableColumn{Tp: ast.JSONTableColumnNested, Path: $3, Columns: $6.([]*ast.JSONTableColumn)}
+	}
+|	"NESTED" stringLit "COLUMNS" '(' JSONTableColumnList ')'
+	{
+		$$ = &ast.JSONTableColumn{Tp: ast.JSONTableColumnNested, Path: $2, Columns: $5.([]*ast.JSONTableColumn)}
+	}
+
+/* JSONTableOnEmptyOnErrorOpt returns a JSONTableColumn carrying only OnEmpty and OnError. */
+JSONTableOnEmptyOnErrorOpt:
+	{
+		$$ = &ast.JSONTableColumn{}
+	}
+|	JSONTableResponse "ON" "EMPTY"
+	{
+		$$ = &ast.JSONTableColumn{OnEmpty: $1.(*ast.JSONTableResponse)}
+	}
+|	JSONTableResponse "ON" "ERROR"
+	{
+		$$ = &ast.JSONTableColumn{OnError: $1.(*ast.JSONTableResponse)}
+	}
+|	JSONTableResponse "ON" "EMPTY" JSONTableResponse "ON" "ERROR"
+	{
+		$$ = &ast.JSONTableColumn{OnEmpty: $1.(*ast.JSONTableResponse), OnError: $4.(*ast.JSONTableResponse)}
+	}
+
+JSONTableResponse:
+	"NULL"
+	{
+		$$ = &ast.JSONTableResponse{Tp: ast.JSONTableResponseNull}
+	}
+|	"ERROR"
+	{
+		$$ = &ast.JSONTableResponse{Tp: ast.JSONTableResponseError}
+	}
+|	"DEFAULT" stringLit
+	{
+		$$ = &ast.JSONTableResponse{Tp: ast.JSONTableResponseDefault, Default: $2}
+	}
 
 PartitionNameListOpt:
 	/* empty */
@@ -8578,6 +9831,7 @@ JoinTable:
 |	TableRef CrossOpt TableRef "ON" Expression
 	{
 		on := &ast.OnCondition{Expr: $5}
+		parser.setNodePos(on, yyS[yypt-1:yypt+1])
 		$$ = &ast.Join{Left: $1.(ast.ResultSetNode), Right: $3.(ast.ResultSetNode), Tp: ast.CrossJoin, On: on}
 	}
 |	TableRef CrossOpt TableRef "USING" '(' ColumnNameList ')'
@@ -8587,6 +9841,7 @@ JoinTable:
 |	TableRef JoinType OuterOpt "JOIN" TableRef "ON" Expression
 	{
 		on := &ast.OnCondition{Expr: $7}
+		parser.setNodePos(on, yyS[yypt-1:yypt+1])
 		$$ = &ast.Join{Left: $1.(ast.ResultSetNode), Right: $5.(ast.ResultSetNode), Tp: $2.(ast.JoinType), On: on}
 	}
 |	TableRef JoinType OuterOpt "JOIN" TableRef "USING" '(' ColumnNameList ')'
@@ -8608,6 +9863,7 @@ JoinTable:
 |	TableRef "STRAIGHT_JOIN" TableRef "ON" Expression
 	{
 		on := &ast.OnCondition{Expr: $5}
+		parser.setNodePos(on, yyS[yypt-1:yypt+1])
 		$$ = &ast.Join{Left: $1.(ast.ResultSetNode), Right: $3.(ast.ResultSetNode), StraightJoin: true, On: on}
 	}
 
@@ -8858,7 +10114,10 @@ SelectStmtIntoOption:
 	{
 		$$ = nil
 	}
-|	"INTO" "OUTFILE" stringLit Fields Lines
+|	SelectStmtInto
+
+SelectStmtInto:
+	"INTO" "OUTFILE" stringLit Fields Lines
 	{
 		x := &ast.SelectIntoOption{
 			Tp:       ast.SelectIntoOutfile,
@@ -8873,6 +10132,30 @@ SelectStmtIntoOption:
 
 		$$ = x
 	}
+|	"INTO" SelectIntoVarList
+	{
+		$$ = &ast.SelectIntoOption{Tp: ast.SelectIntoVars, Vars: $2.([]*ast.SelectIntoVar)}
+	}
+
+SelectIntoVarList:
+	SelectIntoVar
+	{
+		$$ = []*ast.SelectIntoVar{$1.(*ast.SelectIntoVar)}
+	}
+|	SelectIntoVarList ',' SelectIntoVar
+	{
+		$$ = append($1.([]*ast.SelectIntoVar), $3.(*ast.SelectIntoVar))
+	}
+
+SelectIntoVar:
+	Identifier
+	{
+		$$ = &ast.SelectIntoVar{Name: $1}
+	}
+|	singleAtIdentifier
+	{
+		$$ = &ast.SelectIntoVar{Name: strings.TrimPrefix($1, "@"), IsUser: true}
+	}
 
 // See https://dev.mysql.com/doc/refman/5.7/en/subqueries.html
 SubSelect:
@@ -9153,8 +10436,11 @@ SetOprClause:
 			endOffset := parser.endOffset(&yyS[yypt])
 			parser.setLastSelectFieldText(sel, endOffset)
 			sel.IsInBraces = true
+			parser.setNodePos(sel, yyS[yypt-2:yypt+1])
 		} else {
-			setList = []ast.Node{&ast.SetOprSelectList{Selects: $2.([]ast.Node)}}
+			set := &ast.SetOprSelectList{Selects: $2.([]ast.Node)}
+			parser.setNodePos(set, yyS[yypt-2:yypt+1])
+			setList = []ast.Node{set}
 		}
 		$$ = setList
 	}
@@ -9166,9 +10452,11 @@ SetOprClause:
 			parser.setLastSelectFieldText(sel, endOffset)
 			sel.IsInBraces = true
 			sel.With = $2.(*ast.WithClause)
+			parser.setNodePos(sel, yyS[yypt-3:yypt+1])
 		} else {
 			set := &ast.SetOprSelectList{Selects: $3.([]ast.Node)}
 			set.With = $2.(*ast.WithClause)
+			parser.setNodePos(set, yyS[yypt-3:yypt+1])
 			setList = []ast.Node{set}
 		}
 		$$ = setList
@@ -9416,7 +10704,7 @@ ConfigItemName:
 VariableAssignment:
 	VariableName EqOrAssignmentEq SetExpr
 	{
-		$$ = &ast.VariableAssignment{Name: $1, Value: $3, IsSystem: true}
+		$$ = parser.newVariableAssignment($1, $3)
 	}
 |	"GLOBAL" VariableName EqOrAssignmentEq SetExpr
 	{
@@ -10527,6 +11815,8 @@ Statement:
 	EmptyStmt
 |	AdminStmt
 |	AlterDatabaseStmt
+|	AlterEventStmt
+|	AlterProcedureStmt
 |	AlterTableStmt
 |	AlterUserStmt
 |	AlterImportStmt
@@ -10539,6 +11829,7 @@ Statement:
 |	CommitStmt
 |	DeallocateStmt
 |	DeleteFromStmt
+|	DelimiterStmt
 |	ExecuteStmt
 |	ExplainStmt
 |	ChangeStmt
@@ -10547,6 +11838,10 @@ Statement:
 |	CreateIndexStmt
 |	CreateTableStmt
 |	CreateViewStmt
+|	CreateProcedureStmt
+|	CreateFunctionStmt
+|	CreateTriggerStmt
+|	CreateEventStmt
 |	CreateUserStmt
 |	CreateRoleStmt
 |	CreateBindingStmt
@@ -10554,11 +11849,14 @@ Statement:
 |	CreateStatisticsStmt
 |	DoStmt
 |	DropDatabaseStmt
+|	DropEventStmt
 |	DropImportStmt
 |	DropIndexStmt
 |	DropTableStmt
 |	DropSequenceStmt
 |	DropViewStmt
+|	DropProcedureStmt
+|	DropTriggerStmt
 |	DropUserStmt
 |	DropRoleStmt
 |	DropStatisticsStmt
@@ -10619,6 +11917,44 @@ TraceableStmt:
 |	RollbackStmt
 |	SetStmt
 
+/* Statements allowed as the body of stored programs, BEGIN starts a compound statement there. */
+RoutineStmt:
+	AlterTableStmt
+|	CallStmt
+|	CaseStmt
+|	CloseCursorStmt
+|	CommitStmt
+|	CreateIndexStmt
+|	CreateTableStmt
+|	CreateViewStmt
+|	DeallocateStmt
+|	DeleteFromStmt
+|	DoStmt
+|	DropIndexStmt
+|	DropTableStmt
+|	DropViewStmt
+|	ExecuteStmt
+|	FetchCursorStmt
+|	GetDiagnosticsStmt
+|	IfStmt
+|	InsertIntoStmt
+|	IterateStmt
+|	LabeledCompoundStmt
+|	LeaveStmt
+|	LoadDataStmt
+|	OpenCursorStmt
+|	PreparedStmt
+|	RenameTableStmt
+|	ReplaceIntoStmt
+|	ReturnStmt
+|	RollbackStmt
+|	SetOprStmt1
+|	SetStmt
+|	SignalStmt
+|	StartTransactionStmt
+|	TruncateTableStmt
+|	UpdateStmt
+
 ExplainableStmt:
 	DeleteFromStmt
 |	UpdateStmt
@@ -11485,6 +12821,7 @@ FieldLen:
 	}
 
 OptFieldLen:
+	/* empty */ %prec lowerThanParenthese
 	{
 		$$ = types.UnspecifiedLength
 	}
@@ -11514,6 +12851,7 @@ FieldOpts:
 	}
 
 FloatOpt:
+	/* empty */ %prec lowerThanParenthese
 	{
 		$$ = &ast.FloatOpt{Flen: types.UnspecifiedLength, Decimal: types.UnspecifiedLength}
 	}
@@ -11539,6 +12877,7 @@ OptBinMod:
 	}
 
 OptBinary:
+	/* empty */ %prec lowerThanParenthese
 	{
 		$$ = &ast.OptBinary{
 			IsBinary: false,
@@ -11644,10 +12983,13 @@ UpdateStmtNoWith:
 			refs = x
 		} else {
 			refs = &ast.Join{Left: $5.(ast.ResultSetNode)}
+			parser.setNodePos(refs, yyS[yypt-5:yypt-4])
 		}
+		clause := &ast.TableRefsClause{TableRefs: refs}
+		parser.setNodePos(clause, yyS[yypt-5:yypt-4])
 		st := &ast.UpdateStmt{
 			Priority:  $3.(mysql.PriorityEnum),
-			TableRefs: &ast.TableRefsClause{TableRefs: refs},
+			TableRefs: clause,
 			List:      $7.([]*ast.Assignment),
 			IgnoreErr: $4.(bool),
 		}
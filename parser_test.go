@@ -16,6 +16,7 @@ package parser_test
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -71,7 +72,9 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"delayed", "high_priority", "low_priority",
 		"cumeDist", "denseRank", "firstValue", "lag", "lastValue", "lead", "nthValue", "ntile",
 		"over", "percentRank", "rank", "row", "rows", "rowNumber", "window", "linear",
-		"match", "until", "placement", "tablesample",
+		"match", "until", "placement", "tablesample", "json_table",
+		"condition", "continue", "cursor", "declare", "elseif", "exit", "get", "iterate", "leave", "loop",
+		"resignal", "signal", "sqlexception", "sqlstate", "sqlwarning", "undo", "while",
 		// TODO: support the following keywords
 		// "with",
 	}
@@ -109,6 +112,9 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"following", "preceding", "unbounded", "respect", "nulls", "current", "last", "against", "expansion",
 		"chain", "error", "general", "nvarchar", "pack_keys", "parser", "shard_row_id_bits", "pre_split_regions",
 		"constraints", "role", "replicas", "policy", "s3", "strict", "running", "stop", "preserve",
+		"handler", "found", "diagnostics", "stacked", "number", "close", "class_origin", "subclass_origin", "message_text",
+		"mysql_errno", "constraint_catalog", "constraint_schema", "constraint_name", "catalog_name", "schema_name",
+		"table_name", "column_name", "cursor_name", "returned_sqlstate", "empty", "nested", "ordinality", "path",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -320,6 +326,127 @@ func (s *testParserSuite) TestSimple(c *C) {
 	c.Assert(bExpr.R.(ast.ValueExpr).GetValue().(int64), Equals, int64(10))
 }
 
+func (s *testParserSuite) TestSQLModeMatrix(c *C) {
+	modes := []mysql.SQLMode{
+		mysql.ModeNone,
+		mysql.ModeNoBackslashEscapes,
+		mysql.ModePipesAsConcat,
+		mysql.ModeRealAsFloat,
+		mysql.ModeANSIQuotes,
+		mysql.ModeIgnoreSpace,
+		mysql.ModeANSI,
+		mysql.ModeTraditional,
+	}
+	table := []struct {
+		src string
+		// expects are the statements which are parsed in the default mode to the AST of src in each of modes,
+		// "" means src can't be parsed in the mode.
+		expects []string
+	}{
+		{`select 'a\nb', 'c\\d'`, []string{
+			`select 'a\nb', 'c\\d'`,
+			`select 'a\\nb', 'c\\\\d'`,
+			`select 'a\nb', 'c\\d'`,
+			`select 'a\nb', 'c\\d'`,
+			`select 'a\nb', 'c\\d'`,
+			`select 'a\nb', 'c\\d'`,
+			`select 'a\nb', 'c\\d'`,
+			`select 'a\nb', 'c\\d'`,
+		}},
+		{`select 'a\'`, []string{
+			"",
+			`select 'a\\'`,
+			"",
+			"",
+			"",
+			"",
+			"",
+			"",
+		}},
+		{`select a from t where a like 'a\_%'`, []string{
+			`select a from t where a like 'a\_%'`,
+			`select a from t where a like 'a\\_%' escape '\0'`,
+			`select a from t where a like 'a\_%'`,
+			`select a from t where a like 'a\_%'`,
+			`select a from t where a like 'a\_%'`,
+			`select a from t where a like 'a\_%'`,
+			`select a from t where a like 'a\_%'`,
+			`select a from t where a like 'a\_%'`,
+		}},
+		{`select a || b, 'x' || 'y' || 'z', a || b + 1, not a || b from t`, []string{
+			`select a or b, 'x' or 'y' or 'z', a or b + 1, not a or b from t`,
+			`select a or b, 'x' or 'y' or 'z', a or b + 1, not a or b from t`,
+			`select concat(a, b), concat(concat('x', 'y'), 'z'), concat(a, b) + 1, not concat(a, b) from t`,
+			`select a or b, 'x' or 'y' or 'z', a or b + 1, not a or b from t`,
+			`select a or b, 'x' or 'y' or 'z', a or b + 1, not a or b from t`,
+			`select a or b, 'x' or 'y' or 'z', a or b + 1, not a or b from t`,
+			`select concat(a, b), concat(concat('x', 'y'), 'z'), concat(a, b) + 1, not concat(a, b) from t`,
+			`select a or b, 'x' or 'y' or 'z', a or b + 1, not a or b from t`,
+		}},
+		{`create table t (a real, b real(10, 2))`, []string{
+			`create table t (a double, b double(10, 2))`,
+			`create table t (a double, b double(10, 2))`,
+			`create table t (a double, b double(10, 2))`,
+			`create table t (a float, b float(10, 2))`,
+			`create table t (a double, b double(10, 2))`,
+			`create table t (a double, b double(10, 2))`,
+			`create table t (a float, b float(10, 2))`,
+			`create table t (a double, b double(10, 2))`,
+		}},
+		{`select cast(a as real) from t`, []string{
+			`select cast(a as double) from t`,
+			`select cast(a as double) from t`,
+			`select cast(a as double) from t`,
+			`select cast(a as float) from t`,
+			`select cast(a as double) from t`,
+			`select cast(a as double) from t`,
+			`select cast(a as float) from t`,
+			`select cast(a as double) from t`,
+		}},
+		{`select "a" from "t"`, []string{
+			"",
+			"",
+			"",
+			"",
+			"select `a` from `t`",
+			"",
+			"select `a` from `t`",
+			"",
+		}},
+		{`select count (*) from t`, []string{
+			"",
+			"",
+			"",
+			"",
+			"",
+			`select count(*) from t`,
+			`select count(*) from t`,
+			"",
+		}},
+	}
+
+	p := parser.New()
+	for _, t := range table {
+		c.Assert(t.expects, HasLen, len(modes))
+		for i, mode := range modes {
+			comment := Commentf("source %v, mode %d", t.src, mode)
+			p.SetSQLMode(mode)
+			stmt, err := p.ParseOneStmt(t.src, "", "")
+			if t.expects[i] == "" {
+				c.Assert(err, NotNil, comment)
+				continue
+			}
+			c.Assert(err, IsNil, comment)
+			p.SetSQLMode(mysql.ModeNone)
+			expect, err := p.ParseOneStmt(t.expects[i], "", "")
+			c.Assert(err, IsNil, comment)
+			CleanNodeText(stmt)
+			CleanNodeText(expect)
+			c.Assert(stmt, DeepEquals, expect, comment)
+		}
+	}
+}
+
 func (s *testParserSuite) TestSpecialComments(c *C) {
 	parser := parser.New()
 
@@ -367,13 +494,25 @@ func (s *testParserSuite) RunTest(c *C, table []testCase) {
 	parser := parser.New()
 	parser.EnableWindowFunc(s.enableWindowFunc)
 	for _, t := range table {
-		_, _, err := parser.Parse(t.src, "", "")
+		stmts, _, err := parser.Parse(t.src, "", "")
 		comment := Commentf("source %v", t.src)
 		if !t.ok {
 			c.Assert(err, NotNil, comment)
 			continue
 		}
 		c.Assert(err, IsNil, comment)
+		// the statements span the source but the comments and the delimiters around them.
+		offset, delimiter := 0, ";"
+		for _, stmt := range stmts {
+			start, end := stmt.StartPos().Offset, stmt.EndPos().Offset
+			c.Assert(start < end, IsTrue, comment)
+			c.Assert(skipIgnored(t.src[offset:start], delimiter), Equals, "", comment)
+			offset = end
+			if d, ok := stmt.(*ast.DelimiterStmt); ok {
+				delimiter = d.Delimiter
+			}
+		}
+		c.Assert(skipIgnored(t.src[offset:], delimiter), Equals, "", comment)
 		// restore correctness test
 		if t.ok {
 			s.RunRestoreTest(c, t.src, t.restore)
@@ -381,6 +520,33 @@ func (s *testParserSuite) RunTest(c *C, table []testCase) {
 	}
 }
 
+// skipIgnored returns s without its leading whitespaces, comments and delimiters.
+func skipIgnored(s, delimiter string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		switch {
+		case strings.HasPrefix(s, delimiter):
+			s = s[len(delimiter):]
+		case strings.HasPrefix(s, "*/"):
+			s = s[2:]
+		case strings.HasPrefix(s, "/*"):
+			end := strings.Index(s, "*/")
+			if end < 0 {
+				return s
+			}
+			s = s[end+2:]
+		case strings.HasPrefix(s, "--"), strings.HasPrefix(s, "#"):
+			end := strings.IndexByte(s, '\n')
+			if end < 0 {
+				return ""
+			}
+			s = s[end:]
+		default:
+			return s
+		}
+	}
+}
+
 func (s *testParserSuite) RunRestoreTest(c *C, sourceSQLs, expectSQLs string) {
 	var sb strings.Builder
 	parser := parser.New()
@@ -414,13 +580,25 @@ func (s *testParserSuite) RunTestInRealAsFloatMode(c *C, table []testCase) {
 	parser.EnableWindowFunc(s.enableWindowFunc)
 	parser.SetSQLMode(mysql.ModeRealAsFloat)
 	for _, t := range table {
-		_, _, err := parser.Parse(t.src, "", "")
+		stmts, _, err := parser.Parse(t.src, "", "")
 		comment := Commentf("source %v", t.src)
 		if !t.ok {
 			c.Assert(err, NotNil, comment)
 			continue
 		}
 		c.Assert(err, IsNil, comment)
+		// the statements span the source but the comments and the delimiters around them.
+		offset, delimiter := 0, ";"
+		for _, stmt := range stmts {
+			start, end := stmt.StartPos().Offset, stmt.EndPos().Offset
+			c.Assert(start < end, IsTrue, comment)
+			c.Assert(skipIgnored(t.src[offset:start], delimiter), Equals, "", comment)
+			offset = end
+			if d, ok := stmt.(*ast.DelimiterStmt); ok {
+				delimiter = d.Delimiter
+			}
+		}
+		c.Assert(skipIgnored(t.src[offset:], delimiter), Equals, "", comment)
 		// restore correctness test
 		if t.ok {
 			s.RunRestoreTestInRealAsFloatMode(c, t.src, t.restore)
@@ -694,6 +872,14 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"select a,b,a+b from t into outfile '/tmp/result.txt' fields terminated BY ',' optionally enclosed BY '\"' lines starting by 'xy' terminated BY '\r'", true, "SELECT `a`,`b`,`a`+`b` FROM `t` INTO OUTFILE '/tmp/result.txt' FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\"' LINES STARTING BY 'xy' TERMINATED BY '\r'"},
 		{"select a,b,a+b from t into outfile '/tmp/result.txt' fields terminated BY ',' enclosed BY '\"' lines starting by 'xy' terminated BY '\r'", true, "SELECT `a`,`b`,`a`+`b` FROM `t` INTO OUTFILE '/tmp/result.txt' FIELDS TERMINATED BY ',' ENCLOSED BY '\"' LINES STARTING BY 'xy' TERMINATED BY '\r'"},
 
+		// select into variables
+		{"select count(*) into @n from t", true, "SELECT COUNT(1) FROM `t` INTO @`n`"},
+		{"select a, b into @a, @b from dual where c", true, "SELECT `a`,`b` FROM DUAL WHERE `c` INTO @`a`,@`b`"},
+		{"select 1 into outfile '/tmp/1.csv' from t", true, "SELECT 1 FROM `t` INTO OUTFILE '/tmp/1.csv'"},
+		{"select a from t into @a", true, "SELECT `a` FROM `t` INTO @`a`"},
+		{"select 1 into @a from t into @b", false, ""},
+		{"select 1 into @a into @b", false, ""},
+
 		// from join
 		{"SELECT * from t1, t2, t3", true, "SELECT * FROM ((`t1`) JOIN `t2`) JOIN `t3`"},
 		{"select * from t1 join t2 left join t3 on t2.id = t3.id", true, "SELECT * FROM (`t1` JOIN `t2`) LEFT JOIN `t3` ON `t2`.`id`=`t3`.`id`"},
@@ -4253,7 +4439,7 @@ func (s *testParserSuite) TestUnionOrderBy(c *C) {
 func (s *testParserSuite) TestLikeEscape(c *C) {
 	table := []testCase{
 		// for like escape
-		{`select "abc_" like "abc\\_" escape ''`, true, "SELECT _UTF8MB4'abc_' LIKE _UTF8MB4'abc\\_'"},
+		{`select "abc_" like "abc\\_" escape ''`, true, "SELECT _UTF8MB4'abc_' LIKE _UTF8MB4'abc\\_' ESCAPE ''"},
 		{`select "abc_" like "abc\\_" escape '\\'`, true, "SELECT _UTF8MB4'abc_' LIKE _UTF8MB4'abc\\_'"},
 		{`select "abc_" like "abc\\_" escape '||'`, false, ""},
 		{`select "abc" like "escape" escape '+'`, true, "SELECT _UTF8MB4'abc' LIKE _UTF8MB4'escape' ESCAPE '+'"},
@@ -4261,6 +4447,35 @@ func (s *testParserSuite) TestLikeEscape(c *C) {
 	}
 
 	s.RunTest(c, table)
+
+	// the escape character is kept by restoring and parsing again in the same SQL mode.
+	p := parser.New()
+	for _, mode := range []mysql.SQLMode{mysql.ModeNone, mysql.ModeNoBackslashEscapes} {
+		p.SetSQLMode(mode)
+		for _, ca := range []struct {
+			sql    string
+			escape byte
+		}{
+			{"select a like 'x'", '\\'},
+			{"select a like 'x' escape ''", 0},
+			{"select a like 'x' escape '|'", '|'},
+		} {
+			comment := Commentf("%s in mode %v", ca.sql, mode)
+			escape := ca.escape
+			if escape == '\\' && mode.HasNoBackslashEscapesMode() {
+				escape = 0
+			}
+			for i := 0; i < 2; i++ {
+				stmt, err := p.ParseOneStmt(ca.sql, "", "")
+				c.Assert(err, IsNil, comment)
+				like := stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.PatternLikeExpr)
+				c.Assert(like.Escape, Equals, escape, comment)
+				var sb strings.Builder
+				c.Assert(stmt.Restore(NewRestoreCtx(DefaultRestoreFlags, &sb)), IsNil, comment)
+				ca.sql = sb.String()
+			}
+		}
+	}
 }
 
 func (s *testParserSuite) TestLockUnlockTables(c *C) {
@@ -4646,6 +4861,202 @@ func (s *testParserSuite) TestView(c *C) {
 	c.Assert(v.CheckOption, Equals, model.CheckOptionCascaded)
 }
 
+func (s *testParserSuite) TestStoredProgram(c *C) {
+	table := []testCase{
+		// for create procedure
+		{"create procedure p() select 1", true, "CREATE PROCEDURE `p`() SELECT 1"},
+		{"create procedure if not exists db.p(a int, out b varchar(10), inout c decimal(10,2)) update t set a = a + 1", true, "CREATE PROCEDURE IF NOT EXISTS `db`.`p`(IN `a` INT,OUT `b` VARCHAR(10),INOUT `c` DECIMAL(10,2)) UPDATE `t` SET `a`=`a`+1"},
+		{"create definer = 'root'@'%' procedure p(in a int) comment 'x' language sql not deterministic reads sql data sql security invoker select a", true, "CREATE DEFINER = `root`@`%` PROCEDURE `p`(IN `a` INT) COMMENT 'x' LANGUAGE SQL NOT DETERMINISTIC READS SQL DATA SQL SECURITY INVOKER SELECT `a`"},
+		{"create definer = current_user procedure p() contains sql no sql modifies sql data sql security definer call q()", true, "CREATE PROCEDURE `p`() CONTAINS SQL NO SQL MODIFIES SQL DATA SQL SECURITY DEFINER CALL `q`()"},
+		{"create procedure p(a int, b int", false, ""},
+		{"create procedure p()", false, ""},
+		{"create procedure p() begin", false, ""},
+		{"create or replace procedure p() select 1", false, ""},
+		{"create algorithm = merge procedure p() select 1", false, ""},
+
+		// for create function
+		{"create function f(a int, b char(3)) returns int deterministic return a + 1", true, "CREATE FUNCTION `f`(`a` INT,`b` CHAR(3)) RETURNS INT DETERMINISTIC RETURN `a`+1"},
+		{"create function if not exists db.f() returns int(11) (select 1)", true, "CREATE FUNCTION IF NOT EXISTS `db`.`f`() RETURNS INT(11) (SELECT 1)"},
+		{"create function f() returns varchar(10) charset utf8mb4 no sql return 'a'", true, "CREATE FUNCTION `f`() RETURNS VARCHAR(10) CHARACTER SET UTF8MB4 NO SQL RETURN _UTF8MB4'a'"},
+		{"create function f(in a int) returns int return a", false, ""},
+		{"create function f() return 1", false, ""},
+
+		// for alter and drop procedure/function
+		{"alter procedure p", true, "ALTER PROCEDURE `p`"},
+		{"alter procedure db.p comment 'c' sql security definer", true, "ALTER PROCEDURE `db`.`p` COMMENT 'c' SQL SECURITY DEFINER"},
+		{"alter function f no sql", true, "ALTER FUNCTION `f` NO SQL"},
+		{"alter function f deterministic", true, "ALTER FUNCTION `f` DETERMINISTIC"},
+		{"drop procedure p", true, "DROP PROCEDURE `p`"},
+		{"drop procedure if exists db.p", true, "DROP PROCEDURE IF EXISTS `db`.`p`"},
+		{"drop function if exists f", true, "DROP FUNCTION IF EXISTS `f`"},
+
+		// for trigger
+		{"create trigger tr before insert on t for each row set @a = 1", true, "CREATE TRIGGER `tr` BEFORE INSERT ON `t` FOR EACH ROW SET @`a`=1"},
+		{"create definer = 'u' trigger if not exists db.tr after update on db.t for each row follows tr2 delete from t2", true, "CREATE DEFINER = `u`@`%` TRIGGER IF NOT EXISTS `db`.`tr` AFTER UPDATE ON `db`.`t` FOR EACH ROW FOLLOWS `tr2` DELETE FROM `t2`"},
+		{"create trigger tr after delete on t for each row precedes tr2 insert into log values (1)", true, "CREATE TRIGGER `tr` AFTER DELETE ON `t` FOR EACH ROW PRECEDES `tr2` INSERT INTO `log` VALUES (1)"},
+		{"create trigger tr before select on t for each row set @a = 1", false, ""},
+		{"create trigger tr before insert on t set @a = 1", false, ""},
+		{"drop trigger tr", true, "DROP TRIGGER `tr`"},
+		{"drop trigger if exists db.tr", true, "DROP TRIGGER IF EXISTS `db`.`tr`"},
+
+		// for event
+		{"create event e on schedule at current_timestamp + interval 1 hour do delete from t", true, "CREATE EVENT `e` ON SCHEDULE AT DATE_ADD(CURRENT_TIMESTAMP(), INTERVAL 1 HOUR) DO DELETE FROM `t`"},
+		{"create event if not exists e on schedule every 1 day starts '2020-01-01' ends '2021-01-01' on completion not preserve disable on slave comment 'c' do call p()", true, "CREATE EVENT IF NOT EXISTS `e` ON SCHEDULE EVERY 1 DAY STARTS _UTF8MB4'2020-01-01' ENDS _UTF8MB4'2021-01-01' ON COMPLETION NOT PRESERVE DISABLE ON SLAVE COMMENT 'c' DO CALL `p`()"},
+		{"create definer = 'u'@'localhost' event db.e on schedule every 10 minute on completion preserve enable do truncate table t", true, "CREATE DEFINER = `u`@`localhost` EVENT `db`.`e` ON SCHEDULE EVERY 10 MINUTE ON COMPLETION PRESERVE ENABLE DO TRUNCATE TABLE `t`"},
+		{"create event e on schedule every 1 do delete from t", false, ""},
+		{"create event e do delete from t", false, ""},
+		{"alter event e", true, "ALTER EVENT `e`"},
+		{"alter event e on schedule every 2 hour on completion preserve rename to e2 enable comment 'x' do select 1", true, "ALTER EVENT `e` ON SCHEDULE EVERY 2 HOUR ON COMPLETION PRESERVE RENAME TO `e2` ENABLE COMMENT 'x' DO SELECT 1"},
+		{"alter event e on completion not preserve", true, "ALTER EVENT `e` ON COMPLETION NOT PRESERVE"},
+		{"alter definer = 'u' event e disable", true, "ALTER DEFINER = `u`@`%` EVENT `e` DISABLE"},
+		{"drop event e", true, "DROP EVENT `e`"},
+		{"drop event if exists db.e", true, "DROP EVENT IF EXISTS `db`.`e`"},
+	}
+	s.RunTest(c, table)
+
+	p := parser.New()
+	st, err := p.ParseOneStmt("create procedure p(out a int) update t set b = b + 1", "", "")
+	c.Assert(err, IsNil)
+	proc, ok := st.(*ast.CreateProcedureStmt)
+	c.Assert(ok, IsTrue)
+	c.Assert(proc.Params, HasLen, 1)
+	c.Assert(proc.Params[0].Mode, Equals, ast.ProcedureParamModeOut)
+	c.Assert(proc.Definer.CurrentUser, IsTrue)
+	c.Assert(proc.Body.Text(), Equals, "update t set b = b + 1")
+
+	st, err = p.ParseOneStmt("create function f(a int) returns int return a * 2", "", "")
+	c.Assert(err, IsNil)
+	fn, ok := st.(*ast.CreateFunctionStmt)
+	c.Assert(ok, IsTrue)
+	c.Assert(fn.Params[0].Mode, Equals, ast.ProcedureParamModeNone)
+	c.Assert(fn.Returns.Tp, Equals, mysql.TypeLong)
+	c.Assert(fn.Body.Text(), Equals, "return a * 2")
+
+	st, err = p.ParseOneStmt("create trigger tr before update on t for each row set new.a = old.a", "", "")
+	c.Assert(err, IsNil)
+	tr, ok := st.(*ast.CreateTriggerStmt)
+	c.Assert(ok, IsTrue)
+	c.Assert(tr.Timing, Equals, ast.TriggerBefore)
+	c.Assert(tr.Event, Equals, ast.TriggerUpdate)
+	c.Assert(tr.Table.Name.O, Equals, "t")
+	c.Assert(tr.Body.Text(), Equals, "set new.a = old.a")
+
+	// the names of a stored program aren't in scope after it.
+	stmts, _, err := p.Parse("create procedure p(x int) set x = 1; set x = 1; create trigger tr before insert on t for each row set @a = 1; set new.a = 1", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts[1].(*ast.SetStmt).Variables[0].IsSystem, IsTrue)
+	c.Assert(stmts[3].(*ast.SetStmt).Variables[0].IsSystem, IsTrue)
+}
+
+func (s *testParserSuite) TestCompoundStmt(c *C) {
+	table := []testCase{
+		{"create procedure p() begin end", true, "CREATE PROCEDURE `p`() BEGIN END"},
+		{"create procedure p() lbl: begin select 1; end lbl", true, "CREATE PROCEDURE `p`() `lbl`: BEGIN SELECT 1; END `lbl`"},
+		{"create procedure p() lbl: begin end", true, "CREATE PROCEDURE `p`() `lbl`: BEGIN END `lbl`"},
+		{"create procedure p() lbl: begin end other", false, ""},
+		{"create procedure p() begin end lbl", false, ""},
+		{"create procedure p() begin select 1 end", false, ""},
+		{"create procedure p() begin select 1; declare a int; end", false, ""},
+		{"create procedure p() begin begin work; end", false, ""},
+		{"create procedure p() begin start transaction; commit; end", true, "CREATE PROCEDURE `p`() BEGIN START TRANSACTION; COMMIT; END"},
+		{"create procedure p() begin start transaction read only; rollback; end", true, "CREATE PROCEDURE `p`() BEGIN START TRANSACTION READ ONLY; ROLLBACK; END"},
+		{"create procedure p() begin declare x int; select a into x from t where b = 1; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `x` INT; SELECT `a` FROM `t` WHERE `b`=1 INTO `x`; END"},
+		{"create procedure p(out x int) select a, b from t limit 1 into x, @y", true, "CREATE PROCEDURE `p`(OUT `x` INT) SELECT `a`,`b` FROM `t` LIMIT 1 INTO `x`,@`y`"},
+
+		// SET of the local variables, the parameters and the columns of NEW and OLD
+		{"create procedure p() begin declare x int; set x = x + 1; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `x` INT; SET `x`=`x`+1; END"},
+		{"create procedure p(inout x int) set x = 1", true, "CREATE PROCEDURE `p`(INOUT `x` INT) SET `x`=1"},
+		{"create function f(a int) returns int begin set A = a + 1; return a; end", true, "CREATE FUNCTION `f`(`a` INT) RETURNS INT BEGIN SET `A`=`a`+1; RETURN `a`; END"},
+		{"create procedure p() begin declare x int; begin set x = 2, autocommit = 1; end; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `x` INT; BEGIN SET `x`=2, @@SESSION.`autocommit`=1; END; END"},
+		{"create procedure p() begin begin declare x int; end; set x = 1; end", true, "CREATE PROCEDURE `p`() BEGIN BEGIN DECLARE `x` INT; END; SET @@SESSION.`x`=1; END"},
+		{"create procedure p() begin declare done int default 0; declare continue handler for not found set done = 1; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `done` INT DEFAULT 0; DECLARE CONTINUE HANDLER FOR NOT FOUND SET `done`=1; END"},
+		{"create trigger tr before update on t for each row set new.a = old.a", true, "CREATE TRIGGER `tr` BEFORE UPDATE ON `t` FOR EACH ROW SET NEW.`a`=`old`.`a`"},
+		{"create trigger tr before insert on t for each row begin declare d int default 1; set NEW.b = d, d = 2; end", true, "CREATE TRIGGER `tr` BEFORE INSERT ON `t` FOR EACH ROW BEGIN DECLARE `d` INT DEFAULT 1; SET NEW.`b`=`d`, `d`=2; END"},
+		{"create procedure p() set new.a = 1", true, "CREATE PROCEDURE `p`() SET @@SESSION.`new.a`=1"},
+		{"set new.a = 1", true, "SET @@SESSION.`new.a`=1"},
+
+		// for declarations
+		{"create procedure p() begin declare a, b int default 1; declare c varchar(10); end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `a`,`b` INT DEFAULT 1; DECLARE `c` VARCHAR(10); END"},
+		{"create procedure p() begin declare c1 condition for 1051; declare c2 condition for sqlstate '42S02'; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `c1` CONDITION FOR 1051; DECLARE `c2` CONDITION FOR SQLSTATE '42S02'; END"},
+		{"create procedure p() begin declare c1 condition for sqlwarning; end", false, ""},
+		{"create procedure p() begin declare c1 condition for sqlstate '4200'; end", false, ""},
+		{"create procedure p() begin declare c1 condition for sqlstate '00000'; end", false, ""},
+		{"create procedure p() begin declare cur cursor for select a from t where b > 1 union select 1; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE `cur` CURSOR FOR SELECT `a` FROM `t` WHERE `b`>1 UNION SELECT 1; END"},
+		{"create procedure p() begin declare continue handler for not found, sqlexception, c1, 1062, sqlstate value '23000' set @done = 1; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE CONTINUE HANDLER FOR NOT FOUND,SQLEXCEPTION,`c1`,1062,SQLSTATE '23000' SET @`done`=1; END"},
+		{"create procedure p() begin declare exit handler for sqlwarning begin rollback; resignal; end; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE EXIT HANDLER FOR SQLWARNING BEGIN ROLLBACK; RESIGNAL; END; END"},
+		{"create procedure p() begin declare undo handler for sqlexception do 1; end", true, "CREATE PROCEDURE `p`() BEGIN DECLARE UNDO HANDLER FOR SQLEXCEPTION DO 1; END"},
+
+		// for flow control statements
+		{"create procedure p(x int) if x > 1 then select 1; elseif x = 1 then select 2; select 3; else select 4; end if", true, "CREATE PROCEDURE `p`(IN `x` INT) IF `x`>1 THEN SELECT 1; ELSEIF `x`=1 THEN SELECT 2; SELECT 3; ELSE SELECT 4; END IF"},
+		{"create procedure p() if 1 then end if", false, ""},
+		{"create procedure p() if 1 then do 1; end", false, ""},
+		{"create procedure p(x int) case x when 1 then select 1; when 2 then select 2; else begin end; end case", true, "CREATE PROCEDURE `p`(IN `x` INT) CASE `x` WHEN 1 THEN SELECT 1; WHEN 2 THEN SELECT 2; ELSE BEGIN END; END CASE"},
+		{"create procedure p(x int) case when x > 1 then update t set a = 1; end case", true, "CREATE PROCEDURE `p`(IN `x` INT) CASE WHEN `x`>1 THEN UPDATE `t` SET `a`=1; END CASE"},
+		{"create procedure p(x int) case x else do 1; end case", false, ""},
+		{"create procedure p() l1: loop leave l1; iterate l1; end loop l1", true, "CREATE PROCEDURE `p`() `l1`: LOOP LEAVE `l1`; ITERATE `l1`; END LOOP `l1`"},
+		{"create procedure p() loop do 1; end loop", true, "CREATE PROCEDURE `p`() LOOP DO 1; END LOOP"},
+		{"create procedure p() while @i < 10 do set @i = @i + 1; end while", true, "CREATE PROCEDURE `p`() WHILE @`i`<10 DO SET @`i`=@`i`+1; END WHILE"},
+		{"create procedure p() r: repeat set @i = @i - 1; until @i = 0 end repeat", true, "CREATE PROCEDURE `p`() `r`: REPEAT SET @`i`=@`i`-1; UNTIL @`i`=0 END REPEAT `r`"},
+		{"create procedure p() repeat do 1; end repeat", false, ""},
+		{"create procedure p() leave", false, ""},
+
+		// for cursors
+		{"create procedure p() begin open cur; fetch cur into a, b; fetch next from cur into a; fetch from cur into a; close cur; end", true, "CREATE PROCEDURE `p`() BEGIN OPEN `cur`; FETCH `cur` INTO `a`,`b`; FETCH `cur` INTO `a`; FETCH `cur` INTO `a`; CLOSE `cur`; END"},
+		{"create procedure p() fetch next into a", true, "CREATE PROCEDURE `p`() FETCH `next` INTO `a`"},
+		{"create procedure p() fetch cur", false, ""},
+
+		// for signal and resignal
+		{"create procedure p() signal sqlstate '45000' set message_text = 'oops', mysql_errno = 1001", true, "CREATE PROCEDURE `p`() SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = _UTF8MB4'oops', MYSQL_ERRNO = 1001"},
+		{"create procedure p() signal c1 set class_origin = 'a', subclass_origin = 'b', constraint_catalog = 'c', constraint_schema = 'd', constraint_name = 'e', catalog_name = 'f', schema_name = 'g', table_name = 'h', column_name = 'i', cursor_name = 'j'", true, "CREATE PROCEDURE `p`() SIGNAL `c1` SET CLASS_ORIGIN = _UTF8MB4'a', SUBCLASS_ORIGIN = _UTF8MB4'b', CONSTRAINT_CATALOG = _UTF8MB4'c', CONSTRAINT_SCHEMA = _UTF8MB4'd', CONSTRAINT_NAME = _UTF8MB4'e', CATALOG_NAME = _UTF8MB4'f', SCHEMA_NAME = _UTF8MB4'g', TABLE_NAME = _UTF8MB4'h', COLUMN_NAME = _UTF8MB4'i', CURSOR_NAME = _UTF8MB4'j'"},
+		{"create procedure p() signal", false, ""},
+		{"create procedure p() signal sqlstate '45000' set returned_sqlstate = '1'", false, ""},
+		{"create procedure p() resignal", true, "CREATE PROCEDURE `p`() RESIGNAL"},
+		{"create procedure p() resignal sqlstate value '45001' set message_text = 'x'", true, "CREATE PROCEDURE `p`() RESIGNAL SQLSTATE '45001' SET MESSAGE_TEXT = _UTF8MB4'x'"},
+
+		// for get diagnostics
+		{"create procedure p() get diagnostics @n = number, r = row_count", true, "CREATE PROCEDURE `p`() GET DIAGNOSTICS @`n` = NUMBER, `r` = ROW_COUNT"},
+		{"create procedure p() get stacked diagnostics condition 1 @m = message_text, e = mysql_errno, s = returned_sqlstate", true, "CREATE PROCEDURE `p`() GET STACKED DIAGNOSTICS CONDITION 1 @`m` = MESSAGE_TEXT, `e` = MYSQL_ERRNO, `s` = RETURNED_SQLSTATE"},
+		{"create procedure p() get current diagnostics condition n t = table_name", true, "CREATE PROCEDURE `p`() GET DIAGNOSTICS CONDITION `n` `t` = TABLE_NAME"},
+		{"create procedure p() get diagnostics @m = message_text", false, ""},
+		{"create procedure p() get diagnostics condition 1 n = number", false, ""},
+
+		// for the other stored programs
+		{"create function f(x int) returns int begin declare y int default x * 2; return y; end", true, "CREATE FUNCTION `f`(`x` INT) RETURNS INT BEGIN DECLARE `y` INT DEFAULT `x`*2; RETURN `y`; END"},
+		{"create trigger tr before insert on t for each row begin if new.a < 0 then delete from t2; end if; end", true, "CREATE TRIGGER `tr` BEFORE INSERT ON `t` FOR EACH ROW BEGIN IF `new`.`a`<0 THEN DELETE FROM `t2`; END IF; END"},
+		{"create event e on schedule every 1 day do begin delete from t; insert into t values (1); end", true, "CREATE EVENT `e` ON SCHEDULE EVERY 1 DAY DO BEGIN DELETE FROM `t`; INSERT INTO `t` VALUES (1); END"},
+		{"create procedure p() begin select 1; end; select 2", true, "CREATE PROCEDURE `p`() BEGIN SELECT 1; END; SELECT 2"},
+	}
+	s.RunTest(c, table)
+
+	p := parser.New()
+	src := "create procedure p() begin\n  declare a int default 1;\n  if a > 0 then\n    update t set b = a;\n  end if;\nend"
+	st, err := p.ParseOneStmt(src, "", "")
+	c.Assert(err, IsNil)
+	block, ok := st.(*ast.CreateProcedureStmt).Body.(*ast.BlockStmt)
+	c.Assert(ok, IsTrue)
+	c.Assert(block.Text(), Equals, "begin\n  declare a int default 1;\n  if a > 0 then\n    update t set b = a;\n  end if;\nend")
+	c.Assert(block.Body, HasLen, 2)
+	c.Assert(block.Body[0].Text(), Equals, "declare a int default 1")
+	ifStmt, ok := block.Body[1].(*ast.IfStmt)
+	c.Assert(ok, IsTrue)
+	c.Assert(ifStmt.Text(), Equals, "if a > 0 then\n    update t set b = a;\n  end if")
+	c.Assert(ifStmt.Branches[0].Body[0].Text(), Equals, "update t set b = a")
+	_, ok = ifStmt.Branches[0].Body[0].(*ast.UpdateStmt)
+	c.Assert(ok, IsTrue)
+
+	// the text of the query of a cursor ends with the query.
+	src = "create procedure p() begin declare c cursor for select a from t  ; declare continue handler for not found set @done = 1; open c; end; select 9"
+	stmts, _, err := p.Parse(src, "", "")
+	c.Assert(err, IsNil)
+	cursor := stmts[0].(*ast.CreateProcedureStmt).Body.(*ast.BlockStmt).Body[0].(*ast.DeclareCursorStmt)
+	c.Assert(cursor.Select.Text(), Equals, "select a from t")
+
+	// the last field ends before INTO.
+	st, err = p.ParseOneStmt("select a + 1 into x from t", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(st.(*ast.SelectStmt).Fields.Fields[0].Text(), Equals, "a + 1")
+}
+
 func (s *testParserSuite) TestTimestampDiffUnit(c *C) {
 	// Test case for timestampdiff unit.
 	// TimeUnit should be unified to upper case.
@@ -4708,6 +5119,188 @@ func (s *testParserSuite) TestFuncCallExprOffset(c *C) {
 	}
 }
 
+func (s *testParserSuite) TestNodePos(c *C) {
+	sql := "SELECT a + 1 AS x,\n  t.b FROM db.t AS t JOIN u ON t.id = u.id\nWHERE c = 'é' GROUP BY a;\nWITH w AS (SELECT 1) SELECT * FROM w"
+	p := parser.New()
+	stmts, _, err := p.Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 2)
+
+	checkPos := func(n ast.Node, start, end ast.Pos, text string) {
+		c.Assert(n.StartPos(), Equals, start)
+		c.Assert(n.EndPos(), Equals, end)
+		c.Assert(sql[start.Offset:end.Offset], Equals, text)
+	}
+	sel := stmts[0].(*ast.SelectStmt)
+	checkPos(sel, ast.Pos{Line: 1, Col: 1}, ast.Pos{Line: 3, Col: 25, Offset: 87}, sql[:87])
+	checkPos(sel.Fields, ast.Pos{Line: 1, Col: 8, Offset: 7}, ast.Pos{Line: 2, Col: 6, Offset: 24}, "a + 1 AS x,\n  t.b")
+	checkPos(sel.Fields.Fields[0].Expr, ast.Pos{Line: 1, Col: 8, Offset: 7}, ast.Pos{Line: 1, Col: 13, Offset: 12}, "a + 1")
+	col := sel.Fields.Fields[1].Expr.(*ast.ColumnNameExpr)
+	checkPos(col, ast.Pos{Line: 2, Col: 3, Offset: 21}, ast.Pos{Line: 2, Col: 6, Offset: 24}, "t.b")
+	checkPos(col.Name, ast.Pos{Line: 2, Col: 3, Offset: 21}, ast.Pos{Line: 2, Col: 6, Offset: 24}, "t.b")
+	join := sel.From.TableRefs
+	checkPos(join.Left.(*ast.TableSource).Source, ast.Pos{Line: 2, Col: 12, Offset: 30}, ast.Pos{Line: 2, Col: 16, Offset: 34}, "db.t")
+	checkPos(join.On, ast.Pos{Line: 2, Col: 29, Offset: 47}, ast.Pos{Line: 2, Col: 43, Offset: 61}, "ON t.id = u.id")
+	// the columns count characters, not bytes.
+	checkPos(sel.Where, ast.Pos{Line: 3, Col: 7, Offset: 68}, ast.Pos{Line: 3, Col: 14, Offset: 76}, "c = 'é'")
+	checkPos(sel.GroupBy, ast.Pos{Line: 3, Col: 15, Offset: 77}, ast.Pos{Line: 3, Col: 25, Offset: 87}, "GROUP BY a")
+
+	with := stmts[1].(*ast.SelectStmt)
+	checkPos(with, ast.Pos{Line: 4, Col: 1, Offset: 89}, ast.Pos{Line: 4, Col: 37, Offset: 125}, sql[89:])
+	checkPos(with.With, ast.Pos{Line: 4, Col: 6, Offset: 94}, ast.Pos{Line: 4, Col: 21, Offset: 109}, "w AS (SELECT 1)")
+
+	// the statements span the keywords before the rules which built them, and the
+	// table references wrapped by the parser span the table.
+	spans := []struct {
+		sql  string
+		node func(ast.StmtNode) ast.Node
+		text string
+	}{
+		{"CREATE TABLE t (id INT) ENGINE=InnoDB", nil, ""},
+		{"INSERT INTO t (a) VALUES (1)", func(n ast.StmtNode) ast.Node { return n.(*ast.InsertStmt).Table }, "t"},
+		{"REPLACE t SELECT 1", func(n ast.StmtNode) ast.Node { return n.(*ast.InsertStmt).Select }, "SELECT 1"},
+		{"SHOW TABLES FROM db", nil, ""},
+		{"SET ROLE ALL", nil, ""},
+		{"(SELECT 1)", nil, ""},
+		{"UPDATE t AS x SET a = 1", func(n ast.StmtNode) ast.Node { return n.(*ast.UpdateStmt).TableRefs.TableRefs }, "t AS x"},
+		{"DELETE FROM t WHERE a = 1", func(n ast.StmtNode) ast.Node { return n.(*ast.DeleteStmt).TableRefs }, "t"},
+	}
+	for _, ca := range spans {
+		stmt, err := p.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil)
+		c.Assert(ca.sql[stmt.StartPos().Offset:stmt.EndPos().Offset], Equals, ca.sql)
+		if ca.node != nil {
+			n := ca.node(stmt)
+			c.Assert(ca.sql[n.StartPos().Offset:n.EndPos().Offset], Equals, ca.text)
+		}
+	}
+}
+
+func (s *testParserSuite) TestComments(c *C) {
+	sql := "-- users\nCREATE TABLE t (\n  id int, -- the id\n  /* the name */ name text /* inline */ NOT NULL,\n  # index\n  KEY idx (name)\n) ENGINE=InnoDB; -- end\n" +
+		"SELECT /*+ USE_INDEX(t, idx) */ a, -- first\n  b FROM t ORDER BY a /* asc */, b;\n/* tail */"
+	p := parser.New()
+	stmts, _, err := p.Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts[0].LeadingComments(), HasLen, 0)
+
+	p.KeepComments(true)
+	stmts, _, err = p.Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 2)
+	create := stmts[0].(*ast.CreateTableStmt)
+	c.Assert(create.LeadingComments(), HasLen, 1)
+	c.Assert(*create.LeadingComments()[0], Equals, ast.Comment{Text: "-- users", Start: ast.Pos{Line: 1, Col: 1}, End: ast.Pos{Line: 1, Col: 9, Offset: 8}})
+	c.Assert(create.Cols[0].TrailingComments()[0].Text, Equals, "-- the id")
+	c.Assert(create.Cols[1].LeadingComments()[0].Text, Equals, "/* the name */")
+	c.Assert(create.Cols[1].TrailingComments()[0].Text, Equals, "/* inline */")
+	c.Assert(create.Constraints[0].LeadingComments()[0].Text, Equals, "# index")
+	c.Assert(create.TrailingComments()[0].Text, Equals, "-- end")
+	sel := stmts[1].(*ast.SelectStmt)
+	c.Assert(sel.TableHints, HasLen, 1)
+	c.Assert(sel.Fields.Fields[0].TrailingComments()[0].Text, Equals, "-- first")
+	c.Assert(sel.OrderBy.Items[0].TrailingComments()[0].Text, Equals, "/* asc */")
+	c.Assert(sel.TrailingComments()[0].Text, Equals, "/* tail */")
+
+	restore := func(n ast.Node, flags RestoreFlags) string {
+		var sb strings.Builder
+		c.Assert(n.Restore(NewRestoreCtx(flags, &sb)), IsNil)
+		return sb.String()
+	}
+	c.Assert(restore(create, DefaultRestoreFlags), Equals,
+		"CREATE TABLE `t` (`id` INT,`name` TEXT NOT NULL,INDEX `idx`(`name`)) ENGINE = InnoDB")
+	c.Assert(restore(create, DefaultRestoreFlags|RestoreComments), Equals,
+		"-- users\nCREATE TABLE `t` (`id` INT -- the id\n,/* the name */ `name` TEXT NOT NULL /* inline */,# index\nINDEX `idx`(`name`)) ENGINE = InnoDB -- end\n")
+	c.Assert(restore(sel, DefaultRestoreFlags|RestoreComments), Equals,
+		"SELECT /*+ USE_INDEX(`t` `idx`)*/ `a` -- first\n,`b` FROM `t` ORDER BY `a` /* asc */,`b` /* tail */")
+
+	// the restored statements keep the comments, though a comment may be attached to
+	// another node when it's parsed again.
+	comments := []string{"-- users", "-- the id", "/* the name */", "/* inline */", "# index", "-- end", "-- first", "/* asc */", "/* tail */"}
+	var restored, restoredAgain string
+	for _, stmt := range stmts {
+		restored += restore(stmt, DefaultRestoreFlags|RestoreComments)
+		again, err := p.ParseOneStmt(restore(stmt, DefaultRestoreFlags|RestoreComments), "", "")
+		c.Assert(err, IsNil)
+		c.Assert(restore(again, DefaultRestoreFlags), Equals, restore(stmt, DefaultRestoreFlags))
+		restoredAgain += restore(again, DefaultRestoreFlags|RestoreComments)
+	}
+	for _, comment := range comments {
+		c.Assert(strings.Count(restored, comment), Equals, 1, Commentf("%s", comment))
+		c.Assert(strings.Count(restoredAgain, comment), Equals, 1, Commentf("%s", comment))
+	}
+
+	update, err := p.ParseOneStmt("UPDATE t SET a = 1, -- one\n b = 2", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(restore(update, DefaultRestoreFlags|RestoreComments), Equals, "UPDATE `t` SET `a`=1 -- one\n, `b`=2")
+
+	// the comments inside an unparsed statement are kept in its text.
+	stmts, _, err = p.PerfectParse("select 1; -- one\nselect ** from t -- bad\n; select 2 /* two */", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 3)
+	c.Assert(stmts[0].TrailingComments(), HasLen, 0)
+	c.Assert(stmts[1].Text(), Equals, "-- one\nselect ** from t -- bad\n;")
+	c.Assert(stmts[1].TrailingComments(), HasLen, 0)
+	c.Assert(stmts[2].TrailingComments()[0].Text, Equals, "/* two */")
+
+	// the comments are attached in a single pass, a large script doesn't take long.
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&sb, "/* %d */ INSERT INTO t VALUES (%d, 'x'); -- %d\n", i, i, i)
+	}
+	stmts, _, err = p.Parse(sb.String(), "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 20000)
+	for i, stmt := range stmts {
+		c.Assert(stmt.LeadingComments()[0].Text, Equals, fmt.Sprintf("/* %d */", i))
+		c.Assert(stmt.TrailingComments()[0].Text, Equals, fmt.Sprintf("-- %d", i))
+	}
+}
+
+func (s *testParserSuite) TestPrettyRestore(c *C) {
+	cases := []struct {
+		sql    string
+		expect string
+	}{
+		{
+			"CREATE TABLE t (id int PRIMARY KEY, description text, KEY idx (name)) ENGINE=InnoDB",
+			"CREATE TABLE `t` (\n  `id`          INT PRIMARY KEY,\n  `description` TEXT,\n  INDEX `idx`(`name`)\n) ENGINE = InnoDB",
+		},
+		{
+			"WITH w AS (SELECT 1 AS a) SELECT a, COUNT(*) FROM t JOIN (SELECT id FROM v) AS s USING (id) WHERE c = 'x  ' GROUP BY a, b HAVING COUNT(*) > 1 ORDER BY b DESC LIMIT 3",
+			"WITH `w` AS (\n  SELECT 1 AS `a`\n)\nSELECT\n  `a`,\n  COUNT(1)\nFROM `t`\nJOIN (\n  SELECT `id`\n  FROM `v`\n) AS `s` USING (`id`)\nWHERE `c`=_UTF8MB4'x  '\nGROUP BY `a`, `b`\nHAVING COUNT(1)>1\nORDER BY `b` DESC\nLIMIT 3",
+		},
+		{
+			"SELECT a FROM t WHERE a IN (1000000, 2000000, 3000000, 4000000, 5000000, 6000000, 7000000, 8000000, 9000000, 10000000, 11000000)",
+			"SELECT `a`\nFROM `t`\nWHERE `a` IN (1000000, 2000000, 3000000, 4000000, 5000000, 6000000, 7000000, 8000000,\n  9000000, 10000000, 11000000)",
+		},
+		{
+			"SELECT 1 UNION ALL SELECT 2 ORDER BY 1",
+			"SELECT 1\nUNION ALL\nSELECT 2\nORDER BY 1",
+		},
+		{
+			"UPDATE t SET a = 1, b = 2 WHERE c = 3 ORDER BY d LIMIT 1",
+			"UPDATE `t`\nSET `a`=1, `b`=2\nWHERE `c`=3\nORDER BY `d`\nLIMIT 1",
+		},
+	}
+	p := parser.New()
+	for _, ca := range cases {
+		stmt, err := p.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil)
+		var sb strings.Builder
+		c.Assert(stmt.Restore(NewPrettyRestoreCtx(DefaultRestoreFlags, &sb, DefaultPrettyConfig)), IsNil)
+		c.Assert(sb.String(), Equals, ca.expect)
+
+		// the pretty-printed SQL is the same statement.
+		pretty, err := p.ParseOneStmt(sb.String(), "", "")
+		c.Assert(err, IsNil)
+		var expected, restored strings.Builder
+		c.Assert(stmt.Restore(NewRestoreCtx(DefaultRestoreFlags, &expected)), IsNil)
+		c.Assert(pretty.Restore(NewRestoreCtx(DefaultRestoreFlags, &restored)), IsNil)
+		c.Assert(restored.String(), Equals, expected.String())
+	}
+}
+
 func (s *testParserSuite) TestSessionManage(c *C) {
 	table := []testCase{
 		// Kill statement.
@@ -4725,6 +5318,18 @@ func (s *testParserSuite) TestSessionManage(c *C) {
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestDelimiter(c *C) {
+	table := []testCase{
+		{"DELIMITER $$", true, "DELIMITER $$"},
+		{"delimiter //", true, "DELIMITER //"},
+		{"DELIMITER ;;\nSELECT 1;;", true, "DELIMITER ;;; SELECT 1"},
+		{"DELIMITER $$\nSELECT a$$\nDELIMITER ;\nSELECT 1;", true, "DELIMITER $$; SELECT `a`; DELIMITER ;; SELECT 1"},
+		{"DELIMITER", false, ""},
+		{"SELECT 1 DELIMITER $$", false, ""},
+	}
+	s.RunTest(c, table)
+}
+
 func (s *testParserSuite) TestParseShowOpenTables(c *C) {
 	table := []testCase{
 		{"SHOW OPEN TABLES", true, "SHOW OPEN TABLES"},
@@ -4914,6 +5519,28 @@ func (s *testParserSuite) TestTableSample(c *C) {
 	}
 }
 
+func (s *testParserSuite) TestJSONTable(c *C) {
+	table := []testCase{
+		{"select * from json_table('[1, 2]', '$[*]' columns (id for ordinality)) as jt", true, "SELECT * FROM JSON_TABLE(_UTF8MB4'[1, 2]', '$[*]' COLUMNS (`id` FOR ORDINALITY)) AS `jt`"},
+		{"select * from json_table(@j, '$[*]' columns (a int path '$.a', b varchar(10) exists path '$.b')) jt", true, "SELECT * FROM JSON_TABLE(@`j`, '$[*]' COLUMNS (`a` INT PATH '$.a', `b` VARCHAR(10) EXISTS PATH '$.b')) AS `jt`"},
+		{"select * from json_table(@j, '$' columns (a json path '$.a' null on empty)) jt", true, "SELECT * FROM JSON_TABLE(@`j`, '$' COLUMNS (`a` JSON PATH '$.a' NULL ON EMPTY)) AS `jt`"},
+		{"select * from json_table(@j, '$' columns (a int path '$.a' error on error)) jt", true, "SELECT * FROM JSON_TABLE(@`j`, '$' COLUMNS (`a` INT PATH '$.a' ERROR ON ERROR)) AS `jt`"},
+		{"select * from json_table(@j, '$' columns (a int path '$.a' default '0' on empty default '-1' on error)) jt", true, "SELECT * FROM JSON_TABLE(@`j`, '$' COLUMNS (`a` INT PATH '$.a' DEFAULT '0' ON EMPTY DEFAULT '-1' ON ERROR)) AS `jt`"},
+		{"select * from json_table(@j, '$[*]' columns (id for ordinality, nested path '$.b[*]' columns (b int path '$', nested '$.c' columns (c int path '$')))) jt", true, "SELECT * FROM JSON_TABLE(@`j`, '$[*]' COLUMNS (`id` FOR ORDINALITY, NESTED PATH '$.b[*]' COLUMNS (`b` INT PATH '$', NESTED PATH '$.c' COLUMNS (`c` INT PATH '$')))) AS `jt`"},
+		{"select t.id, jt.v from t join json_table(t.doc, '$[*]' columns (v int path '$')) as jt on true where jt.v > 1", true, "SELECT `t`.`id`,`jt`.`v` FROM `t` JOIN JSON_TABLE(`t`.`doc`, '$[*]' COLUMNS (`v` INT PATH '$')) AS `jt` ON TRUE WHERE `jt`.`v`>1"},
+		{"select * from t, json_table(t.doc, '$' columns (path int path '$.path', nested int exists path '$.nested')) jt", true, "SELECT * FROM (`t`) JOIN JSON_TABLE(`t`.`doc`, '$' COLUMNS (`path` INT PATH '$.path', `nested` INT EXISTS PATH '$.nested')) AS `jt`"},
+
+		// an alias is required.
+		{"select * from json_table(@j, '$' columns (a int path '$'))", false, ""},
+		{"select * from json_table(@j, '$' columns ()) jt", false, ""},
+		{"select * from json_table(@j, '$' columns (a int)) jt", false, ""},
+		{"select * from json_table(@j, '$' columns (a int path '$' error on error null on empty)) jt", false, ""},
+		{"select * from json_table(@j, '$' columns (a int exists path '$' null on empty)) jt", false, ""},
+		{"select * from json_table(@j, '$' columns (nested path '$' columns (a for ordinality) b for ordinality)) jt", false, ""},
+	}
+	s.RunTest(c, table)
+}
+
 func (s *testParserSuite) TestGeneratedColumn(c *C) {
 	tests := []struct {
 		input string
@@ -5577,6 +6204,38 @@ func (s *testParserSuite) TestSignedInt64OutOfRange(c *C) {
 func CleanNodeText(node ast.Node) {
 	var cleaner nodeTextCleaner
 	node.Accept(&cleaner)
+	cleanNodePos(reflect.ValueOf(node), make(map[uintptr]struct{}))
+}
+
+// cleanNodePos clears the positions of all the nodes reachable from v,
+// including the ones not visited by Accept.
+func cleanNodePos(v reflect.Value, visited map[uintptr]struct{}) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if _, ok := visited[v.Pointer()]; ok {
+			return
+		}
+		visited[v.Pointer()] = struct{}{}
+		cleanNodePos(v.Elem(), visited)
+	case reflect.Interface:
+		cleanNodePos(v.Elem(), visited)
+	case reflect.Struct:
+		if v.CanAddr() && v.Addr().CanInterface() {
+			if n, ok := v.Addr().Interface().(ast.Node); ok {
+				n.SetPos(ast.Pos{}, ast.Pos{})
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			cleanNodePos(v.Field(i), visited)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			cleanNodePos(v.Index(i), visited)
+		}
+	}
 }
 
 // nodeTextCleaner clean the text of a node and it's child node.
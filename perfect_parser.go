@@ -1,97 +1,217 @@
 package parser
 
 import (
-	"bytes"
+	"sort"
+	"strings"
+	"unicode"
+
 	"github.com/pingcap/parser/ast"
 )
 
 // PerfectParse parses a query string to raw ast.StmtNode. support parses query string
 // who contains unparsed SQL, the unparsed SQL will be parses to ast.UnparsedStmt.
+// DELIMITER directives are handled like the mysql client does, statement boundaries
+// follow the active delimiter and each directive is parsed to ast.DelimiterStmt.
+// Every ast.UnparsedStmt carries the error which made it unparsed, its position in
+// the query string and the tokens expected by the parser at the syntax error.
 func (parser *Parser) PerfectParse(sql, charset, collation string) (stmt []ast.StmtNode, warns []error, err error) {
-	_, warns, err = parser.Parse(sql, charset, collation)
-	if err == nil {
-		return parser.result, warns, nil
-	}
-	// if err is not nil, the query string must be contains unparsed sql.
+	var (
+		delimiter string
+		comments  []*ast.Comment
+		from      = Pos{Line: 1}
+		tracker   = posTracker{r: reader{s: sql, p: Pos{Line: 1}}}
+	)
+	for {
+		_, cWarns, cErr := parser.parseFrom(sql, charset, collation, delimiter, from)
+		warns = append(warns, cWarns...)
+		if parser.lexer.keepComments {
+			comments = parser.comments(comments)
+		}
+		if cErr == nil {
+			stmt = append(stmt, parser.result...)
+			break
+		}
+		// if err is not nil, the query string must be contains unparsed sql.
 
-	if len(parser.result) > 0 {
-		for _, stmt := range parser.result {
-			ast.SetFlag(stmt)
+		for _, s := range parser.result {
+			ast.SetFlag(s)
 		}
 		stmt = append(stmt, parser.result...)
+
+		// The origin SQL text(input args `sql`) consists of many SQL segments,
+		// each SQL segments is a complete SQL and be parsed into `ast.StmtNode`.
+		//
+		//     good SQL segment       bad SQL segment
+		// |---------------------|---------------------|---------------------|---------------------|    origin SQL text
+		//			     		 ^
+		//		            stmtStartPos
+		//                       |---------------------|---------------------|---------------------|    remaining SQL text
+		//
+		//                       |<   unparsed stmt   >|<          continue to parse it           >|
+		//
+		// The bad SQL segment is scanned again from its start, so that the BEGIN...END
+		// blocks already consumed by the parser are taken into account.
+		start := parser.lexer.stmtStartPos
+		start += len(sql[start:]) - len(strings.TrimLeftFunc(sql[start:], unicode.IsSpace))
+		expected := parser.expected
+		l := parser.lexer.InheritScanner(sql[start:])
+		length, _ := stmtEnd(l)
+		stop := start + length
+
+		if stop > start {
+			un := &ast.UnparsedStmt{Err: cErr, Expected: expected}
+			un.SetText(sql[start:stop])
+			tracker.moveTo(start)
+			startPos := tracker.r.p
+			tracker.moveTo(stop)
+			un.SetPos(toASTPos(startPos), toASTPos(tracker.r.p))
+			stmt = append(stmt, un)
+		}
+
+		if stop <= start || stop >= len(sql) {
+			break
+		}
+		delimiter = l.delimiter
+		tracker.moveTo(stop)
+		from = tracker.r.p
+	}
+	if parser.lexer.keepComments {
+		ast.AttachComments(stmt, comments)
 	}
+	return stmt, warns, nil
+}
 
-	// The origin SQL text(input args `sql`) consists of many SQL segments,
-	// each SQL segments is a complete SQL and be parsed into `ast.StmtNode`.
-	//
-	//     good SQL segment       bad SQL segment
-	// |---------------------|---------------------|---------------------|---------------------|    origin SQL text
-	//			     		 ^				^
-	//		            stmtStartPos   lastScanOffset
-	//										|------|---------------------|---------------------|    remaining SQL text
-	//
-	//                       |<   unparsed stmt   >|<          continue to parse it           >|
-
-	start := parser.lexer.stmtStartPos
-	cur := parser.lexer.lastScanOffset
-
-	remainingSql := sql[cur:]
-	l := NewScanner(remainingSql)
-	var v yySymType
-	var endOffset int
-	var scanEnd = 0
-	var defaultDelimiter int = ';'
-	delimiter := defaultDelimiter
-ScanLoop:
-	for {
-		result := l.Lex(&v)
-		switch result {
-		case scanEnd:
-			endOffset = l.lastScanOffset - 1
-			break ScanLoop
-		case delimiter:
-			endOffset = l.lastScanOffset
-			break ScanLoop
-		case begin:
-			// ref: https://dev.mysql.com/doc/refman/8.0/en/begin-end.html
-			// ref: https://dev.mysql.com/doc/refman/8.0/en/stored-programs-defining.html
-			// Support match:
-			// BEGIN
-			// ...
-			// END;
-			//
-			delimiter = scanEnd
-		case end:
-			// match `end;`
-			var ny yySymType
-			next := l.Lex(&ny)
-			if next == defaultDelimiter {
-				delimiter = defaultDelimiter
-				endOffset = l.lastScanOffset
-				break ScanLoop
+// posTracker converts increasing offsets of a query string to positions.
+type posTracker struct {
+	r reader
+}
+
+// moveTo advances the tracker to offset, which must not be less than the current offset.
+func (t *posTracker) moveTo(offset int) {
+	for t.r.p.Offset < offset && !t.r.eof() {
+		t.r.peek()
+		t.r.inc()
+	}
+}
+
+// operatorTokenNames are the names of the operator tokens which are not a single character.
+var operatorTokenNames = map[int]string{
+	pipes:        "||",
+	andand:       "&&",
+	andnot:       "&^",
+	assignmentEq: ":=",
+	nulleq:       "<=>",
+	ge:           ">=",
+	le:           "<=",
+	neq:          "!=",
+	neqSynonym:   "<>",
+	lsh:          "<<",
+	rsh:          ">>",
+	eq:           "=",
+	paramMarker:  "?",
+}
+
+// literalTokenNames are the names of the tokens which have no fixed text, and the
+// texts of the tokens which the lexer returns in place of a keyword or an operator.
+var literalTokenNames = map[int]string{
+	identifier:         "identifier",
+	stringLit:          "string literal",
+	intLit:             "integer literal",
+	decLit:             "decimal literal",
+	floatLit:           "floating-point literal",
+	hexLit:             "hexadecimal literal",
+	bitLit:             "bit literal",
+	singleAtIdentifier: "user variable",
+	doubleAtIdentifier: "system variable",
+	underscoreCS:       "character set introducer",
+	delimiterDirective: "DELIMITER",
+	asof:               "AS OF",
+	not2:               "NOT",
+	pipesAsOr:          "||",
+	jss:                "->",
+	juss:               "->>",
+	distinctRow:        "DISTINCTROW",
+	std:                "STD",
+	stddev:             "STDDEV",
+	variance:           "VARIANCE",
+}
+
+// hiddenTokens are the tokens left out of the expected tokens: the optimizer hints
+// are comments, and the ODBC escapes are rarely what is missing.
+var hiddenTokens = map[int]bool{
+	hintComment:       true,
+	'{':               true,
+	'}':               true,
+	odbcDateType:      true,
+	odbcTimeType:      true,
+	odbcTimestampType: true,
+}
+
+var (
+	// keywordTokenNames maps the keyword tokens to their text, the longest text is
+	// used for the tokens which have synonyms.
+	keywordTokenNames = make(map[int]string)
+	// symbolTokens maps the indexes of yySymNames back to the token IDs.
+	symbolTokens []int
+)
+
+func init() {
+	for _, m := range []map[string]int{tokenMap, btFuncTokenMap, windowFuncTokenMap} {
+		for name, tok := range m {
+			if old, ok := keywordTokenNames[tok]; !ok || len(name) > len(old) || (len(name) == len(old) && name < old) {
+				keywordTokenNames[tok] = name
 			}
 		}
 	}
-	unparsedStmtBuf := bytes.Buffer{}
-	unparsedStmtBuf.WriteString(sql[start:cur])
-	unparsedStmtBuf.WriteString(remainingSql[:endOffset+1])
-
-	unparsedSql := unparsedStmtBuf.String()
-	if len(unparsedSql) > 0 {
-		un := &ast.UnparsedStmt{}
-		un.SetText(unparsedSql)
-		stmt = append(stmt, un)
+	symbolTokens = make([]int, len(yySymNames))
+	for tok, x := range yyXLAT {
+		symbolTokens[x] = tok
 	}
+}
 
-	if len(remainingSql) > endOffset {
-		cStmt, cWarn, cErr := parser.PerfectParse(remainingSql[endOffset+1:], charset, collation)
-		warns = append(warns, cWarn...)
-		if len(cStmt) > 0 {
-			stmt = append(stmt, cStmt...)
+// tokenName returns the text of the token tok, or a description of it when the token
+// has no fixed text, such as "identifier" or "string literal".
+func tokenName(tok, x int) string {
+	if tok < 128 {
+		return string(rune(tok))
+	}
+	if name, ok := operatorTokenNames[tok]; ok {
+		return name
+	}
+	if name, ok := literalTokenNames[tok]; ok {
+		return name
+	}
+	if name, ok := keywordTokenNames[tok]; ok {
+		return name
+	}
+	if tok == yyEOFCode {
+		return "EOF"
+	}
+	return yySymNames[x]
+}
+
+// expectedTokens returns the sorted names of the tokens acceptable in the parser state.
+func expectedTokens(state int) []string {
+	var (
+		names []string
+		seen  = make(map[string]struct{})
+	)
+	for x, action := range yyParseTab[state] {
+		if action == 0 {
+			continue
 		}
-		if cErr == nil {
-			return stmt, warns, cErr
+		// the IDs from yyDefault on are the non-terminal symbols.
+		tok := symbolTokens[x]
+		if tok >= yyDefault || tok == yyErrCode || tok == invalid || hiddenTokens[tok] {
+			continue
 		}
+		name := tokenName(tok, x)
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
 	}
-	return stmt, warns, nil
+	sort.Strings(names)
+	return names
 }
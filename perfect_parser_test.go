@@ -3,6 +3,7 @@ package parser_test
 import (
 	"github.com/pingcap/parser"
 	"github.com/pingcap/parser/ast"
+	"strings"
 	"testing"
 )
 
@@ -103,8 +104,7 @@ BEGIN
 END;
 `,
 			expect: []string{
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
 END;`,
 			},
@@ -117,8 +117,7 @@ SELECT COUNT(*)  FROM user;
 END;
 `,
 			expect: []string{
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
 SELECT COUNT(*)  FROM user;
 END;`,
@@ -133,8 +132,7 @@ SELECT COUNT(*)  FROM user;
 END;
 `,
 			expect: []string{
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
 SELECT COUNT(*)  FROM user;
 SELECT COUNT(*)  FROM user;
@@ -150,8 +148,7 @@ END;
 `,
 			expect: []string{
 				`SELECT * FROM db1.t1;`,
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
 END;`,
 			},
@@ -167,8 +164,7 @@ END;
 `,
 			expect: []string{
 				`SELECT * FROM db1.t1;`,
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
  SELECT COUNT(*)  FROM user;
  SELECT COUNT(*)  FROM user;
@@ -185,8 +181,7 @@ END;
 SELECT * FROM db1.t1;
 `,
 			expect: []string{
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
  SELECT COUNT(*)  FROM user;
  SELECT COUNT(*)  FROM user;
@@ -213,14 +208,12 @@ SELECT * FROM db1.t1;
 `,
 			expect: []string{
 				`SELECT * FROM db1.t1;`,
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
  SELECT COUNT(*)  FROM user;
  SELECT COUNT(*)  FROM user;
 END;`,
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
  SELECT COUNT(*)  FROM user;
  SELECT COUNT(*)  FROM user;
@@ -250,15 +243,13 @@ SELECT * FROM db1.t1;
 `,
 			expect: []string{
 				`SELECT * FROM db1.t1;`,
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
  SELECT COUNT(*)  FROM user;
  SELECT COUNT(*)  FROM user;
 END;`,
 				`SELECT * FROM db1.t1;`,
-				`
-CREATE PROCEDURE proc1(OUT s int)
+				`CREATE PROCEDURE proc1(OUT s int)
 BEGIN
  SELECT COUNT(*)  FROM user;
  SELECT COUNT(*)  FROM user;
@@ -286,3 +277,258 @@ END;`,
 		}
 	}
 }
+
+func TestPerfectParseDelimiter(t *testing.T) {
+	parser := parser.New()
+
+	type testCase struct {
+		sql    string
+		expect []string
+	}
+
+	tc := []testCase{
+		{
+			sql: "DELIMITER $$\nSELECT * FROM db1.t1$$\nDELIMITER ;\nSELECT * FROM db2.t2;",
+			expect: []string{
+				"DELIMITER $$",
+				"SELECT * FROM db1.t1$$",
+				"DELIMITER ;",
+				"SELECT * FROM db2.t2;",
+			},
+		},
+		{
+			sql: `
+DELIMITER //
+CREATE PROCEDURE proc1(OUT s int)
+BEGIN
+ SELECT COUNT(*)  FROM user;
+ SELECT COUNT(*)  FROM user;
+END//
+DELIMITER ;
+SELECT * FROM db1.t1;
+`,
+			expect: []string{
+				"DELIMITER //",
+				`CREATE PROCEDURE proc1(OUT s int)
+BEGIN
+ SELECT COUNT(*)  FROM user;
+ SELECT COUNT(*)  FROM user;
+END//`,
+				"DELIMITER ;",
+				"SELECT * FROM db1.t1;",
+			},
+		},
+		{
+			sql: "delimiter $$\nOPTIMIZE TABLE foo; SELECT 1$$\nSELECT * FROM db1.t1$$",
+			expect: []string{
+				"delimiter $$",
+				"OPTIMIZE TABLE foo; SELECT 1$$",
+				"SELECT * FROM db1.t1$$",
+			},
+		},
+	}
+	for _, c := range tc {
+		stmt, _, err := parser.PerfectParse(c.sql, "", "")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(c.expect) != len(stmt) {
+			t.Errorf("expect sql length is %d, actual is %d", len(c.expect), len(stmt))
+			continue
+		}
+		for i, s := range stmt {
+			if s.Text() != c.expect[i] {
+				t.Errorf("expect sql is [%s], actual is [%s]", c.expect[i], s.Text())
+			}
+		}
+	}
+
+	stmt, _, err := parser.PerfectParse("DELIMITER $$\nSELECT 1$$", "", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d, ok := stmt[0].(*ast.DelimiterStmt); !ok || d.Delimiter != "$$" {
+		t.Errorf("expect stmt is delimiter stmt `$$`, actual is %T", stmt[0])
+	}
+	if _, ok := stmt[1].(*ast.SelectStmt); !ok {
+		t.Errorf("expect stmt type is selectStmt, actual is %T", stmt[1])
+	}
+}
+
+func TestPerfectParseProcedure(t *testing.T) {
+	parser := parser.New()
+
+	sql := `
+CREATE PROCEDURE proc1(OUT s int)
+BEGIN
+ SELECT COUNT(*)  FROM user;
+END;
+CREATE PROCEDURE proc2()
+BEGIN
+ OPTIMIZE TABLE foo;
+END;
+SELECT * FROM db1.t1;
+`
+	stmts, _, err := parser.PerfectParse(sql, "", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(stmts) != 3 {
+		t.Errorf("expect sql length is 3, actual is %d", len(stmts))
+		return
+	}
+	proc, ok := stmts[0].(*ast.CreateProcedureStmt)
+	if !ok {
+		t.Errorf("expect stmt type is CreateProcedureStmt, actual is %T", stmts[0])
+		return
+	}
+	if body, ok := proc.Body.(*ast.BlockStmt); !ok || len(body.Body) != 1 {
+		t.Errorf("expect the procedure body is parsed, actual is %#v", proc.Body)
+	}
+	if _, ok := stmts[1].(*ast.UnparsedStmt); !ok {
+		t.Errorf("expect stmt type is UnparsedStmt, actual is %T", stmts[1])
+	}
+	if stmts[1].Text() != "CREATE PROCEDURE proc2()\nBEGIN\n OPTIMIZE TABLE foo;\nEND;" {
+		t.Errorf("unexpected unparsed sql [%s]", stmts[1].Text())
+	}
+	if _, ok := stmts[2].(*ast.SelectStmt); !ok {
+		t.Errorf("expect stmt type is SelectStmt, actual is %T", stmts[2])
+	}
+}
+
+func TestPerfectParseDiagnostics(t *testing.T) {
+	parser := parser.New()
+
+	sql := "SELECT 1;\nSELECT * FROM;\n  SELECT 2;\nCREATE TABLE t (a int,);\nINSERT INTO t VALUES (1)"
+	stmts, _, err := parser.PerfectParse(sql, "", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(stmts) != 5 {
+		t.Errorf("expect sql length is 5, actual is %d", len(stmts))
+		return
+	}
+
+	type testCase struct {
+		idx        int
+		text       string
+		start, end ast.Pos
+		errMsg     string
+		expected   []string
+	}
+	tc := []testCase{
+		{
+			idx:      1,
+			text:     "SELECT * FROM;",
+			start:    ast.Pos{Line: 2, Col: 1, Offset: 10},
+			end:      ast.Pos{Line: 2, Col: 15, Offset: 24},
+			errMsg:   "line 2 column 15 near \";",
+			expected: []string{"(", "identifier", "DUAL"},
+		},
+		{
+			idx:      3,
+			text:     "CREATE TABLE t (a int,);",
+			start:    ast.Pos{Line: 4, Col: 1, Offset: 37},
+			end:      ast.Pos{Line: 4, Col: 25, Offset: 61},
+			errMsg:   "line 4 column 24 near \");",
+			expected: []string{"identifier", "PRIMARY", "CONSTRAINT"},
+		},
+	}
+	for _, c := range tc {
+		un, ok := stmts[c.idx].(*ast.UnparsedStmt)
+		if !ok {
+			t.Errorf("expect stmt type is UnparsedStmt, actual is %T", stmts[c.idx])
+			continue
+		}
+		if un.Text() != c.text {
+			t.Errorf("expect sql is [%s], actual is [%s]", c.text, un.Text())
+		}
+		if un.StartPos() != c.start || un.EndPos() != c.end {
+			t.Errorf("expect position is %+v-%+v, actual is %+v-%+v", c.start, c.end, un.StartPos(), un.EndPos())
+		}
+		if sql[un.StartPos().Offset:un.EndPos().Offset] != c.text {
+			t.Errorf("expect offsets cover [%s], actual is [%s]", c.text, sql[un.StartPos().Offset:un.EndPos().Offset])
+		}
+		if un.Err == nil || !strings.HasPrefix(un.Err.Error(), c.errMsg) {
+			t.Errorf("expect error starts with [%s], actual is [%v]", c.errMsg, un.Err)
+		}
+		for _, tok := range c.expected {
+			found := false
+			for _, e := range un.Expected {
+				found = found || e == tok
+			}
+			if !found {
+				t.Errorf("expect %s in the expected tokens %v", tok, un.Expected)
+			}
+		}
+	}
+
+	// the columns count characters rather than bytes.
+	stmts, _, err = parser.PerfectParse("SELECT '中文';OPTIMIZE TABLE foo;", "", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if un, ok := stmts[1].(*ast.UnparsedStmt); !ok || un.StartPos() != (ast.Pos{Line: 1, Col: 13, Offset: 16}) {
+		t.Errorf("unexpected unparsed stmt %#v", stmts[1])
+	}
+
+	// the expected tokens are written as in SQL, the internal ones are left out.
+	stmts, _, err = parser.PerfectParse("SELEC 1;SELECT * FROM t WHERE", "", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, stmt := range stmts {
+		un, ok := stmt.(*ast.UnparsedStmt)
+		if !ok {
+			t.Errorf("expect stmt type is UnparsedStmt, actual is %T", stmt)
+			continue
+		}
+		for _, e := range un.Expected {
+			switch e {
+			case "delimiterDirective", "hintComment", "stringLit", "singleAtIdentifier", "std", "not2", "{":
+				t.Errorf("unexpected %s in the expected tokens %v", e, un.Expected)
+			}
+		}
+	}
+	for _, tok := range []string{"string literal", "integer literal", "user variable", "NOT", "STD"} {
+		found := false
+		for _, e := range stmts[1].(*ast.UnparsedStmt).Expected {
+			found = found || e == tok
+		}
+		if !found {
+			t.Errorf("expect %s in the expected tokens %v", tok, stmts[1].(*ast.UnparsedStmt).Expected)
+		}
+	}
+}
+
+func TestPerfectParseLargeScript(t *testing.T) {
+	parser := parser.New()
+
+	var sb strings.Builder
+	for i := 0; i < 5000; i++ {
+		sb.WriteString("OPTIMIZE TABLE foo;\nSELECT 1;\n")
+	}
+	stmts, _, err := parser.PerfectParse(sb.String(), "", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(stmts) != 10000 {
+		t.Errorf("expect sql length is 10000, actual is %d", len(stmts))
+		return
+	}
+	un, ok := stmts[9998].(*ast.UnparsedStmt)
+	if !ok {
+		t.Errorf("expect stmt type is UnparsedStmt, actual is %T", stmts[9998])
+		return
+	}
+	if un.StartPos().Line != 9999 || un.EndPos().Line != 9999 || un.StartPos().Col != 1 {
+		t.Errorf("unexpected position %+v-%+v", un.StartPos(), un.EndPos())
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package privilege derives the privileges a statement needs, following the
+// privilege checks of MySQL.
+//
+// The objects a statement accesses are found by the access package, from the
+// statement alone, so a view is taken as a table, and the privileges checked
+// inside a view, a procedure or a trigger when they run aren't derived.
+package privilege
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pingcap/parser/access"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/auth"
+	"github.com/pingcap/parser/mysql"
+)
+
+// Requirement is the privileges needed at a level. The privileges granted at a
+// higher level meet it as well, like the ones on a database meet a requirement on
+// a table of it.
+type Requirement struct {
+	// ObjectType is ast.ObjectTypeTable for a table, ast.ObjectTypeProcedure or
+	// ast.ObjectTypeFunction for a routine, and ast.ObjectTypeNone for a database or
+	// the global level.
+	ObjectType ast.ObjectTypeType
+	Level      ast.GrantLevel
+	// Privs are the privileges all of which are needed, in the order of their values.
+	Privs mysql.Privileges
+	// Dynamic is a dynamic privilege of MySQL 8.0 which meets the requirement instead
+	// of Privs, like SYSTEM_VARIABLES_ADMIN for the SUPER of SET GLOBAL, or empty.
+	Dynamic string
+}
+
+// Requirements returns the privileges a statement needs, user is the current user
+// and currentDB is the database of the unqualified names.
+//
+// The statements which only affect the current user, like SET PASSWORD without a
+// user, need no privileges. KILL needs SUPER or CONNECTION_ADMIN, as the connection
+// can be of another user.
+func Requirements(stmt ast.StmtNode, user *auth.UserIdentity, currentDB string) []Requirement {
+	d := &deriver{user: user, db: currentDB}
+	d.stmt(stmt)
+	for i := range d.reqs {
+		privs := d.reqs[i].Privs
+		sort.Slice(privs, func(i, j int) bool { return privs[i] < privs[j] })
+	}
+	return d.reqs
+}
+
+type deriver struct {
+	user *auth.UserIdentity
+	db   string
+	reqs []Requirement
+}
+
+func globalLevel() ast.GrantLevel {
+	return ast.GrantLevel{Level: ast.GrantLevelGlobal}
+}
+
+func (d *deriver) dbLevel(db string) ast.GrantLevel {
+	if db == "" {
+		db = d.db
+	}
+	return ast.GrantLevel{Level: ast.GrantLevelDB, DBName: db}
+}
+
+func (d *deriver) tableLevel(db, name string) ast.GrantLevel {
+	if db == "" {
+		db = d.db
+	}
+	return ast.GrantLevel{Level: ast.GrantLevelTable, DBName: db, TableName: name}
+}
+
+func (d *deriver) add(tp ast.ObjectTypeType, level ast.GrantLevel, dynamic string, privs ...mysql.PrivilegeType) {
+	var req *Requirement
+	for i := range d.reqs {
+		if d.reqs[i].ObjectType == tp && d.reqs[i].Level == level && d.reqs[i].Dynamic == dynamic {
+			req = &d.reqs[i]
+			break
+		}
+	}
+	if req == nil {
+		d.reqs = append(d.reqs, Requirement{ObjectType: tp, Level: level, Dynamic: dynamic})
+		req = &d.reqs[len(d.reqs)-1]
+	}
+	for _, priv := range privs {
+		if !req.Privs.Has(priv) {
+			req.Privs = append(req.Privs, priv)
+		}
+	}
+}
+
+func (d *deriver) addGlobal(privs ...mysql.PrivilegeType) {
+	d.add(ast.ObjectTypeNone, globalLevel(), "", privs...)
+}
+
+// addSuper adds SUPER, which the dynamic privilege can replace.
+func (d *deriver) addSuper(dynamic string) {
+	d.add(ast.ObjectTypeNone, globalLevel(), dynamic, mysql.SuperPriv)
+}
+
+func (d *deriver) addDB(db string, privs ...mysql.PrivilegeType) {
+	d.add(ast.ObjectTypeNone, d.dbLevel(db), "", privs...)
+}
+
+func (d *deriver) addTable(tn *ast.TableName, privs ...mysql.PrivilegeType) {
+	d.add(ast.ObjectTypeTable, d.tableLevel(tn.Schema.O, tn.Name.O), "", privs...)
+}
+
+func (d *deriver) isCurrentUser(u *auth.UserIdentity) bool {
+	if u == nil || u.CurrentUser {
+		return true
+	}
+	if d.user == nil {
+		return false
+	}
+	return u.Username == d.user.Username && u.Hostname == d.user.Hostname ||
+		u.Username == d.user.AuthUsername && u.Hostname == d.user.AuthHostname
+}
+
+// objects adds the privileges of the accesses to the objects.
+func (d *deriver) objects(info *access.Info) {
+	for mode := access.ModeRead; mode <= access.ModeExecute; mode++ {
+		for _, obj := range info.Objects[mode] {
+			d.object(mode, obj)
+		}
+	}
+}
+
+var tablePrivs = map[access.Mode]mysql.PrivilegeType{
+	access.ModeRead:   mysql.SelectPriv,
+	access.ModeInsert: mysql.InsertPriv,
+	access.ModeUpdate: mysql.UpdatePriv,
+	access.ModeDelete: mysql.DeletePriv,
+	access.ModeCreate: mysql.CreatePriv,
+	access.ModeAlter:  mysql.AlterPriv,
+	access.ModeDrop:   mysql.DropPriv,
+}
+
+func (d *deriver) object(mode access.Mode, obj access.Object) {
+	switch obj.Kind {
+	case access.KindTable, access.KindView, access.KindSequence:
+		level := d.tableLevel(obj.Schema.O, obj.Name.O)
+		switch {
+		case obj.Temporary && mode == access.ModeCreate:
+			d.addDB(obj.Schema.O, mysql.CreateTMPTablePriv)
+		case obj.Temporary && mode == access.ModeDrop:
+			// the temporary tables are of the session.
+		case obj.Kind == access.KindView && mode == access.ModeCreate:
+			d.add(ast.ObjectTypeTable, level, "", mysql.CreateViewPriv)
+		default:
+			if priv, ok := tablePrivs[mode]; ok {
+				d.add(ast.ObjectTypeTable, level, "", priv)
+			}
+		}
+	case access.KindProcedure, access.KindFunction:
+		tp := ast.ObjectTypeProcedure
+		if obj.Kind == access.KindFunction {
+			tp = ast.ObjectTypeFunction
+		}
+		switch mode {
+		case access.ModeCreate:
+			d.addDB(obj.Schema.O, mysql.CreateRoutinePriv)
+		case access.ModeAlter, access.ModeDrop:
+			d.add(tp, d.tableLevel(obj.Schema.O, obj.Name.O), "", mysql.AlterRoutinePriv)
+		case access.ModeExecute:
+			d.add(tp, d.tableLevel(obj.Schema.O, obj.Name.O), "", mysql.ExecutePriv)
+		}
+	case access.KindTrigger:
+		// the table of a trigger isn't known from its name.
+		d.addDB(obj.Schema.O, mysql.TriggerPriv)
+	case access.KindEvent:
+		d.addDB(obj.Schema.O, mysql.EventPriv)
+	}
+}
+
+// reads adds the privileges of the objects accessed by a node of a statement.
+func (d *deriver) reads(n ast.StmtNode) {
+	d.objects(access.Analyze(n, d.db))
+}
+
+// references adds REFERENCES on the tables the foreign keys of the columns and
+// the constraints refer to.
+func (d *deriver) references(cols []*ast.ColumnDef, constraints []*ast.Constraint) {
+	for _, col := range cols {
+		for _, opt := range col.Options {
+			if opt.Refer != nil {
+				d.addTable(opt.Refer.Table, mysql.ReferencesPriv)
+			}
+		}
+	}
+	for _, cons := range constraints {
+		if cons != nil && cons.Refer != nil {
+			d.addTable(cons.Refer.Table, mysql.ReferencesPriv)
+		}
+	}
+}
+
+func (d *deriver) stmt(stmt ast.StmtNode) {
+	switch x := stmt.(type) {
+	case *ast.ExplainStmt:
+		// EXPLAIN needs the privileges of the statement explained.
+		d.stmt(x.Stmt)
+	case *ast.TraceStmt:
+		d.stmt(x.Stmt)
+	case *ast.SelectStmt:
+		d.reads(x)
+		if x.SelectIntoOpt != nil && x.SelectIntoOpt.Tp != ast.SelectIntoVars {
+			d.addGlobal(mysql.FilePriv)
+		}
+	case *ast.LoadDataStmt:
+		d.reads(x)
+		if !x.IsLocal {
+			d.addGlobal(mysql.FilePriv)
+		}
+
+	case *ast.CreateDatabaseStmt:
+		d.addDB(x.Name, mysql.CreatePriv)
+	case *ast.AlterDatabaseStmt:
+		d.addDB(x.Name, mysql.AlterPriv)
+	case *ast.DropDatabaseStmt:
+		d.addDB(x.Name, mysql.DropPriv)
+	case *ast.CreateTableStmt:
+		d.createTable(x)
+	case *ast.AlterTableStmt:
+		d.alterTable(x)
+	case *ast.RenameTableStmt:
+		for _, t2t := range x.TableToTables {
+			d.addTable(t2t.OldTable, mysql.AlterPriv, mysql.DropPriv)
+			d.addTable(t2t.NewTable, mysql.CreatePriv, mysql.InsertPriv)
+		}
+	case *ast.CreateIndexStmt:
+		d.addTable(x.Table, mysql.IndexPriv)
+	case *ast.DropIndexStmt:
+		d.addTable(x.Table, mysql.IndexPriv)
+	case *ast.CreateViewStmt:
+		d.reads(x)
+		if x.OrReplace {
+			d.addTable(x.ViewName, mysql.DropPriv)
+		}
+	case *ast.CreateTriggerStmt:
+		d.addTable(x.Table, mysql.TriggerPriv)
+	case *ast.LockTablesStmt:
+		for _, lock := range x.TableLocks {
+			d.addDB(lock.Table.Schema.O, mysql.LockTablesPriv)
+			d.addTable(lock.Table, mysql.SelectPriv)
+		}
+	case *ast.AnalyzeTableStmt:
+		for _, tn := range x.TableNames {
+			d.addTable(tn, mysql.SelectPriv, mysql.InsertPriv)
+		}
+
+	case *ast.GrantStmt:
+		d.grant(x.Privs, x.ObjectType, x.Level)
+	case *ast.RevokeStmt:
+		d.grant(x.Privs, x.ObjectType, x.Level)
+	case *ast.GrantRoleStmt, *ast.RevokeRoleStmt:
+		d.addSuper("ROLE_ADMIN")
+	case *ast.CreateUserStmt:
+		if x.IsCreateRole {
+			d.addGlobal(mysql.CreateRolePriv)
+		} else {
+			d.addGlobal(mysql.CreateUserPriv)
+		}
+	case *ast.DropUserStmt:
+		if x.IsDropRole {
+			d.addGlobal(mysql.DropRolePriv)
+		} else {
+			d.addGlobal(mysql.CreateUserPriv)
+		}
+	case *ast.RenameUserStmt:
+		d.addGlobal(mysql.CreateUserPriv)
+	case *ast.AlterUserStmt:
+		if x.CurrentAuth != nil {
+			return
+		}
+		for _, spec := range x.Specs {
+			if !d.isCurrentUser(spec.User) {
+				d.addGlobal(mysql.CreateUserPriv)
+				return
+			}
+		}
+	case *ast.SetPwdStmt:
+		if !d.isCurrentUser(x.User) {
+			d.addGlobal(mysql.CreateUserPriv)
+		}
+	case *ast.SetDefaultRoleStmt:
+		for _, u := range x.UserList {
+			if !d.isCurrentUser(u) {
+				d.addGlobal(mysql.CreateUserPriv)
+				return
+			}
+		}
+
+	case *ast.SetStmt:
+		d.reads(x)
+		for _, v := range x.Variables {
+			if v.IsSystem && v.IsGlobal {
+				d.addSuper("SYSTEM_VARIABLES_ADMIN")
+			}
+		}
+	case *ast.SetConfigStmt:
+		d.addGlobal(mysql.ConfigPriv)
+	case *ast.KillStmt:
+		d.addSuper("CONNECTION_ADMIN")
+	case *ast.FlushStmt:
+		d.addGlobal(mysql.ReloadPriv)
+	case *ast.ShutdownStmt:
+		d.addGlobal(mysql.ShutdownPriv)
+	case *ast.BinlogStmt:
+		d.addSuper("BINLOG_ADMIN")
+	case *ast.BRIEStmt:
+		if x.Kind == ast.BRIEKindBackup {
+			d.addSuper("BACKUP_ADMIN")
+		} else {
+			d.addSuper("RESTORE_ADMIN")
+		}
+	case *ast.ShowStmt:
+		d.show(x)
+	default:
+		d.reads(x)
+	}
+}
+
+func (d *deriver) createTable(stmt *ast.CreateTableStmt) {
+	if stmt.TemporaryKeyword != ast.TemporaryNone {
+		d.addDB(stmt.Table.Schema.O, mysql.CreateTMPTablePriv)
+	} else {
+		d.addTable(stmt.Table, mysql.CreatePriv)
+	}
+	if stmt.ReferTable != nil {
+		d.addTable(stmt.ReferTable, mysql.SelectPriv)
+	}
+	if sel, ok := stmt.Select.(ast.StmtNode); ok {
+		d.reads(sel)
+	}
+	d.references(stmt.Cols, stmt.Constraints)
+}
+
+func (d *deriver) alterTable(stmt *ast.AlterTableStmt) {
+	d.addTable(stmt.Table, mysql.AlterPriv, mysql.CreatePriv, mysql.InsertPriv)
+	for _, spec := range stmt.Specs {
+		switch spec.Tp {
+		case ast.AlterTableRenameTable:
+			d.addTable(stmt.Table, mysql.DropPriv)
+			d.addTable(spec.NewTable, mysql.AlterPriv, mysql.CreatePriv, mysql.InsertPriv)
+		case ast.AlterTableExchangePartition:
+			d.addTable(stmt.Table, mysql.DropPriv)
+			d.addTable(spec.NewTable, mysql.AlterPriv, mysql.CreatePriv, mysql.InsertPriv, mysql.DropPriv)
+		}
+		d.references(spec.NewColumns, append(spec.NewConstraints, spec.Constraint))
+	}
+}
+
+// grant adds the privileges of GRANT and REVOKE, which need the privileges granted
+// and GRANT OPTION.
+func (d *deriver) grant(privs []*ast.PrivElem, tp ast.ObjectTypeType, level *ast.GrantLevel) {
+	lvl := *level
+	if lvl.Level != ast.GrantLevelGlobal && lvl.DBName == "" {
+		lvl.DBName = d.db
+	}
+	if lvl.Level != ast.GrantLevelTable {
+		tp = ast.ObjectTypeNone
+	} else if tp == ast.ObjectTypeNone {
+		tp = ast.ObjectTypeTable
+	}
+	d.add(tp, lvl, "", mysql.GrantPriv)
+	for _, elem := range privs {
+		if elem.Priv == mysql.ExtendedPriv {
+			d.addSuper(strings.ToUpper(elem.Name))
+			continue
+		}
+		d.add(tp, lvl, "", elem.Priv)
+	}
+}
+
+func (d *deriver) show(stmt *ast.ShowStmt) {
+	switch stmt.Tp {
+	case ast.ShowProcessList:
+		if stmt.Full {
+			d.addGlobal(mysql.ProcessPriv)
+		}
+	case ast.ShowGrants, ast.ShowCreateUser:
+		if !d.isCurrentUser(stmt.User) {
+			d.addDB(mysql.SystemDB, mysql.SelectPriv)
+		}
+	default:
+		if stmt.Table != nil {
+			d.addTable(stmt.Table, mysql.SelectPriv)
+		}
+	}
+}
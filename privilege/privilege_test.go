@@ -0,0 +1,149 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privilege_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/auth"
+	"github.com/pingcap/parser/mysql"
+	. "github.com/pingcap/parser/privilege"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testPrivilegeSuite{})
+
+type testPrivilegeSuite struct{}
+
+var objectTypes = map[ast.ObjectTypeType]string{
+	ast.ObjectTypeTable:     "table",
+	ast.ObjectTypeFunction:  "function",
+	ast.ObjectTypeProcedure: "procedure",
+}
+
+// requirements formats the requirements like "table test.t: SELECT,INSERT".
+func requirements(reqs []Requirement) []string {
+	list := []string{}
+	for _, req := range reqs {
+		var s string
+		switch req.Level.Level {
+		case ast.GrantLevelGlobal:
+			s = "global"
+		case ast.GrantLevelDB:
+			s = "db " + req.Level.DBName
+		case ast.GrantLevelTable:
+			s = objectTypes[req.ObjectType] + " " + req.Level.DBName + "." + req.Level.TableName
+		}
+		var privs []string
+		for _, priv := range req.Privs {
+			privs = append(privs, strings.ToUpper(priv.String()))
+		}
+		s += ": " + strings.Join(privs, ",")
+		if req.Dynamic != "" {
+			s += "|" + req.Dynamic
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+func (s *testPrivilegeSuite) TestRequirements(c *C) {
+	table := []struct {
+		sql  string
+		reqs []string
+	}{
+		{"select * from t join other.s using (a)", []string{"table test.t: SELECT", "table other.s: SELECT"}},
+		// a CTE of a subquery doesn't hide the table of the outer query.
+		{"select * from secret where a in (with secret as (select 1) select * from secret)", []string{"table test.secret: SELECT"}},
+		{"with c as (select * from t) select * from c", []string{"table test.t: SELECT"}},
+		{"select a from t into outfile '/tmp/t'", []string{"table test.t: SELECT", "global: FILE"}},
+		{"insert into t select * from s", []string{"table test.s: SELECT", "table test.t: INSERT"}},
+		{"replace into t values (1)", []string{"table test.t: INSERT,DELETE"}},
+		{"update t set a = a + 1", []string{"table test.t: SELECT,UPDATE"}},
+		{"delete from t", []string{"table test.t: DELETE"}},
+		{"load data infile '/tmp/t' into table t", []string{"table test.t: INSERT", "global: FILE"}},
+		{"load data local infile '/tmp/t' into table t", []string{"table test.t: INSERT"}},
+		{"call other.p()", []string{"procedure other.p: EXECUTE"}},
+		{"explain update t set a = 1", []string{"table test.t: UPDATE"}},
+
+		{"create database d", []string{"db d: CREATE"}},
+		{"drop database d", []string{"db d: DROP"}},
+		{"create table t (a int references s (a))", []string{"table test.t: CREATE", "table test.s: REFERENCES"}},
+		{"create temporary table t like other.s", []string{"db test: CREATE TEMPORARY TABLES", "table other.s: SELECT"}},
+		{"create table t select * from s", []string{"table test.t: CREATE", "table test.s: SELECT"}},
+		{"drop temporary table t", []string{}},
+		{"drop table t, other.s", []string{"table test.t: DROP", "table other.s: DROP"}},
+		{"alter table t add column b int", []string{"table test.t: CREATE,INSERT,ALTER"}},
+		{"alter table t rename to other.t", []string{"table test.t: CREATE,INSERT,DROP,ALTER", "table other.t: CREATE,INSERT,ALTER"}},
+		{"rename table t to s", []string{"table test.t: DROP,ALTER", "table test.s: CREATE,INSERT"}},
+		{"truncate table t", []string{"table test.t: DROP"}},
+		{"create index i on t (a)", []string{"table test.t: INDEX"}},
+		{"create or replace view v as select * from t", []string{"table test.t: SELECT", "table test.v: DROP,CREATE VIEW"}},
+		{"create trigger tr before insert on t for each row set @x = 1", []string{"table test.t: TRIGGER"}},
+		{"create procedure p() select 1", []string{"db test: CREATE ROUTINE"}},
+		{"drop function other.f", []string{"function other.f: ALTER ROUTINE"}},
+		{"lock tables t read, other.s write", []string{"db test: LOCK TABLES", "table test.t: SELECT", "db other: LOCK TABLES", "table other.s: SELECT"}},
+		{"analyze table t", []string{"table test.t: SELECT,INSERT"}},
+
+		{"grant select, insert on test.* to 'u'@'%'", []string{"db test: SELECT,INSERT,GRANT OPTION"}},
+		{"grant update on t to 'u'@'%' with grant option", []string{"table test.t: UPDATE,GRANT OPTION"}},
+		{"revoke all on *.* from 'u'@'%'", []string{"global: GRANT OPTION,ALL PRIVILEGES"}},
+		{"grant execute on procedure other.p to 'u'@'%'", []string{"procedure other.p: GRANT OPTION,EXECUTE"}},
+		{"grant system_variables_admin on *.* to 'u'@'%'", []string{"global: GRANT OPTION", "global: SUPER|SYSTEM_VARIABLES_ADMIN"}},
+		{"grant r1 to 'u'@'%'", []string{"global: SUPER|ROLE_ADMIN"}},
+		{"create user 'v'@'%'", []string{"global: CREATE USER"}},
+		{"create role r1", []string{"global: CREATE ROLE"}},
+		{"drop user 'v'@'%'", []string{"global: CREATE USER"}},
+		{"alter user 'u'@'%' identified by 'p'", []string{}},
+		{"alter user 'v'@'%' identified by 'p'", []string{"global: CREATE USER"}},
+		{"set password = 'p'", []string{}},
+		{"set password for 'v'@'%' = 'p'", []string{"global: CREATE USER"}},
+		{"show grants", []string{}},
+		{"show grants for 'v'@'%'", []string{"db mysql: SELECT"}},
+
+		{"set global max_connections = 10", []string{"global: SUPER|SYSTEM_VARIABLES_ADMIN"}},
+		{"set session sql_mode = '', @a = (select max(a) from t)", []string{"table test.t: SELECT"}},
+		{"kill 1", []string{"global: SUPER|CONNECTION_ADMIN"}},
+		{"flush privileges", []string{"global: RELOAD"}},
+		{"shutdown", []string{"global: SHUTDOWN"}},
+		{"show full processlist", []string{"global: PROCESS"}},
+		{"show create table other.t", []string{"table other.t: SELECT"}},
+		{"begin", []string{}},
+	}
+	user := &auth.UserIdentity{Username: "u", Hostname: "localhost", AuthUsername: "u", AuthHostname: "%"}
+	for _, tt := range table {
+		comment := Commentf("sql: %s", tt.sql)
+		stmt, err := parser.New().ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		c.Assert(requirements(Requirements(stmt, user, "test")), DeepEquals, tt.reqs, comment)
+	}
+}
+
+func (s *testPrivilegeSuite) TestPrivsOrder(c *C) {
+	stmt, err := parser.New().ParseOneStmt("insert into t values (1) on duplicate key update a = 2", "", "")
+	c.Assert(err, IsNil)
+	reqs := Requirements(stmt, nil, "test")
+	c.Assert(reqs, HasLen, 1)
+	c.Assert(reqs[0].ObjectType, Equals, ast.ObjectTypeTable)
+	c.Assert(reqs[0].Level, Equals, ast.GrantLevel{Level: ast.GrantLevelTable, DBName: "test", TableName: "t"})
+	c.Assert(reqs[0].Privs, DeepEquals, mysql.Privileges{mysql.InsertPriv, mysql.UpdatePriv})
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver
+
+import (
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
+)
+
+var (
+	// ErrTableNotExists returns for a table which isn't in the schema.
+	ErrTableNotExists = terror.ClassSchema.NewStd(mysql.ErrNoSuchTable)
+	// ErrBadTable returns for a wildcard of a table which isn't in FROM.
+	ErrBadTable = terror.ClassOptimizer.NewStd(mysql.ErrBadTable)
+	// ErrUnknownTable returns for a table of multi-table DELETE which isn't in FROM.
+	ErrUnknownTable = terror.ClassOptimizer.NewStd(mysql.ErrUnknownTable)
+	// ErrNonUniqTable returns for two tables of the same name in FROM.
+	ErrNonUniqTable = terror.ClassOptimizer.NewStd(mysql.ErrNonuniqTable)
+	// ErrNoTablesUsed returns for a wildcard without FROM.
+	ErrNoTablesUsed = terror.ClassOptimizer.NewStd(mysql.ErrNoTablesUsed)
+	// ErrDupFieldName returns for a derived table which has two columns of the same name.
+	ErrDupFieldName = terror.ClassOptimizer.NewStd(mysql.ErrDupFieldName)
+	// ErrUnknownColumn returns for a column which can't be found.
+	ErrUnknownColumn = terror.ClassOptimizer.NewStd(mysql.ErrBadField)
+	// ErrAmbiguous returns for a column which is found in more than one table.
+	ErrAmbiguous = terror.ClassOptimizer.NewStd(mysql.ErrNonUniq)
+	// ErrWrongValueCount returns for the rows of INSERT which don't match its columns.
+	ErrWrongValueCount = terror.ClassOptimizer.NewStd(mysql.ErrWrongValueCountOnRow)
+	// ErrViewWrongList returns for a column list which doesn't match its query.
+	ErrViewWrongList = terror.ClassOptimizer.NewStd(mysql.ErrViewWrongList)
+)
@@ -0,0 +1,838 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resolver binds the names in the DML statements to the tables of a
+// schema, following the scoping rules of MySQL.
+//
+// Every ColumnNameExpr gets its Refer set to the ast.ResultField it refers to, and
+// every ast.TableName of a table or a view gets its TableInfo. A ResultField of a
+// column of a table has its Table and Column set. A ResultField of a derived table,
+// a common table expression or an alias in the select list has no Table, and its
+// Expr is the expression it comes from, so the references can be followed down to
+// the tables. A ResultField of a column of JSON_TABLE has neither.
+package resolver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
+)
+
+// Schema gives the tables which the names are resolved against.
+// *catalog.Catalog is a Schema.
+type Schema interface {
+	// CurrentDB returns the database of the unqualified table names.
+	CurrentDB() string
+	// Table returns the table or the view of the name, or nil if there isn't one.
+	// An empty schema means the current database.
+	Table(schema, name string) *model.TableInfo
+}
+
+type tableSchema struct {
+	db     string
+	tables []*model.TableInfo
+}
+
+// NewSchema returns a Schema of the tables of a single database.
+func NewSchema(db string, tables []*model.TableInfo) Schema {
+	return &tableSchema{db: db, tables: tables}
+}
+
+func (s *tableSchema) CurrentDB() string {
+	return s.db
+}
+
+func (s *tableSchema) Table(schema, name string) *model.TableInfo {
+	if schema != "" && !strings.EqualFold(schema, s.db) {
+		return nil
+	}
+	for _, tbl := range s.tables {
+		if strings.EqualFold(tbl.Name.L, name) {
+			return tbl
+		}
+	}
+	return nil
+}
+
+// Result is the result of Resolve.
+type Result struct {
+	// Fields are the columns of the result set of the statement, they are nil if
+	// the statement doesn't return one.
+	Fields []*ast.ResultField
+	// Names maps every column name to the field it refers to, including the names
+	// which aren't expressions, like the columns of INSERT and the assignments.
+	Names map[*ast.ColumnName]*ast.ResultField
+	// Wildcards maps every wildcard to the fields it stands for.
+	Wildcards map[*ast.WildCardField][]*ast.ResultField
+	// Queries maps every SELECT, including the ones of the set operations and the
+	// subqueries, to the columns of its result.
+	Queries map[*ast.SelectStmt][]*ast.ResultField
+	// Derived maps every field of a derived table or a common table expression to
+	// the query it comes from, a SELECT, a set operation or a SetOprSelectList. The
+	// field is the column of the query at its Column.Offset.
+	Derived map[*ast.ResultField]ast.Node
+}
+
+// Resolve binds the names in a SELECT, a set operation, an INSERT, an UPDATE or a
+// DELETE, and the ones in the queries of EXPLAIN, CREATE VIEW and CREATE TABLE ...
+// SELECT. Nothing is done for the other statements.
+func Resolve(stmt ast.StmtNode, schema Schema) (*Result, error) {
+	r := &resolver{
+		schema: schema,
+		result: &Result{
+			Names:     make(map[*ast.ColumnName]*ast.ResultField),
+			Wildcards: make(map[*ast.WildCardField][]*ast.ResultField),
+			Queries:   make(map[*ast.SelectStmt][]*ast.ResultField),
+			Derived:   make(map[*ast.ResultField]ast.Node),
+		},
+	}
+	if err := r.stmt(stmt); err != nil {
+		return nil, err
+	}
+	return r.result, nil
+}
+
+type resolver struct {
+	schema Schema
+	result *Result
+}
+
+// source is a table in FROM.
+type source struct {
+	// name is the alias or the name of the table.
+	name model.CIStr
+	// schema is the database of a table, it's empty for a derived table.
+	schema model.CIStr
+	table  *model.TableInfo
+	fields []*ast.ResultField
+	// coalesced are the columns joined with the ones of the sources before by USING
+	// or NATURAL JOIN, which an unqualified name doesn't refer to.
+	coalesced map[string]bool
+}
+
+// cte is a common table expression.
+type cte struct {
+	query  ast.Node
+	fields []*ast.ResultField
+}
+
+// scope is a query block, whose names are looked up in itself and then in the outer ones.
+type scope struct {
+	parent  *scope
+	sources []*source
+	ctes    map[string]*cte
+	// aliases are the aliased fields of the select list, which GROUP BY, HAVING
+	// and ORDER BY can refer to.
+	aliases []*ast.ResultField
+}
+
+func (sc *scope) cte(name string) *cte {
+	for s := sc; s != nil; s = s.parent {
+		if c, ok := s.ctes[name]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+func (sc *scope) alias(cn *ast.ColumnName) *ast.ResultField {
+	if cn.Table.L != "" {
+		return nil
+	}
+	for _, f := range sc.aliases {
+		if f.ColumnAsName.L == cn.Name.L {
+			return f
+		}
+	}
+	return nil
+}
+
+func (sc *scope) lookup(cn *ast.ColumnName, clause string) (*ast.ResultField, error) {
+	var found *ast.ResultField
+	for _, src := range sc.sources {
+		if cn.Table.L != "" {
+			if src.name.L != cn.Table.L || cn.Schema.L != "" && src.schema.L != cn.Schema.L {
+				continue
+			}
+		} else if src.coalesced[cn.Name.L] {
+			continue
+		}
+		for _, f := range src.fields {
+			if f.Column.Name.L != cn.Name.L {
+				continue
+			}
+			if found != nil {
+				return nil, ErrAmbiguous.GenWithStackByArgs(cn.OrigColName(), clause)
+			}
+			found = f
+		}
+	}
+	return found, nil
+}
+
+// aliasMode tells how the aliases in the select list are looked up.
+type aliasMode int
+
+const (
+	aliasNone aliasMode = iota
+	// aliasFirst looks up the aliases before the columns, like ORDER BY and HAVING.
+	aliasFirst
+	// aliasLast looks up the aliases after the columns of the query block, like GROUP BY.
+	aliasLast
+)
+
+// column binds a column name. The clause names the part of the statement in the errors.
+func (r *resolver) column(cn *ast.ColumnName, sc *scope, clause string, mode aliasMode) (*ast.ResultField, error) {
+	var f *ast.ResultField
+	if mode == aliasFirst {
+		f = sc.alias(cn)
+	}
+	for s := sc; s != nil && f == nil; s = s.parent {
+		var err error
+		if f, err = s.lookup(cn, clause); err != nil {
+			return nil, err
+		}
+		if f == nil && s == sc && mode == aliasLast {
+			f = sc.alias(cn)
+		}
+	}
+	if f == nil {
+		return nil, ErrUnknownColumn.GenWithStackByArgs(cn.OrigColName(), clause)
+	}
+	r.result.Names[cn] = f
+	return f, nil
+}
+
+type exprVisitor struct {
+	r      *resolver
+	sc     *scope
+	clause string
+	mode   aliasMode
+	err    error
+}
+
+func (v *exprVisitor) Enter(n ast.Node) (ast.Node, bool) {
+	if v.err != nil {
+		return n, true
+	}
+	switch x := n.(type) {
+	case *ast.SubqueryExpr:
+		_, v.err = v.r.resultSet(x.Query, v.sc)
+		return n, true
+	case *ast.ColumnNameExpr:
+		x.Refer, v.err = v.r.column(x.Name, v.sc, v.clause, v.mode)
+		return n, true
+	case *ast.DefaultExpr:
+		if x.Name != nil {
+			_, v.err = v.r.column(x.Name, v.sc, v.clause, aliasNone)
+		}
+	case *ast.MatchAgainst:
+		for _, cn := range x.ColumnNames {
+			if _, v.err = v.r.column(cn, v.sc, v.clause, aliasNone); v.err != nil {
+				break
+			}
+		}
+	}
+	return n, false
+}
+
+func (v *exprVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	return n, v.err == nil
+}
+
+// expr binds the names in an expression, or in a node holding expressions.
+func (r *resolver) expr(n ast.Node, sc *scope, clause string, mode aliasMode) error {
+	v := &exprVisitor{r: r, sc: sc, clause: clause, mode: mode}
+	n.Accept(v)
+	return v.err
+}
+
+func (r *resolver) byItems(items []*ast.ByItem, sc *scope, clause string, mode aliasMode) error {
+	for _, item := range items {
+		if err := r.expr(item.Expr, sc, clause, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *resolver) stmt(stmt ast.StmtNode) (err error) {
+	switch x := stmt.(type) {
+	case *ast.SelectStmt:
+		r.result.Fields, err = r.resultSet(x, nil)
+	case *ast.SetOprStmt:
+		r.result.Fields, err = r.resultSet(x, nil)
+	case *ast.InsertStmt:
+		return r.insert(x)
+	case *ast.UpdateStmt:
+		return r.update(x)
+	case *ast.DeleteStmt:
+		return r.delete(x)
+	case *ast.ExplainStmt:
+		return r.stmt(x.Stmt)
+	case *ast.CreateViewStmt:
+		var fields []*ast.ResultField
+		if fields, err = r.resultSet(x.Select, nil); err == nil {
+			r.result.Fields, err = renameFields(fields, x.Cols)
+		}
+	case *ast.CreateTableStmt:
+		if x.Select != nil {
+			r.result.Fields, err = r.resultSet(x.Select, nil)
+		}
+	}
+	return err
+}
+
+// resultSet binds the names in a query, whose outer query block is outer, and
+// returns the columns of its result.
+func (r *resolver) resultSet(n ast.Node, outer *scope) ([]*ast.ResultField, error) {
+	switch x := n.(type) {
+	case *ast.SelectStmt:
+		return r.selectStmt(x, outer)
+	case *ast.SetOprStmt:
+		return r.setOpr(x.With, x.SelectList, x.OrderBy, outer)
+	case *ast.SetOprSelectList:
+		return r.setOpr(nil, x, nil, outer)
+	}
+	return nil, nil
+}
+
+func (r *resolver) with(with *ast.WithClause, sc *scope) error {
+	if with == nil {
+		return nil
+	}
+	if sc.ctes == nil {
+		sc.ctes = make(map[string]*cte, len(with.CTEs))
+	}
+	for _, expr := range with.CTEs {
+		if with.IsRecursive {
+			// the query can refer to the CTE itself, whose columns are given by its first query block.
+			if err := r.cte(expr, firstQuery(expr.Query.Query), sc); err != nil {
+				return err
+			}
+		}
+		if err := r.cte(expr, expr.Query.Query, sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *resolver) cte(expr *ast.CommonTableExpression, query ast.Node, sc *scope) error {
+	fields, err := r.resultSet(query, sc)
+	if err != nil {
+		return err
+	}
+	if fields, err = renameFields(fields, expr.ColNameList); err != nil {
+		return err
+	}
+	sc.ctes[expr.Name.L] = &cte{query: query, fields: fields}
+	return nil
+}
+
+func firstQuery(n ast.Node) ast.Node {
+	switch x := n.(type) {
+	case *ast.SetOprStmt:
+		return firstQuery(x.SelectList)
+	case *ast.SetOprSelectList:
+		return firstQuery(x.Selects[0])
+	}
+	return n
+}
+
+// renameFields names the fields by a column list.
+func renameFields(fields []*ast.ResultField, names []model.CIStr) ([]*ast.ResultField, error) {
+	if len(names) == 0 {
+		return fields, nil
+	}
+	if len(names) != len(fields) {
+		return nil, ErrViewWrongList.GenWithStackByArgs()
+	}
+	renamed := make([]*ast.ResultField, len(fields))
+	for i, f := range fields {
+		col := *f.Column
+		col.Name = names[i]
+		renamed[i] = &ast.ResultField{Column: &col, ColumnAsName: names[i], Expr: f.Expr}
+	}
+	return renamed, nil
+}
+
+func (r *resolver) setOpr(with *ast.WithClause, list *ast.SetOprSelectList, orderBy *ast.OrderByClause, outer *scope) ([]*ast.ResultField, error) {
+	sc := &scope{parent: outer}
+	if err := r.with(with, sc); err != nil {
+		return nil, err
+	}
+	if err := r.with(list.With, sc); err != nil {
+		return nil, err
+	}
+	var fields []*ast.ResultField
+	for i, sel := range list.Selects {
+		selFields, err := r.resultSet(sel, sc)
+		if err != nil {
+			return nil, err
+		}
+		// the result is named after the first query block.
+		if i == 0 {
+			fields = selFields
+		}
+	}
+	if orderBy != nil {
+		sc = &scope{parent: outer, sources: []*source{{fields: fields}}}
+		if err := r.byItems(orderBy.Items, sc, "order clause", aliasNone); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+func (r *resolver) selectStmt(sel *ast.SelectStmt, outer *scope) ([]*ast.ResultField, error) {
+	sc := &scope{parent: outer}
+	if err := r.with(sel.With, sc); err != nil {
+		return nil, err
+	}
+	if sel.From != nil {
+		if err := r.tableRefs(sel.From.TableRefs, sc); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Where != nil {
+		if err := r.expr(sel.Where, sc, "where clause", aliasNone); err != nil {
+			return nil, err
+		}
+	}
+
+	var fields []*ast.ResultField
+	if sel.Kind == ast.SelectStmtKindValues {
+		for _, row := range sel.Lists {
+			if err := r.expr(row, sc, "field list", aliasNone); err != nil {
+				return nil, err
+			}
+		}
+		// the columns are named like MySQL.
+		for i, expr := range sel.Lists[0].Values {
+			fields = append(fields, newField(fmt.Sprintf("column_%d", i), i, expr))
+		}
+	} else {
+		for _, field := range sel.Fields.Fields {
+			if field.WildCard != nil {
+				expanded, err := r.wildcard(field.WildCard, sc)
+				if err != nil {
+					return nil, err
+				}
+				r.result.Wildcards[field.WildCard] = expanded
+				for _, f := range expanded {
+					expr := &ast.ColumnNameExpr{
+						Name:  &ast.ColumnName{Schema: f.DBName, Table: f.TableAsName, Name: f.Column.Name},
+						Refer: f,
+					}
+					expr.SetType(&f.Column.FieldType)
+					fields = append(fields, newField(f.Column.Name.O, len(fields), expr))
+				}
+				continue
+			}
+			if err := r.expr(field.Expr, sc, "field list", aliasNone); err != nil {
+				return nil, err
+			}
+			f := newField(fieldName(field), len(fields), field.Expr)
+			fields = append(fields, f)
+			if field.AsName.L != "" {
+				sc.aliases = append(sc.aliases, f)
+			}
+		}
+	}
+
+	r.result.Queries[sel] = fields
+
+	if sel.GroupBy != nil {
+		if err := r.byItems(sel.GroupBy.Items, sc, "group statement", aliasLast); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Having != nil {
+		if err := r.expr(sel.Having.Expr, sc, "having clause", aliasFirst); err != nil {
+			return nil, err
+		}
+	}
+	for i := range sel.WindowSpecs {
+		if err := r.expr(&sel.WindowSpecs[i], sc, "window clause", aliasNone); err != nil {
+			return nil, err
+		}
+	}
+	if sel.OrderBy != nil {
+		if err := r.byItems(sel.OrderBy.Items, sc, "order clause", aliasFirst); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+func newField(name string, offset int, expr ast.ExprNode) *ast.ResultField {
+	col := &model.ColumnInfo{
+		Name:      model.NewCIStr(name),
+		Offset:    offset,
+		FieldType: *expr.GetType(),
+		State:     model.StatePublic,
+	}
+	return &ast.ResultField{Column: col, ColumnAsName: col.Name, Expr: expr}
+}
+
+// fieldName names a field like MySQL: by its alias, its column name or its text.
+func fieldName(field *ast.SelectField) string {
+	if field.AsName.L != "" {
+		return field.AsName.O
+	}
+	if cn, ok := field.Expr.(*ast.ColumnNameExpr); ok {
+		return cn.Name.Name.O
+	}
+	if text := field.Text(); text != "" {
+		return text
+	}
+	var sb strings.Builder
+	_ = field.Expr.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb))
+	return sb.String()
+}
+
+func (r *resolver) wildcard(wf *ast.WildCardField, sc *scope) ([]*ast.ResultField, error) {
+	if len(sc.sources) == 0 {
+		return nil, ErrNoTablesUsed.GenWithStackByArgs()
+	}
+	var (
+		fields  []*ast.ResultField
+		matched bool
+	)
+	for _, src := range sc.sources {
+		if wf.Table.L != "" && (src.name.L != wf.Table.L || wf.Schema.L != "" && src.schema.L != wf.Schema.L) {
+			continue
+		}
+		matched = true
+		for _, f := range src.fields {
+			// the columns joined by USING appear once.
+			if wf.Table.L == "" && src.coalesced[f.Column.Name.L] {
+				continue
+			}
+			fields = append(fields, f)
+		}
+	}
+	if !matched {
+		return nil, ErrBadTable.GenWithStackByArgs(wf.Table.O)
+	}
+	return fields, nil
+}
+
+// tableRefs adds the tables of a FROM clause to the scope.
+func (r *resolver) tableRefs(n ast.ResultSetNode, sc *scope) error {
+	switch x := n.(type) {
+	case *ast.Join:
+		start := len(sc.sources)
+		if err := r.tableRefs(x.Left, sc); err != nil {
+			return err
+		}
+		if x.Right == nil {
+			return nil
+		}
+		mid := len(sc.sources)
+		if err := r.tableRefs(x.Right, sc); err != nil {
+			return err
+		}
+		if err := r.join(x, sc.sources[start:mid], sc.sources[mid:]); err != nil {
+			return err
+		}
+		if x.On != nil {
+			return r.expr(x.On.Expr, sc, "on clause", aliasNone)
+		}
+	case *ast.TableSource:
+		if join, ok := x.Source.(*ast.Join); ok {
+			return r.tableRefs(join, sc)
+		}
+		src, err := r.tableSource(x, sc)
+		if err != nil {
+			return err
+		}
+		for _, s := range sc.sources {
+			if s.name.L == src.name.L && s.schema.L == src.schema.L {
+				return ErrNonUniqTable.GenWithStackByArgs(src.name.O)
+			}
+		}
+		sc.sources = append(sc.sources, src)
+	}
+	return nil
+}
+
+// join coalesces the columns of USING or NATURAL JOIN.
+func (r *resolver) join(join *ast.Join, left, right []*source) error {
+	using := join.Using
+	if join.NaturalJoin {
+		for _, lsrc := range left {
+			for _, f := range lsrc.fields {
+				cn := &ast.ColumnName{Name: f.Column.Name}
+				if findField(right, cn.Name) != nil && !containsName(using, cn.Name) {
+					using = append(using, cn)
+				}
+			}
+		}
+	}
+	for _, cn := range using {
+		f := findField(left, cn.Name)
+		if f == nil || findField(right, cn.Name) == nil {
+			return ErrUnknownColumn.GenWithStackByArgs(cn.Name.O, "from clause")
+		}
+		if join.Using != nil {
+			r.result.Names[cn] = f
+		}
+		for _, src := range right {
+			if findField([]*source{src}, cn.Name) != nil {
+				if src.coalesced == nil {
+					src.coalesced = make(map[string]bool)
+				}
+				src.coalesced[cn.Name.L] = true
+			}
+		}
+	}
+	return nil
+}
+
+// findField finds a column in the sources.
+func findField(sources []*source, name model.CIStr) *ast.ResultField {
+	for _, src := range sources {
+		for _, f := range src.fields {
+			if f.Column.Name.L == name.L {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+func containsName(names []*ast.ColumnName, name model.CIStr) bool {
+	for _, cn := range names {
+		if cn.Name.L == name.L {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *resolver) tableSource(ts *ast.TableSource, sc *scope) (*source, error) {
+	switch x := ts.Source.(type) {
+	case *ast.TableName:
+		name := ts.AsName
+		if name.L == "" {
+			name = x.Name
+		}
+		if x.Schema.L == "" {
+			if c := sc.cte(x.Name.L); c != nil {
+				return r.derivedSource(name, c.query, c.fields)
+			}
+		}
+		tbl := r.schema.Table(x.Schema.O, x.Name.O)
+		schema := x.Schema
+		if schema.L == "" {
+			schema = model.NewCIStr(r.schema.CurrentDB())
+		}
+		if tbl == nil {
+			return nil, ErrTableNotExists.GenWithStackByArgs(schema.O, x.Name.O)
+		}
+		x.TableInfo = tbl
+		src := &source{name: name, schema: schema, table: tbl}
+		for _, col := range tbl.Columns {
+			if col.Hidden {
+				continue
+			}
+			src.fields = append(src.fields, &ast.ResultField{
+				Column:       col,
+				ColumnAsName: col.Name,
+				Table:        tbl,
+				TableAsName:  name,
+				DBName:       schema,
+				TableName:    x,
+			})
+		}
+		return src, nil
+	case *ast.JSONTable:
+		// unlike a derived table, JSON_TABLE can refer to the tables before it.
+		if err := r.expr(x.Expr, sc, "from clause", aliasNone); err != nil {
+			return nil, err
+		}
+		src := &source{name: ts.AsName}
+		if err := jsonTableFields(src, x.Columns); err != nil {
+			return nil, err
+		}
+		return src, nil
+	default:
+		// a derived table can't refer to the tables before it in FROM.
+		fields, err := r.resultSet(x, &scope{parent: sc.parent, ctes: sc.ctes})
+		if err != nil {
+			return nil, err
+		}
+		return r.derivedSource(ts.AsName, x, fields)
+	}
+}
+
+// jsonTableFields appends the columns of JSON_TABLE to the fields of src, the
+// ones of NESTED PATH are flattened in order.
+func jsonTableFields(src *source, cols []*ast.JSONTableColumn) error {
+	for _, col := range cols {
+		if col.Tp == ast.JSONTableColumnNested {
+			if err := jsonTableFields(src, col.Columns); err != nil {
+				return err
+			}
+			continue
+		}
+		if findField([]*source{src}, col.Name) != nil {
+			return ErrDupFieldName.GenWithStackByArgs(col.Name.O)
+		}
+		ft := col.Type
+		if col.Tp == ast.JSONTableColumnOrdinality {
+			ft = types.NewFieldType(mysql.TypeLonglong)
+			ft.Flag |= mysql.UnsignedFlag
+		}
+		c := &model.ColumnInfo{
+			Name:      col.Name,
+			Offset:    len(src.fields),
+			FieldType: *ft,
+			State:     model.StatePublic,
+		}
+		src.fields = append(src.fields, &ast.ResultField{Column: c, ColumnAsName: c.Name, TableAsName: src.name})
+	}
+	return nil
+}
+
+func (r *resolver) derivedSource(name model.CIStr, query ast.Node, fields []*ast.ResultField) (*source, error) {
+	src := &source{name: name}
+	for _, f := range fields {
+		if findField([]*source{src}, f.Column.Name) != nil {
+			return nil, ErrDupFieldName.GenWithStackByArgs(f.Column.Name.O)
+		}
+		df := &ast.ResultField{
+			Column:       f.Column,
+			ColumnAsName: f.ColumnAsName,
+			TableAsName:  name,
+			Expr:         f.Expr,
+		}
+		r.result.Derived[df] = query
+		src.fields = append(src.fields, df)
+	}
+	return src, nil
+}
+
+func (r *resolver) insert(stmt *ast.InsertStmt) error {
+	sc := &scope{}
+	if err := r.tableRefs(stmt.Table.TableRefs, sc); err != nil {
+		return err
+	}
+	cols := sc.sources[0].fields
+	if len(stmt.Columns) > 0 {
+		cols = make([]*ast.ResultField, 0, len(stmt.Columns))
+		for _, cn := range stmt.Columns {
+			f, err := r.column(cn, sc, "field list", aliasNone)
+			if err != nil {
+				return err
+			}
+			cols = append(cols, f)
+		}
+	}
+	for i, row := range stmt.Lists {
+		// an empty row inserts the default values.
+		if len(row) > 0 && len(row) != len(cols) {
+			return ErrWrongValueCount.GenWithStackByArgs(i + 1)
+		}
+		for _, expr := range row {
+			if err := r.expr(expr, sc, "field list", aliasNone); err != nil {
+				return err
+			}
+		}
+	}
+	if stmt.Select != nil {
+		fields, err := r.resultSet(stmt.Select, nil)
+		if err != nil {
+			return err
+		}
+		if len(fields) != len(cols) {
+			return ErrWrongValueCount.GenWithStackByArgs(1)
+		}
+	}
+	if err := r.assignments(stmt.Setlist, sc); err != nil {
+		return err
+	}
+	return r.assignments(stmt.OnDuplicate, sc)
+}
+
+func (r *resolver) assignments(list []*ast.Assignment, sc *scope) error {
+	for _, a := range list {
+		if _, err := r.column(a.Column, sc, "field list", aliasNone); err != nil {
+			return err
+		}
+		if err := r.expr(a.Expr, sc, "field list", aliasNone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *resolver) update(stmt *ast.UpdateStmt) error {
+	sc := &scope{}
+	if err := r.with(stmt.With, sc); err != nil {
+		return err
+	}
+	if err := r.tableRefs(stmt.TableRefs.TableRefs, sc); err != nil {
+		return err
+	}
+	if err := r.assignments(stmt.List, sc); err != nil {
+		return err
+	}
+	return r.filter(stmt.Where, stmt.Order, sc)
+}
+
+func (r *resolver) delete(stmt *ast.DeleteStmt) error {
+	sc := &scope{}
+	if err := r.with(stmt.With, sc); err != nil {
+		return err
+	}
+	if err := r.tableRefs(stmt.TableRefs.TableRefs, sc); err != nil {
+		return err
+	}
+	if stmt.Tables != nil {
+		for _, tn := range stmt.Tables.Tables {
+			var found *source
+			for _, src := range sc.sources {
+				if src.name.L == tn.Name.L && (tn.Schema.L == "" || src.schema.L == tn.Schema.L) {
+					found = src
+					break
+				}
+			}
+			if found == nil {
+				return ErrUnknownTable.GenWithStackByArgs(tn.Name.O, "MULTI DELETE")
+			}
+			tn.TableInfo = found.table
+		}
+	}
+	return r.filter(stmt.Where, stmt.Order, sc)
+}
+
+// filter binds the names in WHERE and ORDER BY of UPDATE and DELETE.
+func (r *resolver) filter(where ast.ExprNode, order *ast.OrderByClause, sc *scope) error {
+	if where != nil {
+		if err := r.expr(where, sc, "where clause", aliasNone); err != nil {
+			return err
+		}
+	}
+	if order != nil {
+		return r.byItems(order.Items, sc, "order clause", aliasNone)
+	}
+	return nil
+}
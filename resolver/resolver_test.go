@@ -0,0 +1,292 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/catalog"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/resolver"
+	"github.com/pingcap/parser/terror"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testResolverSuite{})
+
+type testResolverSuite struct {
+	schema resolver.Schema
+}
+
+func (s *testResolverSuite) SetUpSuite(c *C) {
+	cat := catalog.New()
+	stmts, _, err := parser.New().Parse(`
+		CREATE DATABASE test;
+		CREATE DATABASE other;
+		CREATE TABLE other.t (a INT, x INT);
+		USE test;
+		CREATE TABLE t (a INT, b INT, c INT);
+		CREATE TABLE s (a INT, d INT);
+		CREATE TABLE u (a INT, b INT, e INT);
+		CREATE VIEW v (va, vb) AS SELECT a, b FROM t;
+	`, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(cat.ExecAll(stmts), IsNil)
+	s.schema = cat
+}
+
+func (s *testResolverSuite) resolve(c *C, sql string) (ast.StmtNode, *resolver.Result, error) {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	res, err := resolver.Resolve(stmt, s.schema)
+	return stmt, res, err
+}
+
+// origin follows a field down to the column of a table it comes from.
+func origin(f *ast.ResultField) string {
+	var path []string
+	for f != nil {
+		if f.Table != nil {
+			return strings.Join(append(path, f.Table.Name.O+"."+f.Column.Name.O), "->")
+		}
+		path = append(path, f.TableAsName.O+"."+f.Column.Name.O)
+		cn, ok := f.Expr.(*ast.ColumnNameExpr)
+		if !ok {
+			break
+		}
+		f = cn.Refer
+	}
+	return strings.Join(append(path, "expr"), "->")
+}
+
+// bindings returns the origins of the column names in the order of the statement.
+func bindings(stmt ast.StmtNode, res *resolver.Result) []string {
+	v := &nameCollector{}
+	stmt.Accept(v)
+	var list []string
+	for _, cn := range v.names {
+		if f, ok := res.Names[cn]; ok {
+			list = append(list, cn.OrigColName()+"="+origin(f))
+		}
+	}
+	return list
+}
+
+type nameCollector struct {
+	names []*ast.ColumnName
+}
+
+func (v *nameCollector) Enter(n ast.Node) (ast.Node, bool) {
+	if cn, ok := n.(*ast.ColumnName); ok {
+		v.names = append(v.names, cn)
+	}
+	return n, false
+}
+
+func (v *nameCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+func fieldNames(fields []*ast.ResultField) []string {
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Column.Name.O)
+	}
+	return names
+}
+
+func (s *testResolverSuite) TestBinding(c *C) {
+	table := []struct {
+		sql      string
+		bindings []string
+	}{
+		{"select a, t.b from t where c > 1", []string{"a=t.a", "t.b=t.b", "c=t.c"}},
+		{"select test.t.a from t", []string{"test.t.a=t.a"}},
+		{"select x.a from t as x", []string{"x.a=t.a"}},
+		{"select t.a, s.d from t join s on t.a = s.a", []string{"t.a=t.a", "s.d=s.d", "t.a=t.a", "s.a=s.a"}},
+		{"select test.t.a, other.t.x from test.t, other.t", []string{"test.t.a=t.a", "other.t.x=t.x"}},
+		{"select va from v", []string{"va=v.va"}},
+		// USING and NATURAL JOIN coalesce the columns.
+		{"select a, d from t join s using (a)", []string{"a=t.a", "d=s.d", "a=t.a"}},
+		{"select a, b from t natural join u", []string{"a=t.a", "b=t.b"}},
+		{"select s.a from t join s using (a)", []string{"s.a=s.a", "a=t.a"}},
+		// derived tables and common table expressions.
+		{"select y from (select a as y from t) dt", []string{"y=dt.y->t.a", "a=t.a"}},
+		{"select dt.b from (select * from t) dt", []string{"dt.b=dt.b->t.b"}},
+		{"with cte as (select a, d from s) select a from cte", []string{"a=s.a", "d=s.d", "a=cte.a->s.a"}},
+		{"with cte (p, q) as (select a, d from s) select q from cte", []string{"a=s.a", "d=s.d", "q=cte.q->s.d"}},
+		{"with t as (select d from s) select d from t", []string{"d=s.d", "d=t.d->s.d"}},
+		{"with recursive r (n) as (select 1 union all select n + 1 from r where n < 3) select n from r",
+			[]string{"n=r.n->expr", "n=r.n->expr", "n=r.n->expr"}},
+		// JSON_TABLE can refer to the tables before it.
+		{"select jt.id, t.a from t, json_table(t.b, '$[*]' columns (id for ordinality, v int path '$')) as jt where v > 0",
+			[]string{"jt.id=jt.id->expr", "t.a=t.a", "t.b=t.b", "v=jt.v->expr"}},
+		// correlated subqueries look up the outer query blocks.
+		{"select a from t where exists (select 1 from s where s.a = t.b)", []string{"a=t.a", "s.a=s.a", "t.b=t.b"}},
+		{"select a from t where b in (select d from s where d = c)", []string{"a=t.a", "b=t.b", "d=s.d", "d=s.d", "c=t.c"}},
+		{"select (select a from s) from t", []string{"a=s.a"}},
+		// aliases in the select list.
+		{"select a as x from t order by x", []string{"a=t.a", "x=.x->t.a"}},
+		{"select a + 1 as b from t order by b", []string{"a=t.a", "b=.b->expr"}},
+		{"select a as b from t group by b", []string{"a=t.a", "b=t.b"}},
+		{"select a as z from t group by z having z > 1", []string{"a=t.a", "z=.z->t.a", "z=.z->t.a"}},
+		{"select a from t union select d from s order by a", []string{"a=t.a", "d=s.d", "a=.a->t.a"}},
+		// DML.
+		{"insert into t (a, b) values (1, 2)", []string{"a=t.a", "b=t.b"}},
+		{"insert into t select a, d, e from s join u using (a)", []string{"a=s.a", "d=s.d", "e=u.e", "a=s.a"}},
+		{"insert into t set a = 1 on duplicate key update b = values(c)", []string{"a=t.a", "b=t.b", "c=t.c"}},
+		{"update t set a = b + 1 where c = 1 order by a", []string{"a=t.a", "b=t.b", "c=t.c", "a=t.a"}},
+		{"update t, s set t.a = s.d where t.a = s.a", []string{"t.a=t.a", "s.d=s.d", "t.a=t.a", "s.a=s.a"}},
+		{"delete from t where a = 1", []string{"a=t.a"}},
+		{"delete x from t x join s on x.a = s.a where d > 0", []string{"x.a=t.a", "s.a=s.a", "d=s.d"}},
+		{"explain select b from t", []string{"b=t.b"}},
+	}
+	for _, tt := range table {
+		stmt, res, err := s.resolve(c, tt.sql)
+		c.Assert(err, IsNil, Commentf("sql: %s", tt.sql))
+		c.Assert(bindings(stmt, res), DeepEquals, tt.bindings, Commentf("sql: %s", tt.sql))
+	}
+}
+
+func (s *testResolverSuite) TestFields(c *C) {
+	table := []struct {
+		sql    string
+		fields []string
+	}{
+		{"select * from t", []string{"a", "b", "c"}},
+		{"select s.*, t.* from t, s", []string{"a", "d", "a", "b", "c"}},
+		{"select * from t join u using (a)", []string{"a", "b", "c", "b", "e"}},
+		{"select * from t natural join u", []string{"a", "b", "c", "e"}},
+		{"select u.* from t join u using (a)", []string{"a", "b", "e"}},
+		{"select a, b + 1, c as z, 'x' from t", []string{"a", "b + 1", "z", "'x'"}},
+		{"select * from (select a, d as e from s) dt", []string{"a", "e"}},
+		{"select d from s union select b from t", []string{"d"}},
+		{"values row(1, 2), row(3, 4)", []string{"column_0", "column_1"}},
+		{"table s", []string{"a", "d"}},
+		{"select * from json_table('[]', '$' columns (a int path '$.a', nested path '$.b[*]' columns (b int path '$'))) jt", []string{"a", "b"}},
+		{"create view w (p, q) as select * from s", []string{"p", "q"}},
+		{"create table w select a, c from t", []string{"a", "c"}},
+		{"insert into t values (1, 2, 3)", nil},
+	}
+	for _, tt := range table {
+		_, res, err := s.resolve(c, tt.sql)
+		c.Assert(err, IsNil, Commentf("sql: %s", tt.sql))
+		c.Assert(fieldNames(res.Fields), DeepEquals, tt.fields, Commentf("sql: %s", tt.sql))
+	}
+}
+
+func (s *testResolverSuite) TestTables(c *C) {
+	stmt, res, err := s.resolve(c, "select * from t, other.t as o, v")
+	c.Assert(err, IsNil)
+	sel := stmt.(*ast.SelectStmt)
+	wf := sel.Fields.Fields[0].WildCard
+	c.Assert(res.Wildcards[wf], HasLen, 7)
+	for _, f := range res.Fields {
+		c.Assert(f.Expr.(*ast.ColumnNameExpr).Refer, NotNil)
+	}
+	c.Assert(res.Fields[3].Expr.(*ast.ColumnNameExpr).Refer.DBName.O, Equals, "other")
+	c.Assert(res.Fields[3].Expr.(*ast.ColumnNameExpr).Refer.TableAsName.O, Equals, "o")
+
+	stmt, _, err = s.resolve(c, "delete t, s from t join s using (a)")
+	c.Assert(err, IsNil)
+	for _, tn := range stmt.(*ast.DeleteStmt).Tables.Tables {
+		c.Assert(tn.TableInfo, NotNil)
+		c.Assert(tn.TableInfo.Name.L, Equals, tn.Name.L)
+	}
+
+	tbl := &model.TableInfo{
+		Name: model.NewCIStr("T1"),
+		Columns: []*model.ColumnInfo{
+			{Name: model.NewCIStr("id"), State: model.StatePublic},
+			{Name: model.NewCIStr("_hidden"), State: model.StatePublic, Hidden: true},
+		},
+	}
+	stmt, err = parser.New().ParseOneStmt("select * from db.t1 where id > 0", "", "")
+	c.Assert(err, IsNil)
+	res, err = resolver.Resolve(stmt, resolver.NewSchema("DB", []*model.TableInfo{tbl}))
+	c.Assert(err, IsNil)
+	c.Assert(fieldNames(res.Fields), DeepEquals, []string{"id"})
+	c.Assert(res.Fields[0].Expr.(*ast.ColumnNameExpr).Refer.Table, Equals, tbl)
+	_, err = resolver.Resolve(stmt, resolver.NewSchema("test", []*model.TableInfo{tbl}))
+	c.Assert(terror.ErrorEqual(err, resolver.ErrTableNotExists), IsTrue)
+}
+
+func (s *testResolverSuite) TestDerived(c *C) {
+	stmt, res, err := s.resolve(c, "with c (x) as (select a from t union select d from s) select c.x, dt.e from c, (select a, b as e from u) dt")
+	c.Assert(err, IsNil)
+	sel := stmt.(*ast.SelectStmt)
+	c.Assert(res.Queries, HasLen, 4)
+	c.Assert(fieldNames(res.Queries[sel]), DeepEquals, []string{"x", "e"})
+
+	x := sel.Fields.Fields[0].Expr.(*ast.ColumnNameExpr).Refer
+	c.Assert(res.Derived[x], Equals, sel.With.CTEs[0].Query.Query)
+	c.Assert(x.Column.Offset, Equals, 0)
+	e := sel.Fields.Fields[1].Expr.(*ast.ColumnNameExpr).Refer
+	dt := sel.From.TableRefs.Right.(*ast.TableSource).Source
+	c.Assert(res.Derived[e], Equals, dt)
+	c.Assert(e.Column.Offset, Equals, 1)
+	c.Assert(res.Queries[dt.(*ast.SelectStmt)][1].Expr, Equals, e.Expr)
+}
+
+func (s *testResolverSuite) TestErrors(c *C) {
+	table := []struct {
+		sql string
+		err *terror.Error
+		msg string
+	}{
+		{"select z from t", resolver.ErrUnknownColumn, "Unknown column 'z' in 'field list'"},
+		{"select a from t where t.z = 1", resolver.ErrUnknownColumn, "Unknown column 't.z' in 'where clause'"},
+		{"select a from t, s", resolver.ErrAmbiguous, "Column 'a' in field list is ambiguous"},
+		{"select t.a from t join s on a = 1", resolver.ErrAmbiguous, "Column 'a' in on clause is ambiguous"},
+		{"select a from t group by d", resolver.ErrUnknownColumn, "Unknown column 'd' in 'group statement'"},
+		{"select a from t order by z", resolver.ErrUnknownColumn, "Unknown column 'z' in 'order clause'"},
+		{"select a from t having z", resolver.ErrUnknownColumn, "Unknown column 'z' in 'having clause'"},
+		{"select a from t x where t.a = 1", resolver.ErrUnknownColumn, "Unknown column 't.a' in 'where clause'"},
+		{"select * from t join s using (b)", resolver.ErrUnknownColumn, "Unknown column 'b' in 'from clause'"},
+		{"select * from t join s on t.a = u.a", resolver.ErrUnknownColumn, "Unknown column 'u.a' in 'on clause'"},
+		{"select * from t, t", resolver.ErrNonUniqTable, "Not unique table/alias: 't'"},
+		{"select * from t x, s x", resolver.ErrNonUniqTable, "Not unique table/alias: 'x'"},
+		{"select * from nope", resolver.ErrTableNotExists, "Table 'test.nope' doesn't exist"},
+		{"select s.* from t", resolver.ErrBadTable, "Unknown table 's'"},
+		{"select *", resolver.ErrNoTablesUsed, "No tables used"},
+		{"select * from (select a, a from t) dt", resolver.ErrDupFieldName, "Duplicate column name 'a'"},
+		{"select a from t, (select d from s where s.a = t.a) dt", resolver.ErrUnknownColumn, "Unknown column 't.a' in 'where clause'"},
+		{"select * from json_table('[]', '$' columns (a int path '$.a', a int path '$.b')) jt", resolver.ErrDupFieldName, "Duplicate column name 'a'"},
+		{"select * from json_table(t.a, '$' columns (x int path '$')) jt, t", resolver.ErrUnknownColumn, "Unknown column 't.a' in 'from clause'"},
+		{"with cte (x) as (select a, b from t) select * from cte", resolver.ErrViewWrongList, ""},
+		{"create view w (x) as select a, b from t", resolver.ErrViewWrongList, ""},
+		{"insert into t (a, b) values (1, 2), (3)", resolver.ErrWrongValueCount, "Column count doesn't match value count at row 2"},
+		{"insert into t values (1, 2)", resolver.ErrWrongValueCount, "Column count doesn't match value count at row 1"},
+		{"insert into t (a) select a, b from t", resolver.ErrWrongValueCount, ""},
+		{"insert into t (z) values (1)", resolver.ErrUnknownColumn, "Unknown column 'z' in 'field list'"},
+		{"update t set z = 1", resolver.ErrUnknownColumn, "Unknown column 'z' in 'field list'"},
+		{"delete s from t", resolver.ErrUnknownTable, "Unknown table 's' in MULTI DELETE"},
+		{"delete from t where d = 1", resolver.ErrUnknownColumn, "Unknown column 'd' in 'where clause'"},
+	}
+	for _, tt := range table {
+		_, _, err := s.resolve(c, tt.sql)
+		c.Assert(terror.ErrorEqual(err, tt.err), IsTrue, Commentf("sql: %s, err: %v", tt.sql, err))
+		if tt.msg != "" {
+			c.Assert(err, ErrorMatches, ".*"+tt.msg, Commentf("sql: %s", tt.sql))
+		}
+	}
+}
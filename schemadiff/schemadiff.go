@@ -0,0 +1,542 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schemadiff generates the DDL statements which migrate the tables defined
+// by some CREATE TABLE statements into the tables defined by others.
+//
+// The definitions are compared by their restored SQL text, so that two definitions
+// are the same only if they are written in the same way, for example `INT` and
+// `INT(11)` are different. Renames can't be told from dropping and adding by the
+// definitions, they are given by Hints.
+package schemadiff
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/model"
+)
+
+// Hints lists the renames. The names are case-insensitive, a table is named as
+// `db.t` if its CREATE TABLE statement has a schema, otherwise as `t`.
+type Hints struct {
+	// Tables maps the new table names to the old ones.
+	Tables map[string]string
+	// Columns maps the new column names to the old ones, by the new table names.
+	Columns map[string]map[string]string
+	// Indexes maps the new index names to the old ones, by the new table names.
+	Indexes map[string]map[string]string
+}
+
+func (h *Hints) oldTable(name string) string {
+	if h == nil {
+		return ""
+	}
+	return lookup(h.Tables, name)
+}
+
+func (h *Hints) oldColumn(table, name string) string {
+	if h == nil {
+		return ""
+	}
+	return lookup(h.Columns[lookupKey(h.Columns, table)], name)
+}
+
+func (h *Hints) oldIndex(table, name string) string {
+	if h == nil {
+		return ""
+	}
+	return lookup(h.Indexes[lookupKey(h.Indexes, table)], name)
+}
+
+// lookup returns the value of the key name in m case-insensitively, in lower case.
+func lookup(m map[string]string, name string) string {
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return strings.ToLower(v)
+		}
+	}
+	return ""
+}
+
+func lookupKey(m map[string]map[string]string, name string) string {
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return ""
+}
+
+// DiffSchema returns the statements which migrate the tables defined by from into the
+// tables defined by to. A table in to but not in from is created by its CREATE TABLE
+// statement, a table in from but not in to is dropped, and the other tables are
+// altered by DiffTable, in the order of to.
+func DiffSchema(from, to []*ast.CreateTableStmt, hints *Hints) ([]ast.StmtNode, error) {
+	fromTables := make(map[string]*ast.CreateTableStmt, len(from))
+	for _, stmt := range from {
+		name := tableName(stmt.Table)
+		if _, ok := fromTables[name]; ok {
+			return nil, errors.Errorf("table %s is defined twice", name)
+		}
+		fromTables[name] = stmt
+	}
+	toTables := make(map[string]struct{}, len(to))
+	var stmts []ast.StmtNode
+	for _, stmt := range to {
+		name := tableName(stmt.Table)
+		if _, ok := toTables[name]; ok {
+			return nil, errors.Errorf("table %s is defined twice", name)
+		}
+		toTables[name] = struct{}{}
+
+		oldName := hints.oldTable(name)
+		if oldName == "" {
+			oldName = name
+		} else if _, ok := fromTables[oldName]; !ok {
+			return nil, errors.Errorf("table %s is renamed from unknown table %s", name, oldName)
+		}
+		old, ok := fromTables[oldName]
+		if !ok {
+			stmts = append(stmts, stmt)
+			continue
+		}
+		delete(fromTables, oldName)
+		alter, err := DiffTable(old, stmt, hints)
+		if err != nil {
+			return nil, err
+		}
+		if alter != nil {
+			stmts = append(stmts, alter)
+		}
+	}
+	for _, stmt := range from {
+		if _, ok := fromTables[tableName(stmt.Table)]; ok {
+			stmts = append(stmts, &ast.DropTableStmt{Tables: []*ast.TableName{stmt.Table}})
+		}
+	}
+	return stmts, nil
+}
+
+// DiffTable returns the ALTER TABLE statement which changes the table defined by from
+// into the table defined by to, or nil if they are the same. The table is renamed if
+// their names are different. The specifications are in the order of:
+//   - DROP PRIMARY KEY, DROP INDEX, DROP FOREIGN KEY and DROP CHECK,
+//   - DROP COLUMN,
+//   - ADD COLUMN, MODIFY COLUMN, CHANGE COLUMN and RENAME COLUMN, in the order of the columns of to,
+//   - RENAME INDEX and ADD constraints,
+//   - the table options and the partitioning,
+//   - RENAME AS.
+//
+// The table options which are only in from are reset to their default, and it's an error
+// when the default depends on the server, e.g. ENGINE. AUTO_INCREMENT is ignored.
+func DiffTable(from, to *ast.CreateTableStmt, hints *Hints) (*ast.AlterTableStmt, error) {
+	for _, stmt := range []*ast.CreateTableStmt{from, to} {
+		if stmt.ReferTable != nil || stmt.Select != nil {
+			return nil, errors.Errorf("can't diff table %s defined by LIKE or AS SELECT", tableName(stmt.Table))
+		}
+	}
+	d := &differ{
+		table: tableName(to.Table),
+		hints: hints,
+	}
+	fromCols, fromConstraints := liftKeys(from)
+	toCols, toConstraints := liftKeys(to)
+	dropConstraints, addConstraints, err := d.diffConstraints(fromConstraints, toConstraints)
+	if err != nil {
+		return nil, err
+	}
+	dropColumns, columns, err := d.diffColumns(fromCols, toCols)
+	if err != nil {
+		return nil, err
+	}
+	var specs []*ast.AlterTableSpec
+	specs = append(specs, dropConstraints...)
+	specs = append(specs, dropColumns...)
+	specs = append(specs, columns...)
+	specs = append(specs, addConstraints...)
+	spec, err := d.diffOptions(from.Options, to.Options)
+	if err != nil {
+		return nil, err
+	}
+	if spec != nil {
+		specs = append(specs, spec)
+	}
+	if partitionDefinition(from.Partition) != partitionDefinition(to.Partition) {
+		if to.Partition == nil {
+			specs = append(specs, &ast.AlterTableSpec{Tp: ast.AlterTableRemovePartitioning})
+		} else {
+			specs = append(specs, &ast.AlterTableSpec{Tp: ast.AlterTablePartition, Partition: to.Partition})
+		}
+	}
+	if tableName(from.Table) != d.table {
+		specs = append(specs, &ast.AlterTableSpec{Tp: ast.AlterTableRenameTable, NewTable: to.Table})
+	}
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	return &ast.AlterTableStmt{Table: from.Table, Specs: specs}, nil
+}
+
+// liftKeys returns the columns and the constraints of stmt, with the PRIMARY KEY and
+// UNIQUE KEY column options written as constraints, so that `a INT PRIMARY KEY` is the
+// same as `a INT, PRIMARY KEY (a)`. stmt is left unchanged.
+func liftKeys(stmt *ast.CreateTableStmt) ([]*ast.ColumnDef, []*ast.Constraint) {
+	cols := make([]*ast.ColumnDef, 0, len(stmt.Cols))
+	var constraints []*ast.Constraint
+	for _, col := range stmt.Cols {
+		var options []*ast.ColumnOption
+		for _, opt := range col.Options {
+			keys := []*ast.IndexPartSpecification{{Column: &ast.ColumnName{Name: col.Name.Name}}}
+			switch opt.Tp {
+			case ast.ColumnOptionPrimaryKey:
+				c := &ast.Constraint{Tp: ast.ConstraintPrimaryKey, Keys: keys}
+				if opt.PrimaryKeyTp != model.PrimaryKeyTypeDefault {
+					c.Option = &ast.IndexOption{PrimaryKeyTp: opt.PrimaryKeyTp}
+				}
+				constraints = append(constraints, c)
+			case ast.ColumnOptionUniqKey:
+				constraints = append(constraints, &ast.Constraint{Tp: ast.ConstraintUniq, Keys: keys})
+			default:
+				options = append(options, opt)
+			}
+		}
+		if len(options) != len(col.Options) {
+			def := *col
+			def.Options = options
+			col = &def
+		}
+		cols = append(cols, col)
+	}
+	return cols, append(constraints, stmt.Constraints...)
+}
+
+type differ struct {
+	table string
+	hints *Hints
+}
+
+// diffColumns returns the specifications dropping the columns, and those adding, changing
+// and moving the columns.
+func (d *differ) diffColumns(from, to []*ast.ColumnDef) (drops, specs []*ast.AlterTableSpec, err error) {
+	fromCols := make(map[string]*ast.ColumnDef, len(from))
+	for _, col := range from {
+		fromCols[col.Name.Name.L] = col
+	}
+	// oldNames maps the columns of to to the ones of from.
+	oldNames := make(map[string]string, len(to))
+	for _, col := range to {
+		name := col.Name.Name.L
+		oldName := d.hints.oldColumn(d.table, name)
+		if oldName == "" {
+			oldName = name
+		} else if _, ok := fromCols[oldName]; !ok {
+			return nil, nil, errors.Errorf("column %s.%s is renamed from unknown column %s", d.table, name, oldName)
+		}
+		if _, ok := fromCols[oldName]; ok {
+			oldNames[name] = oldName
+		}
+	}
+	kept := make(map[string]struct{}, len(oldNames))
+	for _, oldName := range oldNames {
+		kept[oldName] = struct{}{}
+	}
+
+	// current is the column order of the table being altered, by the new names.
+	var current []string
+	newNames := make(map[string]string, len(oldNames))
+	for name, oldName := range oldNames {
+		newNames[oldName] = name
+	}
+	for _, col := range from {
+		name := col.Name.Name.L
+		if _, ok := kept[name]; !ok {
+			drops = append(drops, &ast.AlterTableSpec{Tp: ast.AlterTableDropColumn, OldColumnName: col.Name})
+			continue
+		}
+		current = append(current, newNames[name])
+	}
+
+	for i, col := range to {
+		name := col.Name.Name.L
+		position := &ast.ColumnPosition{Tp: ast.ColumnPositionNone}
+		if i == 0 {
+			position.Tp = ast.ColumnPositionFirst
+		} else {
+			position.Tp = ast.ColumnPositionAfter
+			position.RelativeColumn = &ast.ColumnName{Name: to[i-1].Name.Name}
+		}
+		oldName, ok := oldNames[name]
+		if !ok {
+			// it's appended if all the columns before it are in place.
+			if i == len(current) {
+				position = &ast.ColumnPosition{Tp: ast.ColumnPositionNone}
+			}
+			current = insert(current, i, name)
+			specs = append(specs, &ast.AlterTableSpec{
+				Tp:         ast.AlterTableAddColumns,
+				NewColumns: []*ast.ColumnDef{col},
+				Position:   position,
+			})
+			continue
+		}
+		// the columns before i are in place.
+		moved := current[i] != name
+		if moved {
+			current = insert(remove(current, name), i, name)
+		} else {
+			position = &ast.ColumnPosition{Tp: ast.ColumnPositionNone}
+		}
+		old := fromCols[oldName]
+		changed := columnDefinition(old) != columnDefinition(col)
+		switch {
+		case oldName != name && !changed && !moved:
+			specs = append(specs, &ast.AlterTableSpec{
+				Tp:            ast.AlterTableRenameColumn,
+				OldColumnName: old.Name,
+				NewColumnName: col.Name,
+			})
+		case oldName != name:
+			specs = append(specs, &ast.AlterTableSpec{
+				Tp:            ast.AlterTableChangeColumn,
+				OldColumnName: old.Name,
+				NewColumns:    []*ast.ColumnDef{col},
+				Position:      position,
+			})
+		case changed || moved:
+			specs = append(specs, &ast.AlterTableSpec{
+				Tp:         ast.AlterTableModifyColumn,
+				NewColumns: []*ast.ColumnDef{col},
+				Position:   position,
+			})
+		}
+	}
+	return drops, specs, nil
+}
+
+func insert(names []string, i int, name string) []string {
+	names = append(names, "")
+	copy(names[i+1:], names[i:])
+	names[i] = name
+	return names
+}
+
+func remove(names []string, name string) []string {
+	for i, n := range names {
+		if n == name {
+			return append(names[:i], names[i+1:]...)
+		}
+	}
+	return names
+}
+
+// columnDefinition returns the definition of col without its name, the options are sorted.
+func columnDefinition(col *ast.ColumnDef) string {
+	def := *col
+	def.Name = &ast.ColumnName{}
+	def.Options = nil
+	options := make([]string, 0, len(col.Options))
+	for _, opt := range col.Options {
+		options = append(options, restore(opt))
+	}
+	sort.Strings(options)
+	return restore(&def) + " " + strings.Join(options, " ")
+}
+
+// diffConstraints returns the specifications dropping the constraints, and those renaming
+// and adding the constraints.
+func (d *differ) diffConstraints(from, to []*ast.Constraint) (drops, specs []*ast.AlterTableSpec, err error) {
+	// the constraints without names are identified by their definitions.
+	fromNamed := make(map[string]*ast.Constraint, len(from))
+	fromUnnamed := make(map[string]*ast.Constraint)
+	for _, c := range from {
+		if name := constraintName(c); name != "" {
+			fromNamed[name] = c
+		} else {
+			fromUnnamed[constraintDefinition(c)] = c
+		}
+	}
+	kept := make(map[*ast.Constraint]struct{}, len(to))
+	for _, c := range to {
+		def := constraintDefinition(c)
+		name := constraintName(c)
+		if name == "" {
+			if old, ok := fromUnnamed[def]; ok {
+				kept[old] = struct{}{}
+				continue
+			}
+			specs = append(specs, &ast.AlterTableSpec{Tp: ast.AlterTableAddConstraint, Constraint: c})
+			continue
+		}
+		oldName := d.hints.oldIndex(d.table, name)
+		if oldName == "" {
+			oldName = name
+		} else if _, ok := fromNamed[oldName]; !ok {
+			return nil, nil, errors.Errorf("index %s of table %s is renamed from unknown index %s", name, d.table, oldName)
+		}
+		old, ok := fromNamed[oldName]
+		if ok && constraintDefinition(old) == def {
+			kept[old] = struct{}{}
+			if oldName != name {
+				specs = append(specs, &ast.AlterTableSpec{
+					Tp:      ast.AlterTableRenameIndex,
+					FromKey: model.NewCIStr(old.Name),
+					ToKey:   model.NewCIStr(c.Name),
+				})
+			}
+			continue
+		}
+		specs = append(specs, &ast.AlterTableSpec{Tp: ast.AlterTableAddConstraint, Constraint: c})
+	}
+	for _, c := range from {
+		if _, ok := kept[c]; ok {
+			continue
+		}
+		spec, err := dropConstraint(d.table, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		drops = append(drops, spec)
+	}
+	return drops, specs, nil
+}
+
+// constraintName returns the name of the constraint in lower case. An index without
+// a name is named after its first column like MySQL does, the primary key is named
+// PRIMARY. The other constraints without names have no names.
+func constraintName(c *ast.Constraint) string {
+	switch c.Tp {
+	case ast.ConstraintPrimaryKey:
+		return "primary"
+	case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintUniq, ast.ConstraintUniqKey,
+		ast.ConstraintUniqIndex, ast.ConstraintFulltext:
+		if c.Name == "" && len(c.Keys) > 0 && c.Keys[0].Column != nil {
+			return c.Keys[0].Column.Name.L
+		}
+	}
+	return strings.ToLower(c.Name)
+}
+
+// constraintDefinition returns the definition of c without its name.
+func constraintDefinition(c *ast.Constraint) string {
+	def := *c
+	def.Name = ""
+	return restore(&def)
+}
+
+func dropConstraint(table string, c *ast.Constraint) (*ast.AlterTableSpec, error) {
+	switch c.Tp {
+	case ast.ConstraintPrimaryKey:
+		return &ast.AlterTableSpec{Tp: ast.AlterTableDropPrimaryKey}, nil
+	case ast.ConstraintForeignKey:
+		if c.Name == "" {
+			return nil, errors.Errorf("can't drop the foreign key without a name of table %s", table)
+		}
+		return &ast.AlterTableSpec{Tp: ast.AlterTableDropForeignKey, Name: c.Name}, nil
+	case ast.ConstraintCheck:
+		if c.Name == "" {
+			return nil, errors.Errorf("can't drop the check constraint without a name of table %s", table)
+		}
+		return &ast.AlterTableSpec{Tp: ast.AlterTableDropCheck, Constraint: &ast.Constraint{Name: c.Name}}, nil
+	}
+	name := c.Name
+	if name == "" {
+		if len(c.Keys) == 0 || c.Keys[0].Column == nil {
+			return nil, errors.Errorf("can't drop the index without a name of table %s", table)
+		}
+		name = c.Keys[0].Column.Name.O
+	}
+	return &ast.AlterTableSpec{Tp: ast.AlterTableDropIndex, Name: name}, nil
+}
+
+func partitionDefinition(p *ast.PartitionOptions) string {
+	if p == nil {
+		return ""
+	}
+	return restore(p)
+}
+
+// diffOptions returns the specification setting the options of to which aren't in from,
+// and resetting the options of from whose type isn't in to.
+func (d *differ) diffOptions(from, to []*ast.TableOption) (*ast.AlterTableSpec, error) {
+	fromOpts := make(map[string]struct{}, len(from))
+	for _, opt := range from {
+		fromOpts[restore(opt)] = struct{}{}
+	}
+	toTypes := make(map[ast.TableOptionType]struct{}, len(to))
+	var options []*ast.TableOption
+	for _, opt := range to {
+		toTypes[opt.Tp] = struct{}{}
+		if opt.Tp == ast.TableOptionAutoIncrement {
+			continue
+		}
+		if _, ok := fromOpts[restore(opt)]; !ok {
+			options = append(options, opt)
+		}
+	}
+	for _, opt := range from {
+		if _, ok := toTypes[opt.Tp]; ok || opt.Tp == ast.TableOptionAutoIncrement {
+			continue
+		}
+		reset, err := d.resetOption(opt)
+		if err != nil {
+			return nil, err
+		}
+		toTypes[opt.Tp] = struct{}{}
+		options = append(options, reset)
+	}
+	if len(options) == 0 {
+		return nil, nil
+	}
+	return &ast.AlterTableSpec{Tp: ast.AlterTableOption, Options: options}, nil
+}
+
+// resetOption returns the option setting the default value of the type of opt.
+func (d *differ) resetOption(opt *ast.TableOption) (*ast.TableOption, error) {
+	switch opt.Tp {
+	case ast.TableOptionComment, ast.TableOptionConnection:
+		return &ast.TableOption{Tp: opt.Tp, StrValue: ""}, nil
+	case ast.TableOptionAvgRowLength, ast.TableOptionCheckSum, ast.TableOptionTableCheckSum,
+		ast.TableOptionKeyBlockSize, ast.TableOptionMaxRows, ast.TableOptionMinRows,
+		ast.TableOptionDelayKeyWrite, ast.TableOptionShardRowID, ast.TableOptionPreSplitRegion:
+		return &ast.TableOption{Tp: opt.Tp, UintValue: 0}, nil
+	case ast.TableOptionRowFormat:
+		return &ast.TableOption{Tp: opt.Tp, UintValue: ast.RowFormatDefault}, nil
+	case ast.TableOptionStatsPersistent, ast.TableOptionStatsAutoRecalc,
+		ast.TableOptionStatsSamplePages, ast.TableOptionPackKeys:
+		return &ast.TableOption{Tp: opt.Tp, Default: true}, nil
+	}
+	return nil, errors.Errorf("can't reset the table option %s of table %s", restore(opt), d.table)
+}
+
+func tableName(tn *ast.TableName) string {
+	if tn.Schema.L != "" {
+		return tn.Schema.L + "." + tn.Name.L
+	}
+	return tn.Name.L
+}
+
+type restorer interface {
+	Restore(ctx *format.RestoreCtx) error
+}
+
+// restore returns the SQL text of n.
+func restore(n restorer) string {
+	var sb strings.Builder
+	// the nodes built by the parser can always be restored.
+	_ = n.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb))
+	return sb.String()
+}
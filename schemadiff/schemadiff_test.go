@@ -0,0 +1,252 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemadiff_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+	. "github.com/pingcap/parser/schemadiff"
+	_ "github.com/pingcap/parser/test_driver"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testSchemaDiffSuite{})
+
+type testSchemaDiffSuite struct {
+}
+
+func parseTables(c *C, sql string) []*ast.CreateTableStmt {
+	stmts, _, err := parser.New().Parse(sql, "", "")
+	c.Assert(err, IsNil)
+	tables := make([]*ast.CreateTableStmt, 0, len(stmts))
+	for _, stmt := range stmts {
+		tables = append(tables, stmt.(*ast.CreateTableStmt))
+	}
+	return tables
+}
+
+func restore(c *C, n ast.Node) string {
+	var sb strings.Builder
+	c.Assert(n.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)), IsNil)
+	return sb.String()
+}
+
+func (s *testSchemaDiffSuite) TestDiffTable(c *C) {
+	cases := []struct {
+		from   string
+		to     string
+		hints  *Hints
+		expect string
+	}{
+		{
+			"CREATE TABLE t (a INT, b INT)",
+			"CREATE TABLE t (a INT, b INT)",
+			nil,
+			"",
+		},
+		{
+			"CREATE TABLE t (a INT, b INT)",
+			"CREATE TABLE t (a INT, b INT, c VARCHAR(10) NOT NULL DEFAULT '')",
+			nil,
+			"ALTER TABLE `t` ADD COLUMN `c` VARCHAR(10) NOT NULL DEFAULT _UTF8MB4''",
+		},
+		{
+			"CREATE TABLE t (a INT, b INT)",
+			"CREATE TABLE t (z INT, a INT, c INT, b INT)",
+			nil,
+			"ALTER TABLE `t` ADD COLUMN `z` INT FIRST, ADD COLUMN `c` INT AFTER `a`",
+		},
+		{
+			"CREATE TABLE t (a INT, b INT, c INT)",
+			"CREATE TABLE t (a BIGINT, c INT)",
+			nil,
+			"ALTER TABLE `t` DROP COLUMN `b`, MODIFY COLUMN `a` BIGINT",
+		},
+		{
+			// the options are compared regardless of their order.
+			"CREATE TABLE t (a INT NOT NULL DEFAULT 1, b INT)",
+			"CREATE TABLE t (a INT DEFAULT 1 NOT NULL, b INT)",
+			nil,
+			"",
+		},
+		{
+			"CREATE TABLE t (a INT, b INT, c INT)",
+			"CREATE TABLE t (c INT, a INT, b INT)",
+			nil,
+			"ALTER TABLE `t` MODIFY COLUMN `c` INT FIRST",
+		},
+		{
+			"CREATE TABLE t (a INT, b INT, c INT)",
+			"CREATE TABLE t (a INT, c INT, b INT)",
+			nil,
+			"ALTER TABLE `t` MODIFY COLUMN `c` INT AFTER `a`",
+		},
+		{
+			// without hints, a renamed column is dropped and added.
+			"CREATE TABLE t (a INT, b INT)",
+			"CREATE TABLE t (a INT, bb INT)",
+			nil,
+			"ALTER TABLE `t` DROP COLUMN `b`, ADD COLUMN `bb` INT",
+		},
+		{
+			"CREATE TABLE t (a INT, b INT)",
+			"CREATE TABLE t (a INT, bb INT)",
+			&Hints{Columns: map[string]map[string]string{"T": {"BB": "b"}}},
+			"ALTER TABLE `t` RENAME COLUMN `b` TO `bb`",
+		},
+		{
+			"CREATE TABLE t (a INT, b INT)",
+			"CREATE TABLE t (bb BIGINT, a INT)",
+			&Hints{Columns: map[string]map[string]string{"t": {"bb": "b"}}},
+			"ALTER TABLE `t` CHANGE COLUMN `b` `bb` BIGINT FIRST",
+		},
+		{
+			"CREATE TABLE t (a INT, b INT, c INT, PRIMARY KEY (a), INDEX (b), UNIQUE KEY uk (c), KEY idx_bc (b, c))",
+			"CREATE TABLE t (a INT, b INT, c INT, PRIMARY KEY (a, b), INDEX (b), UNIQUE KEY uk (c), KEY idx_cb (c, b), FULLTEXT KEY ft (c))",
+			nil,
+			"ALTER TABLE `t` DROP PRIMARY KEY, DROP INDEX `idx_bc`, ADD PRIMARY KEY(`a`, `b`), ADD INDEX `idx_cb`(`c`, `b`), ADD FULLTEXT `ft`(`c`)",
+		},
+		{
+			"CREATE TABLE t (a INT, b INT, KEY idx_a (a), KEY idx_b (b))",
+			"CREATE TABLE t (a INT, b INT, KEY idx_a2 (a), KEY idx_b2 (a, b))",
+			&Hints{Indexes: map[string]map[string]string{"t": {"idx_a2": "idx_a", "idx_b2": "idx_b"}}},
+			"ALTER TABLE `t` DROP INDEX `idx_b`, RENAME INDEX `idx_a` TO `idx_a2`, ADD INDEX `idx_b2`(`a`, `b`)",
+		},
+		{
+			// the inline keys are the same as the constraints.
+			"CREATE TABLE t (id INT PRIMARY KEY, a INT UNIQUE)",
+			"CREATE TABLE t (id INT, a INT, PRIMARY KEY (id), UNIQUE (a))",
+			nil,
+			"",
+		},
+		{
+			"CREATE TABLE t (id INT, a INT, PRIMARY KEY (id), UNIQUE (a))",
+			"CREATE TABLE t (id INT PRIMARY KEY, a INT UNIQUE)",
+			nil,
+			"",
+		},
+		{
+			"CREATE TABLE t (id INT PRIMARY KEY, a INT UNIQUE)",
+			"CREATE TABLE t (id INT, a INT, PRIMARY KEY (id, a))",
+			nil,
+			"ALTER TABLE `t` DROP PRIMARY KEY, DROP INDEX `a`, ADD PRIMARY KEY(`id`, `a`)",
+		},
+		{
+			"CREATE TABLE t (id INT, a INT)",
+			"CREATE TABLE t (id INT PRIMARY KEY, a INT UNIQUE, b INT UNIQUE)",
+			nil,
+			"ALTER TABLE `t` ADD COLUMN `b` INT, ADD PRIMARY KEY(`id`), ADD UNIQUE(`a`), ADD UNIQUE(`b`)",
+		},
+		{
+			"CREATE TABLE t (a INT, CONSTRAINT fk FOREIGN KEY (a) REFERENCES p (id), CONSTRAINT ck CHECK (a > 0))",
+			"CREATE TABLE t (a INT)",
+			nil,
+			"ALTER TABLE `t` DROP FOREIGN KEY `fk`, DROP CHECK `ck`",
+		},
+		{
+			"CREATE TABLE t (a INT) ENGINE = InnoDB AUTO_INCREMENT = 10 COMMENT = 'x'",
+			"CREATE TABLE t (a INT) ENGINE = InnoDB AUTO_INCREMENT = 20 COMMENT = 'y' DEFAULT CHARSET = utf8mb4",
+			nil,
+			"ALTER TABLE `t` COMMENT = 'y' DEFAULT CHARACTER SET = UTF8MB4",
+		},
+		{
+			// the options which are only in from are reset.
+			"CREATE TABLE t (a INT) ENGINE = InnoDB COMMENT = 'x' ROW_FORMAT = COMPACT KEY_BLOCK_SIZE = 8 STATS_AUTO_RECALC = 1",
+			"CREATE TABLE t (a INT) ENGINE = InnoDB",
+			nil,
+			"ALTER TABLE `t` COMMENT = '' ROW_FORMAT = DEFAULT KEY_BLOCK_SIZE = 0 STATS_AUTO_RECALC = DEFAULT",
+		},
+		{
+			"CREATE TABLE t (a INT)",
+			"CREATE TABLE t (a INT) PARTITION BY HASH (a) PARTITIONS 4",
+			nil,
+			"ALTER TABLE `t` PARTITION BY HASH (`a`) PARTITIONS 4",
+		},
+		{
+			"CREATE TABLE t (a INT) PARTITION BY HASH (a) PARTITIONS 4",
+			"CREATE TABLE t (a INT)",
+			nil,
+			"ALTER TABLE `t` REMOVE PARTITIONING",
+		},
+		{
+			"CREATE TABLE db.t (a INT)",
+			"CREATE TABLE db.t2 (a INT, b INT)",
+			nil,
+			"ALTER TABLE `db`.`t` ADD COLUMN `b` INT, RENAME AS `db`.`t2`",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("from %s to %s", ca.from, ca.to)
+		from, to := parseTables(c, ca.from)[0], parseTables(c, ca.to)[0]
+		alter, err := DiffTable(from, to, ca.hints)
+		c.Assert(err, IsNil, comment)
+		if ca.expect == "" {
+			c.Assert(alter, IsNil, comment)
+			continue
+		}
+		c.Assert(alter, NotNil, comment)
+		sql := restore(c, alter)
+		c.Assert(sql, Equals, ca.expect, comment)
+
+		// the restored statement can be parsed.
+		_, err = parser.New().ParseOneStmt(sql, "", "")
+		c.Assert(err, IsNil, comment)
+	}
+}
+
+func (s *testSchemaDiffSuite) TestDiffSchema(c *C) {
+	from := parseTables(c, "CREATE TABLE a (id INT); CREATE TABLE b (id INT); CREATE TABLE c (id INT); CREATE TABLE d (id INT)")
+	to := parseTables(c, "CREATE TABLE e (id INT); CREATE TABLE a (id INT, x INT); CREATE TABLE b (id INT); CREATE TABLE c2 (id INT)")
+	stmts, err := DiffSchema(from, to, &Hints{Tables: map[string]string{"c2": "c"}})
+	c.Assert(err, IsNil)
+	var sqls []string
+	for _, stmt := range stmts {
+		sqls = append(sqls, restore(c, stmt))
+	}
+	c.Assert(sqls, DeepEquals, []string{
+		"CREATE TABLE `e` (`id` INT)",
+		"ALTER TABLE `a` ADD COLUMN `x` INT",
+		"ALTER TABLE `c` RENAME AS `c2`",
+		"DROP TABLE `d`",
+	})
+}
+
+func (s *testSchemaDiffSuite) TestErrors(c *C) {
+	from := parseTables(c, "CREATE TABLE t (a INT, FOREIGN KEY (a) REFERENCES p (id)); CREATE TABLE t2 (a INT)")
+	to := parseTables(c, "CREATE TABLE t (a INT); CREATE TABLE t3 LIKE t2")
+
+	_, err := DiffTable(from[0], to[0], nil)
+	c.Assert(err, ErrorMatches, "can't drop the foreign key without a name of table t")
+	_, err = DiffTable(from[1], to[1], nil)
+	c.Assert(err, ErrorMatches, "can't diff table t3 defined by LIKE or AS SELECT")
+	_, err = DiffTable(from[1], from[1], &Hints{Columns: map[string]map[string]string{"t2": {"a": "x"}}})
+	c.Assert(err, ErrorMatches, "column t2.a is renamed from unknown column x")
+	_, err = DiffSchema(from, from, &Hints{Tables: map[string]string{"t2": "x"}})
+	c.Assert(err, ErrorMatches, "table t2 is renamed from unknown table x")
+	_, err = DiffSchema(from, append(from, from[0]), nil)
+	c.Assert(err, ErrorMatches, "table t is defined twice")
+
+	from = parseTables(c, "CREATE TABLE t (a INT) ENGINE = InnoDB COMMENT = 'x'")
+	to = parseTables(c, "CREATE TABLE t (a INT)")
+	_, err = DiffTable(from[0], to[0], nil)
+	c.Assert(err, ErrorMatches, "can't reset the table option ENGINE = InnoDB of table t")
+}
@@ -0,0 +1,230 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	// io is the name of a token, see parser.y.
+	goio "io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/ast"
+)
+
+// splitterChunkSize is the minimum number of bytes the StmtSplitter reads at a time.
+var splitterChunkSize = 64 * 1024
+
+// SplitStmt is a statement read by StmtSplitter.
+type SplitStmt struct {
+	// Text is the statement text, including its delimiter.
+	Text string
+	// Start is the position of the first character of the statement in the stream,
+	// End is the position right after its last character.
+	Start ast.Pos
+	End   ast.Pos
+	// Stmts is the parse result of Text, it's usually a single statement.
+	Stmts []ast.StmtNode
+	Warns []error
+	// Err is the error reported when parsing Text, the line numbers in it are relative to Text.
+	Err error
+}
+
+// StmtSplitter splits the SQL text read from an io.Reader into statements and parses
+// them one by one. The statement boundaries are found by the Scanner, so quotes,
+// comments, `/*! */` version comments, BEGIN...END blocks and DELIMITER directives
+// are handled like Parse does. Only the statement being split is kept in memory.
+type StmtSplitter struct {
+	parser    *Parser
+	charset   string
+	collation string
+
+	r   goio.Reader
+	eof bool
+	// buf holds the text read from r but not returned yet.
+	buf string
+	// pos is the position of buf in the stream.
+	pos       ast.Pos
+	delimiter string
+}
+
+// NewStmtSplitter returns a StmtSplitter which reads the SQL text from r and parses
+// the statements with the parser. If charset or collation is "", default charset and
+// collation will be used. The parser must not be used by others until the splitting ends.
+func (parser *Parser) NewStmtSplitter(r goio.Reader, charset, collation string) *StmtSplitter {
+	return &StmtSplitter{
+		parser:    parser,
+		charset:   charset,
+		collation: collation,
+		r:         r,
+		pos:       ast.Pos{Line: 1, Col: 1},
+	}
+}
+
+// Next returns the next statement, or io.EOF if there are no statements left.
+// A statement which can't be parsed is returned with its Err set, the error returned
+// by Next is only about reading the underlying io.Reader.
+func (s *StmtSplitter) Next() (*SplitStmt, error) {
+	for {
+		if err := s.skipSpace(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(s.buf) == 0 && s.eof {
+			return nil, goio.EOF
+		}
+		l := s.parser.lexer.InheritScanner(s.buf)
+		l.SetDelimiter(s.delimiter)
+		n, complete := stmtEnd(l)
+		if !complete && !s.eof {
+			if err := s.fill(); err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+
+		text := s.buf[:n]
+		if !complete {
+			text = strings.TrimRightFunc(text, unicode.IsSpace)
+		}
+		stmt := &SplitStmt{Text: text, Start: s.pos}
+		s.advance(n)
+		stmt.End = advancePos(stmt.Start, text)
+
+		stmts, warns, err := s.parser.parse(text, s.charset, s.collation, s.delimiter)
+		s.delimiter = l.delimiter
+		if err == nil && len(stmts) == 0 && !complete {
+			// only comments are left.
+			continue
+		}
+		stmt.Stmts = append([]ast.StmtNode(nil), stmts...)
+		stmt.Warns, stmt.Err = warns, err
+		return stmt, nil
+	}
+}
+
+// fill reads at least splitterChunkSize bytes more into the buffer, the buffer is
+// doubled at least so that the statement is scanned again only a few times.
+func (s *StmtSplitter) fill() error {
+	size := splitterChunkSize
+	if len(s.buf) > size {
+		size = len(s.buf)
+	}
+	chunk := make([]byte, size)
+	n, err := goio.ReadFull(s.r, chunk)
+	s.buf += string(chunk[:n])
+	switch err {
+	case nil:
+	case goio.EOF, goio.ErrUnexpectedEOF:
+		s.eof = true
+	default:
+		return err
+	}
+	return nil
+}
+
+// skipSpace drops the leading white spaces of the buffer, reading more if needed.
+func (s *StmtSplitter) skipSpace() error {
+	for {
+		n := len(s.buf) - len(strings.TrimLeftFunc(s.buf, unicode.IsSpace))
+		s.advance(n)
+		if len(s.buf) > 0 || s.eof {
+			return nil
+		}
+		if err := s.fill(); err != nil {
+			return err
+		}
+	}
+}
+
+// advance drops the first n bytes of the buffer.
+func (s *StmtSplitter) advance(n int) {
+	s.pos = advancePos(s.pos, s.buf[:n])
+	s.buf = s.buf[n:]
+	if len(s.buf) == 0 {
+		// release the memory of the returned statements.
+		s.buf = ""
+	}
+}
+
+// advancePos returns the position right after text which starts at p.
+func advancePos(p ast.Pos, text string) ast.Pos {
+	p.Offset += len(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		p.Line += strings.Count(text, "\n")
+		p.Col = 1
+		text = text[i+1:]
+	}
+	p.Col += utf8.RuneCountInString(text)
+	return p
+}
+
+// stmtEnd scans the first statement of l and returns its length, including the
+// delimiter. complete is false if the text ends before the statement is terminated.
+func stmtEnd(l *Scanner) (n int, complete bool) {
+	const scanEnd = 0
+	var (
+		v, lv     yySymType
+		lookahead = -1
+		// blocks holds the BEGIN and CASE tokens not closed by END yet.
+		blocks []int
+	)
+	// the BEGIN...END special case only applies to the default delimiter, a custom
+	// delimiter is used exactly because the stored program body contains `;`.
+	isDefaultDelimiter := l.delimiter == ""
+	for first := true; ; first = false {
+		var tok int
+		if lookahead >= 0 {
+			tok, v, lookahead = lookahead, lv, -1
+		} else {
+			tok = l.Lex(&v)
+		}
+		switch {
+		case tok == scanEnd:
+			return l.lastScanOffset, false
+		case tok == delimiterDirective && first:
+			// the directive ends at the line end.
+			return l.r.p.Offset, l.r.p.Offset < len(l.r.s)
+		case l.isDelimiter(tok, v.ident) && len(blocks) == 0:
+			return l.lastScanOffset + len(v.ident), true
+		case !isDefaultDelimiter:
+		case tok == begin && (!first || len(blocks) > 0):
+			// ref: https://dev.mysql.com/doc/refman/8.0/en/begin-end.html
+			// ref: https://dev.mysql.com/doc/refman/8.0/en/stored-programs-defining.html
+			// Support match:
+			// BEGIN
+			// ...
+			// END;
+			//
+			// A BEGIN which starts the statement begins a transaction instead.
+			blocks = append(blocks, begin)
+		case tok == caseKwd && len(blocks) > 0:
+			// both the CASE statement and the CASE expression end with END.
+			blocks = append(blocks, caseKwd)
+		case tok == end && len(blocks) > 0:
+			next := l.Lex(&lv)
+			switch next {
+			case ifKwd, loop, while, repeat:
+				// `END IF`, `END LOOP` and so on don't close a BEGIN...END block.
+				continue
+			case scanEnd:
+				return l.lastScanOffset, false
+			case caseKwd:
+			default:
+				lookahead = next
+			}
+			blocks = blocks[:len(blocks)-1]
+		}
+	}
+}
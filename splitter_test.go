@@ -0,0 +1,99 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	goio "io"
+	"strings"
+	"testing/iotest"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/ast"
+)
+
+var _ = Suite(&testSplitterSuite{})
+
+type testSplitterSuite struct {
+}
+
+func (s *testSplitterSuite) TestSplit(c *C) {
+	defer func(size int) {
+		splitterChunkSize = size
+	}(splitterChunkSize)
+
+	stmts := []string{
+		"SELECT 'a;b', \"c;d\", `e;f` FROM t;",
+		"/* c1; */ SELECT 1 -- c2;\n+ 1 # c3;\n;",
+		"/*!40101 SET NAMES utf8 */;",
+		"BEGIN;",
+		"INSERT INTO t VALUES ('it''s;', 'a\\';');",
+		"COMMIT;",
+		"CREATE PROCEDURE p(a INT)\nBEGIN\n  DECLARE b INT DEFAULT CASE a WHEN 1 THEN 2 END;\n  lbl: BEGIN\n    IF a > 1 THEN\n      SELECT 1;\n    END IF;\n  END lbl;\n  CASE a\n    WHEN 1 THEN BEGIN SELECT 2; END;\n  END CASE;\nEND;",
+		"DELIMITER $$",
+		"CREATE PROCEDURE q()\nBEGIN\n  SELECT 'x$$';\nEND$$",
+		"DELIMITER ;",
+		"OPTIMIZE TABLE t;",
+		"SELECT '中文'",
+	}
+	sql := strings.Join(stmts, "\n  ")
+
+	for _, size := range []int{1, 2, 3, 7, 64 * 1024} {
+		splitterChunkSize = size
+		sp := New().NewStmtSplitter(iotest.HalfReader(strings.NewReader(sql)), "", "")
+		for i, expect := range stmts {
+			comment := Commentf("chunk size %d, stmt %d", size, i)
+			stmt, err := sp.Next()
+			c.Assert(err, IsNil, comment)
+			c.Assert(stmt.Text, Equals, expect, comment)
+			c.Assert(sql[stmt.Start.Offset:stmt.End.Offset], Equals, expect, comment)
+			if expect == "OPTIMIZE TABLE t;" {
+				c.Assert(stmt.Err, NotNil, comment)
+				c.Assert(stmt.Stmts, HasLen, 0, comment)
+				continue
+			}
+			c.Assert(stmt.Err, IsNil, comment)
+			c.Assert(stmt.Stmts, HasLen, 1, comment)
+		}
+		_, err := sp.Next()
+		c.Assert(err, Equals, goio.EOF)
+	}
+}
+
+func (s *testSplitterSuite) TestSplitPos(c *C) {
+	sql := "SELECT 1;\n\n  SELECT '中文'; SELECT\n2;\n-- the end\n"
+	sp := New().NewStmtSplitter(strings.NewReader(sql), "", "")
+
+	expects := []struct {
+		start, end ast.Pos
+	}{
+		{ast.Pos{Line: 1, Col: 1, Offset: 0}, ast.Pos{Line: 1, Col: 10, Offset: 9}},
+		{ast.Pos{Line: 3, Col: 3, Offset: 13}, ast.Pos{Line: 3, Col: 15, Offset: 29}},
+		{ast.Pos{Line: 3, Col: 16, Offset: 30}, ast.Pos{Line: 4, Col: 3, Offset: 39}},
+	}
+	for _, expect := range expects {
+		stmt, err := sp.Next()
+		c.Assert(err, IsNil)
+		c.Assert(stmt.Start, Equals, expect.start)
+		c.Assert(stmt.End, Equals, expect.end)
+	}
+	_, err := sp.Next()
+	c.Assert(err, Equals, goio.EOF)
+}
+
+func (s *testSplitterSuite) TestSplitReadError(c *C) {
+	sp := New().NewStmtSplitter(iotest.TimeoutReader(strings.NewReader("SELECT 1;")), "", "")
+	_, err := sp.Next()
+	c.Assert(errors.Cause(err), Equals, iotest.ErrTimeout)
+}
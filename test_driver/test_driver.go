@@ -16,6 +16,7 @@
 package test_driver
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
@@ -24,6 +25,7 @@ import (
 	"github.com/pingcap/parser/charset"
 	"github.com/pingcap/parser/format"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/types"
 )
 
 func init() {
@@ -42,6 +44,8 @@ func init() {
 		b, err := NewBitLiteral(str)
 		return b, err
 	}
+	ast.RegisterJSONType("ValueExpr", &ValueExpr{})
+	ast.RegisterJSONType("ParamMarkerExpr", &ParamMarkerExpr{})
 }
 
 var (
@@ -185,6 +189,130 @@ func (n *ValueExpr) Accept(v ast.Visitor) (ast.Node, bool) {
 	return v.Leave(n)
 }
 
+// kindNames are the names of the kinds in the JSON encoding of ValueExpr.
+var kindNames = map[byte]string{
+	KindNull:          "Null",
+	KindInt64:         "Int64",
+	KindUint64:        "Uint64",
+	KindFloat32:       "Float32",
+	KindFloat64:       "Float64",
+	KindString:        "String",
+	KindBytes:         "Bytes",
+	KindBinaryLiteral: "BinaryLiteral",
+	KindMysqlDecimal:  "MysqlDecimal",
+}
+
+// valueExprJSON is the JSON encoding of ValueExpr.
+type valueExprJSON struct {
+	Kind  string
+	Value json.RawMessage `json:",omitempty"`
+	Type  types.FieldType
+}
+
+func (n *ValueExpr) toJSON() (*valueExprJSON, error) {
+	kind, ok := kindNames[n.Kind()]
+	if !ok {
+		return nil, fmt.Errorf("can't encode the value of kind %d into JSON", n.Kind())
+	}
+	var value interface{}
+	switch n.Kind() {
+	case KindNull:
+	case KindInt64:
+		value = n.GetInt64()
+	case KindUint64:
+		value = n.GetUint64()
+	case KindFloat32:
+		value = n.GetFloat32()
+	case KindFloat64:
+		value = n.GetFloat64()
+	case KindString:
+		value = n.GetString()
+	case KindBytes, KindBinaryLiteral:
+		value = n.GetBytes()
+	case KindMysqlDecimal:
+		value = n.GetMysqlDecimal().String()
+	}
+	v := &valueExprJSON{Kind: kind, Type: n.Type}
+	if value != nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		v.Value = data
+	}
+	return v, nil
+}
+
+func (n *ValueExpr) fromJSON(v *valueExprJSON) error {
+	var err error
+	switch v.Kind {
+	case kindNames[KindNull]:
+		n.SetNull()
+	case kindNames[KindInt64]:
+		var i int64
+		err = json.Unmarshal(v.Value, &i)
+		n.SetInt64(i)
+	case kindNames[KindUint64]:
+		var u uint64
+		err = json.Unmarshal(v.Value, &u)
+		n.SetUint64(u)
+	case kindNames[KindFloat32]:
+		var f float32
+		err = json.Unmarshal(v.Value, &f)
+		n.SetFloat32(f)
+	case kindNames[KindFloat64]:
+		var f float64
+		err = json.Unmarshal(v.Value, &f)
+		n.SetFloat64(f)
+	case kindNames[KindString]:
+		var s string
+		err = json.Unmarshal(v.Value, &s)
+		n.SetString(s)
+	case kindNames[KindBytes]:
+		var b []byte
+		err = json.Unmarshal(v.Value, &b)
+		n.SetBytes(b)
+	case kindNames[KindBinaryLiteral]:
+		var b []byte
+		err = json.Unmarshal(v.Value, &b)
+		n.SetBinaryLiteral(b)
+	case kindNames[KindMysqlDecimal]:
+		var s string
+		if err = json.Unmarshal(v.Value, &s); err == nil {
+			dec := new(MyDecimal)
+			err = dec.FromString([]byte(s))
+			n.SetMysqlDecimal(dec)
+		}
+	default:
+		return fmt.Errorf("unknown value kind %q", v.Kind)
+	}
+	if err != nil {
+		return err
+	}
+	n.Type = v.Type
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface, it encodes the fields of the node
+// for ast.EncodeJSON.
+func (n *ValueExpr) MarshalJSON() ([]byte, error) {
+	v, err := n.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (n *ValueExpr) UnmarshalJSON(data []byte) error {
+	var v valueExprJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	n.projectionOffset = -1
+	return n.fromJSON(&v)
+}
+
 // ParamMarkerExpr expression holds a place for another expression.
 // Used in parsing prepare statement.
 type ParamMarkerExpr struct {
@@ -221,6 +349,38 @@ func (n *ParamMarkerExpr) Accept(v ast.Visitor) (ast.Node, bool) {
 	return v.Leave(n)
 }
 
+// paramMarkerExprJSON is the JSON encoding of ParamMarkerExpr.
+type paramMarkerExprJSON struct {
+	valueExprJSON
+	Offset    int
+	Order     int
+	InExecute bool `json:",omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (n *ParamMarkerExpr) MarshalJSON() ([]byte, error) {
+	v, err := n.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&paramMarkerExprJSON{
+		valueExprJSON: *v,
+		Offset:        n.Offset,
+		Order:         n.Order,
+		InExecute:     n.InExecute,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (n *ParamMarkerExpr) UnmarshalJSON(data []byte) error {
+	var v paramMarkerExprJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	n.Offset, n.Order, n.InExecute = v.Offset, v.Order, v.InExecute
+	return n.fromJSON(&v.valueExprJSON)
+}
+
 // SetOrder implements the ParamMarkerExpr interface.
 func (n *ParamMarkerExpr) SetOrder(order int) {
 	n.Order = order
@@ -0,0 +1,378 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeinfer
+
+import (
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/opcode"
+	"github.com/pingcap/parser/types"
+)
+
+// intArg returns the value of an integer literal.
+func intArg(args []ast.ExprNode, i int) (int64, bool) {
+	if i >= len(args) {
+		return 0, false
+	}
+	v, ok := args[i].(ast.ValueExpr)
+	if !ok {
+		return 0, false
+	}
+	switch x := v.GetValue().(type) {
+	case int64:
+		return x, true
+	case uint64:
+		return int64(x), true
+	}
+	return 0, false
+}
+
+// fsp returns the fractional seconds precision given by an argument.
+func fsp(args []ast.ExprNode, i int) int {
+	v, _ := intArg(args, i)
+	return int(v)
+}
+
+func argType(args []ast.ExprNode, i int) *types.FieldType {
+	if i >= len(args) {
+		return types.NewFieldType(mysql.TypeUnspecified)
+	}
+	return args[i].GetType()
+}
+
+var (
+	intFuncs = map[string]bool{
+		// string functions
+		ast.ASCII: true, ast.BitLength: true, ast.CharLength: true, ast.CharacterLength: true, ast.Length: true,
+		ast.OctetLength: true, ast.Instr: true, ast.Locate: true, ast.Position: true, ast.Ord: true,
+		ast.FindInSet: true, ast.Field: true, ast.Strcmp: true,
+		// math functions
+		ast.Sign: true, ast.Interval: true,
+		// date and time functions
+		ast.Day: true, ast.DayOfMonth: true, ast.DayOfWeek: true, ast.DayOfYear: true, ast.Hour: true,
+		ast.MicroSecond: true, ast.Minute: true, ast.Month: true, ast.Quarter: true, ast.Second: true,
+		ast.Week: true, ast.Weekday: true, ast.WeekOfYear: true, ast.Year: true, ast.YearWeek: true,
+		ast.ToDays: true, ast.ToSeconds: true, ast.DateDiff: true, ast.TimestampDiff: true,
+		ast.PeriodAdd: true, ast.PeriodDiff: true, ast.TimeToSec: true, ast.Extract: true,
+		// information functions
+		ast.Benchmark: true, ast.Coercibility: true, ast.FoundRows: true, ast.RowCount: true,
+		// JSON functions
+		ast.JSONContains: true, ast.JSONContainsPath: true, ast.JSONValid: true, ast.JSONDepth: true,
+		ast.JSONLength: true, ast.JSONStorageSize: true,
+		// miscellaneous functions
+		ast.GetLock: true, ast.ReleaseLock: true, ast.ReleaseAllLocks: true, ast.IsFreeLock: true,
+		ast.IsUsedLock: true, ast.IsIPv4: true, ast.IsIPv4Compat: true, ast.IsIPv4Mapped: true,
+		ast.IsIPv6: true, ast.Sleep: true, ast.UncompressedLength: true, ast.ValidatePasswordStrength: true,
+	}
+	unsignedFuncs = map[string]bool{
+		ast.CRC32: true, ast.BitCount: true, ast.ConnectionID: true, ast.LastInsertId: true,
+		ast.InetAton: true, ast.UUIDShort: true,
+	}
+	realFuncs = map[string]bool{
+		ast.Acos: true, ast.Asin: true, ast.Atan: true, ast.Atan2: true, ast.Cos: true, ast.Cot: true,
+		ast.Degrees: true, ast.Exp: true, ast.Ln: true, ast.Log: true, ast.Log2: true, ast.Log10: true,
+		ast.PI: true, ast.Pow: true, ast.Power: true, ast.Radians: true, ast.Rand: true, ast.Sin: true,
+		ast.Sqrt: true, ast.Tan: true,
+	}
+	// jsonFuncs return JSON documents.
+	jsonFuncs = map[string]bool{
+		ast.JSONExtract: true, ast.JSONArray: true, ast.JSONObject: true, ast.JSONMerge: true,
+		ast.JSONMergePatch: true, ast.JSONMergePreserve: true, ast.JSONSet: true, ast.JSONInsert: true,
+		ast.JSONReplace: true, ast.JSONRemove: true, ast.JSONArrayAppend: true, ast.JSONArrayInsert: true,
+		ast.JSONKeys: true,
+	}
+	// sysconstFuncs return the strings of the system in utf8.
+	sysconstFuncs = map[string]bool{
+		ast.Charset: true, ast.Collation: true, ast.CurrentUser: true, ast.CurrentRole: true,
+		ast.Database: true, ast.Schema: true, ast.SessionUser: true, ast.SystemUser: true,
+		ast.User: true, ast.Version: true, ast.TiDBVersion: true,
+	}
+	// argStringFuncs return a string of the collation of their string arguments, which
+	// is as long as the first one.
+	argStringFuncs = map[string]bool{
+		ast.Lower: true, ast.Lcase: true, ast.Upper: true, ast.Ucase: true, ast.Trim: true,
+		ast.LTrim: true, ast.RTrim: true, ast.Reverse: true, ast.Left: true, ast.Right: true,
+		ast.Substring: true, ast.Substr: true, ast.Mid: true, ast.SubstringIndex: true,
+		ast.Replace: true, ast.InsertFunc: true, ast.Lpad: true, ast.Rpad: true, ast.Repeat: true,
+		ast.Elt: true, ast.MakeSet: true, ast.ExportSet: true, ast.Quote: true, ast.Soundex: true,
+	}
+	// stringFuncs return a string of the connection whose length is given, or -1 if
+	// it depends on the arguments.
+	stringFuncs = map[string]int{
+		ast.Bin: 64, ast.Oct: 22, ast.Conv: 64, ast.Format: -1, ast.Space: -1, ast.ToBase64: -1,
+		ast.DateFormat: -1, ast.TimeFormat: -1, ast.DayName: 9, ast.MonthName: 9, ast.GetFormat: 17,
+		ast.MD5: 32, ast.SHA1: 40, ast.SHA: 40, ast.SHA2: 128, ast.UUID: 36, ast.InetNtoa: 15,
+		ast.Inet6Ntoa: 39, ast.BinToUUID: 36, ast.JSONUnquote: -1, ast.JSONType: 51, ast.JSONQuote: -1,
+		ast.JSONPretty: -1, ast.JSONSearch: -1, ast.PasswordFunc: 41, ast.FormatBytes: -1,
+		ast.FormatNanoTime: -1,
+	}
+	// binaryFuncs return a binary string whose length is given, or -1 if it depends on the arguments.
+	binaryFuncs = map[string]int{
+		ast.Unhex: -1, ast.FromBase64: -1, ast.AesEncrypt: -1, ast.AesDecrypt: -1, ast.Compress: -1,
+		ast.Uncompress: -1, ast.RandomBytes: -1, ast.UUIDToBin: 16, ast.Inet6Aton: 16,
+		ast.DesEncrypt: -1, ast.DesDecrypt: -1, ast.Encode: -1, ast.Decode: -1, ast.LoadFile: -1,
+	}
+	// dateUnits are the units of interval which keep a DATE a DATE.
+	dateUnits = map[ast.TimeUnitType]bool{
+		ast.TimeUnitDay: true, ast.TimeUnitWeek: true, ast.TimeUnitMonth: true, ast.TimeUnitQuarter: true,
+		ast.TimeUnitYear: true, ast.TimeUnitYearMonth: true,
+	}
+)
+
+// funcCall returns the type of a built-in function, a function which isn't known is
+// of mysql.TypeUnspecified.
+func (e *inferrer) funcCall(name string, args []ast.ExprNode) (*types.FieldType, charset.Coercibility, error) {
+	numeric := func(tp *types.FieldType) (*types.FieldType, charset.Coercibility, error) {
+		return tp, charset.CoercibilityNumeric, nil
+	}
+	switch {
+	case intFuncs[name]:
+		return numeric(newIntType(false, mysql.MaxIntWidth+1))
+	case unsignedFuncs[name]:
+		return numeric(newIntType(true, mysql.MaxIntWidth))
+	case realFuncs[name]:
+		return numeric(newRealType())
+	case jsonFuncs[name]:
+		return newJSONType(), charset.CoercibilityImplicit, nil
+	case sysconstFuncs[name]:
+		return newStringType(types.UnspecifiedLength, charset.CollationUTF8), charset.CoercibilitySysconst, nil
+	case argStringFuncs[name]:
+		co, c, err := e.mergeCollation(name, false, args...)
+		if err != nil {
+			return nil, 0, err
+		}
+		return newStringType(displayLength(argType(args, 0)), co), c, nil
+	}
+	if flen, ok := stringFuncs[name]; ok {
+		return newStringType(flen, e.collation), charset.CoercibilityCoercible, nil
+	}
+	if flen, ok := binaryFuncs[name]; ok {
+		return newStringType(flen, charset.CollationBin), charset.CoercibilityCoercible, nil
+	}
+
+	switch name {
+	case ast.Concat, ast.ConcatWS:
+		co, c, err := e.mergeCollation(name, false, args...)
+		if err != nil {
+			return nil, 0, err
+		}
+		flen := 0
+		for _, arg := range args {
+			l := displayLength(arg.GetType())
+			if l < 0 || flen < 0 {
+				flen = types.UnspecifiedLength
+			} else {
+				flen += l
+			}
+		}
+		return newStringType(flen, co), c, nil
+	case ast.Convert:
+		// CONVERT(expr USING charset), whose second argument is the name of the charset.
+		if v, ok := args[len(args)-1].(ast.ValueExpr); ok && len(args) == 2 {
+			if desc, err := charset.GetCharsetDesc(v.GetString()); err == nil {
+				return newStringType(displayLength(argType(args, 0)), desc.DefaultCollation), charset.CoercibilityImplicit, nil
+			}
+		}
+	case ast.Hex:
+		if l := displayLength(argType(args, 0)); l > 0 {
+			return newStringType(l*2, e.collation), charset.CoercibilityCoercible, nil
+		}
+		return newStringType(types.UnspecifiedLength, e.collation), charset.CoercibilityCoercible, nil
+	case ast.CharFunc:
+		return newStringType(len(args)-1, charset.CollationBin), charset.CoercibilityCoercible, nil
+
+	case ast.If:
+		return e.mergeTypes(name, args[1:])
+	case ast.Ifnull:
+		return e.mergeTypes(name, args)
+	case ast.Coalesce:
+		return e.mergeTypes(name, args)
+	case ast.Greatest, ast.Least:
+		tp, c, err := e.mergeTypes(name, args)
+		if err == nil && tp.EvalType() == types.ETString {
+			_, _, err = e.mergeCollation(name, true, args...)
+		}
+		return tp, c, err
+	case ast.Nullif:
+		tp := argType(args, 0).Clone()
+		return tp, coercibilityOf(tp, e.coercibility(args[0])), nil
+	case ast.AnyValue:
+		return argType(args, 0).Clone(), e.coercibility(args[0]), nil
+
+	case ast.Abs:
+		tp := argType(args, 0)
+		switch {
+		case tp.Tp == mysql.TypeUnspecified:
+			return numeric(types.NewFieldType(mysql.TypeUnspecified))
+		case arithEvalType(tp) == types.ETInt:
+			prec, _ := decimalDigits(tp)
+			return numeric(newIntType(mysql.HasUnsignedFlag(tp.Flag), prec))
+		case arithEvalType(tp) == types.ETDecimal:
+			return numeric(newDecimalType(decimalDigits(tp)))
+		}
+		return numeric(newRealType())
+	case ast.Ceil, ast.Ceiling, ast.Floor:
+		tp := argType(args, 0)
+		switch {
+		case tp.Tp == mysql.TypeUnspecified:
+			return numeric(types.NewFieldType(mysql.TypeUnspecified))
+		case arithEvalType(tp) == types.ETInt:
+			prec, _ := decimalDigits(tp)
+			return numeric(newIntType(mysql.HasUnsignedFlag(tp.Flag), prec))
+		case arithEvalType(tp) == types.ETDecimal:
+			// the result is an integer if it fits in a BIGINT.
+			prec, scale := decimalDigits(tp)
+			if prec-scale+1 < mysql.MaxIntWidth-1 {
+				return numeric(newIntType(mysql.HasUnsignedFlag(tp.Flag), prec-scale+1))
+			}
+			return numeric(newDecimalType(prec-scale+1, 0))
+		}
+		return numeric(newRealType())
+	case ast.Round, ast.Truncate:
+		tp := argType(args, 0)
+		switch {
+		case tp.Tp == mysql.TypeUnspecified:
+			return numeric(types.NewFieldType(mysql.TypeUnspecified))
+		case arithEvalType(tp) == types.ETInt:
+			prec, _ := decimalDigits(tp)
+			return numeric(newIntType(mysql.HasUnsignedFlag(tp.Flag), prec+1))
+		case arithEvalType(tp) == types.ETDecimal:
+			prec, scale := decimalDigits(tp)
+			d, ok := intArg(args, 1)
+			if !ok && len(args) > 1 {
+				// the scale isn't known until it's run.
+				d = int64(scale)
+			}
+			newScale := min(max(int(d), 0), scale)
+			return numeric(newDecimalType(prec-scale+newScale+1, newScale))
+		}
+		return numeric(newRealType())
+	case ast.Mod:
+		return numeric(arithmetic(opcode.Mod, argType(args, 0), argType(args, 1)))
+
+	case ast.Now, ast.CurrentTimestamp, ast.LocalTime, ast.LocalTimestamp, ast.Sysdate, ast.UTCTimestamp:
+		return numeric(newTimeType(mysql.TypeDatetime, fsp(args, 0)))
+	case ast.Curdate, ast.CurrentDate, ast.UTCDate, ast.Date, ast.FromDays, ast.MakeDate, ast.LastDay:
+		return numeric(newTimeType(mysql.TypeDate, 0))
+	case ast.Curtime, ast.CurrentTime, ast.UTCTime:
+		return numeric(newTimeType(mysql.TypeDuration, fsp(args, 0)))
+	case ast.Time, ast.SecToTime, ast.TimeDiff:
+		return numeric(newTimeType(mysql.TypeDuration, max(argType(args, 0).Decimal, 0)))
+	case ast.MakeTime:
+		return numeric(newTimeType(mysql.TypeDuration, max(argType(args, 2).Decimal, 0)))
+	case ast.Timestamp, ast.ConvertTz, ast.StrToDate:
+		return numeric(newTimeType(mysql.TypeDatetime, max(argType(args, 0).Decimal, 0)))
+	case ast.FromUnixTime:
+		if len(args) > 1 {
+			return newStringType(types.UnspecifiedLength, e.collation), charset.CoercibilityCoercible, nil
+		}
+		return numeric(newTimeType(mysql.TypeDatetime, max(argType(args, 0).Decimal, 0)))
+	case ast.UnixTimestamp:
+		if len(args) == 0 || argType(args, 0).Decimal <= 0 {
+			return numeric(newIntType(false, 11))
+		}
+		return numeric(newDecimalType(12+argType(args, 0).Decimal, argType(args, 0).Decimal))
+	case ast.DateAdd, ast.DateSub, ast.AddDate, ast.SubDate:
+		tp := argType(args, 0)
+		unit := ast.TimeUnitDay
+		if len(args) > 2 {
+			if u, ok := args[2].(*ast.TimeUnitExpr); ok {
+				unit = u.Unit
+			}
+		}
+		switch tp.Tp {
+		case mysql.TypeDate:
+			if dateUnits[unit] {
+				return numeric(newTimeType(mysql.TypeDate, 0))
+			}
+			return numeric(newTimeType(mysql.TypeDatetime, 0))
+		case mysql.TypeDatetime, mysql.TypeTimestamp:
+			return numeric(newTimeType(mysql.TypeDatetime, tp.Decimal))
+		case mysql.TypeDuration:
+			return numeric(newTimeType(mysql.TypeDuration, tp.Decimal))
+		}
+		return newStringType(mysql.MaxDatetimeWidthWithFsp, e.collation), charset.CoercibilityCoercible, nil
+	case ast.AddTime, ast.SubTime:
+		tp := argType(args, 0)
+		switch tp.Tp {
+		case mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+			return numeric(newTimeType(tp.Tp, tp.Decimal))
+		}
+		return newStringType(mysql.MaxDatetimeWidthWithFsp, e.collation), charset.CoercibilityCoercible, nil
+	}
+	return types.NewFieldType(mysql.TypeUnspecified), charset.CoercibilityImplicit, nil
+}
+
+// aggregate returns the type of an aggregate function.
+func (e *inferrer) aggregate(name string, args []ast.ExprNode) (*types.FieldType, charset.Coercibility, error) {
+	switch name {
+	case ast.AggFuncCount, ast.AggFuncApproxCountDistinct:
+		return newIntType(false, mysql.MaxIntWidth+1), charset.CoercibilityNumeric, nil
+	case ast.AggFuncBitAnd, ast.AggFuncBitOr, ast.AggFuncBitXor:
+		return newIntType(true, mysql.MaxIntWidth+1), charset.CoercibilityNumeric, nil
+	case ast.AggFuncVarPop, ast.AggFuncVarSamp, ast.AggFuncStddevPop, ast.AggFuncStddevSamp,
+		"std", "stddev", "variance":
+		return newRealType(), charset.CoercibilityNumeric, nil
+	case ast.AggFuncJsonArrayagg, ast.AggFuncJsonObjectAgg:
+		return newJSONType(), charset.CoercibilityImplicit, nil
+	case ast.AggFuncMax, ast.AggFuncMin, ast.AggFuncFirstRow, ast.AggFuncApproxPercentile:
+		tp := argType(args, 0).Clone()
+		tp.Flag &^= mysql.NotNullFlag
+		return tp, e.coercibility(args[0]), nil
+	case ast.AggFuncSum, ast.AggFuncAvg:
+		tp := argType(args, 0)
+		switch {
+		case tp.Tp == mysql.TypeUnspecified:
+			return types.NewFieldType(mysql.TypeUnspecified), charset.CoercibilityNumeric, nil
+		case arithEvalType(tp) == types.ETReal:
+			return newRealType(), charset.CoercibilityNumeric, nil
+		}
+		prec, scale := decimalDigits(tp)
+		if name == ast.AggFuncSum {
+			// the sum has the digits of a BIGINT more.
+			return newDecimalType(prec+mysql.MaxIntWidth+2, scale), charset.CoercibilityNumeric, nil
+		}
+		return newDecimalType(prec+divPrecisionIncrement, scale+divPrecisionIncrement), charset.CoercibilityNumeric, nil
+	case ast.AggFuncGroupConcat:
+		// the last argument is the separator.
+		co, c, err := e.mergeCollation(name, false, args[:len(args)-1]...)
+		if err != nil {
+			return nil, 0, err
+		}
+		// the result is truncated to the default group_concat_max_len.
+		return newStringType(1024, co), c, nil
+	}
+	return types.NewFieldType(mysql.TypeUnspecified), charset.CoercibilityImplicit, nil
+}
+
+// window returns the type of a window function, which may be an aggregate function.
+func (e *inferrer) window(name string, args []ast.ExprNode) (*types.FieldType, charset.Coercibility, error) {
+	switch name {
+	case ast.WindowFuncRowNumber, ast.WindowFuncRank, ast.WindowFuncDenseRank, ast.WindowFuncNtile:
+		return newIntType(false, mysql.MaxIntWidth+1), charset.CoercibilityNumeric, nil
+	case ast.WindowFuncCumeDist, ast.WindowFuncPercentRank:
+		return newRealType(), charset.CoercibilityNumeric, nil
+	case ast.WindowFuncLead, ast.WindowFuncLag:
+		if len(args) > 2 {
+			return e.mergeTypes(name, []ast.ExprNode{args[0], args[2]})
+		}
+		fallthrough
+	case ast.WindowFuncFirstValue, ast.WindowFuncLastValue, ast.WindowFuncNthValue:
+		tp := argType(args, 0).Clone()
+		tp.Flag &^= mysql.NotNullFlag
+		return tp, e.coercibility(args[0]), nil
+	}
+	return e.aggregate(name, args)
+}
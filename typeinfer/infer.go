@@ -0,0 +1,360 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package typeinfer infers the result types of the expressions statically,
+// following the rules of MySQL.
+//
+// The types of the columns come from the ast.ResultField in ColumnNameExpr.Refer,
+// which is set by the resolver package, so the statements are usually resolved
+// before their types are inferred. The unresolved columns, the parameter markers
+// and the functions which aren't known keep the type mysql.TypeUnspecified, and so
+// do the expressions depending on them.
+package typeinfer
+
+import (
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/opcode"
+	"github.com/pingcap/parser/types"
+)
+
+// Result is the result of Infer.
+type Result struct {
+	// Coercibility is the coercibility of the collation of every expression typed.
+	Coercibility map[ast.ExprNode]charset.Coercibility
+}
+
+// Infer fills the types of the expressions in a node, from the leaves up, by their
+// SetType. cs and co are the charset and the collation of the connection, which are
+// of the strings converted from the other types, like CAST(1 AS CHAR). They are the
+// default ones if empty.
+//
+// The types of the fields of the derived tables and the common table expressions
+// referred to are updated too.
+func Infer(node ast.Node, cs, co string) (*Result, error) {
+	if cs == "" {
+		cs = mysql.DefaultCharset
+	}
+	if co == "" {
+		co = mysql.DefaultCollationName
+	}
+	e := &inferrer{
+		charset:   cs,
+		collation: co,
+		result:    &Result{Coercibility: make(map[ast.ExprNode]charset.Coercibility)},
+		done:      make(map[ast.ExprNode]bool),
+		visiting:  make(map[ast.ExprNode]bool),
+	}
+	node.Accept(e)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.result, nil
+}
+
+type inferrer struct {
+	charset   string
+	collation string
+	result    *Result
+	// done are the expressions typed, the ones referred to by the columns may be
+	// typed before they're visited.
+	done     map[ast.ExprNode]bool
+	visiting map[ast.ExprNode]bool
+	err      error
+}
+
+func (e *inferrer) Enter(n ast.Node) (ast.Node, bool) {
+	if e.err != nil {
+		return n, true
+	}
+	if x, ok := n.(ast.ExprNode); ok {
+		if e.done[x] {
+			return n, true
+		}
+		e.visiting[x] = true
+	}
+	return n, false
+}
+
+func (e *inferrer) Leave(n ast.Node) (ast.Node, bool) {
+	x, ok := n.(ast.ExprNode)
+	if !ok || e.err != nil || e.done[x] {
+		return n, e.err == nil
+	}
+	delete(e.visiting, x)
+	e.done[x] = true
+	tp, c, err := e.infer(x)
+	if err != nil {
+		e.err = err
+		return n, false
+	}
+	if tp != nil {
+		x.SetType(tp)
+		e.result.Coercibility[x] = c
+	}
+	return n, true
+}
+
+// coercibility returns the coercibility of an expression, which is guessed by its
+// type if it isn't typed.
+func (e *inferrer) coercibility(x ast.ExprNode) charset.Coercibility {
+	if c, ok := e.result.Coercibility[x]; ok {
+		return c
+	}
+	return coercibilityOf(x.GetType(), charset.CoercibilityImplicit)
+}
+
+// coercibilityOf returns c for a string, or the coercibility of the other types.
+func coercibilityOf(tp *types.FieldType, c charset.Coercibility) charset.Coercibility {
+	switch {
+	case tp.Tp == mysql.TypeNull:
+		return charset.CoercibilityIgnorable
+	case tp.Tp != mysql.TypeUnspecified && tp.EvalType() != types.ETString:
+		return charset.CoercibilityNumeric
+	}
+	return c
+}
+
+// infer returns the type of an expression whose children are typed, or nil to keep its type.
+func (e *inferrer) infer(x ast.ExprNode) (*types.FieldType, charset.Coercibility, error) {
+	switch x := x.(type) {
+	case ast.ParamMarkerExpr:
+		return types.NewFieldType(mysql.TypeUnspecified), charset.CoercibilityCoercible, nil
+	case ast.ValueExpr:
+		tp := literalType(x)
+		return tp, coercibilityOf(tp, charset.CoercibilityCoercible), nil
+	case *ast.ColumnNameExpr:
+		return e.column(x.Refer)
+	case *ast.ValuesExpr:
+		if x.Column != nil {
+			return e.column(x.Column.Refer)
+		}
+	case *ast.ParenthesesExpr:
+		return x.Expr.GetType().Clone(), e.coercibility(x.Expr), nil
+	case *ast.SubqueryExpr:
+		if field := firstField(x.Query); field != nil {
+			tp := field.GetType().Clone()
+			return tp, coercibilityOf(tp, charset.CoercibilityImplicit), nil
+		}
+		return types.NewFieldType(mysql.TypeUnspecified), charset.CoercibilityImplicit, nil
+	case *ast.UnaryOperationExpr:
+		return e.unaryOperation(x)
+	case *ast.BinaryOperationExpr:
+		return e.binaryOperation(x)
+	case *ast.BetweenExpr:
+		return e.comparison("between", x.Expr, x.Left, x.Right)
+	case *ast.PatternInExpr:
+		if x.Sel != nil {
+			return newBoolType(), charset.CoercibilityNumeric, nil
+		}
+		return e.comparison("in", append([]ast.ExprNode{x.Expr}, x.List...)...)
+	case *ast.PatternLikeExpr:
+		return e.stringComparison("like", x.Expr, x.Pattern)
+	case *ast.PatternRegexpExpr:
+		return e.stringComparison("regexp", x.Expr, x.Pattern)
+	case *ast.IsNullExpr, *ast.IsTruthExpr, *ast.ExistsSubqueryExpr, *ast.CompareSubqueryExpr:
+		return newBoolType(), charset.CoercibilityNumeric, nil
+	case *ast.CaseExpr:
+		return e.caseExpr(x)
+	case *ast.SetCollationExpr:
+		return e.setCollation(x)
+	case *ast.FuncCastExpr:
+		return e.cast(x)
+	case *ast.FuncCallExpr:
+		return e.funcCall(strings.ToLower(x.FnName.L), x.Args)
+	case *ast.AggregateFuncExpr:
+		return e.aggregate(strings.ToLower(x.F), x.Args)
+	case *ast.WindowFuncExpr:
+		return e.window(strings.ToLower(x.F), x.Args)
+	}
+	return nil, 0, nil
+}
+
+// literalType returns the type of a literal. The precision of a decimal is counted
+// by its digits, since the length may include the point.
+func literalType(x ast.ValueExpr) *types.FieldType {
+	tp := x.GetType().Clone()
+	if tp.Tp == mysql.TypeNewDecimal {
+		var sb strings.Builder
+		if err := x.Restore(format.NewRestoreCtx(0, &sb)); err == nil {
+			digits := 0
+			for _, ch := range sb.String() {
+				if ch >= '0' && ch <= '9' {
+					digits++
+				}
+			}
+			tp.Flen = digits
+		}
+	}
+	return tp
+}
+
+// column returns the type of the field a column refers to. The field of a derived
+// table gets the type of its expression.
+func (e *inferrer) column(f *ast.ResultField) (*types.FieldType, charset.Coercibility, error) {
+	switch {
+	case f == nil || f.Column == nil:
+		return nil, 0, nil
+	case f.Table == nil && f.Expr != nil:
+		if e.visiting[f.Expr] {
+			return types.NewFieldType(mysql.TypeUnspecified), charset.CoercibilityImplicit, nil
+		}
+		if !e.done[f.Expr] {
+			f.Expr.Accept(e)
+			if e.err != nil {
+				return nil, 0, e.err
+			}
+		}
+		f.Column.FieldType = *f.Expr.GetType()
+	}
+	tp := f.Column.FieldType.Clone()
+	if et := tp.EvalType(); et != types.ETString && et != types.ETJson && tp.Charset == "" {
+		// the columns of the other types than strings may not have a charset in the schema.
+		setBinary(tp)
+	}
+	return tp, coercibilityOf(tp, charset.CoercibilityImplicit), nil
+}
+
+// firstField returns the expression of the first column of a query.
+func firstField(n ast.Node) ast.ExprNode {
+	switch x := n.(type) {
+	case *ast.SelectStmt:
+		if x.Kind == ast.SelectStmtKindValues && len(x.Lists) > 0 && len(x.Lists[0].Values) > 0 {
+			return x.Lists[0].Values[0]
+		}
+		if x.Fields != nil && len(x.Fields.Fields) > 0 {
+			return x.Fields.Fields[0].Expr
+		}
+	case *ast.SetOprStmt:
+		return firstField(x.SelectList)
+	case *ast.SetOprSelectList:
+		if len(x.Selects) > 0 {
+			return firstField(x.Selects[0])
+		}
+	}
+	return nil
+}
+
+func opName(op opcode.Op) string {
+	var sb strings.Builder
+	op.Format(&sb)
+	return strings.TrimSpace(sb.String())
+}
+
+func (e *inferrer) unaryOperation(x *ast.UnaryOperationExpr) (*types.FieldType, charset.Coercibility, error) {
+	tp := x.V.GetType()
+	switch x.Op {
+	case opcode.Not, opcode.Not2:
+		return newBoolType(), charset.CoercibilityNumeric, nil
+	case opcode.BitNeg:
+		return newIntType(true, mysql.MaxIntWidth+1), charset.CoercibilityNumeric, nil
+	case opcode.Plus:
+		return tp.Clone(), e.coercibility(x.V), nil
+	}
+	if tp.Tp == mysql.TypeUnspecified {
+		return types.NewFieldType(mysql.TypeUnspecified), charset.CoercibilityNumeric, nil
+	}
+	switch arithEvalType(tp) {
+	case types.ETInt:
+		prec, _ := decimalDigits(tp)
+		return newIntType(false, prec+1), charset.CoercibilityNumeric, nil
+	case types.ETDecimal:
+		prec, scale := decimalDigits(tp)
+		return newDecimalType(prec, scale), charset.CoercibilityNumeric, nil
+	}
+	return newRealType(), charset.CoercibilityNumeric, nil
+}
+
+func (e *inferrer) binaryOperation(x *ast.BinaryOperationExpr) (*types.FieldType, charset.Coercibility, error) {
+	switch x.Op {
+	case opcode.LogicAnd, opcode.LogicOr, opcode.LogicXor:
+		return newBoolType(), charset.CoercibilityNumeric, nil
+	case opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE, opcode.NullEQ:
+		return e.comparison(opName(x.Op), x.L, x.R)
+	case opcode.And, opcode.Or, opcode.Xor, opcode.LeftShift, opcode.RightShift:
+		return newIntType(true, mysql.MaxIntWidth+1), charset.CoercibilityNumeric, nil
+	}
+	return arithmetic(x.Op, x.L.GetType(), x.R.GetType()), charset.CoercibilityNumeric, nil
+}
+
+// comparison returns the type of a comparison of values, whose collations are
+// merged if they're compared as strings.
+func (e *inferrer) comparison(op string, args ...ast.ExprNode) (*types.FieldType, charset.Coercibility, error) {
+	for _, arg := range args[1:] {
+		if CompareType(args[0].GetType(), arg.GetType()) == types.ETString {
+			return e.stringComparison(op, args...)
+		}
+	}
+	return newBoolType(), charset.CoercibilityNumeric, nil
+}
+
+func (e *inferrer) stringComparison(op string, args ...ast.ExprNode) (*types.FieldType, charset.Coercibility, error) {
+	if _, _, err := e.mergeCollation(op, true, args...); err != nil {
+		return nil, 0, err
+	}
+	return newBoolType(), charset.CoercibilityNumeric, nil
+}
+
+func (e *inferrer) caseExpr(x *ast.CaseExpr) (*types.FieldType, charset.Coercibility, error) {
+	results := make([]ast.ExprNode, 0, len(x.WhenClauses)+1)
+	for _, w := range x.WhenClauses {
+		if x.Value != nil {
+			if _, _, err := e.comparison("case", x.Value, w.Expr); err != nil {
+				return nil, 0, err
+			}
+		}
+		results = append(results, w.Result)
+	}
+	if x.ElseClause != nil {
+		results = append(results, x.ElseClause)
+	}
+	return e.mergeTypes("case", results)
+}
+
+func (e *inferrer) setCollation(x *ast.SetCollationExpr) (*types.FieldType, charset.Coercibility, error) {
+	co, err := charset.GetCollationByName(x.Collate)
+	if err != nil {
+		return nil, 0, err
+	}
+	tp := x.Expr.GetType().Clone()
+	switch {
+	case tp.Tp == mysql.TypeUnspecified || tp.Tp == mysql.TypeNull:
+	case tp.EvalType() != types.ETString:
+		// a number is converted to a string of the connection.
+		tp = newStringType(displayLength(tp), e.collation)
+	case tp.Charset == "":
+		tp.Charset = e.charset
+	}
+	if tp.Charset != "" && tp.Charset != co.CharsetName {
+		return nil, 0, ErrCollationCharsetMismatch.GenWithStackByArgs(co.Name, tp.Charset)
+	}
+	tp.Charset, tp.Collate = co.CharsetName, co.Name
+	return tp, charset.CoercibilityExplicit, nil
+}
+
+func (e *inferrer) cast(x *ast.FuncCastExpr) (*types.FieldType, charset.Coercibility, error) {
+	tp := x.Tp.Clone()
+	if tp.EvalType() == types.ETString {
+		if tp.Charset == "" {
+			tp.Charset, tp.Collate = e.charset, e.collation
+		}
+		if tp.Flen < 0 {
+			tp.Flen = displayLength(x.Expr.GetType())
+		}
+	}
+	return tp, coercibilityOf(tp, charset.CoercibilityImplicit), nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeinfer_test
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/catalog"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/resolver"
+	"github.com/pingcap/parser/terror"
+	_ "github.com/pingcap/parser/test_driver"
+	"github.com/pingcap/parser/typeinfer"
+	"github.com/pingcap/parser/types"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testInferSuite{})
+
+type testInferSuite struct {
+	cat *catalog.Catalog
+}
+
+func (s *testInferSuite) SetUpSuite(c *C) {
+	s.cat = catalog.New()
+	stmts, _, err := parser.New().Parse(`
+		CREATE DATABASE test;
+		USE test;
+		CREATE TABLE t (
+			i INT, u INT UNSIGNED, b BIGINT, ti TINYINT,
+			d DECIMAL(10,2), d2 DECIMAL(5,4), f FLOAT, r DOUBLE,
+			s VARCHAR(10), s2 CHAR(5) COLLATE utf8mb4_general_ci, l VARCHAR(20) CHARSET latin1,
+			s3 VARCHAR(5) COLLATE utf8mb4_unicode_ci, a VARCHAR(5) CHARSET ascii,
+			bin VARBINARY(8), txt TEXT, e ENUM('a', 'b'),
+			dt DATETIME, dt3 DATETIME(3), da DATE, tm TIME, ts TIMESTAMP, j JSON
+		) DEFAULT CHARSET utf8mb4 COLLATE utf8mb4_bin;
+	`, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(s.cat.ExecAll(stmts), IsNil)
+}
+
+func (s *testInferSuite) infer(c *C, sql string) (ast.StmtNode, *typeinfer.Result, error) {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	_, err = resolver.Resolve(stmt, s.cat)
+	c.Assert(err, IsNil, Commentf("sql: %s", sql))
+	res, err := typeinfer.Infer(stmt, "", "")
+	return stmt, res, err
+}
+
+func (s *testInferSuite) TestInfer(c *C) {
+	table := []struct {
+		expr    string
+		tp      byte
+		chs     string
+		flag    uint
+		flen    int
+		decimal int
+	}{
+		// literals and columns
+		{"1", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 1, 0},
+		{"1.50", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 3, 2},
+		{"'abc'", mysql.TypeVarString, charset.CharsetUTF8MB4, 0, 3, types.UnspecifiedLength},
+		{"null", mysql.TypeNull, charset.CharsetBin, mysql.BinaryFlag, 0, 0},
+		{"d", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 10, 2},
+		{"(s)", mysql.TypeVarchar, charset.CharsetUTF8MB4, 0, 10, types.UnspecifiedLength},
+		// arithmetic
+		{"i + 1", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 12, 0},
+		{"u + i", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag | mysql.UnsignedFlag, 12, 0},
+		{"i * ti", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 15, 0},
+		{"d + d2", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 13, 4},
+		{"d - i", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 14, 2},
+		{"d * d2", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 15, 6},
+		{"d / d2", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 18, 6},
+		{"i / 2", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 15, 4},
+		{"d % 3", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 10, 2},
+		{"i div d", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 11, 0},
+		{"i + f", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag, mysql.MaxRealWidth, types.UnspecifiedLength},
+		{"s + 1", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag, mysql.MaxRealWidth, types.UnspecifiedLength},
+		{"dt + 0", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 15, 0},
+		{"dt3 + 0", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 18, 3},
+		{"-d", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 10, 2},
+		{"i & 1", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag | mysql.UnsignedFlag, 21, 0},
+		// comparisons
+		{"s = 1", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 1, 0},
+		{"s like 'a%' and i in (1, 2)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 1, 0},
+		{"s = s2", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 1, 0},
+		{"i is null", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 1, 0},
+		// CASE and the control flow functions
+		{"case when i > 0 then i else d end", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 13, 2},
+		{"case i when 1 then 'a' when 2 then 'bcd' end", mysql.TypeVarString, charset.CharsetUTF8MB4, 0, 3, types.UnspecifiedLength},
+		{"case when i > 0 then s else null end", mysql.TypeVarString, charset.CharsetUTF8MB4, 0, 10, types.UnspecifiedLength},
+		{"if(i, 1, 2.5)", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 2, 1},
+		{"ifnull(i, ti)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 11, 0},
+		{"coalesce(ti, ti)", mysql.TypeTiny, charset.CharsetBin, mysql.BinaryFlag, 4, 0},
+		{"coalesce(i, s)", mysql.TypeVarString, charset.CharsetUTF8MB4, 0, 11, types.UnspecifiedLength},
+		{"coalesce(da, dt3)", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag, 23, 3},
+		{"coalesce(txt, s)", mysql.TypeBlob, charset.CharsetUTF8MB4, 0, 65535, types.UnspecifiedLength},
+		{"nullif(s, 'a')", mysql.TypeVarchar, charset.CharsetUTF8MB4, 0, 10, types.UnspecifiedLength},
+		// functions
+		{"concat(s, 1)", mysql.TypeVarString, charset.CharsetUTF8MB4, 0, 11, types.UnspecifiedLength},
+		{"concat(s, bin)", mysql.TypeVarString, charset.CharsetBin, mysql.BinaryFlag, 18, types.UnspecifiedLength},
+		{"concat(s, l)", mysql.TypeVarString, charset.CharsetUTF8MB4, 0, 30, types.UnspecifiedLength},
+		{"upper(l)", mysql.TypeVarString, charset.CharsetLatin1, 0, 20, types.UnspecifiedLength},
+		{"length(s)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 21, 0},
+		{"convert(s using latin1)", mysql.TypeVarString, charset.CharsetLatin1, 0, 10, types.UnspecifiedLength},
+		{"abs(u)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag | mysql.UnsignedFlag, 10, 0},
+		{"round(d, 1)", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 10, 1},
+		{"floor(d)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 9, 0},
+		{"sqrt(i)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag, mysql.MaxRealWidth, types.UnspecifiedLength},
+		{"now(3)", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag, 23, 3},
+		{"curdate()", mysql.TypeDate, charset.CharsetBin, mysql.BinaryFlag, 10, 0},
+		{"date_add(da, interval 1 day)", mysql.TypeDate, charset.CharsetBin, mysql.BinaryFlag, 10, 0},
+		{"da + interval 1 hour", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag, 19, 0},
+		{"date_format(dt, '%Y')", mysql.TypeVarString, charset.CharsetUTF8MB4, 0, types.UnspecifiedLength, types.UnspecifiedLength},
+		{"user()", mysql.TypeVarString, charset.CharsetUTF8, 0, types.UnspecifiedLength, types.UnspecifiedLength},
+		{"json_extract(j, '$.a')", mysql.TypeJSON, charset.CharsetUTF8MB4, mysql.BinaryFlag, 4194304, 0},
+		{"no_such_func(i)", mysql.TypeUnspecified, "", 0, types.UnspecifiedLength, types.UnspecifiedLength},
+		// casts and collations
+		{"cast(i as char)", mysql.TypeVarString, charset.CharsetUTF8MB4, 0, 11, types.UnspecifiedLength},
+		{"cast(s as decimal(5, 2))", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 5, 2},
+		{"s collate utf8mb4_general_ci", mysql.TypeVarchar, charset.CharsetUTF8MB4, 0, 10, types.UnspecifiedLength},
+		// aggregate and window functions
+		{"count(*)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 21, 0},
+		{"sum(i)", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 33, 0},
+		{"sum(d)", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 32, 2},
+		{"avg(d)", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 14, 6},
+		{"avg(r)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag, mysql.MaxRealWidth, types.UnspecifiedLength},
+		{"max(dt3)", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag, 23, 3},
+		{"group_concat(s)", mysql.TypeVarString, charset.CharsetUTF8MB4, 0, 1024, types.UnspecifiedLength},
+		{"bit_or(i)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag | mysql.UnsignedFlag, 21, 0},
+		{"row_number() over ()", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 21, 0},
+		{"sum(d) over ()", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 32, 2},
+		// subqueries
+		{"(select max(d) from t)", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag, 10, 2},
+		{"exists (select 1)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag, 1, 0},
+	}
+	for _, tt := range table {
+		sql := "select " + tt.expr + " from t"
+		stmt, _, err := s.infer(c, sql)
+		c.Assert(err, IsNil, Commentf("sql: %s", sql))
+		tp := stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.GetType()
+		comment := Commentf("sql: %s, type: %#v", sql, tp)
+		c.Assert(tp.Tp, Equals, tt.tp, comment)
+		c.Assert(tp.Charset, Equals, tt.chs, comment)
+		c.Assert(tp.Flag&(mysql.BinaryFlag|mysql.UnsignedFlag), Equals, tt.flag, comment)
+		c.Assert(tp.Flen, Equals, tt.flen, comment)
+		c.Assert(tp.Decimal, Equals, tt.decimal, comment)
+	}
+}
+
+func (s *testInferSuite) TestDerived(c *C) {
+	stmt, _, err := s.infer(c, "select x + 1, y from (select d as x, concat(s, 'a') as y from t) dt order by x")
+	c.Assert(err, IsNil)
+	fields := stmt.(*ast.SelectStmt).Fields.Fields
+	tp := fields[0].Expr.GetType()
+	c.Assert(tp.Tp, Equals, mysql.TypeNewDecimal)
+	c.Assert(tp.Flen, Equals, 11)
+	c.Assert(tp.Decimal, Equals, 2)
+	c.Assert(fields[1].Expr.GetType().Tp, Equals, mysql.TypeVarString)
+	c.Assert(fields[1].Expr.GetType().Flen, Equals, 11)
+
+	stmt, _, err = s.infer(c, "with recursive r (n) as (select 1 union all select n + 1 from r where n < 3) select n from r")
+	c.Assert(err, IsNil)
+	tp = stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.GetType()
+	c.Assert(tp.Tp, Equals, mysql.TypeLonglong)
+}
+
+func (s *testInferSuite) TestCoercibility(c *C) {
+	table := []struct {
+		expr string
+		c    charset.Coercibility
+	}{
+		{"s", charset.CoercibilityImplicit},
+		{"'a'", charset.CoercibilityCoercible},
+		{"_latin1'a'", charset.CoercibilityCoercible},
+		{"1", charset.CoercibilityNumeric},
+		{"null", charset.CoercibilityIgnorable},
+		{"s collate utf8mb4_general_ci", charset.CoercibilityExplicit},
+		{"user()", charset.CoercibilitySysconst},
+		{"concat(s, 'a')", charset.CoercibilityImplicit},
+		{"concat(s, s2)", charset.CoercibilityImplicit},
+		{"concat(s2, s3)", charset.CoercibilityNone},
+		{"concat('a', s2 collate utf8mb4_bin)", charset.CoercibilityExplicit},
+	}
+	for _, tt := range table {
+		sql := "select " + tt.expr + " from t"
+		stmt, res, err := s.infer(c, sql)
+		c.Assert(err, IsNil, Commentf("sql: %s", sql))
+		expr := stmt.(*ast.SelectStmt).Fields.Fields[0].Expr
+		c.Assert(res.Coercibility[expr], Equals, tt.c, Commentf("sql: %s", sql))
+	}
+}
+
+func (s *testInferSuite) TestErrors(c *C) {
+	table := []struct {
+		expr string
+		err  *terror.Error
+		msg  string
+	}{
+		{"l = a", typeinfer.ErrIllegalMixCollation,
+			"Illegal mix of collations \\(latin1_bin,IMPLICIT\\) and \\(ascii_bin,IMPLICIT\\) for operation '='"},
+		{"s collate utf8mb4_bin = s2 collate utf8mb4_general_ci", typeinfer.ErrIllegalMixCollation,
+			"Illegal mix of collations \\(utf8mb4_bin,EXPLICIT\\) and \\(utf8mb4_general_ci,EXPLICIT\\) for operation '='"},
+		{"concat(s2, s3) = 'a'", typeinfer.ErrIllegalMixCollation, ""},
+		{"s2 in ('a', s3)", typeinfer.ErrIllegalMixCollation, ""},
+		{"s2 like s3", typeinfer.ErrIllegalMixCollation, ""},
+		{"l collate utf8mb4_bin", typeinfer.ErrCollationCharsetMismatch, "COLLATION 'utf8mb4_bin' is not valid for CHARACTER SET 'latin1'"},
+	}
+	for _, tt := range table {
+		sql := "select " + tt.expr + " from t"
+		_, _, err := s.infer(c, sql)
+		c.Assert(terror.ErrorEqual(err, tt.err), IsTrue, Commentf("sql: %s, err: %v", sql, err))
+		if tt.msg != "" {
+			c.Assert(err, ErrorMatches, ".*"+tt.msg, Commentf("sql: %s", sql))
+		}
+	}
+}
+
+func (s *testInferSuite) TestCompareType(c *C) {
+	newType := func(tp byte) *types.FieldType {
+		ft := types.NewFieldType(tp)
+		if tp == mysql.TypeDatetime {
+			ft.Decimal = 0
+		}
+		return ft
+	}
+	table := []struct {
+		lhs, rhs byte
+		et       types.EvalType
+	}{
+		{mysql.TypeVarchar, mysql.TypeLonglong, types.ETReal},
+		{mysql.TypeVarchar, mysql.TypeString, types.ETString},
+		{mysql.TypeLong, mysql.TypeLonglong, types.ETInt},
+		{mysql.TypeLong, mysql.TypeNewDecimal, types.ETDecimal},
+		{mysql.TypeNewDecimal, mysql.TypeDouble, types.ETReal},
+		{mysql.TypeDatetime, mysql.TypeVarchar, types.ETDatetime},
+		{mysql.TypeDate, mysql.TypeTimestamp, types.ETDatetime},
+		{mysql.TypeDuration, mysql.TypeDuration, types.ETDuration},
+		{mysql.TypeDatetime, mysql.TypeLonglong, types.ETInt},
+		{mysql.TypeJSON, mysql.TypeLonglong, types.ETJson},
+		{mysql.TypeNull, mysql.TypeLonglong, types.ETInt},
+	}
+	for _, tt := range table {
+		c.Assert(typeinfer.CompareType(newType(tt.lhs), newType(tt.rhs)), Equals, tt.et, Commentf("%d, %d", tt.lhs, tt.rhs))
+		c.Assert(typeinfer.CompareType(newType(tt.rhs), newType(tt.lhs)), Equals, tt.et, Commentf("%d, %d", tt.rhs, tt.lhs))
+	}
+}
@@ -0,0 +1,425 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeinfer
+
+import (
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/charset"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/opcode"
+	"github.com/pingcap/parser/types"
+)
+
+// divPrecisionIncrement is the default of the variable div_precision_increment,
+// which is the number of the digits the scale of a division increases.
+const divPrecisionIncrement = 4
+
+func setBinary(tp *types.FieldType) {
+	tp.Charset, tp.Collate = charset.CharsetBin, charset.CollationBin
+	tp.Flag |= mysql.BinaryFlag
+}
+
+func newIntType(unsigned bool, flen int) *types.FieldType {
+	tp := types.NewFieldType(mysql.TypeLonglong)
+	tp.Flen, tp.Decimal = flen, 0
+	if unsigned {
+		tp.Flag |= mysql.UnsignedFlag
+	}
+	setBinary(tp)
+	return tp
+}
+
+func newBoolType() *types.FieldType {
+	return newIntType(false, 1)
+}
+
+func newRealType() *types.FieldType {
+	tp := types.NewFieldType(mysql.TypeDouble)
+	tp.Flen = mysql.MaxRealWidth
+	setBinary(tp)
+	return tp
+}
+
+func newDecimalType(prec, scale int) *types.FieldType {
+	if scale > mysql.MaxDecimalScale {
+		scale = mysql.MaxDecimalScale
+	}
+	if prec > mysql.MaxDecimalWidth {
+		prec = mysql.MaxDecimalWidth
+	}
+	if prec < scale || prec == 0 {
+		prec = scale + 1
+	}
+	tp := types.NewFieldType(mysql.TypeNewDecimal)
+	tp.Flen, tp.Decimal = prec, scale
+	setBinary(tp)
+	return tp
+}
+
+// newStringType returns a VARCHAR of a collation, or a VARBINARY of the binary collation.
+func newStringType(flen int, co string) *types.FieldType {
+	tp := types.NewFieldType(mysql.TypeVarString)
+	tp.Flen = flen
+	if co == charset.CollationBin {
+		setBinary(tp)
+		return tp
+	}
+	tp.Collate = co
+	if collation, err := charset.GetCollationByName(co); err == nil {
+		tp.Charset = collation.CharsetName
+	}
+	return tp
+}
+
+// newTimeType returns a DATE, a DATETIME, a TIMESTAMP or a TIME of a fractional seconds precision.
+func newTimeType(tpCode byte, fsp int) *types.FieldType {
+	if fsp < 0 {
+		fsp = 0
+	}
+	tp := types.NewFieldType(tpCode)
+	tp.Decimal = fsp
+	switch tpCode {
+	case mysql.TypeDate:
+		tp.Flen, tp.Decimal = mysql.MaxDateWidth, 0
+	case mysql.TypeDuration:
+		tp.Flen = mysql.MaxDurationWidthNoFsp
+	default:
+		tp.Flen = mysql.MaxDatetimeWidthNoFsp
+	}
+	if tp.Decimal > 0 {
+		tp.Flen += tp.Decimal + 1
+	}
+	setBinary(tp)
+	return tp
+}
+
+func newJSONType() *types.FieldType {
+	tp := types.NewFieldType(mysql.TypeJSON)
+	tp.Flen, tp.Decimal = mysql.GetDefaultFieldLengthAndDecimalForCast(mysql.TypeJSON)
+	tp.Charset, tp.Collate = charset.CharsetUTF8MB4, charset.CollationUTF8MB4
+	tp.Flag |= mysql.BinaryFlag
+	return tp
+}
+
+func isTemporal(et types.EvalType) bool {
+	return et == types.ETDatetime || et == types.ETTimestamp || et == types.ETDuration
+}
+
+// arithEvalType returns the type a value is calculated as in arithmetic. A temporal
+// value is a number like 20201231235959, a decimal if it has fractional seconds.
+func arithEvalType(tp *types.FieldType) types.EvalType {
+	if tp.Tp == mysql.TypeNull {
+		return types.ETInt
+	}
+	switch et := tp.EvalType(); et {
+	case types.ETInt, types.ETDecimal, types.ETReal:
+		return et
+	case types.ETDatetime, types.ETTimestamp, types.ETDuration:
+		if tp.Decimal > 0 {
+			return types.ETDecimal
+		}
+		return types.ETInt
+	}
+	return types.ETReal
+}
+
+func mergeArithEvalType(et1, et2 types.EvalType) types.EvalType {
+	switch {
+	case et1 == types.ETReal || et2 == types.ETReal:
+		return types.ETReal
+	case et1 == types.ETDecimal || et2 == types.ETDecimal:
+		return types.ETDecimal
+	}
+	return types.ETInt
+}
+
+// decimalDigits returns the precision and the scale of a number as a decimal.
+func decimalDigits(tp *types.FieldType) (prec int, scale int) {
+	switch tp.Tp {
+	case mysql.TypeNull:
+		return 1, 0
+	case mysql.TypeDate:
+		return 8, 0
+	case mysql.TypeDatetime, mysql.TypeTimestamp:
+		scale = max(tp.Decimal, 0)
+		return 14 + scale, scale
+	case mysql.TypeDuration:
+		scale = max(tp.Decimal, 0)
+		return 6 + scale, scale
+	}
+	switch tp.EvalType() {
+	case types.ETInt:
+		prec = tp.Flen
+		if prec <= 0 {
+			prec, _ = mysql.GetDefaultFieldLengthAndDecimal(tp.Tp)
+			// the default lengths count the sign, which the unsigned integers except BIGINT don't have.
+			if mysql.HasUnsignedFlag(tp.Flag) && mysql.IsIntegerType(tp.Tp) && tp.Tp != mysql.TypeLonglong {
+				prec--
+			}
+		}
+		if prec <= 0 {
+			prec = mysql.MaxIntWidth
+		}
+		return prec, 0
+	case types.ETDecimal:
+		prec, scale = tp.Flen, max(tp.Decimal, 0)
+		if prec <= 0 {
+			prec = mysql.MaxDecimalWidth
+		}
+		return max(prec, scale), scale
+	}
+	return mysql.MaxDecimalWidth, mysql.MaxDecimalScale
+}
+
+// displayLength returns the number of the characters a value is displayed in, or
+// types.UnspecifiedLength if it isn't known.
+func displayLength(tp *types.FieldType) int {
+	switch tp.EvalType() {
+	case types.ETInt:
+		prec, _ := decimalDigits(tp)
+		return prec
+	case types.ETReal:
+		return mysql.MaxRealWidth
+	case types.ETDecimal:
+		prec, scale := decimalDigits(tp)
+		if scale > 0 {
+			prec++
+		}
+		return prec + 1
+	}
+	if tp.Flen > 0 || tp.Tp == mysql.TypeNull {
+		return tp.Flen
+	}
+	switch tp.Tp {
+	case mysql.TypeTinyBlob, mysql.TypeBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob:
+		flen, _ := mysql.GetDefaultFieldLengthAndDecimal(tp.Tp)
+		return flen
+	}
+	return types.UnspecifiedLength
+}
+
+// arithmetic returns the type of an arithmetic operation.
+func arithmetic(op opcode.Op, lhs, rhs *types.FieldType) *types.FieldType {
+	if lhs.Tp == mysql.TypeUnspecified || rhs.Tp == mysql.TypeUnspecified {
+		return types.NewFieldType(mysql.TypeUnspecified)
+	}
+	et := mergeArithEvalType(arithEvalType(lhs), arithEvalType(rhs))
+	unsigned := mysql.HasUnsignedFlag(lhs.Flag) || mysql.HasUnsignedFlag(rhs.Flag)
+	p1, s1 := decimalDigits(lhs)
+	p2, s2 := decimalDigits(rhs)
+	switch op {
+	case opcode.Div:
+		if et == types.ETReal {
+			return newRealType()
+		}
+		return newDecimalType(p1+s2+divPrecisionIncrement, s1+divPrecisionIncrement)
+	case opcode.IntDiv:
+		return newIntType(unsigned, max(p1-s1, 1))
+	}
+
+	switch et {
+	case types.ETInt:
+		var flen int
+		switch op {
+		case opcode.Mul:
+			flen = p1 + p2
+		case opcode.Mod:
+			flen = max(p1, p2)
+			unsigned = mysql.HasUnsignedFlag(lhs.Flag)
+		default:
+			flen = max(p1, p2) + 1
+		}
+		return newIntType(unsigned, min(flen, mysql.MaxIntWidth+1))
+	case types.ETDecimal:
+		switch op {
+		case opcode.Mul:
+			return newDecimalType(p1+p2, s1+s2)
+		case opcode.Mod:
+			scale := max(s1, s2)
+			return newDecimalType(max(p1-s1, p2-s2)+scale, scale)
+		default:
+			scale := max(s1, s2)
+			return newDecimalType(max(p1-s1, p2-s2)+scale+1, scale)
+		}
+	}
+	return newRealType()
+}
+
+// CompareType returns the type two values are compared as, following MySQL. Two
+// strings are compared as strings, a temporal value and a string or another
+// temporal value as temporal values, JSON as JSON, and the numbers as the type of
+// their arithmetic, which is a DOUBLE for a string and a number, so a VARCHAR column
+// compared with an integer is converted to a DOUBLE.
+func CompareType(lhs, rhs *types.FieldType) types.EvalType {
+	switch {
+	case lhs.Tp == mysql.TypeNull && rhs.Tp == mysql.TypeNull:
+		return types.ETString
+	case lhs.Tp == mysql.TypeNull || lhs.Tp == mysql.TypeUnspecified:
+		return rhs.EvalType()
+	case rhs.Tp == mysql.TypeNull || rhs.Tp == mysql.TypeUnspecified:
+		return lhs.EvalType()
+	}
+	et1, et2 := lhs.EvalType(), rhs.EvalType()
+	switch {
+	case et1 == types.ETJson || et2 == types.ETJson:
+		return types.ETJson
+	case et1 == types.ETString && et2 == types.ETString:
+		return types.ETString
+	case isTemporal(et1) && isTemporal(et2):
+		if et1 == et2 {
+			return et1
+		}
+		return types.ETDatetime
+	case isTemporal(et1) && et2 == types.ETString:
+		return et1
+	case isTemporal(et2) && et1 == types.ETString:
+		return et2
+	}
+	return mergeArithEvalType(arithEvalType(lhs), arithEvalType(rhs))
+}
+
+// mergeCollation merges the collations of the string arguments of an operation.
+// A comparison is strict, which can't be done with CoercibilityNone. The collation
+// of the connection is returned if there are no strings.
+func (e *inferrer) mergeCollation(op string, strict bool, args ...ast.ExprNode) (string, charset.Coercibility, error) {
+	co, c, found := e.collation, charset.CoercibilityNumeric, false
+	for _, arg := range args {
+		tp := arg.GetType()
+		if tp.Tp == mysql.TypeNull || tp.Tp == mysql.TypeUnspecified || tp.EvalType() != types.ETString {
+			continue
+		}
+		argCo, argC := tp.Collate, e.coercibility(arg)
+		if argCo == "" {
+			argCo = e.collation
+		}
+		if !found {
+			co, c, found = argCo, argC, true
+			continue
+		}
+		newCo, newC, ok := charset.MergeCollation(co, c, argCo, argC)
+		if !ok || strict && newC == charset.CoercibilityNone {
+			return "", 0, ErrIllegalMixCollation.GenWithStackByArgs(co, c.String(), argCo, argC.String(), op)
+		}
+		co, c = newCo, newC
+	}
+	return co, c, nil
+}
+
+// blobRank orders the BLOB types by their sizes.
+var blobRank = map[byte]int{
+	mysql.TypeTinyBlob:   1,
+	mysql.TypeBlob:       2,
+	mysql.TypeMediumBlob: 3,
+	mysql.TypeLongBlob:   4,
+}
+
+// mergeTypes returns the type of a result which may be any of the arguments, like
+// CASE, IF and COALESCE. The NULL arguments are ignored.
+func (e *inferrer) mergeTypes(op string, args []ast.ExprNode) (*types.FieldType, charset.Coercibility, error) {
+	var tps []*types.FieldType
+	for _, arg := range args {
+		tp := arg.GetType()
+		switch tp.Tp {
+		case mysql.TypeUnspecified:
+			return types.NewFieldType(mysql.TypeUnspecified), charset.CoercibilityImplicit, nil
+		case mysql.TypeNull:
+			continue
+		}
+		tps = append(tps, tp)
+	}
+	if len(tps) == 0 {
+		tp := types.NewFieldType(mysql.TypeNull)
+		tp.Flen, tp.Decimal = 0, 0
+		setBinary(tp)
+		return tp, charset.CoercibilityIgnorable, nil
+	}
+
+	sameType, numeric, temporal, json := true, true, true, true
+	unsigned := true
+	for _, tp := range tps {
+		sameType = sameType && tp.Tp == tps[0].Tp
+		et := tp.EvalType()
+		numeric = numeric && (et == types.ETInt || et == types.ETDecimal || et == types.ETReal)
+		temporal = temporal && isTemporal(et)
+		json = json && et == types.ETJson
+		unsigned = unsigned && mysql.HasUnsignedFlag(tp.Flag)
+	}
+	switch {
+	case numeric:
+		et := tps[0].EvalType()
+		intDigits, scale, flen := 0, 0, 0
+		for _, tp := range tps {
+			et = mergeArithEvalType(et, tp.EvalType())
+			p, s := decimalDigits(tp)
+			intDigits, scale, flen = max(intDigits, p-s), max(scale, s), max(flen, p)
+		}
+		switch et {
+		case types.ETInt:
+			tp := newIntType(unsigned, flen)
+			if sameType {
+				tp.Tp = tps[0].Tp
+			}
+			return tp, charset.CoercibilityNumeric, nil
+		case types.ETDecimal:
+			return newDecimalType(intDigits+scale, scale), charset.CoercibilityNumeric, nil
+		}
+		return newRealType(), charset.CoercibilityNumeric, nil
+	case temporal:
+		fsp := 0
+		for _, tp := range tps {
+			fsp = max(fsp, tp.Decimal)
+		}
+		if sameType {
+			return newTimeType(tps[0].Tp, fsp), charset.CoercibilityNumeric, nil
+		}
+		return newTimeType(mysql.TypeDatetime, fsp), charset.CoercibilityNumeric, nil
+	case json:
+		return newJSONType(), charset.CoercibilityImplicit, nil
+	}
+
+	co, c, err := e.mergeCollation(op, false, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	flen, rank := 0, 0
+	for _, tp := range tps {
+		if l := displayLength(tp); l < 0 || flen < 0 {
+			flen = types.UnspecifiedLength
+		} else {
+			flen = max(flen, l)
+		}
+		rank = max(rank, blobRank[tp.Tp])
+	}
+	tp := newStringType(flen, co)
+	for tpCode, r := range blobRank {
+		if r == rank {
+			tp.Tp = tpCode
+		}
+	}
+	return tp, c, nil
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
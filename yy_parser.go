@@ -18,6 +18,7 @@ import (
 	"math"
 	"regexp"
 	"strconv"
+	"strings"
 	"unicode"
 
 	"github.com/pingcap/errors"
@@ -85,6 +86,12 @@ type Parser struct {
 	explicitCharset       bool
 	strictDoubleFieldType bool
 
+	// expected holds the tokens acceptable at the first syntax error of the last parse.
+	expected []string
+
+	// routine holds the names of the stored program being parsed.
+	routine routineScope
+
 	// the following fields are used by yyParse to reduce allocation.
 	cache  []yySymType
 	yylval yySymType
@@ -100,6 +107,112 @@ func yySetOffset(yyVAL *yySymType, offset int) {
 func yyhintSetOffset(_ *yyhintSymType, _ int) {
 }
 
+// yySetSpan sets the start and end positions of the symbol reduced by yyParse, syms
+// holds the symbol before the rule followed by the symbols of the rule. A rule which
+// matches nothing is placed at the lookahead token if it's read, or right after the
+// symbol before it otherwise, the empty symbols at the end of a rule are ignored.
+func yySetSpan(parser *Parser, syms []yySymType, lookahead bool) {
+	prev, rule := &syms[0], syms[1:]
+	if len(rule) == 0 {
+		if lookahead {
+			parser.yyVAL.startPos, parser.yyVAL.endPos = parser.yylval.startPos, parser.yylval.startPos
+		} else {
+			parser.yyVAL.startPos, parser.yyVAL.endPos = prev.endPos, prev.endPos
+		}
+		return
+	}
+	end := rule[0].startPos
+	for i := len(rule) - 1; i >= 0; i-- {
+		if rule[i].endPos != rule[i].startPos {
+			end = rule[i].endPos
+			break
+		}
+	}
+	parser.yyVAL.endPos = end
+}
+
+func yyhintSetSpan(_ *hintParser, _ []yyhintSymType, _ bool) {
+}
+
+// yySetNodePos sets the positions of the node built by the rule just reduced,
+// typ is the type of the rule in the %union, one of the yyType constants, flagged by
+// yyPassThrough if the rule returns the value of one of its symbols as it is.
+func yySetNodePos(parser *Parser, typ uint8) {
+	// a rule like `WhereClause: "WHERE" Expression` doesn't change the positions of
+	// the node of its symbol, but a statement spans the keywords before the rule which
+	// built it, like `SetRoleStmt: "SET" "ROLE" SetRoleOpt`.
+	if typ&yyPassThrough != 0 {
+		typ &^= yyPassThrough
+		if typ != yyTypeStatement {
+			return
+		}
+	}
+	var n ast.Node
+	switch typ {
+	case yyTypeExpr:
+		n = parser.yyVAL.expr
+	case yyTypeStatement:
+		n = parser.yyVAL.statement
+	case yyTypeItem:
+		n, _ = parser.yyVAL.item.(ast.Node)
+	}
+	if n == nil {
+		return
+	}
+	n.SetPos(toASTPos(parser.yyVAL.startPos), toASTPos(parser.yyVAL.endPos))
+	// the column name of a column reference is built in the same rule.
+	if x, ok := n.(*ast.ColumnNameExpr); ok && x.Name != nil && x.Name.StartPos() == (ast.Pos{}) {
+		x.Name.SetPos(n.StartPos(), n.EndPos())
+	}
+}
+
+func yyhintSetNodePos(_ *hintParser, _ uint8) {
+}
+
+// setNodePos sets the positions of a node built inside a rule, it spans the symbols
+// syms of the rule, the empty symbols at the end are ignored.
+func (parser *Parser) setNodePos(n ast.Node, syms []yySymType) {
+	end := syms[0].startPos
+	for i := len(syms) - 1; i >= 0; i-- {
+		if syms[i].endPos != syms[i].startPos {
+			end = syms[i].endPos
+			break
+		}
+	}
+	n.SetPos(toASTPos(syms[0].startPos), toASTPos(end))
+}
+
+// singleTableRefs returns the table references of a statement on the single table ts,
+// the wrapping nodes span ts.
+func (parser *Parser) singleTableRefs(ts *ast.TableSource) *ast.TableRefsClause {
+	join := &ast.Join{Left: ts}
+	join.SetPos(ts.StartPos(), ts.EndPos())
+	refs := &ast.TableRefsClause{TableRefs: join}
+	refs.SetPos(ts.StartPos(), ts.EndPos())
+	return refs
+}
+
+// toASTPos converts the position tracked by the Scanner to ast.Pos. The Scanner counts
+// the columns of the first line from 0, but the other lines from 1.
+func toASTPos(p Pos) ast.Pos {
+	if p.Line == 1 {
+		p.Col++
+	}
+	return ast.Pos{Line: p.Line, Col: p.Col, Offset: p.Offset}
+}
+
+// yySyntaxError is called by yyParse when no action exists for the lookahead
+// token in the given state, only the first error of a parse is recorded.
+func yySyntaxError(parser *Parser, state int) {
+	if parser.expected != nil || len(parser.lexer.errs) > 0 {
+		return
+	}
+	parser.expected = expectedTokens(state)
+}
+
+func yyhintSyntaxError(_ *hintParser, _ int) {
+}
+
 type stmtTexter interface {
 	stmtText() string
 }
@@ -135,6 +248,37 @@ func (parser *Parser) SetParserConfig(config ParserConfig) {
 // Parse parses a query string to raw ast.StmtNode.
 // If charset or collation is "", default charset and collation will be used.
 func (parser *Parser) Parse(sql, charset, collation string) (stmt []ast.StmtNode, warns []error, err error) {
+	return parser.parse(sql, charset, collation, "")
+}
+
+// parse parses a query string with the given active delimiter, an empty delimiter means the default `;`.
+func (parser *Parser) parse(sql, charset, collation, delimiter string) (stmt []ast.StmtNode, warns []error, err error) {
+	stmt, warns, err = parser.parseFrom(sql, charset, collation, delimiter, Pos{Line: 1})
+	if err == nil && parser.lexer.keepComments {
+		ast.AttachComments(stmt, parser.comments(nil))
+	}
+	return stmt, warns, err
+}
+
+// comments appends the comments scanned by the last parse to cs, the comments
+// which are already in cs are skipped.
+func (parser *Parser) comments(cs []*ast.Comment) []*ast.Comment {
+	for _, c := range parser.lexer.comments {
+		if len(cs) > 0 && c.start.Offset <= cs[len(cs)-1].Start.Offset {
+			continue
+		}
+		cs = append(cs, &ast.Comment{
+			Text:  parser.src[c.start.Offset:c.end.Offset],
+			Start: toASTPos(c.start),
+			End:   toASTPos(c.end),
+		})
+	}
+	return cs
+}
+
+// parseFrom is like parse but starts scanning at the position from of sql,
+// offsets and line numbers stay relative to the whole sql.
+func (parser *Parser) parseFrom(sql, charset, collation, delimiter string, from Pos) (stmt []ast.StmtNode, warns []error, err error) {
 	if charset == "" {
 		charset = mysql.DefaultCharset
 	}
@@ -145,9 +289,15 @@ func (parser *Parser) Parse(sql, charset, collation string) (stmt []ast.StmtNode
 	parser.collation = collation
 	parser.src = sql
 	parser.result = parser.result[:0]
+	parser.expected = nil
+	parser.routine.reset()
 
 	var l yyLexer
 	parser.lexer.reset(sql)
+	parser.lexer.SetDelimiter(delimiter)
+	if from.Offset > 0 {
+		parser.lexer.seek(from)
+	}
 	l = &parser.lexer
 	yyParse(l, parser)
 
@@ -166,6 +316,75 @@ func (parser *Parser) Parse(sql, charset, collation string) (stmt []ast.StmtNode
 	return parser.result, warns, nil
 }
 
+// routineScope is the names of a stored program being parsed, which the bare names
+// of SET refer to before the system variables, like MySQL does.
+type routineScope struct {
+	params map[string]bool
+	// blocks are the variables declared in the enclosing BEGIN ... END blocks which
+	// have declarations, innermost last.
+	blocks []map[string]bool
+	// trigger is true in the body of a trigger, which can set the columns of NEW and OLD.
+	trigger bool
+}
+
+// reset is called at the end of a CREATE statement of a stored program.
+func (s *routineScope) reset() {
+	*s = routineScope{blocks: s.blocks[:0]}
+}
+
+func (s *routineScope) addParam(name string) {
+	if s.params == nil {
+		s.params = make(map[string]bool)
+	}
+	s.params[strings.ToLower(name)] = true
+}
+
+func (s *routineScope) pushBlock() {
+	s.blocks = append(s.blocks, make(map[string]bool))
+}
+
+func (s *routineScope) popBlock() {
+	if len(s.blocks) > 0 {
+		s.blocks = s.blocks[:len(s.blocks)-1]
+	}
+}
+
+// declare adds the variables of a declaration to the innermost block.
+func (s *routineScope) declare(decl ast.StmtNode) {
+	x, ok := decl.(*ast.DeclareVarStmt)
+	if !ok || len(s.blocks) == 0 {
+		return
+	}
+	for _, name := range x.Names {
+		s.blocks[len(s.blocks)-1][strings.ToLower(name)] = true
+	}
+}
+
+func (s *routineScope) isLocal(name string) bool {
+	name = strings.ToLower(name)
+	for _, block := range s.blocks {
+		if block[name] {
+			return true
+		}
+	}
+	return s.params[name]
+}
+
+// newVariableAssignment returns the assignment of `SET name = value`, where name is a
+// local variable or a parameter of the stored program being parsed, a column of NEW
+// or OLD in a trigger, or else a system variable.
+func (parser *Parser) newVariableAssignment(name string, value ast.ExprNode) *ast.VariableAssignment {
+	if parser.routine.isLocal(name) {
+		return &ast.VariableAssignment{Name: name, Value: value, IsLocal: true}
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 && parser.routine.trigger {
+		if row := strings.ToUpper(name[:i]); row == "NEW" || row == "OLD" {
+			return &ast.VariableAssignment{Name: name[i+1:], Value: value, TriggerRow: row}
+		}
+	}
+	return &ast.VariableAssignment{Name: name, Value: value, IsSystem: true}
+}
+
 func (parser *Parser) lastErrorAsWarn() {
 	parser.lexer.lastErrorAsWarn()
 }
@@ -194,6 +413,12 @@ func (parser *Parser) EnableWindowFunc(val bool) {
 	parser.lexer.EnableWindowFunc(val)
 }
 
+// KeepComments controls whether the parser collects the comments and attaches them to
+// the AST nodes, see ast.AttachComments. Optimizer hints and `/*!` sections aren't comments.
+func (parser *Parser) KeepComments(val bool) {
+	parser.lexer.keepComments = val
+}
+
 // ParseErrorWith returns "You have a syntax error near..." error message compatible with mysql.
 func ParseErrorWith(errstr string, lineno int) error {
 	if len(errstr) > mysql.ErrTextLength {